@@ -3,14 +3,17 @@ module RecSIS/etl
 go 1.23.1
 
 require (
-	github.com/BurntSushi/toml v1.5.0 // indirect
+	github.com/BurntSushi/toml v1.5.0
+	github.com/jmoiron/sqlx v1.4.0
+	github.com/lib/pq v1.10.9
+	github.com/meilisearch/meilisearch-go v0.32.0
+	github.com/sijms/go-ora/v2 v2.8.24
+)
+
+require (
 	github.com/andybalholm/brotli v1.1.1 // indirect
 	github.com/golang-jwt/jwt/v4 v4.5.1 // indirect
-	github.com/jmoiron/sqlx v1.4.0 // indirect
 	github.com/josharian/intern v1.0.0 // indirect
-	github.com/lib/pq v1.10.9 // indirect
 	github.com/mailru/easyjson v0.9.0 // indirect
-	github.com/meilisearch/meilisearch-go v0.32.0 // indirect
 	github.com/sijms/go-ora v1.3.2 // indirect
-	github.com/sijms/go-ora/v2 v2.8.24 // indirect
 )
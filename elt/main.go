@@ -51,7 +51,7 @@ func main() {
 	// Meilisearch
 	start = time.Now()
 	err = uploadToMeili(recsis, meili, []meiliUpload{
-		{table: "povinn2searchable", index: "courses"},
+		{table: "povinn2searchable", index: "courses", sortable: courseSortable, filterable: courseFilterable, maxTotalHits: courseMaxTotalHits},
 		{table: "ankecy2searchable", index: "survey"},
 		{table: "studplan2searchable", index: "degree-plans"},
 	})
@@ -120,6 +120,7 @@ func transform(recsis *sqlx.DB) error {
 			pklas2JSON,
 			povinn2jazykAgg,
 			ankecy2JSON,
+			ucit2teachers,
 			studmetadata2intermediate,
 		},
 		parallelRunner{
@@ -181,6 +182,10 @@ func migrate(db *sqlx.DB) error {
 	if err != nil {
 		return err
 	}
+	err = snapshotCourseHistory(tx)
+	if err != nil {
+		return err
+	}
 	err = migrateFilters(tx)
 	if err != nil {
 		return err
@@ -197,6 +202,18 @@ func migrate(db *sqlx.DB) error {
 	if err != nil {
 		return err
 	}
+	err = migrateTeachers(tx)
+	if err != nil {
+		return err
+	}
+	err = migrateSearchDocuments(tx)
+	if err != nil {
+		return err
+	}
+	err = updateSavedSearchMatches(tx)
+	if err != nil {
+		return err
+	}
 	if err = tx.Commit(); err != nil {
 		return err
 	}
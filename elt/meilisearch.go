@@ -4,14 +4,45 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"slices"
 
 	"github.com/jmoiron/sqlx"
 	"github.com/meilisearch/meilisearch-go"
 )
 
+// courseSortable are attributes of the courses index the course search can
+// sort by.
+var courseSortable = []string{
+	"code",
+	"credits",
+	"title.cs",
+	"title.en",
+	"rating_average",
+	"rating_count",
+	"valid_from",
+	"weekly_hours",
+}
+
+// courseFilterable are attributes of the courses index the course search
+// filters by besides the attributes of filters, e.g. code for personal facets
+// resolved from user data.
+var courseFilterable = []string{
+	"code",
+}
+
+// courseMaxTotalHits is the maximum number of search results of the courses
+// index. It is above the number of courses so that exports of course search
+// results are complete (Meilisearch default is 1000).
+const courseMaxTotalHits = 100000
+
 type meiliUpload struct {
-	table string
-	index string
+	table    string
+	index    string
+	sortable []string
+	// added to filterable attributes set by other means
+	filterable []string
+	// zero keeps the default of Meilisearch
+	maxTotalHits int64
 }
 
 func uploadToMeili(src *sqlx.DB, meili meilisearch.ServiceManager, operations []meiliUpload) error {
@@ -28,6 +59,21 @@ func uploadToMeili(src *sqlx.DB, meili meilisearch.ServiceManager, operations []
 			errs = append(errs, err)
 			continue
 		}
+		if err = updateSortable(meili, op.index, op.sortable); err != nil {
+			log.Printf("❌ meilisearch: sortable attributes of %s: %v", op.index, err)
+			errs = append(errs, err)
+			continue
+		}
+		if err = addFilterable(meili, op.index, op.filterable); err != nil {
+			log.Printf("❌ meilisearch: filterable attributes of %s: %v", op.index, err)
+			errs = append(errs, err)
+			continue
+		}
+		if err = updatePagination(meili, op.index, op.maxTotalHits); err != nil {
+			log.Printf("❌ meilisearch: pagination of %s: %v", op.index, err)
+			errs = append(errs, err)
+			continue
+		}
 		log.Printf("✅ meilisearch: loading %s successfull", op.index)
 	}
 	if len(errs) > 0 {
@@ -78,3 +124,53 @@ func uploadToMeilisearch(client meilisearch.ServiceManager, indexName string, da
 	_ = task.TaskUID
 	return nil
 }
+
+// updateSortable sets sortable attributes of the index. Indexes without
+// sortable attributes are left untouched so that settings made by other means
+// are kept.
+func updateSortable(client meilisearch.ServiceManager, indexName string, sortable []string) error {
+	if len(sortable) == 0 {
+		return nil
+	}
+	_, err := client.Index(indexName).UpdateSortableAttributes(&sortable)
+	return err
+}
+
+// updatePagination sets the maximum number of search results of the index.
+// Indexes without the maximum are left untouched.
+func updatePagination(client meilisearch.ServiceManager, indexName string, maxTotalHits int64) error {
+	if maxTotalHits == 0 {
+		return nil
+	}
+	_, err := client.Index(indexName).UpdatePagination(&meilisearch.Pagination{MaxTotalHits: maxTotalHits})
+	return err
+}
+
+// addFilterable adds the attributes to filterable attributes of the index.
+// Filterable attributes which are already set are kept.
+func addFilterable(client meilisearch.ServiceManager, indexName string, filterable []string) error {
+	if len(filterable) == 0 {
+		return nil
+	}
+	index := client.Index(indexName)
+	current, err := index.GetFilterableAttributes()
+	if err != nil {
+		return err
+	}
+	attributes := []string{}
+	if current != nil {
+		attributes = append(attributes, *current...)
+	}
+	missing := false
+	for _, attribute := range filterable {
+		if !slices.Contains(attributes, attribute) {
+			attributes = append(attributes, attribute)
+			missing = true
+		}
+	}
+	if !missing {
+		return nil
+	}
+	_, err = index.UpdateFilterableAttributes(&attributes)
+	return err
+}
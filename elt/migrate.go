@@ -1,6 +1,10 @@
 package main
 
-import "github.com/jmoiron/sqlx"
+import (
+	"log"
+
+	"github.com/jmoiron/sqlx"
+)
 
 func migrateCourses(tx *sqlx.Tx) error {
 	var err error
@@ -53,6 +57,97 @@ func migrateCourses(tx *sqlx.Tx) error {
 	return nil
 }
 
+// snapshotCourseHistory stores the courses as the snapshot of the current
+// academic year, which starts in October. Courses no longer taught are stored
+// as of their last academic year and courses not taught yet as of their first
+// one. Repeated loads within the academic year overwrite its snapshot.
+func snapshotCourseHistory(tx *sqlx.Tx) error {
+	var err error
+	_, err = tx.Exec(`--sql
+		INSERT INTO webapp.course_history (
+			code,
+			lang,
+			academic_year,
+			title,
+			valid_from,
+			valid_to,
+			lecture_range_winter,
+			seminar_range_winter,
+			lecture_range_summer,
+			seminar_range_summer,
+			range_unit,
+			exam,
+			credits,
+			guarantors,
+			prerequisites,
+			corequisites,
+			incompatibilities,
+			interchangeabilities,
+			annotation,
+			syllabus,
+			terms_of_passing,
+			literature,
+			requirements_of_assessment,
+			entry_requirements,
+			aim
+		) SELECT
+			code,
+			lang,
+			GREATEST(valid_from, LEAST(valid_to, EXTRACT(YEAR FROM CURRENT_DATE - INTERVAL '9 months')::INT)),
+			title,
+			valid_from,
+			valid_to,
+			lecture_range_winter,
+			seminar_range_winter,
+			lecture_range_summer,
+			seminar_range_summer,
+			range_unit,
+			exam,
+			credits,
+			guarantors,
+			prerequisites,
+			corequisites,
+			incompatibilities,
+			interchangeabilities,
+			annotation,
+			syllabus,
+			terms_of_passing,
+			literature,
+			requirements_of_assessment,
+			entry_requirements,
+			aim
+		FROM povinn2courses
+		ON CONFLICT (code, lang, academic_year) DO UPDATE SET
+			title = EXCLUDED.title,
+			valid_from = EXCLUDED.valid_from,
+			valid_to = EXCLUDED.valid_to,
+			lecture_range_winter = EXCLUDED.lecture_range_winter,
+			seminar_range_winter = EXCLUDED.seminar_range_winter,
+			lecture_range_summer = EXCLUDED.lecture_range_summer,
+			seminar_range_summer = EXCLUDED.seminar_range_summer,
+			range_unit = EXCLUDED.range_unit,
+			exam = EXCLUDED.exam,
+			credits = EXCLUDED.credits,
+			guarantors = EXCLUDED.guarantors,
+			prerequisites = EXCLUDED.prerequisites,
+			corequisites = EXCLUDED.corequisites,
+			incompatibilities = EXCLUDED.incompatibilities,
+			interchangeabilities = EXCLUDED.interchangeabilities,
+			annotation = EXCLUDED.annotation,
+			syllabus = EXCLUDED.syllabus,
+			terms_of_passing = EXCLUDED.terms_of_passing,
+			literature = EXCLUDED.literature,
+			requirements_of_assessment = EXCLUDED.requirements_of_assessment,
+			entry_requirements = EXCLUDED.entry_requirements,
+			aim = EXCLUDED.aim,
+			updated_at = NOW();
+	`)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
 func migrateFilters(tx *sqlx.Tx) error {
 	var err error
 	_, err = tx.Exec(`--sql
@@ -171,3 +266,145 @@ func migrateStudPlanCourses(tx *sqlx.Tx) error {
 	}
 	return nil
 }
+
+func migrateTeachers(tx *sqlx.Tx) error {
+	var err error
+	_, err = tx.Exec(`--sql
+		DELETE FROM webapp.teachers WHERE TRUE;
+		INSERT INTO webapp.teachers (
+			id,
+			lang,
+			first_name,
+			last_name,
+			title_before,
+			title_after,
+			department
+		) SELECT
+			id,
+			lang,
+			first_name,
+			last_name,
+			title_before,
+			title_after,
+			department
+		FROM ucit2teachers;
+	`)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// migrateSearchDocuments copies documents of Meilisearch indexes for
+// PostgreSQL full-text search. Searchable attributes are weighted in the same
+// order as their ranking in Meilisearch.
+func migrateSearchDocuments(tx *sqlx.Tx) error {
+	var err error
+	_, err = tx.Exec(`--sql
+		DELETE FROM webapp.search_documents WHERE TRUE;
+		INSERT INTO webapp.search_documents (
+			index_uid,
+			id,
+			doc,
+			search_cs,
+			search_en
+		) SELECT
+			'courses',
+			s.id,
+			TO_JSONB(s),
+			SETWEIGHT(JSONB_TO_TSVECTOR('webapp.czech', JSONB_BUILD_ARRAY(s.code, s.title), '["string"]'), 'A')
+			|| SETWEIGHT(JSONB_TO_TSVECTOR('webapp.czech', JSONB_BUILD_ARRAY(s.guarantors, s.teachers), '["string"]'), 'B')
+			|| SETWEIGHT(JSONB_TO_TSVECTOR('webapp.czech', JSONB_BUILD_ARRAY(s.annotation, s.syllabus, s.aim, s.terms_of_passing, s.requirements_of_assessment, s.literature), '["string"]'), 'C'),
+			SETWEIGHT(JSONB_TO_TSVECTOR('english', JSONB_BUILD_ARRAY(s.code, s.title), '["string"]'), 'A')
+			|| SETWEIGHT(JSONB_TO_TSVECTOR('english', JSONB_BUILD_ARRAY(s.guarantors, s.teachers), '["string"]'), 'B')
+			|| SETWEIGHT(JSONB_TO_TSVECTOR('english', JSONB_BUILD_ARRAY(s.annotation, s.syllabus, s.aim, s.terms_of_passing, s.requirements_of_assessment, s.literature), '["string"]'), 'C')
+		FROM povinn2searchable s;
+		INSERT INTO webapp.search_documents (
+			index_uid,
+			id,
+			doc,
+			search_cs,
+			search_en
+		) SELECT
+			'survey',
+			s.id,
+			TO_JSONB(s),
+			TO_TSVECTOR('webapp.czech', COALESCE(s.content, '')),
+			TO_TSVECTOR('english', COALESCE(s.content, ''))
+		FROM ankecy2searchable s;
+		INSERT INTO webapp.search_documents (
+			index_uid,
+			id,
+			doc,
+			search_cs,
+			search_en
+		) SELECT
+			'degree-plans',
+			s.id,
+			TO_JSONB(s),
+			SETWEIGHT(JSONB_TO_TSVECTOR('webapp.czech', JSONB_BUILD_ARRAY(s.code, s.title), '["string"]'), 'A'),
+			SETWEIGHT(JSONB_TO_TSVECTOR('english', JSONB_BUILD_ARRAY(s.code, s.title), '["string"]'), 'A')
+		FROM studplan2searchable s;
+	`)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// updateSavedSearchMatches finds courses matching searches saved by users in
+// the documents just migrated. Courses which did not match a search before are
+// marked as new, so users are notified about them on the home page. Matching
+// is done by the saved_search_courses function which is used also when the
+// search is saved by the webapp. Each search is updated under a savepoint, so
+// a search which cannot be evaluated keeps its matches and does not fail the
+// load.
+func updateSavedSearchMatches(tx *sqlx.Tx) error {
+	var ids []int
+	err := tx.Select(&ids, `--sql
+		SELECT id FROM webapp.saved_searches ORDER BY id;
+	`)
+	if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		if _, err = tx.Exec(`SAVEPOINT saved_search;`); err != nil {
+			return err
+		}
+		_, err = tx.Exec(`--sql
+			WITH current_matches AS (
+				SELECT course_code
+				FROM webapp.saved_search_courses($1)
+			), removed AS (
+				DELETE FROM webapp.saved_search_matches m
+				WHERE m.saved_search_id = $1
+				AND NOT EXISTS (
+					SELECT 1
+					FROM current_matches c
+					WHERE c.course_code = m.course_code
+				)
+			)
+			INSERT INTO webapp.saved_search_matches (
+				saved_search_id,
+				course_code,
+				is_new
+			) SELECT
+				$1,
+				course_code,
+				TRUE
+			FROM current_matches
+			ON CONFLICT (saved_search_id, course_code) DO NOTHING;
+		`, id)
+		if err != nil {
+			log.Printf("❌ Saved search %d skipped: %v", id, err)
+			if _, err = tx.Exec(`ROLLBACK TO SAVEPOINT saved_search;`); err != nil {
+				return err
+			}
+			continue
+		}
+		if _, err = tx.Exec(`RELEASE SAVEPOINT saved_search;`); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -272,6 +272,40 @@ var ucit2JSON = transformation{
 	`,
 }
 
+/*
+Prerequisites:
+  - ucit
+  - ustav2json
+*/
+var ucit2teachers = transformation{
+	name: "ucit2teachers",
+	query: `--sql
+	DROP TABLE IF EXISTS ucit2teachers;
+	CREATE TABLE ucit2teachers (
+		id VARCHAR(10),
+		lang VARCHAR(2),
+		first_name VARCHAR(50),
+		last_name VARCHAR(50),
+		title_before VARCHAR(100),
+		title_after VARCHAR(100),
+		department jsonb
+	);
+	INSERT INTO ucit2teachers
+	SELECT
+		u.kod,
+		l.lang,
+		u.jmeno,
+		u.prijmeni,
+		u.titulpred,
+		u.titulza,
+		uj.department
+	FROM ucit u
+	CROSS JOIN (VALUES ('cs'), ('en')) l(lang)
+	LEFT JOIN ustav2json uj ON u.ustav = uj.id AND uj.lang = l.lang
+	WHERE u.kod IS NOT NULL
+	`,
+}
+
 /*
 Prerequisites:
   - pamela
@@ -979,6 +1013,7 @@ Prerequisites:
   - povinn
   - povinn2jazyk_agg
   - typypov
+  - webapp.course_overall_ratings
 */
 var povinn2searchable = transformation{
 	name: "povinn2searchable",
@@ -1007,7 +1042,11 @@ var povinn2searchable = transformation{
 			terms_of_passing JSONB,
 			literature JSONB,
 			requirements_of_assessment JSONB,
-			aim JSONB
+			aim JSONB,
+			valid_from INT,
+			weekly_hours INT,
+			rating_average FLOAT,
+			rating_count INT
 		);
 			WITH guarantors as (
 			SELECT DISTINCT
@@ -1048,6 +1087,13 @@ var povinn2searchable = transformation{
 				jsonb_agg(aim->'content') aim
 			FROM povinn2courses
 			GROUP BY code
+		), ratings as (
+			SELECT
+				course_code,
+				AVG(rating) AS rating_average,
+				COUNT(rating) AS rating_count
+			FROM webapp.course_overall_ratings
+			GROUP BY course_code
 		)
 		INSERT INTO povinn2searchable
 		SELECT
@@ -1073,7 +1119,14 @@ var povinn2searchable = transformation{
 			d.terms_of_passing,
 			d.literature,
 			d.requirements_of_assessment,
-			d.aim
+			d.aim,
+			pc.valid_from,
+			GREATEST(
+				COALESCE(pc.lecture_range_winter, 0) + COALESCE(pc.seminar_range_winter, 0),
+				COALESCE(pc.lecture_range_summer, 0) + COALESCE(pc.seminar_range_summer, 0)
+			) weekly_hours,
+			COALESCE(r.rating_average, 0) rating_average,
+			COALESCE(r.rating_count, 0) rating_count
 		FROM povinn2courses pc
 		LEFT JOIN povinn p ON p.povinn = pc.code
 		LEFT JOIN povinn2jazyk_agg pja ON pc.code = pja.course_code AND pc.lang = pja.lang
@@ -1084,6 +1137,7 @@ var povinn2searchable = transformation{
 		LEFT JOIN povinn pvn on pvn.povinn = pc.code
 		LEFT JOIN descriptions d on pc.code = d.code
 		LEFT JOIN ustav u ON pvn.pgarant = u.kod
+		LEFT JOIN ratings r ON pc.code = r.course_code
 		WHERE pc.lang='cs'
 		AND credits > 0
 		AND (taught_state = 'V' OR taught_state = 'N')
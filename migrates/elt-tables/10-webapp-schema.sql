@@ -126,4 +126,38 @@ CREATE TABLE filter_values (
     description_cs VARCHAR(200),
     description_en VARCHAR(200),
     position INT NOT NULL
-);
\ No newline at end of file
+);
+DROP TABLE IF EXISTS teachers CASCADE;
+
+CREATE TABLE teachers (
+    id VARCHAR(10) NOT NULL,
+    lang CHAR(2) NOT NULL,
+    first_name VARCHAR(50),
+    last_name VARCHAR(50),
+    title_before VARCHAR(100),
+    title_after VARCHAR(100),
+    department JSONB,
+    PRIMARY KEY (id, lang)
+);
+
+DROP TABLE IF EXISTS search_documents CASCADE;
+
+-- Czech has no built-in text search configuration. Words are only lowercased
+-- unless a Czech ispell dictionary is installed and mapped to the
+-- configuration.
+DROP TEXT SEARCH CONFIGURATION IF EXISTS czech;
+CREATE TEXT SEARCH CONFIGURATION czech (COPY = pg_catalog.simple);
+
+-- Documents of Meilisearch indexes for PostgreSQL full-text search.
+CREATE TABLE search_documents (
+    index_uid VARCHAR(20) NOT NULL,
+    id INT NOT NULL,
+    doc JSONB NOT NULL,
+    search_cs TSVECTOR NOT NULL,
+    search_en TSVECTOR NOT NULL,
+    PRIMARY KEY (index_uid, id)
+);
+
+CREATE INDEX search_documents_search_cs_idx ON search_documents USING GIN (search_cs);
+CREATE INDEX search_documents_search_en_idx ON search_documents USING GIN (search_en);
+CREATE INDEX search_documents_doc_idx ON search_documents USING GIN (doc jsonb_path_ops);
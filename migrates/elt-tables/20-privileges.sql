@@ -13,5 +13,10 @@ ON
     webapp.degree_plans,
     webapp.filter_categories,
     webapp.filter_values,
-    webapp.filters
+    webapp.filters,
+    webapp.search_documents,
+    webapp.teachers
 TO elt;
+
+-- saved searches are matched against search documents after migration
+GRANT SELECT ON webapp.search_documents TO elt;
@@ -0,0 +1,70 @@
+SET search_path TO webapp;
+
+-- Named blueprint scenarios (each user has exactly one active scenario)
+CREATE TABLE IF NOT EXISTS blueprint_scenarios (
+    id INT GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    user_id VARCHAR(8) NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    active BOOLEAN NOT NULL DEFAULT false,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (user_id, name),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS blueprint_scenarios_one_active_idx
+ON blueprint_scenarios(user_id) WHERE active;
+
+-- Create default scenario for every existing user
+INSERT INTO blueprint_scenarios (user_id, name, active)
+SELECT u.id, 'Blueprint', true
+FROM users u
+WHERE NOT EXISTS (
+    SELECT 1 FROM blueprint_scenarios s WHERE s.user_id = u.id
+);
+
+-- Assign existing blueprint years to the default scenario
+ALTER TABLE blueprint_years ADD COLUMN IF NOT EXISTS scenario_id INT;
+
+UPDATE blueprint_years y
+SET scenario_id = s.id
+FROM blueprint_scenarios s
+WHERE s.user_id = y.user_id
+    AND s.active
+    AND y.scenario_id IS NULL;
+
+ALTER TABLE blueprint_years ALTER scenario_id SET NOT NULL;
+
+DO $$
+BEGIN
+    IF NOT EXISTS (
+        SELECT 1
+        FROM pg_constraint
+        WHERE conname = 'blueprint_years_scenario_id_fkey'
+          AND conrelid = 'webapp.blueprint_years'::regclass
+    ) THEN
+        ALTER TABLE blueprint_years
+        ADD CONSTRAINT blueprint_years_scenario_id_fkey
+        FOREIGN KEY (scenario_id)
+        REFERENCES blueprint_scenarios(id)
+        ON DELETE CASCADE;
+    END IF;
+END$$;
+
+-- Years are unique per scenario instead of per user
+ALTER TABLE blueprint_years DROP CONSTRAINT IF EXISTS blueprint_years_user_id_academic_year_key;
+
+DO $$
+BEGIN
+    IF NOT EXISTS (
+        SELECT 1
+        FROM pg_constraint
+        WHERE conname = 'blueprint_years_scenario_id_academic_year_key'
+    ) THEN
+        ALTER TABLE blueprint_years
+        ADD CONSTRAINT blueprint_years_scenario_id_academic_year_key
+        UNIQUE (scenario_id, academic_year);
+    END IF;
+END$$;
+
+GRANT SELECT, INSERT, DELETE, UPDATE ON blueprint_scenarios TO webapp;
+GRANT SELECT ON blueprint_scenarios TO recommender;
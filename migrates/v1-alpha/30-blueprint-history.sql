@@ -0,0 +1,154 @@
+SET search_path TO webapp;
+
+-- Journal of blueprint changes used for undo/redo and restoring earlier states.
+-- Entry with undone = false holds the state before the change, entry with
+-- undone = true holds the state after the change (used for redo).
+CREATE TABLE IF NOT EXISTS blueprint_history (
+    id INT GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    scenario_id INT NOT NULL,
+    action VARCHAR(50) NOT NULL,
+    snapshot JSONB NOT NULL,
+    undone BOOLEAN NOT NULL DEFAULT false,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    FOREIGN KEY (scenario_id) REFERENCES blueprint_scenarios(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS blueprint_history_scenario_id_idx
+ON blueprint_history(scenario_id, id);
+
+-- Serializes years, semesters and courses of a scenario
+CREATE OR REPLACE FUNCTION blueprint_snapshot(p_scenario_id INT)
+    RETURNS JSONB
+AS
+$$
+    SELECT jsonb_build_object(
+        'years', COALESCE((
+            SELECT jsonb_agg(y.academic_year ORDER BY y.academic_year)
+            FROM blueprint_years y
+            WHERE y.scenario_id = p_scenario_id
+        ), '[]'::jsonb),
+        'semesters', COALESCE((
+            SELECT jsonb_agg(jsonb_build_object(
+                'academic_year', y.academic_year,
+                'semester', bs.semester,
+                'folded', bs.folded
+            ) ORDER BY y.academic_year, bs.semester)
+            FROM blueprint_years y
+            INNER JOIN blueprint_semesters bs
+                ON y.id = bs.blueprint_year_id
+            WHERE y.scenario_id = p_scenario_id
+        ), '[]'::jsonb),
+        'courses', COALESCE((
+            SELECT jsonb_agg(jsonb_build_object(
+                'academic_year', y.academic_year,
+                'semester', bs.semester,
+                'code', bc.course_code,
+                'valid_from', bc.course_valid_from,
+                'position', bc.position,
+                'secondary_position', bc.secondary_position
+            ) ORDER BY y.academic_year, bs.semester, bc.position)
+            FROM blueprint_years y
+            INNER JOIN blueprint_semesters bs
+                ON y.id = bs.blueprint_year_id
+            INNER JOIN blueprint_courses bc
+                ON bs.id = bc.blueprint_semester_id
+            WHERE y.scenario_id = p_scenario_id
+        ), '[]'::jsonb)
+    );
+$$ LANGUAGE sql STABLE;
+
+-- Replaces content of a scenario with a snapshot created by blueprint_snapshot
+CREATE OR REPLACE FUNCTION blueprint_restore(p_scenario_id INT, p_snapshot JSONB)
+    RETURNS VOID
+AS
+$$
+DECLARE
+    v_user_id VARCHAR(8);
+BEGIN
+    SELECT user_id INTO v_user_id FROM blueprint_scenarios WHERE id = p_scenario_id;
+
+    DELETE FROM blueprint_years WHERE scenario_id = p_scenario_id;
+
+    INSERT INTO blueprint_years (user_id, scenario_id, academic_year)
+    SELECT v_user_id, p_scenario_id, y::int
+    FROM jsonb_array_elements_text(p_snapshot->'years') y;
+
+    INSERT INTO blueprint_semesters (blueprint_year_id, semester, folded)
+    SELECT y.id, (s->>'semester')::int, (s->>'folded')::boolean
+    FROM jsonb_array_elements(p_snapshot->'semesters') s
+    INNER JOIN blueprint_years y
+        ON y.scenario_id = p_scenario_id
+        AND y.academic_year = (s->>'academic_year')::int;
+
+    INSERT INTO blueprint_courses (blueprint_semester_id, course_code, course_valid_from, position, secondary_position)
+    SELECT bs.id, c->>'code', (c->>'valid_from')::int, (c->>'position')::int, (c->>'secondary_position')::int
+    FROM jsonb_array_elements(p_snapshot->'courses') c
+    INNER JOIN blueprint_years y
+        ON y.scenario_id = p_scenario_id
+        AND y.academic_year = (c->>'academic_year')::int
+    INNER JOIN blueprint_semesters bs
+        ON y.id = bs.blueprint_year_id
+        AND bs.semester = (c->>'semester')::int;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Records the current state of the active scenario before a change. Any undone
+-- entries are discarded (the redo stack is cleared) and only the newest
+-- 50 entries are kept.
+CREATE OR REPLACE FUNCTION record_blueprint_history(p_user_id VARCHAR(8), p_action VARCHAR(50))
+    RETURNS VOID
+AS
+$$
+DECLARE
+    v_scenario_id INT;
+BEGIN
+    SELECT id INTO v_scenario_id FROM blueprint_scenarios WHERE user_id = p_user_id AND active;
+    IF v_scenario_id IS NULL THEN
+        RETURN;
+    END IF;
+
+    DELETE FROM blueprint_history WHERE scenario_id = v_scenario_id AND undone;
+
+    INSERT INTO blueprint_history (scenario_id, action, snapshot)
+    VALUES (v_scenario_id, p_action, blueprint_snapshot(v_scenario_id));
+
+    DELETE FROM blueprint_history
+    WHERE scenario_id = v_scenario_id
+        AND id NOT IN (
+            SELECT id
+            FROM blueprint_history
+            WHERE scenario_id = v_scenario_id
+            ORDER BY id DESC
+            LIMIT 50
+        );
+END;
+$$ LANGUAGE plpgsql;
+
+-- Swaps the stored snapshot with the current state and flips the entry
+-- between done and undone. Used by both undo and redo.
+CREATE OR REPLACE FUNCTION swap_blueprint_history(p_history_id INT)
+    RETURNS VOID
+AS
+$$
+DECLARE
+    v_scenario_id INT;
+    v_snapshot JSONB;
+BEGIN
+    SELECT scenario_id, snapshot INTO v_scenario_id, v_snapshot
+    FROM blueprint_history
+    WHERE id = p_history_id
+    FOR UPDATE;
+
+    UPDATE blueprint_history
+    SET snapshot = blueprint_snapshot(v_scenario_id), undone = NOT undone
+    WHERE id = p_history_id;
+
+    PERFORM blueprint_restore(v_scenario_id, v_snapshot);
+END;
+$$ LANGUAGE plpgsql;
+
+GRANT SELECT, INSERT, DELETE, UPDATE ON blueprint_history TO webapp;
+GRANT EXECUTE ON FUNCTION blueprint_snapshot(INT) TO webapp;
+GRANT EXECUTE ON FUNCTION blueprint_restore(INT, JSONB) TO webapp;
+GRANT EXECUTE ON FUNCTION record_blueprint_history(VARCHAR, VARCHAR) TO webapp;
+GRANT EXECUTE ON FUNCTION swap_blueprint_history(INT) TO webapp;
@@ -0,0 +1,118 @@
+SET search_path TO webapp;
+
+-- Completion status, grade and completion date of blueprint courses
+ALTER TABLE blueprint_courses ADD COLUMN IF NOT EXISTS status VARCHAR(10) NOT NULL DEFAULT 'planned';
+ALTER TABLE blueprint_courses ADD COLUMN IF NOT EXISTS grade INT;
+ALTER TABLE blueprint_courses ADD COLUMN IF NOT EXISTS completed_at DATE;
+
+DO $$
+BEGIN
+    IF NOT EXISTS (
+        SELECT 1
+        FROM pg_constraint
+        WHERE conname = 'blueprint_courses_status_check'
+    ) THEN
+        ALTER TABLE blueprint_courses
+        ADD CONSTRAINT blueprint_courses_status_check
+        CHECK (status IN ('planned', 'enrolled', 'passed', 'failed'));
+    END IF;
+END$$;
+
+DO $$
+BEGIN
+    IF NOT EXISTS (
+        SELECT 1
+        FROM pg_constraint
+        WHERE conname = 'blueprint_courses_grade_check'
+    ) THEN
+        ALTER TABLE blueprint_courses
+        ADD CONSTRAINT blueprint_courses_grade_check
+        CHECK (grade BETWEEN 1 AND 4);
+    END IF;
+END$$;
+
+-- Snapshots used by blueprint history include the completion status
+CREATE OR REPLACE FUNCTION blueprint_snapshot(p_scenario_id INT)
+    RETURNS JSONB
+AS
+$$
+    SELECT jsonb_build_object(
+        'years', COALESCE((
+            SELECT jsonb_agg(y.academic_year ORDER BY y.academic_year)
+            FROM blueprint_years y
+            WHERE y.scenario_id = p_scenario_id
+        ), '[]'::jsonb),
+        'semesters', COALESCE((
+            SELECT jsonb_agg(jsonb_build_object(
+                'academic_year', y.academic_year,
+                'semester', bs.semester,
+                'folded', bs.folded
+            ) ORDER BY y.academic_year, bs.semester)
+            FROM blueprint_years y
+            INNER JOIN blueprint_semesters bs
+                ON y.id = bs.blueprint_year_id
+            WHERE y.scenario_id = p_scenario_id
+        ), '[]'::jsonb),
+        'courses', COALESCE((
+            SELECT jsonb_agg(jsonb_build_object(
+                'academic_year', y.academic_year,
+                'semester', bs.semester,
+                'code', bc.course_code,
+                'valid_from', bc.course_valid_from,
+                'position', bc.position,
+                'secondary_position', bc.secondary_position,
+                'status', bc.status,
+                'grade', bc.grade,
+                'completed_at', bc.completed_at
+            ) ORDER BY y.academic_year, bs.semester, bc.position)
+            FROM blueprint_years y
+            INNER JOIN blueprint_semesters bs
+                ON y.id = bs.blueprint_year_id
+            INNER JOIN blueprint_courses bc
+                ON bs.id = bc.blueprint_semester_id
+            WHERE y.scenario_id = p_scenario_id
+        ), '[]'::jsonb)
+    );
+$$ LANGUAGE sql STABLE;
+
+CREATE OR REPLACE FUNCTION blueprint_restore(p_scenario_id INT, p_snapshot JSONB)
+    RETURNS VOID
+AS
+$$
+DECLARE
+    v_user_id VARCHAR(8);
+BEGIN
+    SELECT user_id INTO v_user_id FROM blueprint_scenarios WHERE id = p_scenario_id;
+
+    DELETE FROM blueprint_years WHERE scenario_id = p_scenario_id;
+
+    INSERT INTO blueprint_years (user_id, scenario_id, academic_year)
+    SELECT v_user_id, p_scenario_id, y::int
+    FROM jsonb_array_elements_text(p_snapshot->'years') y;
+
+    INSERT INTO blueprint_semesters (blueprint_year_id, semester, folded)
+    SELECT y.id, (s->>'semester')::int, (s->>'folded')::boolean
+    FROM jsonb_array_elements(p_snapshot->'semesters') s
+    INNER JOIN blueprint_years y
+        ON y.scenario_id = p_scenario_id
+        AND y.academic_year = (s->>'academic_year')::int;
+
+    INSERT INTO blueprint_courses (blueprint_semester_id, course_code, course_valid_from, position, secondary_position, status, grade, completed_at)
+    SELECT
+        bs.id,
+        c->>'code',
+        (c->>'valid_from')::int,
+        (c->>'position')::int,
+        (c->>'secondary_position')::int,
+        COALESCE(c->>'status', 'planned'),
+        (c->>'grade')::int,
+        (c->>'completed_at')::date
+    FROM jsonb_array_elements(p_snapshot->'courses') c
+    INNER JOIN blueprint_years y
+        ON y.scenario_id = p_scenario_id
+        AND y.academic_year = (c->>'academic_year')::int
+    INNER JOIN blueprint_semesters bs
+        ON y.id = bs.blueprint_year_id
+        AND bs.semester = (c->>'semester')::int;
+END;
+$$ LANGUAGE plpgsql;
@@ -0,0 +1,18 @@
+SET search_path TO webapp;
+
+-- Read-only links to a blueprint. Links carry only the random nonce and the
+-- expiration signed by the webapp, never the owner's user ID.
+CREATE TABLE IF NOT EXISTS blueprint_shares (
+    id INT GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    user_id VARCHAR(8) NOT NULL,
+    nonce VARCHAR(32) NOT NULL UNIQUE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    expires_at TIMESTAMPTZ NOT NULL,
+    revoked_at TIMESTAMPTZ,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS blueprint_shares_user_id_idx
+ON blueprint_shares(user_id);
+
+GRANT SELECT, INSERT, UPDATE, DELETE ON blueprint_shares TO webapp;
@@ -0,0 +1,19 @@
+SET search_path TO webapp;
+
+-- Start year of studies anchors blueprint years to calendar academic years.
+-- It is nullable and set by the user in the blueprint until SIS integration
+-- provides it (the original NOT NULL column was dropped in 10-webapp-schema).
+ALTER TABLE studies ADD COLUMN IF NOT EXISTS start_year INT;
+
+DO $$
+BEGIN
+    IF NOT EXISTS (
+        SELECT 1
+        FROM pg_constraint
+        WHERE conname = 'studies_start_year_check'
+    ) THEN
+        ALTER TABLE studies
+        ADD CONSTRAINT studies_start_year_check
+        CHECK (start_year IS NULL OR start_year BETWEEN 2000 AND 2100);
+    END IF;
+END$$;
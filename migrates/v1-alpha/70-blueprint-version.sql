@@ -0,0 +1,83 @@
+SET search_path TO webapp;
+
+-- Version of a scenario changes with every recorded change of its content. The
+-- blueprint page sends the version it was rendered with and changes made
+-- against an older version are rejected. Versions are taken from a single
+-- sequence, so versions of different scenarios never collide.
+CREATE SEQUENCE IF NOT EXISTS blueprint_version_seq;
+
+ALTER TABLE blueprint_scenarios
+ADD COLUMN IF NOT EXISTS version BIGINT NOT NULL DEFAULT nextval('blueprint_version_seq');
+
+-- Version of the scenario right after the change (NULL for older entries)
+ALTER TABLE blueprint_history ADD COLUMN IF NOT EXISTS version BIGINT;
+
+-- Records the current state of the active scenario before a change. Any undone
+-- entries are discarded (the redo stack is cleared) and only the newest
+-- 50 entries are kept. The version of the scenario is increased.
+CREATE OR REPLACE FUNCTION record_blueprint_history(p_user_id VARCHAR(8), p_action VARCHAR(50))
+    RETURNS VOID
+AS
+$$
+DECLARE
+    v_scenario_id INT;
+    v_version BIGINT;
+BEGIN
+    SELECT id INTO v_scenario_id FROM blueprint_scenarios WHERE user_id = p_user_id AND active;
+    IF v_scenario_id IS NULL THEN
+        RETURN;
+    END IF;
+
+    UPDATE blueprint_scenarios
+    SET version = nextval('blueprint_version_seq')
+    WHERE id = v_scenario_id
+    RETURNING version INTO v_version;
+
+    DELETE FROM blueprint_history WHERE scenario_id = v_scenario_id AND undone;
+
+    INSERT INTO blueprint_history (scenario_id, action, snapshot, version)
+    VALUES (v_scenario_id, p_action, blueprint_snapshot(v_scenario_id), v_version);
+
+    DELETE FROM blueprint_history
+    WHERE scenario_id = v_scenario_id
+        AND id NOT IN (
+            SELECT id
+            FROM blueprint_history
+            WHERE scenario_id = v_scenario_id
+            ORDER BY id DESC
+            LIMIT 50
+        );
+END;
+$$ LANGUAGE plpgsql;
+
+-- Swaps the stored snapshot with the current state and flips the entry
+-- between done and undone. Used by both undo and redo. The version of the
+-- scenario is increased and stored in the entry.
+CREATE OR REPLACE FUNCTION swap_blueprint_history(p_history_id INT)
+    RETURNS VOID
+AS
+$$
+DECLARE
+    v_scenario_id INT;
+    v_snapshot JSONB;
+    v_version BIGINT;
+BEGIN
+    SELECT scenario_id, snapshot INTO v_scenario_id, v_snapshot
+    FROM blueprint_history
+    WHERE id = p_history_id
+    FOR UPDATE;
+
+    UPDATE blueprint_scenarios
+    SET version = nextval('blueprint_version_seq')
+    WHERE id = v_scenario_id
+    RETURNING version INTO v_version;
+
+    UPDATE blueprint_history
+    SET snapshot = blueprint_snapshot(v_scenario_id), undone = NOT undone, version = v_version
+    WHERE id = p_history_id;
+
+    PERFORM blueprint_restore(v_scenario_id, v_snapshot);
+END;
+$$ LANGUAGE plpgsql;
+
+GRANT USAGE ON SEQUENCE blueprint_version_seq TO webapp;
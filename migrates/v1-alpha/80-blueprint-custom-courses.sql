@@ -0,0 +1,135 @@
+SET search_path TO webapp;
+
+-- Custom blueprint courses (Erasmus courses, courses of other faculties,
+-- placeholders) have no SIS course. They carry their own title, credits and
+-- teaching semester (1 winter, 2 summer, 3 both) and may be mapped to a SIS
+-- course they substitute.
+ALTER TABLE blueprint_courses ALTER COLUMN course_code DROP NOT NULL;
+ALTER TABLE blueprint_courses ALTER COLUMN course_valid_from DROP NOT NULL;
+ALTER TABLE blueprint_courses ADD COLUMN IF NOT EXISTS custom_title VARCHAR(250);
+ALTER TABLE blueprint_courses ADD COLUMN IF NOT EXISTS custom_credits INT;
+ALTER TABLE blueprint_courses ADD COLUMN IF NOT EXISTS custom_semester INT;
+ALTER TABLE blueprint_courses ADD COLUMN IF NOT EXISTS mapped_course_code VARCHAR(10);
+
+DO $$
+BEGIN
+    IF NOT EXISTS (
+        SELECT 1
+        FROM pg_constraint
+        WHERE conname = 'blueprint_courses_custom_check'
+    ) THEN
+        ALTER TABLE blueprint_courses
+        ADD CONSTRAINT blueprint_courses_custom_check
+        CHECK (
+            (
+                course_code IS NOT NULL
+                AND custom_title IS NULL
+                AND custom_credits IS NULL
+                AND custom_semester IS NULL
+                AND mapped_course_code IS NULL
+            ) OR (
+                course_code IS NULL
+                AND custom_title IS NOT NULL
+                AND custom_credits >= 0
+                AND custom_semester IN (1, 2, 3)
+            )
+        );
+    END IF;
+END$$;
+
+-- Snapshots used by blueprint history include custom courses
+CREATE OR REPLACE FUNCTION blueprint_snapshot(p_scenario_id INT)
+    RETURNS JSONB
+AS
+$$
+    SELECT jsonb_build_object(
+        'years', COALESCE((
+            SELECT jsonb_agg(y.academic_year ORDER BY y.academic_year)
+            FROM blueprint_years y
+            WHERE y.scenario_id = p_scenario_id
+        ), '[]'::jsonb),
+        'semesters', COALESCE((
+            SELECT jsonb_agg(jsonb_build_object(
+                'academic_year', y.academic_year,
+                'semester', bs.semester,
+                'folded', bs.folded
+            ) ORDER BY y.academic_year, bs.semester)
+            FROM blueprint_years y
+            INNER JOIN blueprint_semesters bs
+                ON y.id = bs.blueprint_year_id
+            WHERE y.scenario_id = p_scenario_id
+        ), '[]'::jsonb),
+        'courses', COALESCE((
+            SELECT jsonb_agg(jsonb_build_object(
+                'academic_year', y.academic_year,
+                'semester', bs.semester,
+                'code', bc.course_code,
+                'valid_from', bc.course_valid_from,
+                'position', bc.position,
+                'secondary_position', bc.secondary_position,
+                'status', bc.status,
+                'grade', bc.grade,
+                'completed_at', bc.completed_at,
+                'custom_title', bc.custom_title,
+                'custom_credits', bc.custom_credits,
+                'custom_semester', bc.custom_semester,
+                'mapped_code', bc.mapped_course_code
+            ) ORDER BY y.academic_year, bs.semester, bc.position)
+            FROM blueprint_years y
+            INNER JOIN blueprint_semesters bs
+                ON y.id = bs.blueprint_year_id
+            INNER JOIN blueprint_courses bc
+                ON bs.id = bc.blueprint_semester_id
+            WHERE y.scenario_id = p_scenario_id
+        ), '[]'::jsonb)
+    );
+$$ LANGUAGE sql STABLE;
+
+CREATE OR REPLACE FUNCTION blueprint_restore(p_scenario_id INT, p_snapshot JSONB)
+    RETURNS VOID
+AS
+$$
+DECLARE
+    v_user_id VARCHAR(8);
+BEGIN
+    SELECT user_id INTO v_user_id FROM blueprint_scenarios WHERE id = p_scenario_id;
+
+    DELETE FROM blueprint_years WHERE scenario_id = p_scenario_id;
+
+    INSERT INTO blueprint_years (user_id, scenario_id, academic_year)
+    SELECT v_user_id, p_scenario_id, y::int
+    FROM jsonb_array_elements_text(p_snapshot->'years') y;
+
+    INSERT INTO blueprint_semesters (blueprint_year_id, semester, folded)
+    SELECT y.id, (s->>'semester')::int, (s->>'folded')::boolean
+    FROM jsonb_array_elements(p_snapshot->'semesters') s
+    INNER JOIN blueprint_years y
+        ON y.scenario_id = p_scenario_id
+        AND y.academic_year = (s->>'academic_year')::int;
+
+    INSERT INTO blueprint_courses (
+        blueprint_semester_id, course_code, course_valid_from, position, secondary_position, status, grade, completed_at,
+        custom_title, custom_credits, custom_semester, mapped_course_code
+    )
+    SELECT
+        bs.id,
+        c->>'code',
+        (c->>'valid_from')::int,
+        (c->>'position')::int,
+        (c->>'secondary_position')::int,
+        COALESCE(c->>'status', 'planned'),
+        (c->>'grade')::int,
+        (c->>'completed_at')::date,
+        c->>'custom_title',
+        (c->>'custom_credits')::int,
+        (c->>'custom_semester')::int,
+        c->>'mapped_code'
+    FROM jsonb_array_elements(p_snapshot->'courses') c
+    INNER JOIN blueprint_years y
+        ON y.scenario_id = p_scenario_id
+        AND y.academic_year = (c->>'academic_year')::int
+    INNER JOIN blueprint_semesters bs
+        ON y.id = bs.blueprint_year_id
+        AND bs.semester = (c->>'semester')::int;
+END;
+$$ LANGUAGE plpgsql;
@@ -0,0 +1,5 @@
+SET search_path TO webapp;
+
+-- The ELT adds average rating and number of ratings to the course search
+-- index so that search results can be sorted by them.
+GRANT SELECT ON course_overall_ratings TO elt;
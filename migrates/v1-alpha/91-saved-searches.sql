@@ -0,0 +1,54 @@
+SET search_path TO webapp;
+
+-- Course searches saved by users. The search is kept both as URL query of
+-- the course search page (to re-run it) and as full-text query and SQL/JSON
+-- path filter over search_documents (for the ELT to find new matches).
+CREATE TABLE IF NOT EXISTS saved_searches (
+    id INT GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    user_id VARCHAR(8) NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    url_query TEXT NOT NULL,
+    text_query TEXT NOT NULL,
+    filter TEXT NOT NULL,
+    lang CHAR(2) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (user_id, name),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+-- Courses matching a saved search. Matches found by the ELT after the search
+-- was saved are new until the user marks them as seen.
+CREATE TABLE IF NOT EXISTS saved_search_matches (
+    saved_search_id INT NOT NULL,
+    course_code VARCHAR(10) NOT NULL,
+    is_new BOOLEAN NOT NULL DEFAULT FALSE,
+    PRIMARY KEY (saved_search_id, course_code),
+    FOREIGN KEY (saved_search_id) REFERENCES saved_searches(id) ON DELETE CASCADE
+);
+
+-- Codes of courses matching the saved search. The webapp stores them when
+-- the search is saved and the ELT compares them with the stored ones after
+-- each load, so both have to match courses the same way. Names are qualified
+-- as the ELT does not have webapp in its search path.
+CREATE OR REPLACE FUNCTION saved_search_courses(p_saved_search_id INT)
+    RETURNS TABLE (course_code TEXT)
+AS
+$$
+    SELECT DISTINCT d.doc->>'code'
+    FROM webapp.saved_searches s
+    INNER JOIN webapp.search_documents d
+        ON d.index_uid = 'courses'
+        AND (s.text_query = '' OR CASE s.lang
+            WHEN 'en' THEN d.search_en @@ to_tsquery('english', s.text_query)
+            ELSE d.search_cs @@ to_tsquery('webapp.czech', s.text_query)
+        END)
+        AND (s.filter = '' OR d.doc @? s.filter::jsonpath)
+    WHERE s.id = p_saved_search_id;
+$$ LANGUAGE sql STABLE;
+
+GRANT SELECT, INSERT, UPDATE, DELETE ON saved_searches TO webapp;
+GRANT SELECT, INSERT, UPDATE, DELETE ON saved_search_matches TO webapp;
+GRANT SELECT ON saved_searches TO elt;
+GRANT SELECT, INSERT, UPDATE, DELETE ON saved_search_matches TO elt;
+GRANT EXECUTE ON FUNCTION saved_search_courses(INT) TO webapp;
+GRANT EXECUTE ON FUNCTION saved_search_courses(INT) TO elt;
@@ -0,0 +1,14 @@
+SET search_path TO webapp;
+
+-- Courses a user collected for side-by-side comparison (the comparison tray
+-- shown on the courses and blueprint pages). The webapp keeps at most four
+-- courses per user.
+CREATE TABLE IF NOT EXISTS course_comparisons (
+    user_id VARCHAR(8) NOT NULL,
+    course_code VARCHAR(10) NOT NULL,
+    added_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, course_code),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+GRANT SELECT, INSERT, DELETE ON course_comparisons TO webapp;
@@ -0,0 +1,38 @@
+SET search_path TO webapp;
+
+-- Snapshots of courses per academic year (identified by the calendar year it
+-- starts in, e.g. 2025 for 2025/26). Unlike courses, which hold the current
+-- record only and are replaced on every ELT load, the ELT upserts the snapshot
+-- of the academic year of the load so the history is kept across loads.
+CREATE TABLE IF NOT EXISTS course_history (
+    code VARCHAR(10) NOT NULL,
+    lang CHAR(2) NOT NULL,
+    academic_year INT NOT NULL,
+    title VARCHAR(250),
+    valid_from INT,
+    valid_to INT,
+    lecture_range_winter INT,
+    seminar_range_winter INT,
+    lecture_range_summer INT,
+    seminar_range_summer INT,
+    range_unit JSONB,
+    exam VARCHAR(30),
+    credits INT,
+    guarantors JSONB,
+    prerequisites JSONB,
+    corequisites JSONB,
+    incompatibilities JSONB,
+    interchangeabilities JSONB,
+    annotation JSONB,
+    syllabus JSONB,
+    terms_of_passing JSONB,
+    literature JSONB,
+    requirements_of_assessment JSONB,
+    entry_requirements JSONB,
+    aim JSONB,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (code, lang, academic_year)
+);
+
+GRANT SELECT, INSERT, UPDATE ON course_history TO elt;
+GRANT SELECT ON course_history TO webapp;
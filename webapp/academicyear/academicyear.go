@@ -0,0 +1,83 @@
+package academicyear
+
+/** PACKAGE DESCRIPTION
+
+The academicyear package anchors blueprint years, which are positions 1, 2, 3…, to calendar academic years using the year the user started studying. An academic year is identified by the calendar year it starts in, e.g. 2025 for "2025/26", which is also how course validity (valid_from, valid_to) is stored.
+
+The start year is stored in studies.start_year and may be unknown, in which case functions return zero values and labels fall back to plain positions. Authentication puts the start year of the user into the request context so that any component can label years without querying the database.
+
+*/
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Unlimited is the valid_to of courses and degree plans which are still valid.
+const Unlimited = 9999
+
+const (
+	MinStartYear = 2000
+	// MaxStartYearOffset limits the start year to the next academic year.
+	MaxStartYearOffset = 1
+)
+
+// MaxStartYear returns the latest start year a user can choose at time now.
+func MaxStartYear(now time.Time) int {
+	return now.Year() + MaxStartYearOffset
+}
+
+// Of returns the calendar year in which the academic year of the blueprint
+// year (position) starts, or 0 if the start year is unknown.
+func Of(startYear, position int) int {
+	if startYear <= 0 || position <= 0 {
+		return 0
+	}
+	return startYear + position - 1
+}
+
+// Label returns e.g. "2025/26" for 2025, or "" for 0.
+func Label(year int) string {
+	if year <= 0 {
+		return ""
+	}
+	return fmt.Sprintf("%d/%02d", year, (year+1)%100)
+}
+
+// PositionLabel returns the academic year label of a blueprint year or "" if
+// the start year is unknown.
+func PositionLabel(startYear, position int) string {
+	return Label(Of(startYear, position))
+}
+
+// IsValid reports whether year lies within the validity window of a course.
+// Unknown years are always valid.
+func IsValid(year, validFrom, validTo int) bool {
+	if year <= 0 {
+		return true
+	}
+	return year >= validFrom && year <= validTo
+}
+
+// Suffix returns e.g. " (2025/26)" to be appended to the label of a blueprint
+// year if the start year is stored in ctx, otherwise "".
+func Suffix(ctx context.Context, position int) string {
+	if label := PositionLabel(StartYear(ctx), position); label != "" {
+		return " (" + label + ")"
+	}
+	return ""
+}
+
+type startYearKey struct{}
+
+// WithStartYear stores the study start year of the current user in ctx.
+func WithStartYear(ctx context.Context, startYear int) context.Context {
+	return context.WithValue(ctx, startYearKey{}, startYear)
+}
+
+// StartYear returns the study start year stored in ctx or 0 if unknown.
+func StartYear(ctx context.Context) int {
+	startYear, _ := ctx.Value(startYearKey{}).(int)
+	return startYear
+}
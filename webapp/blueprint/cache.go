@@ -1,129 +1,352 @@
 package blueprint
 
 import (
-	"fmt"
+	"container/list"
+	"database/sql"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/michalhercik/RecSIS/language"
 )
 
 type Adapter interface {
 	blueprint(userID string, lang language.Language) (*blueprintPage, error)
-	moveCourses(userID string, lang language.Language, year int, semester semesterAssignment, position int, courses ...int) error
-	appendCourses(userID string, lang language.Language, year int, semester semesterAssignment, courses ...int) error
-	unassignSemester(userID string, lang language.Language, year int, semester semesterAssignment) error
-	removeCourses(userID string, lang language.Language, courses ...int) error
-	removeCoursesBySemester(userID string, lang language.Language, year int, semester semesterAssignment) error
-	addYear(userID string, lang language.Language) error
-	removeYear(userID string, lang language.Language, shouldUnassign bool) error
+	moveCourses(userID string, lang language.Language, version int64, year int, semester semesterAssignment, position int, courses ...int) error
+	appendCourses(userID string, lang language.Language, version int64, year int, semester semesterAssignment, courses ...int) error
+	unassignSemester(userID string, lang language.Language, version int64, year int, semester semesterAssignment) error
+	removeCourses(userID string, lang language.Language, version int64, courses ...int) error
+	removeCoursesBySemester(userID string, lang language.Language, version int64, year int, semester semesterAssignment) error
+	addYear(userID string, lang language.Language, version int64) error
+	removeYear(userID string, lang language.Language, version int64, shouldUnassign bool) error
 	foldSemester(userID string, lang language.Language, year int, semester semesterAssignment, folded bool) error
+	createScenario(userID string, lang language.Language, name string) error
+	cloneScenario(userID string, lang language.Language, sourceID int, name string) error
+	renameScenario(userID string, lang language.Language, scenarioID int, name string) error
+	deleteScenario(userID string, lang language.Language, scenarioID int) error
+	activateScenario(userID string, lang language.Language, scenarioID int) error
+	setCourseStatus(userID string, lang language.Language, version int64, courseID int, status courseStatus, grade sql.NullInt64, completedAt sql.NullTime) error
+	importBlueprint(userID string, lang language.Language, version int64, data importData, mode string) error
+	addCustomCourse(userID string, lang language.Language, version int64, c customCourse) error
+	editCustomCourse(userID string, lang language.Language, version int64, courseID int, c customCourse) error
+	degreePlanCourses(userID string, lang language.Language) ([]course, error)
+	applySchedule(userID string, lang language.Language, version int64, placements []importCourse) error
+	applyFix(userID string, lang language.Language, version int64, f fix) error
+	setStartYear(userID string, lang language.Language, startYear int) error
+	shares(userID string, lang language.Language) ([]share, error)
+	createShare(userID string, lang language.Language, nonce string, expiresAt time.Time) error
+	revokeShare(userID string, lang language.Language, shareID int) error
+	undo(userID string, lang language.Language, version int64) error
+	redo(userID string, lang language.Language, version int64) error
+	restoreHistory(userID string, lang language.Language, version int64, historyID int) error
+	preview(userID string, lang language.Language, change func(a Adapter) error) (*blueprintPage, error)
+	degreePlanBlocs(userID string, lang language.Language) ([]degreePlanBloc, error)
 }
 
+//================================================================================
+// Cache
+//================================================================================
+
+const (
+	DefaultCacheTTL        = 5 * time.Minute
+	DefaultCacheMaxEntries = 1000
+)
+
+// Cache keeps recently loaded blueprints in memory and is safe for concurrent
+// use. Entries expire after TTL and the least recently used entry is evicted
+// when MaxEntries is reached (zero values mean the defaults). All entries of
+// a user are dropped after every change made through the cache. Other
+// packages changing the blueprint must call Invalidate.
+//
+// Cache must not be copied after first use.
 type Cache struct {
 	Source     Adapter
-	blueprints map[string]*blueprintPage
+	TTL        time.Duration
+	MaxEntries int
+
+	mu      sync.Mutex
+	entries map[cacheKey]*list.Element
+	lru     *list.List
+	// generation is increased by every invalidation so that a blueprint
+	// loaded concurrently with a change is not stored.
+	generation uint64
+	hits       atomic.Uint64
+	misses     atomic.Uint64
 }
 
-func (c Cache) blueprint(userID string, lang language.Language) (*blueprintPage, error) {
-	if c.blueprints == nil {
-		c.blueprints = make(map[string]*blueprintPage)
-	}
-	key := generateKey(userID, lang)
-	bp, ok := c.blueprints[key]
-	if ok {
-		return bp, nil
-	}
-	bp, err := c.Source.blueprint(userID, lang)
-	if err != nil {
-		return nil, err
-	}
-	c.blueprints[key] = bp
-	return bp, nil
+type cacheKey struct {
+	userID string
+	lang   language.Language
 }
 
-func generateKey(userID string, lang language.Language) string {
-	return string(fmt.Sprintf("%s:%s", userID, lang))
+type cacheEntry struct {
+	key       cacheKey
+	blueprint *blueprintPage
+	expires   time.Time
 }
 
-func (c Cache) invalidate(key string) {
-	delete(c.blueprints, key)
+// CacheStats is a snapshot of cache counters.
+type CacheStats struct {
+	Hits    uint64
+	Misses  uint64
+	Entries int
 }
 
-func (c Cache) moveCourses(userID string, lang language.Language, year int, semester semesterAssignment, position int, courses ...int) error {
-	key := generateKey(userID, lang)
-	c.invalidate(key)
-	err := c.Source.moveCourses(userID, lang, year, semester, position, courses...)
-	if err != nil {
-		return err
+func (c *Cache) Stats() CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return CacheStats{
+		Hits:    c.hits.Load(),
+		Misses:  c.misses.Load(),
+		Entries: len(c.entries),
 	}
-	return nil
 }
 
-func (c Cache) appendCourses(userID string, lang language.Language, year int, semester semesterAssignment, courses ...int) error {
-	key := generateKey(userID, lang)
-	c.invalidate(key)
-	err := c.Source.appendCourses(userID, lang, year, semester, courses...)
-	if err != nil {
-		return err
+// Invalidate drops cached blueprints of the user in all languages.
+func (c *Cache) Invalidate(userID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.generation++
+	for _, lang := range []language.Language{language.CS, language.EN} {
+		if elem, ok := c.entries[cacheKey{userID, lang}]; ok {
+			c.remove(elem)
+		}
 	}
-	return nil
 }
 
-func (c Cache) unassignSemester(userID string, lang language.Language, year int, semester semesterAssignment) error {
-	key := generateKey(userID, lang)
-	c.invalidate(key)
-	err := c.Source.unassignSemester(userID, lang, year, semester)
-	if err != nil {
-		return err
+// blueprint returns a copy of the cached blueprint, so callers are free to
+// modify it (e.g. when generating warnings).
+func (c *Cache) blueprint(userID string, lang language.Language) (*blueprintPage, error) {
+	key := cacheKey{userID, lang}
+	c.mu.Lock()
+	c.init()
+	if elem, ok := c.entries[key]; ok {
+		entry := elem.Value.(*cacheEntry)
+		if time.Now().Before(entry.expires) {
+			c.lru.MoveToFront(elem)
+			c.mu.Unlock()
+			c.hits.Add(1)
+			return entry.blueprint.clone(), nil
+		}
+		c.remove(elem)
 	}
-	return nil
-}
+	generation := c.generation
+	c.mu.Unlock()
+	c.misses.Add(1)
 
-func (c Cache) removeCourses(userID string, lang language.Language, courses ...int) error {
-	key := generateKey(userID, lang)
-	c.invalidate(key)
-	err := c.Source.removeCourses(userID, lang, courses...)
+	bp, err := c.Source.blueprint(userID, lang)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	return nil
-}
 
-func (c Cache) removeCoursesBySemester(userID string, lang language.Language, year int, semester semesterAssignment) error {
-	key := generateKey(userID, lang)
-	c.invalidate(key)
-	err := c.Source.removeCoursesBySemester(userID, lang, year, semester)
-	if err != nil {
-		return err
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if generation == c.generation {
+		c.store(key, bp)
 	}
-	return nil
+	return bp.clone(), nil
 }
 
-func (c Cache) addYear(userID string, lang language.Language) error {
-	key := generateKey(userID, lang)
-	c.invalidate(key)
-	err := c.Source.addYear(userID, lang)
-	if err != nil {
-		return err
+func (c *Cache) init() {
+	if c.entries == nil {
+		c.entries = make(map[cacheKey]*list.Element)
+		c.lru = list.New()
 	}
-	return nil
 }
 
-func (c Cache) removeYear(userID string, lang language.Language, shouldUnassign bool) error {
-	key := generateKey(userID, lang)
-	c.invalidate(key)
-	err := c.Source.removeYear(userID, lang, shouldUnassign)
-	if err != nil {
-		return err
+func (c *Cache) store(key cacheKey, bp *blueprintPage) {
+	ttl := c.TTL
+	if ttl <= 0 {
+		ttl = DefaultCacheTTL
+	}
+	maxEntries := c.MaxEntries
+	if maxEntries <= 0 {
+		maxEntries = DefaultCacheMaxEntries
+	}
+	if elem, ok := c.entries[key]; ok {
+		c.remove(elem)
 	}
-	return nil
+	for c.lru.Len() >= maxEntries {
+		c.remove(c.lru.Back())
+	}
+	c.entries[key] = c.lru.PushFront(&cacheEntry{
+		key:       key,
+		blueprint: bp,
+		expires:   time.Now().Add(ttl),
+	})
 }
 
-func (c Cache) foldSemester(userID string, lang language.Language, year int, semester semesterAssignment, folded bool) error {
-	key := generateKey(userID, lang)
-	c.invalidate(key)
-	err := c.Source.foldSemester(userID, lang, year, semester, folded)
-	if err != nil {
-		return err
-	}
-	return nil
+func (c *Cache) remove(elem *list.Element) {
+	c.lru.Remove(elem)
+	delete(c.entries, elem.Value.(*cacheEntry).key)
+}
+
+// change runs operation and invalidates the user's blueprints afterwards,
+// even if the operation fails, since it may have been partially applied.
+func (c *Cache) change(userID string, operation func() error) error {
+	defer c.Invalidate(userID)
+	return operation()
+}
+
+func (c *Cache) moveCourses(userID string, lang language.Language, version int64, year int, semester semesterAssignment, position int, courses ...int) error {
+	return c.change(userID, func() error {
+		return c.Source.moveCourses(userID, lang, version, year, semester, position, courses...)
+	})
+}
+
+func (c *Cache) appendCourses(userID string, lang language.Language, version int64, year int, semester semesterAssignment, courses ...int) error {
+	return c.change(userID, func() error {
+		return c.Source.appendCourses(userID, lang, version, year, semester, courses...)
+	})
+}
+
+func (c *Cache) unassignSemester(userID string, lang language.Language, version int64, year int, semester semesterAssignment) error {
+	return c.change(userID, func() error {
+		return c.Source.unassignSemester(userID, lang, version, year, semester)
+	})
+}
+
+func (c *Cache) removeCourses(userID string, lang language.Language, version int64, courses ...int) error {
+	return c.change(userID, func() error {
+		return c.Source.removeCourses(userID, lang, version, courses...)
+	})
+}
+
+func (c *Cache) removeCoursesBySemester(userID string, lang language.Language, version int64, year int, semester semesterAssignment) error {
+	return c.change(userID, func() error {
+		return c.Source.removeCoursesBySemester(userID, lang, version, year, semester)
+	})
+}
+
+func (c *Cache) addYear(userID string, lang language.Language, version int64) error {
+	return c.change(userID, func() error {
+		return c.Source.addYear(userID, lang, version)
+	})
+}
+
+func (c *Cache) removeYear(userID string, lang language.Language, version int64, shouldUnassign bool) error {
+	return c.change(userID, func() error {
+		return c.Source.removeYear(userID, lang, version, shouldUnassign)
+	})
+}
+
+func (c *Cache) foldSemester(userID string, lang language.Language, year int, semester semesterAssignment, folded bool) error {
+	return c.change(userID, func() error {
+		return c.Source.foldSemester(userID, lang, year, semester, folded)
+	})
+}
+
+func (c *Cache) createScenario(userID string, lang language.Language, name string) error {
+	return c.change(userID, func() error {
+		return c.Source.createScenario(userID, lang, name)
+	})
+}
+
+func (c *Cache) cloneScenario(userID string, lang language.Language, sourceID int, name string) error {
+	return c.change(userID, func() error {
+		return c.Source.cloneScenario(userID, lang, sourceID, name)
+	})
+}
+
+func (c *Cache) renameScenario(userID string, lang language.Language, scenarioID int, name string) error {
+	return c.change(userID, func() error {
+		return c.Source.renameScenario(userID, lang, scenarioID, name)
+	})
+}
+
+func (c *Cache) deleteScenario(userID string, lang language.Language, scenarioID int) error {
+	return c.change(userID, func() error {
+		return c.Source.deleteScenario(userID, lang, scenarioID)
+	})
+}
+
+func (c *Cache) activateScenario(userID string, lang language.Language, scenarioID int) error {
+	return c.change(userID, func() error {
+		return c.Source.activateScenario(userID, lang, scenarioID)
+	})
+}
+
+func (c *Cache) setCourseStatus(userID string, lang language.Language, version int64, courseID int, status courseStatus, grade sql.NullInt64, completedAt sql.NullTime) error {
+	return c.change(userID, func() error {
+		return c.Source.setCourseStatus(userID, lang, version, courseID, status, grade, completedAt)
+	})
+}
+
+func (c *Cache) importBlueprint(userID string, lang language.Language, version int64, data importData, mode string) error {
+	return c.change(userID, func() error {
+		return c.Source.importBlueprint(userID, lang, version, data, mode)
+	})
+}
+
+func (c *Cache) addCustomCourse(userID string, lang language.Language, version int64, course customCourse) error {
+	return c.change(userID, func() error {
+		return c.Source.addCustomCourse(userID, lang, version, course)
+	})
+}
+
+func (c *Cache) editCustomCourse(userID string, lang language.Language, version int64, courseID int, course customCourse) error {
+	return c.change(userID, func() error {
+		return c.Source.editCustomCourse(userID, lang, version, courseID, course)
+	})
+}
+
+func (c *Cache) degreePlanCourses(userID string, lang language.Language) ([]course, error) {
+	return c.Source.degreePlanCourses(userID, lang)
+}
+
+// preview does not change the stored blueprint, so the cache is kept.
+func (c *Cache) preview(userID string, lang language.Language, change func(a Adapter) error) (*blueprintPage, error) {
+	return c.Source.preview(userID, lang, change)
+}
+
+func (c *Cache) degreePlanBlocs(userID string, lang language.Language) ([]degreePlanBloc, error) {
+	return c.Source.degreePlanBlocs(userID, lang)
+}
+
+func (c *Cache) applySchedule(userID string, lang language.Language, version int64, placements []importCourse) error {
+	return c.change(userID, func() error {
+		return c.Source.applySchedule(userID, lang, version, placements)
+	})
+}
+
+func (c *Cache) applyFix(userID string, lang language.Language, version int64, f fix) error {
+	return c.change(userID, func() error {
+		return c.Source.applyFix(userID, lang, version, f)
+	})
+}
+
+func (c *Cache) setStartYear(userID string, lang language.Language, startYear int) error {
+	return c.change(userID, func() error {
+		return c.Source.setStartYear(userID, lang, startYear)
+	})
+}
+
+func (c *Cache) shares(userID string, lang language.Language) ([]share, error) {
+	return c.Source.shares(userID, lang)
+}
+
+func (c *Cache) createShare(userID string, lang language.Language, nonce string, expiresAt time.Time) error {
+	return c.Source.createShare(userID, lang, nonce, expiresAt)
+}
+
+func (c *Cache) revokeShare(userID string, lang language.Language, shareID int) error {
+	return c.Source.revokeShare(userID, lang, shareID)
+}
+
+func (c *Cache) undo(userID string, lang language.Language, version int64) error {
+	return c.change(userID, func() error {
+		return c.Source.undo(userID, lang, version)
+	})
+}
+
+func (c *Cache) redo(userID string, lang language.Language, version int64) error {
+	return c.change(userID, func() error {
+		return c.Source.redo(userID, lang, version)
+	})
+}
+
+func (c *Cache) restoreHistory(userID string, lang language.Language, version int64, historyID int) error {
+	return c.change(userID, func() error {
+		return c.Source.restoreHistory(userID, lang, version, historyID)
+	})
 }
@@ -0,0 +1,129 @@
+package blueprint
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/michalhercik/RecSIS/errorx"
+	"github.com/michalhercik/RecSIS/language"
+)
+
+//================================================================================
+// Constants
+//================================================================================
+
+const (
+	customTitleParam    string = "custom-title"
+	customCreditsParam  string = "custom-credits"
+	customSemesterParam string = "custom-semester"
+	customMappingParam  string = "custom-mapping"
+)
+
+const (
+	maxCustomTitleLength = 250
+	maxCourseCodeLength  = 10
+)
+
+//================================================================================
+// Data Types and Methods
+//================================================================================
+
+// customCourse is a user-defined blueprint course without a SIS course, e.g.
+// an Erasmus course or a placeholder for an elective. It may be mapped to the
+// SIS course it substitutes (empty mappedCode if not).
+type customCourse struct {
+	title      string
+	credits    int
+	semester   teachingSemester
+	mappedCode string
+}
+
+//================================================================================
+// Handlers
+//================================================================================
+
+// customCourseAddition adds a custom course to unassigned courses.
+func (s Server) customCourseAddition(w http.ResponseWriter, r *http.Request) {
+	lang := language.FromContext(r.Context())
+	userID := s.Auth.UserID(r)
+	c, err := parseCustomCourse(r)
+	if err != nil {
+		s.renderChangeError(w, r, userID, lang, err)
+		return
+	}
+	s.applyChange(w, r, userID, lang, func(a Adapter) error {
+		return a.addCustomCourse(userID, lang, requestVersion(r), c)
+	})
+}
+
+// customCourseChange changes title, credits, semester or mapping of a custom
+// course. Courses from SIS cannot be changed.
+func (s Server) customCourseChange(w http.ResponseWriter, r *http.Request) {
+	lang := language.FromContext(r.Context())
+	t := texts[lang]
+	userID := s.Auth.UserID(r)
+	courseID, err := strconv.Atoi(r.PathValue(recordID))
+	if err != nil {
+		s.Error.Log(errorx.AddContext(fmt.Errorf("unable to parse course ID to int: %w", err)))
+		s.Error.Render(w, r, http.StatusBadRequest, t.errInvalidCourseID, lang)
+		return
+	}
+	c, err := parseCustomCourse(r)
+	if err != nil {
+		s.renderChangeError(w, r, userID, lang, err)
+		return
+	}
+	s.applyChange(w, r, userID, lang, func(a Adapter) error {
+		return a.editCustomCourse(userID, lang, requestVersion(r), courseID, c)
+	})
+}
+
+//================================================================================
+// Parse parameters
+//================================================================================
+
+// parseCustomCourse parses the custom course from the request. Credits and
+// semester which are not numbers are reported as out of range by validate.
+func parseCustomCourse(r *http.Request) (customCourse, error) {
+	lang := language.FromContext(r.Context())
+	credits, err := strconv.Atoi(r.FormValue(customCreditsParam))
+	if err != nil {
+		credits = -1
+	}
+	semester, err := strconv.Atoi(r.FormValue(customSemesterParam))
+	if err != nil {
+		semester = 0
+	}
+	result := customCourse{
+		title:      strings.TrimSpace(r.FormValue(customTitleParam)),
+		credits:    credits,
+		semester:   teachingSemester(semester),
+		mappedCode: strings.TrimSpace(r.FormValue(customMappingParam)),
+	}
+	return result, result.validate(texts[lang])
+}
+
+// validate checks the custom course both when it is added by the user and
+// when it is imported. Existence of the mapped course is checked by
+// checkMappedCourse.
+func (c customCourse) validate(t text) error {
+	invalid := func(err error, userMsg string) error {
+		return errorx.NewHTTPErr(errorx.AddContext(err), http.StatusBadRequest, userMsg)
+	}
+	if c.title == "" || utf8.RuneCountInString(c.title) > maxCustomTitleLength {
+		return invalid(fmt.Errorf("invalid custom course title %q", c.title), fmt.Sprintf(t.errInvalidCustomTitle, maxCustomTitleLength))
+	}
+	if c.credits < 0 || c.credits > maxCreditCap {
+		return invalid(fmt.Errorf("invalid custom course credits %d", c.credits), fmt.Sprintf(t.errInvalidCustomCredits, maxCreditCap))
+	}
+	if c.semester < teachingWinterOnly || c.semester > teachingBoth {
+		return invalid(fmt.Errorf("invalid custom course semester %d", c.semester), t.errInvalidCustomSemester)
+	}
+	if len(c.mappedCode) > maxCourseCodeLength {
+		return invalid(fmt.Errorf("invalid mapped course code %q", c.mappedCode), t.errUnknownMappedCourse)
+	}
+	return nil
+}
@@ -1,9 +1,13 @@
 package blueprint
 
 import (
+	"database/sql"
+	"errors"
 	"fmt"
 	"net/http"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/jmoiron/sqlx"
 	"github.com/lib/pq"
@@ -14,17 +18,31 @@ import (
 )
 
 const (
-	uniqueViolationCode       = "23505"
-	duplicateCoursesViolation = "blueprint_courses_blueprint_semester_id_course_code_key"
+	uniqueViolationCode        = "23505"
+	duplicateCoursesViolation  = "blueprint_courses_blueprint_semester_id_course_code_key"
+	duplicateScenarioViolation = "blueprint_scenarios_user_id_name_key"
 )
 
 type DBManager struct {
 	DB *sqlx.DB
+	// dryRun replaces the commit of a blueprint change (see preview)
+	dryRun func(tx *sqlx.Tx) error
 }
 
 type dbBlueprintRecord struct {
 	dbds.Course
 	blueprintRecordPosition
+	ValidFrom   int           `db:"valid_from"`
+	ValidTo     int           `db:"valid_to"`
+	TaughtState string        `db:"taught_state"`
+	Status      string        `db:"status"`
+	Grade       sql.NullInt64 `db:"grade"`
+	CompletedAt sql.NullTime  `db:"completed_at"`
+	// Custom courses have no SIS course, MappedCode is the SIS course they
+	// substitute
+	Custom             bool                `db:"custom"`
+	MappedCode         sql.NullString      `db:"mapped_course_code"`
+	MappedInterchanges dbds.RequisiteSlice `db:"mapped_interchangeabilities"`
 }
 
 type blueprintRecordPosition struct {
@@ -35,9 +53,6 @@ type blueprintRecordPosition struct {
 
 func (m DBManager) blueprint(userID string, lang language.Language) (*blueprintPage, error) {
 	t := texts[lang]
-	var records []dbBlueprintRecord
-	var semestersInfo []blueprintRecordPosition
-
 	tx, err := m.DB.Beginx()
 	if err != nil {
 		return nil, errorx.NewHTTPErr(
@@ -47,6 +62,30 @@ func (m DBManager) blueprint(userID string, lang language.Language) (*blueprintP
 		)
 	}
 	defer tx.Rollback()
+	bp, err := loadBlueprint(tx, userID, lang)
+	if err != nil {
+		return nil, errorx.AddContext(err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, errorx.NewHTTPErr(
+			errorx.AddContext(fmt.Errorf("tx.Commit: %w", err)),
+			http.StatusInternalServerError,
+			t.errCannotGetBlueprint,
+		)
+	}
+	return bp, nil
+}
+
+// loadBlueprint loads the active scenario of the user within tx, so it also
+// sees changes not committed yet.
+func loadBlueprint(tx *sqlx.Tx, userID string, lang language.Language) (*blueprintPage, error) {
+	t := texts[lang]
+	var records []dbBlueprintRecord
+	var semestersInfo []blueprintRecordPosition
+	var scenarios []dbScenario
+	var history []dbHistoryEntry
+	var startYear sql.NullInt64
+
 	if err := tx.Select(&records, sqlquery.SelectCourses, userID, lang); err != nil {
 		return nil, errorx.NewHTTPErr(
 			errorx.AddContext(fmt.Errorf("sqlquery.SelectCourses: %w", err)),
@@ -61,15 +100,33 @@ func (m DBManager) blueprint(userID string, lang language.Language) (*blueprintP
 			t.errCannotGetBlueprint,
 		)
 	}
-	if err := tx.Commit(); err != nil {
+	if err := tx.Select(&scenarios, sqlquery.SelectScenarios, userID); err != nil {
 		return nil, errorx.NewHTTPErr(
-			errorx.AddContext(fmt.Errorf("tx.Commit: %w", err)),
+			errorx.AddContext(fmt.Errorf("sqlquery.SelectScenarios: %w", err)),
+			http.StatusInternalServerError,
+			t.errCannotGetBlueprint,
+		)
+	}
+	if err := tx.Select(&history, sqlquery.SelectHistory, userID); err != nil {
+		return nil, errorx.NewHTTPErr(
+			errorx.AddContext(fmt.Errorf("sqlquery.SelectHistory: %w", err)),
+			http.StatusInternalServerError,
+			t.errCannotGetBlueprint,
+		)
+	}
+	if err := tx.Get(&startYear, sqlquery.SelectStartYear, userID); err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, errorx.NewHTTPErr(
+			errorx.AddContext(fmt.Errorf("sqlquery.SelectStartYear: %w", err)),
 			http.StatusInternalServerError,
 			t.errCannotGetBlueprint,
 		)
 	}
 
-	var bp blueprintPage
+	bp := blueprintPage{
+		scenarios: intoScenarios(scenarios),
+		history:   intoHistory(history),
+		startYear: int(startYear.Int64),
+	}
 	if err := makeBlueprintPage(&bp, semestersInfo, lang); err != nil {
 		return nil, errorx.AddContext(err)
 	}
@@ -146,12 +203,23 @@ func intoCourse(from *dbBlueprintRecord) course {
 		seminarRangeWinter: from.SeminarRangeWinter,
 		lectureRangeSummer: from.LectureRangeSummer,
 		seminarRangeSummer: from.SeminarRangeSummer,
+		rangeUnit:          from.RangeUnit.Abbr,
 		examType:           from.ExamType,
 		credits:            from.Credits,
+		status:             courseStatus(from.Status),
+		grade:              from.Grade,
+		completedAt:        from.CompletedAt,
 		guarantors:         intoTeacherSlice(from.Guarantors),
 		prerequisites:      intoRequisites(from.Prerequisites),
 		corequisites:       intoRequisites(from.Corequisites),
 		incompatibles:      intoRequisites(from.Incompatibilities),
+		validFrom:          from.ValidFrom,
+		validTo:            from.ValidTo,
+		taughtState:        from.TaughtState,
+		taughtStateTitle:   from.State,
+		custom:             from.Custom,
+		mappedCode:         from.MappedCode.String,
+		substitutes:        requisiteCodes(intoRequisites(from.MappedInterchanges)),
 	}
 }
 
@@ -181,139 +249,149 @@ func intoRequisites(from dbds.RequisiteSlice) requisiteSlice {
 	return result
 }
 
-func (m DBManager) moveCourses(userID string, lang language.Language, year int, semester semesterAssignment, position int, courses ...int) error {
-	res, err := m.DB.Exec(sqlquery.MoveCourses, userID, pq.Array(courses), year, int(semester), position)
-	if err != nil {
-		// Handle unique violation for blueprint_semester_id, course_code
-		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == uniqueViolationCode && pqErr.Constraint == duplicateCoursesViolation {
-			userErrMsg := texts[lang].errDuplicateCourseInBP
-			if len(courses) > 1 {
-				userErrMsg = texts[lang].errDuplicateCoursesInBP
+func (m DBManager) moveCourses(userID string, lang language.Language, version int64, year int, semester semesterAssignment, position int, courses ...int) error {
+	return m.withHistory(userID, lang, version, actionMoveCourses, texts[lang].errCannotMoveCourses, func(tx *sqlx.Tx) error {
+		res, err := tx.Exec(sqlquery.MoveCourses, userID, pq.Array(courses), year, int(semester), position)
+		if err != nil {
+			// Handle unique violation for blueprint_semester_id, course_code
+			if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == uniqueViolationCode && pqErr.Constraint == duplicateCoursesViolation {
+				userErrMsg := texts[lang].errDuplicateCourseInBP
+				if len(courses) > 1 {
+					userErrMsg = texts[lang].errDuplicateCoursesInBP
+				}
+				return errorx.NewHTTPErr(
+					errorx.AddContext(fmt.Errorf("sqlquery.MoveCourses: %w", err), errorx.P("year", year), errorx.P("semester", semester), errorx.P("position", position), errorx.P("courses", strings.Join(itoaSlice(courses), ","))),
+					http.StatusConflict,
+					userErrMsg,
+				)
 			}
 			return errorx.NewHTTPErr(
 				errorx.AddContext(fmt.Errorf("sqlquery.MoveCourses: %w", err), errorx.P("year", year), errorx.P("semester", semester), errorx.P("position", position), errorx.P("courses", strings.Join(itoaSlice(courses), ","))),
-				http.StatusConflict,
-				userErrMsg,
+				http.StatusInternalServerError,
+				texts[lang].errCannotMoveCourses,
 			)
 		}
-		return errorx.NewHTTPErr(
-			errorx.AddContext(fmt.Errorf("sqlquery.MoveCourses: %w", err), errorx.P("year", year), errorx.P("semester", semester), errorx.P("position", position), errorx.P("courses", strings.Join(itoaSlice(courses), ","))),
-			http.StatusInternalServerError,
-			texts[lang].errCannotMoveCourses,
-		)
-	}
-	rowsAffected, err := res.RowsAffected()
-	if err != nil || rowsAffected == 0 {
-		return errorx.NewHTTPErr(
-			errorx.AddContext(fmt.Errorf("sqlquery.MoveCourses: %w", err), errorx.P("year", year), errorx.P("semester", semester), errorx.P("position", position), errorx.P("courses", strings.Join(itoaSlice(courses), ","))),
-			http.StatusBadRequest,
-			texts[lang].errCannotMoveCourses,
-		)
-	}
-	return nil
+		rowsAffected, err := res.RowsAffected()
+		if err != nil || rowsAffected == 0 {
+			return errorx.NewHTTPErr(
+				errorx.AddContext(fmt.Errorf("sqlquery.MoveCourses: %w", err), errorx.P("year", year), errorx.P("semester", semester), errorx.P("position", position), errorx.P("courses", strings.Join(itoaSlice(courses), ","))),
+				http.StatusBadRequest,
+				texts[lang].errCannotMoveCourses,
+			)
+		}
+		return nil
+	})
 }
 
-func (m DBManager) appendCourses(userID string, lang language.Language, year int, semester semesterAssignment, courses ...int) error {
-	res, err := m.DB.Exec(sqlquery.AppendCourses, userID, year, int(semester), pq.Array(courses))
-	if err != nil {
-		// Handle unique violation for blueprint_semester_id, course_code
-		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == uniqueViolationCode && pqErr.Constraint == duplicateCoursesViolation {
-			userErrMsg := texts[lang].errDuplicateCourseInBP
-			if len(courses) > 1 {
-				userErrMsg = texts[lang].errDuplicateCoursesInBP
+func (m DBManager) appendCourses(userID string, lang language.Language, version int64, year int, semester semesterAssignment, courses ...int) error {
+	return m.withHistory(userID, lang, version, actionMoveCourses, texts[lang].errCannotAppendCourses, func(tx *sqlx.Tx) error {
+		res, err := tx.Exec(sqlquery.AppendCourses, userID, year, int(semester), pq.Array(courses))
+		if err != nil {
+			// Handle unique violation for blueprint_semester_id, course_code
+			if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == uniqueViolationCode && pqErr.Constraint == duplicateCoursesViolation {
+				userErrMsg := texts[lang].errDuplicateCourseInBP
+				if len(courses) > 1 {
+					userErrMsg = texts[lang].errDuplicateCoursesInBP
+				}
+				return errorx.NewHTTPErr(
+					errorx.AddContext(fmt.Errorf("sqlquery.AppendCourses: %w", err), errorx.P("year", year), errorx.P("semester", semester), errorx.P("courses", strings.Join(itoaSlice(courses), ","))),
+					http.StatusConflict,
+					userErrMsg,
+				)
 			}
 			return errorx.NewHTTPErr(
 				errorx.AddContext(fmt.Errorf("sqlquery.AppendCourses: %w", err), errorx.P("year", year), errorx.P("semester", semester), errorx.P("courses", strings.Join(itoaSlice(courses), ","))),
-				http.StatusConflict,
-				userErrMsg,
+				http.StatusInternalServerError,
+				texts[lang].errCannotAppendCourses,
 			)
 		}
-		return errorx.NewHTTPErr(
-			errorx.AddContext(fmt.Errorf("sqlquery.AppendCourses: %w", err), errorx.P("year", year), errorx.P("semester", semester), errorx.P("courses", strings.Join(itoaSlice(courses), ","))),
-			http.StatusInternalServerError,
-			texts[lang].errCannotAppendCourses,
-		)
-	}
-	rowsAffected, err := res.RowsAffected()
-	if err != nil || rowsAffected == 0 {
-		return errorx.NewHTTPErr(
-			errorx.AddContext(fmt.Errorf("sqlquery.MoveCourses: %w", err), errorx.P("year", year), errorx.P("semester", semester), errorx.P("courses", strings.Join(itoaSlice(courses), ","))),
-			http.StatusBadRequest,
-			texts[lang].errCannotAppendCourses,
-		)
-	}
-	return nil
+		rowsAffected, err := res.RowsAffected()
+		if err != nil || rowsAffected == 0 {
+			return errorx.NewHTTPErr(
+				errorx.AddContext(fmt.Errorf("sqlquery.MoveCourses: %w", err), errorx.P("year", year), errorx.P("semester", semester), errorx.P("courses", strings.Join(itoaSlice(courses), ","))),
+				http.StatusBadRequest,
+				texts[lang].errCannotAppendCourses,
+			)
+		}
+		return nil
+	})
 }
 
-func (m DBManager) unassignSemester(userID string, lang language.Language, year int, semester semesterAssignment) error {
-	res, err := m.DB.Exec(sqlquery.UnassignCoursesBySemester, userID, year, int(semester))
-	if err != nil {
-		// Handle unique violation for blueprint_semester_id, course_code
-		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == uniqueViolationCode && pqErr.Constraint == duplicateCoursesViolation {
+func (m DBManager) unassignSemester(userID string, lang language.Language, version int64, year int, semester semesterAssignment) error {
+	return m.withHistory(userID, lang, version, actionUnassignSemester, texts[lang].errCannotUnassignSemester, func(tx *sqlx.Tx) error {
+		res, err := tx.Exec(sqlquery.UnassignCoursesBySemester, userID, year, int(semester))
+		if err != nil {
+			// Handle unique violation for blueprint_semester_id, course_code
+			if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == uniqueViolationCode && pqErr.Constraint == duplicateCoursesViolation {
+				return errorx.NewHTTPErr(
+					errorx.AddContext(fmt.Errorf("sqlquery.UnassignSemester: %w", err), errorx.P("year", year), errorx.P("semester", semester)),
+					http.StatusConflict,
+					texts[lang].errDuplicateCoursesInBPUnassigned,
+				)
+			}
 			return errorx.NewHTTPErr(
 				errorx.AddContext(fmt.Errorf("sqlquery.UnassignSemester: %w", err), errorx.P("year", year), errorx.P("semester", semester)),
-				http.StatusConflict,
-				texts[lang].errDuplicateCoursesInBPUnassigned,
+				http.StatusInternalServerError,
+				texts[lang].errCannotUnassignSemester,
 			)
 		}
-		return errorx.NewHTTPErr(
-			errorx.AddContext(fmt.Errorf("sqlquery.UnassignSemester: %w", err), errorx.P("year", year), errorx.P("semester", semester)),
-			http.StatusInternalServerError,
-			texts[lang].errCannotUnassignSemester,
-		)
-	}
-	rowsAffected, err := res.RowsAffected()
-	if err != nil || rowsAffected == 0 {
-		return errorx.NewHTTPErr(
-			errorx.AddContext(fmt.Errorf("sqlquery.UnassignSemester: %w", err), errorx.P("year", year), errorx.P("semester", semester)),
-			http.StatusBadRequest,
-			texts[lang].errCannotUnassignSemester,
-		)
-	}
-	return nil
+		rowsAffected, err := res.RowsAffected()
+		if err != nil || rowsAffected == 0 {
+			return errorx.NewHTTPErr(
+				errorx.AddContext(fmt.Errorf("sqlquery.UnassignSemester: %w", err), errorx.P("year", year), errorx.P("semester", semester)),
+				http.StatusBadRequest,
+				texts[lang].errCannotUnassignSemester,
+			)
+		}
+		return nil
+	})
 }
 
-func (m DBManager) removeCourses(userID string, lang language.Language, courses ...int) error {
-	res, err := m.DB.Exec(sqlquery.RemoveCoursesByID, userID, pq.Array(courses))
-	if err != nil {
-		return errorx.NewHTTPErr(
-			errorx.AddContext(fmt.Errorf("sqlquery.RemoveCoursesByID: %w", err), errorx.P("courses", strings.Join(itoaSlice(courses), ","))),
-			http.StatusInternalServerError,
-			texts[lang].errCannotRemoveCourses,
-		)
-	}
-	rowsAffected, err := res.RowsAffected()
-	if err != nil || rowsAffected == 0 {
-		return errorx.NewHTTPErr(
-			errorx.AddContext(fmt.Errorf("sqlquery.RemoveCoursesByID: %w", err), errorx.P("courses", strings.Join(itoaSlice(courses), ","))),
-			http.StatusBadRequest,
-			texts[lang].errCannotRemoveCourses,
-		)
-	}
-	return nil
+func (m DBManager) removeCourses(userID string, lang language.Language, version int64, courses ...int) error {
+	return m.withHistory(userID, lang, version, actionRemoveCourses, texts[lang].errCannotRemoveCourses, func(tx *sqlx.Tx) error {
+		res, err := tx.Exec(sqlquery.RemoveCoursesByID, userID, pq.Array(courses))
+		if err != nil {
+			return errorx.NewHTTPErr(
+				errorx.AddContext(fmt.Errorf("sqlquery.RemoveCoursesByID: %w", err), errorx.P("courses", strings.Join(itoaSlice(courses), ","))),
+				http.StatusInternalServerError,
+				texts[lang].errCannotRemoveCourses,
+			)
+		}
+		rowsAffected, err := res.RowsAffected()
+		if err != nil || rowsAffected == 0 {
+			return errorx.NewHTTPErr(
+				errorx.AddContext(fmt.Errorf("sqlquery.RemoveCoursesByID: %w", err), errorx.P("courses", strings.Join(itoaSlice(courses), ","))),
+				http.StatusBadRequest,
+				texts[lang].errCannotRemoveCourses,
+			)
+		}
+		return nil
+	})
 }
 
-func (m DBManager) removeCoursesBySemester(userID string, lang language.Language, year int, semester semesterAssignment) error {
-	res, err := m.DB.Exec(sqlquery.RemoveCoursesBySemester, userID, year, int(semester))
-	if err != nil {
-		return errorx.NewHTTPErr(
-			errorx.AddContext(fmt.Errorf("sqlquery.RemoveCoursesBySemester: %w", err), errorx.P("year", year), errorx.P("semester", semester)),
-			http.StatusInternalServerError,
-			texts[lang].errCannotRemoveCourses,
-		)
-	}
-	rowsAffected, err := res.RowsAffected()
-	if err != nil || rowsAffected == 0 {
-		return errorx.NewHTTPErr(
-			errorx.AddContext(fmt.Errorf("sqlquery.RemoveCoursesBySemester: %w", err), errorx.P("year", year), errorx.P("semester", semester)),
-			http.StatusBadRequest,
-			texts[lang].errCannotRemoveCourses,
-		)
-	}
-	return nil
+func (m DBManager) removeCoursesBySemester(userID string, lang language.Language, version int64, year int, semester semesterAssignment) error {
+	return m.withHistory(userID, lang, version, actionRemoveCourses, texts[lang].errCannotRemoveCourses, func(tx *sqlx.Tx) error {
+		res, err := tx.Exec(sqlquery.RemoveCoursesBySemester, userID, year, int(semester))
+		if err != nil {
+			return errorx.NewHTTPErr(
+				errorx.AddContext(fmt.Errorf("sqlquery.RemoveCoursesBySemester: %w", err), errorx.P("year", year), errorx.P("semester", semester)),
+				http.StatusInternalServerError,
+				texts[lang].errCannotRemoveCourses,
+			)
+		}
+		rowsAffected, err := res.RowsAffected()
+		if err != nil || rowsAffected == 0 {
+			return errorx.NewHTTPErr(
+				errorx.AddContext(fmt.Errorf("sqlquery.RemoveCoursesBySemester: %w", err), errorx.P("year", year), errorx.P("semester", semester)),
+				http.StatusBadRequest,
+				texts[lang].errCannotRemoveCourses,
+			)
+		}
+		return nil
+	})
 }
 
-func (m DBManager) addYear(userID string, lang language.Language) error {
+func (m DBManager) addYear(userID string, lang language.Language, version int64) error {
 	tx, err := m.DB.Beginx()
 	if err != nil {
 		return errorx.NewHTTPErr(
@@ -323,6 +401,9 @@ func (m DBManager) addYear(userID string, lang language.Language) error {
 		)
 	}
 	defer tx.Rollback()
+	if err = recordHistory(tx, userID, lang, version, actionAddYear, texts[lang].errCannotAddYear); err != nil {
+		return errorx.AddContext(err)
+	}
 	var newYearID int
 	err = tx.Get(&newYearID, sqlquery.InsertYear, userID)
 	if err != nil {
@@ -340,7 +421,7 @@ func (m DBManager) addYear(userID string, lang language.Language) error {
 			texts[lang].errCannotAddYear,
 		)
 	}
-	if err = tx.Commit(); err != nil {
+	if err = m.commit(tx); err != nil {
 		return errorx.NewHTTPErr(
 			errorx.AddContext(fmt.Errorf("tx.Commit: %w", err)),
 			http.StatusInternalServerError,
@@ -350,7 +431,7 @@ func (m DBManager) addYear(userID string, lang language.Language) error {
 	return nil
 }
 
-func (m DBManager) removeYear(userID string, lang language.Language, shouldUnassign bool) error {
+func (m DBManager) removeYear(userID string, lang language.Language, version int64, shouldUnassign bool) error {
 	tx, err := m.DB.Beginx()
 	if err != nil {
 		return errorx.NewHTTPErr(
@@ -360,6 +441,9 @@ func (m DBManager) removeYear(userID string, lang language.Language, shouldUnass
 		)
 	}
 	defer tx.Rollback()
+	if err = recordHistory(tx, userID, lang, version, actionRemoveYear, texts[lang].errCannotRemoveYear); err != nil {
+		return errorx.AddContext(err)
+	}
 	if shouldUnassign {
 		_, err := tx.Exec(sqlquery.UnassignLastYear, userID)
 		if err != nil {
@@ -386,7 +470,7 @@ func (m DBManager) removeYear(userID string, lang language.Language, shouldUnass
 			texts[lang].errCannotRemoveYear,
 		)
 	}
-	if err = tx.Commit(); err != nil {
+	if err = m.commit(tx); err != nil {
 		return errorx.NewHTTPErr(
 			errorx.AddContext(fmt.Errorf("tx.Commit: %w", err)),
 			http.StatusInternalServerError,
@@ -408,6 +492,74 @@ func (m DBManager) foldSemester(userID string, lang language.Language, year int,
 	return nil
 }
 
+// withHistory runs operation in a transaction after recording the current
+// state of the blueprint, so the operation can be undone later. The
+// operation is rejected if the blueprint is no longer at version.
+func (m DBManager) withHistory(userID string, lang language.Language, version int64, action historyAction, errMsg string, operation func(tx *sqlx.Tx) error) error {
+	tx, err := m.DB.Beginx()
+	if err != nil {
+		return errorx.NewHTTPErr(
+			errorx.AddContext(fmt.Errorf("DB.Beginx: %w", err)),
+			http.StatusInternalServerError,
+			errMsg,
+		)
+	}
+	defer tx.Rollback()
+	if err = recordHistory(tx, userID, lang, version, action, errMsg); err != nil {
+		return errorx.AddContext(err)
+	}
+	if err = operation(tx); err != nil {
+		return err
+	}
+	if err = m.commit(tx); err != nil {
+		return errorx.NewHTTPErr(
+			errorx.AddContext(fmt.Errorf("tx.Commit: %w", err)),
+			http.StatusInternalServerError,
+			errMsg,
+		)
+	}
+	return nil
+}
+
+func recordHistory(tx *sqlx.Tx, userID string, lang language.Language, version int64, action historyAction, errMsg string) error {
+	if err := checkVersion(tx, userID, lang, version, errMsg); err != nil {
+		return errorx.AddContext(err)
+	}
+	if _, err := tx.Exec(sqlquery.RecordHistory, userID, string(action)); err != nil {
+		return errorx.NewHTTPErr(
+			errorx.AddContext(fmt.Errorf("sqlquery.RecordHistory: %w", err), errorx.P("action", action)),
+			http.StatusInternalServerError,
+			errMsg,
+		)
+	}
+	return nil
+}
+
+// checkVersion locks the active scenario until the end of the transaction
+// and fails if its version is not version. Zero version skips the check
+// (the request did not carry any).
+func checkVersion(tx *sqlx.Tx, userID string, lang language.Language, version int64, errMsg string) error {
+	var current int64
+	if err := tx.Get(&current, sqlquery.LockVersion, userID); err != nil {
+		return errorx.NewHTTPErr(
+			errorx.AddContext(fmt.Errorf("sqlquery.LockVersion: %w", err)),
+			http.StatusInternalServerError,
+			errMsg,
+		)
+	}
+	if version != 0 && version != current {
+		return versionConflictError{
+			HTTPError: errorx.NewHTTPErr(
+				errorx.AddContext(fmt.Errorf("blueprint version %d is stale", version), errorx.P("current", current)),
+				http.StatusConflict,
+				texts[lang].errBlueprintChanged,
+			),
+			version: version,
+		}
+	}
+	return nil
+}
+
 // transform int slice to string slice
 func itoaSlice(ints []int) []string {
 	strs := make([]string, len(ints))
@@ -416,3 +568,937 @@ func itoaSlice(ints []int) []string {
 	}
 	return strs
 }
+
+func (m DBManager) setCourseStatus(userID string, lang language.Language, version int64, courseID int, status courseStatus, grade sql.NullInt64, completedAt sql.NullTime) error {
+	t := texts[lang]
+	return m.withHistory(userID, lang, version, actionSetStatus, t.errCannotSetStatus, func(tx *sqlx.Tx) error {
+		res, err := tx.Exec(sqlquery.SetCourseStatus, userID, courseID, string(status), grade, completedAt)
+		if err != nil {
+			return errorx.NewHTTPErr(
+				errorx.AddContext(fmt.Errorf("sqlquery.SetCourseStatus: %w", err), errorx.P("course", courseID), errorx.P("status", status)),
+				http.StatusInternalServerError,
+				t.errCannotSetStatus,
+			)
+		}
+		rowsAffected, err := res.RowsAffected()
+		if err != nil || rowsAffected == 0 {
+			return errorx.NewHTTPErr(
+				errorx.AddContext(fmt.Errorf("sqlquery.SetCourseStatus: %w", err), errorx.P("course", courseID), errorx.P("status", status)),
+				http.StatusBadRequest,
+				t.errCannotSetStatus,
+			)
+		}
+		return nil
+	})
+}
+
+//================================================================================
+// Scenarios
+//================================================================================
+
+type dbScenario struct {
+	ID      int    `db:"id"`
+	Name    string `db:"name"`
+	Active  bool   `db:"active"`
+	Version int64  `db:"version"`
+}
+
+func intoScenarios(from []dbScenario) []scenario {
+	scenarios := make([]scenario, len(from))
+	for i, s := range from {
+		scenarios[i] = scenario{
+			id:      s.ID,
+			name:    s.Name,
+			active:  s.Active,
+			version: s.Version,
+		}
+	}
+	return scenarios
+}
+
+func (m DBManager) createScenario(userID string, lang language.Language, name string) error {
+	t := texts[lang]
+	tx, err := m.DB.Beginx()
+	if err != nil {
+		return errorx.NewHTTPErr(
+			errorx.AddContext(fmt.Errorf("DB.Beginx: %w", err)),
+			http.StatusInternalServerError,
+			t.errCannotCreateScenario,
+		)
+	}
+	defer tx.Rollback()
+	scenarioID, err := insertScenario(tx, userID, name, lang)
+	if err != nil {
+		return errorx.AddContext(err)
+	}
+	if _, err = tx.Exec(sqlquery.InsertScenarioUnassigned, userID, scenarioID); err != nil {
+		return errorx.NewHTTPErr(
+			errorx.AddContext(fmt.Errorf("sqlquery.InsertScenarioUnassigned: %w", err), errorx.P("scenario", scenarioID)),
+			http.StatusInternalServerError,
+			t.errCannotCreateScenario,
+		)
+	}
+	if err = activateScenario(tx, userID, scenarioID, lang); err != nil {
+		return errorx.AddContext(err)
+	}
+	if err = tx.Commit(); err != nil {
+		return errorx.NewHTTPErr(
+			errorx.AddContext(fmt.Errorf("tx.Commit: %w", err)),
+			http.StatusInternalServerError,
+			t.errCannotCreateScenario,
+		)
+	}
+	return nil
+}
+
+func (m DBManager) cloneScenario(userID string, lang language.Language, sourceID int, name string) error {
+	t := texts[lang]
+	tx, err := m.DB.Beginx()
+	if err != nil {
+		return errorx.NewHTTPErr(
+			errorx.AddContext(fmt.Errorf("DB.Beginx: %w", err)),
+			http.StatusInternalServerError,
+			t.errCannotCloneScenario,
+		)
+	}
+	defer tx.Rollback()
+	var ownedID int
+	if err = tx.Get(&ownedID, sqlquery.SelectScenarioID, userID, sourceID); err != nil {
+		return errorx.NewHTTPErr(
+			errorx.AddContext(fmt.Errorf("sqlquery.SelectScenarioID: %w", err), errorx.P("source", sourceID)),
+			http.StatusNotFound,
+			t.errScenarioNotFound,
+		)
+	}
+	scenarioID, err := insertScenario(tx, userID, name, lang)
+	if err != nil {
+		return errorx.AddContext(err)
+	}
+	copyQueries := []struct {
+		name  string
+		query string
+		args  []any
+	}{
+		{"sqlquery.CopyScenarioYears", sqlquery.CopyScenarioYears, []any{userID, sourceID, scenarioID}},
+		{"sqlquery.CopyScenarioSemesters", sqlquery.CopyScenarioSemesters, []any{sourceID, scenarioID}},
+		{"sqlquery.CopyScenarioCourses", sqlquery.CopyScenarioCourses, []any{sourceID, scenarioID}},
+	}
+	for _, q := range copyQueries {
+		if _, err = tx.Exec(q.query, q.args...); err != nil {
+			return errorx.NewHTTPErr(
+				errorx.AddContext(fmt.Errorf("%s: %w", q.name, err), errorx.P("source", sourceID), errorx.P("scenario", scenarioID)),
+				http.StatusInternalServerError,
+				t.errCannotCloneScenario,
+			)
+		}
+	}
+	if err = activateScenario(tx, userID, scenarioID, lang); err != nil {
+		return errorx.AddContext(err)
+	}
+	if err = tx.Commit(); err != nil {
+		return errorx.NewHTTPErr(
+			errorx.AddContext(fmt.Errorf("tx.Commit: %w", err)),
+			http.StatusInternalServerError,
+			t.errCannotCloneScenario,
+		)
+	}
+	return nil
+}
+
+func insertScenario(tx *sqlx.Tx, userID, name string, lang language.Language) (int, error) {
+	var scenarioID int
+	err := tx.Get(&scenarioID, sqlquery.InsertScenario, userID, name)
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == uniqueViolationCode && pqErr.Constraint == duplicateScenarioViolation {
+			return 0, errorx.NewHTTPErr(
+				errorx.AddContext(fmt.Errorf("sqlquery.InsertScenario: %w", err), errorx.P("name", name)),
+				http.StatusConflict,
+				texts[lang].errDuplicateScenarioName,
+			)
+		}
+		return 0, errorx.NewHTTPErr(
+			errorx.AddContext(fmt.Errorf("sqlquery.InsertScenario: %w", err), errorx.P("name", name)),
+			http.StatusInternalServerError,
+			texts[lang].errCannotCreateScenario,
+		)
+	}
+	return scenarioID, nil
+}
+
+func (m DBManager) renameScenario(userID string, lang language.Language, scenarioID int, name string) error {
+	res, err := m.DB.Exec(sqlquery.RenameScenario, userID, scenarioID, name)
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == uniqueViolationCode && pqErr.Constraint == duplicateScenarioViolation {
+			return errorx.NewHTTPErr(
+				errorx.AddContext(fmt.Errorf("sqlquery.RenameScenario: %w", err), errorx.P("scenario", scenarioID), errorx.P("name", name)),
+				http.StatusConflict,
+				texts[lang].errDuplicateScenarioName,
+			)
+		}
+		return errorx.NewHTTPErr(
+			errorx.AddContext(fmt.Errorf("sqlquery.RenameScenario: %w", err), errorx.P("scenario", scenarioID), errorx.P("name", name)),
+			http.StatusInternalServerError,
+			texts[lang].errCannotRenameScenario,
+		)
+	}
+	rowsAffected, err := res.RowsAffected()
+	if err != nil || rowsAffected == 0 {
+		return errorx.NewHTTPErr(
+			errorx.AddContext(fmt.Errorf("sqlquery.RenameScenario: %w", err), errorx.P("scenario", scenarioID), errorx.P("name", name)),
+			http.StatusNotFound,
+			texts[lang].errScenarioNotFound,
+		)
+	}
+	return nil
+}
+
+func (m DBManager) deleteScenario(userID string, lang language.Language, scenarioID int) error {
+	t := texts[lang]
+	tx, err := m.DB.Beginx()
+	if err != nil {
+		return errorx.NewHTTPErr(
+			errorx.AddContext(fmt.Errorf("DB.Beginx: %w", err)),
+			http.StatusInternalServerError,
+			t.errCannotDeleteScenario,
+		)
+	}
+	defer tx.Rollback()
+	var count int
+	if err = tx.Get(&count, sqlquery.CountScenarios, userID); err != nil {
+		return errorx.NewHTTPErr(
+			errorx.AddContext(fmt.Errorf("sqlquery.CountScenarios: %w", err)),
+			http.StatusInternalServerError,
+			t.errCannotDeleteScenario,
+		)
+	}
+	if count <= 1 {
+		return errorx.NewHTTPErr(
+			errorx.AddContext(fmt.Errorf("cannot delete the only scenario"), errorx.P("scenario", scenarioID)),
+			http.StatusConflict,
+			t.errCannotDeleteLastScenario,
+		)
+	}
+	var wasActive bool
+	if err = tx.Get(&wasActive, sqlquery.DeleteScenario, userID, scenarioID); err != nil {
+		return errorx.NewHTTPErr(
+			errorx.AddContext(fmt.Errorf("sqlquery.DeleteScenario: %w", err), errorx.P("scenario", scenarioID)),
+			http.StatusNotFound,
+			t.errScenarioNotFound,
+		)
+	}
+	if wasActive {
+		if _, err = tx.Exec(sqlquery.ActivateFirstScenario, userID); err != nil {
+			return errorx.NewHTTPErr(
+				errorx.AddContext(fmt.Errorf("sqlquery.ActivateFirstScenario: %w", err)),
+				http.StatusInternalServerError,
+				t.errCannotDeleteScenario,
+			)
+		}
+	}
+	if err = tx.Commit(); err != nil {
+		return errorx.NewHTTPErr(
+			errorx.AddContext(fmt.Errorf("tx.Commit: %w", err)),
+			http.StatusInternalServerError,
+			t.errCannotDeleteScenario,
+		)
+	}
+	return nil
+}
+
+func (m DBManager) activateScenario(userID string, lang language.Language, scenarioID int) error {
+	t := texts[lang]
+	tx, err := m.DB.Beginx()
+	if err != nil {
+		return errorx.NewHTTPErr(
+			errorx.AddContext(fmt.Errorf("DB.Beginx: %w", err)),
+			http.StatusInternalServerError,
+			t.errCannotActivateScenario,
+		)
+	}
+	defer tx.Rollback()
+	if err = activateScenario(tx, userID, scenarioID, lang); err != nil {
+		return errorx.AddContext(err)
+	}
+	if err = tx.Commit(); err != nil {
+		return errorx.NewHTTPErr(
+			errorx.AddContext(fmt.Errorf("tx.Commit: %w", err)),
+			http.StatusInternalServerError,
+			t.errCannotActivateScenario,
+		)
+	}
+	return nil
+}
+
+func activateScenario(tx *sqlx.Tx, userID string, scenarioID int, lang language.Language) error {
+	t := texts[lang]
+	if _, err := tx.Exec(sqlquery.DeactivateScenarios, userID); err != nil {
+		return errorx.NewHTTPErr(
+			errorx.AddContext(fmt.Errorf("sqlquery.DeactivateScenarios: %w", err), errorx.P("scenario", scenarioID)),
+			http.StatusInternalServerError,
+			t.errCannotActivateScenario,
+		)
+	}
+	res, err := tx.Exec(sqlquery.ActivateScenario, userID, scenarioID)
+	if err != nil {
+		return errorx.NewHTTPErr(
+			errorx.AddContext(fmt.Errorf("sqlquery.ActivateScenario: %w", err), errorx.P("scenario", scenarioID)),
+			http.StatusInternalServerError,
+			t.errCannotActivateScenario,
+		)
+	}
+	rowsAffected, err := res.RowsAffected()
+	if err != nil || rowsAffected == 0 {
+		return errorx.NewHTTPErr(
+			errorx.AddContext(fmt.Errorf("sqlquery.ActivateScenario: %w", err), errorx.P("scenario", scenarioID)),
+			http.StatusNotFound,
+			t.errScenarioNotFound,
+		)
+	}
+	return nil
+}
+
+//================================================================================
+// History
+//================================================================================
+
+type dbHistoryEntry struct {
+	ID        int       `db:"id"`
+	Action    string    `db:"action"`
+	Undone    bool      `db:"undone"`
+	CreatedAt time.Time `db:"created_at"`
+	Version   int64     `db:"version"`
+}
+
+func intoHistory(from []dbHistoryEntry) []historyEntry {
+	history := make([]historyEntry, len(from))
+	for i, h := range from {
+		history[i] = historyEntry{
+			id:        h.ID,
+			action:    historyAction(h.Action),
+			undone:    h.Undone,
+			version:   h.Version,
+			createdAt: h.CreatedAt,
+		}
+	}
+	return history
+}
+
+func (m DBManager) undo(userID string, lang language.Language, version int64) error {
+	return m.swapHistory(userID, lang, version, sqlquery.Undo, "sqlquery.Undo", texts[lang].errCannotUndo, texts[lang].errNothingToUndo)
+}
+
+func (m DBManager) redo(userID string, lang language.Language, version int64) error {
+	return m.swapHistory(userID, lang, version, sqlquery.Redo, "sqlquery.Redo", texts[lang].errCannotRedo, texts[lang].errNothingToRedo)
+}
+
+func (m DBManager) swapHistory(userID string, lang language.Language, version int64, query, queryName, errMsg, emptyMsg string) error {
+	tx, err := m.DB.Beginx()
+	if err != nil {
+		return errorx.NewHTTPErr(
+			errorx.AddContext(fmt.Errorf("DB.Beginx: %w", err)),
+			http.StatusInternalServerError,
+			errMsg,
+		)
+	}
+	defer tx.Rollback()
+	if err = checkVersion(tx, userID, lang, version, errMsg); err != nil {
+		return errorx.AddContext(err)
+	}
+	res, err := tx.Exec(query, userID)
+	if err != nil {
+		return errorx.NewHTTPErr(
+			errorx.AddContext(fmt.Errorf("%s: %w", queryName, err)),
+			http.StatusInternalServerError,
+			errMsg,
+		)
+	}
+	rowsAffected, err := res.RowsAffected()
+	if err != nil || rowsAffected == 0 {
+		return errorx.NewHTTPErr(
+			errorx.AddContext(fmt.Errorf("%s: no history entry: %w", queryName, err)),
+			http.StatusConflict,
+			emptyMsg,
+		)
+	}
+	if err = m.commit(tx); err != nil {
+		return errorx.NewHTTPErr(
+			errorx.AddContext(fmt.Errorf("tx.Commit: %w", err)),
+			http.StatusInternalServerError,
+			errMsg,
+		)
+	}
+	return nil
+}
+
+func (m DBManager) restoreHistory(userID string, lang language.Language, version int64, historyID int) error {
+	t := texts[lang]
+	var snapshot []byte
+	if err := m.DB.Get(&snapshot, sqlquery.SelectHistorySnapshot, userID, historyID); err != nil {
+		return errorx.NewHTTPErr(
+			errorx.AddContext(fmt.Errorf("sqlquery.SelectHistorySnapshot: %w", err), errorx.P("history", historyID)),
+			http.StatusNotFound,
+			t.errHistoryNotFound,
+		)
+	}
+	return m.withHistory(userID, lang, version, actionRestore, t.errCannotRestoreHistory, func(tx *sqlx.Tx) error {
+		if _, err := tx.Exec(sqlquery.RestoreSnapshot, userID, snapshot); err != nil {
+			return errorx.NewHTTPErr(
+				errorx.AddContext(fmt.Errorf("sqlquery.RestoreSnapshot: %w", err), errorx.P("history", historyID)),
+				http.StatusInternalServerError,
+				t.errCannotRestoreHistory,
+			)
+		}
+		return nil
+	})
+}
+
+//================================================================================
+// Import
+//================================================================================
+
+func (m DBManager) importBlueprint(userID string, lang language.Language, version int64, data importData, mode string) error {
+	t := texts[lang]
+	if err := m.validateImport(data, lang); err != nil {
+		return errorx.AddContext(err)
+	}
+	return m.withHistory(userID, lang, version, actionImport, t.errCannotImport, func(tx *sqlx.Tx) error {
+		if mode == importReplace {
+			if _, err := tx.Exec(sqlquery.ClearCourses, userID); err != nil {
+				return errorx.NewHTTPErr(
+					errorx.AddContext(fmt.Errorf("sqlquery.ClearCourses: %w", err)),
+					http.StatusInternalServerError,
+					t.errCannotImport,
+				)
+			}
+			if _, err := tx.Exec(sqlquery.DeleteYearsAfter, userID, data.years); err != nil {
+				return errorx.NewHTTPErr(
+					errorx.AddContext(fmt.Errorf("sqlquery.DeleteYearsAfter: %w", err), errorx.P("years", data.years)),
+					http.StatusInternalServerError,
+					t.errCannotImport,
+				)
+			}
+		}
+		if err := ensureYears(tx, userID, data.years, t.errCannotImport); err != nil {
+			return errorx.AddContext(err)
+		}
+		if mode == importReplace && len(data.semesters) > 0 {
+			years := make([]int, len(data.semesters))
+			semesters := make([]int, len(data.semesters))
+			folded := make([]bool, len(data.semesters))
+			for i, s := range data.semesters {
+				years[i], semesters[i], folded[i] = s.year, int(s.semester), s.folded
+			}
+			if _, err := tx.Exec(sqlquery.SetSemestersFolded, userID, pq.Array(years), pq.Array(semesters), pq.Array(folded)); err != nil {
+				return errorx.NewHTTPErr(
+					errorx.AddContext(fmt.Errorf("sqlquery.SetSemestersFolded: %w", err)),
+					http.StatusInternalServerError,
+					t.errCannotImport,
+				)
+			}
+		}
+		if len(data.courses) == 0 {
+			return nil
+		}
+		if _, err := tx.Exec(sqlquery.ImportCourses, importCoursesArgs(userID, data.courses)...); err != nil {
+			return errorx.NewHTTPErr(
+				errorx.AddContext(fmt.Errorf("sqlquery.ImportCourses: %w", err)),
+				http.StatusInternalServerError,
+				t.errCannotImport,
+			)
+		}
+		return nil
+	})
+}
+
+// importCoursesArgs returns arguments of sqlquery.ImportCourses for the
+// given courses.
+func importCoursesArgs(userID string, courses []importCourse) []any {
+	years := make([]int, len(courses))
+	semesters := make([]int, len(courses))
+	codes := make([]string, len(courses))
+	positions := make([]int, len(courses))
+	statuses := make([]string, len(courses))
+	grades := make([]int, len(courses))
+	completed := make([]string, len(courses))
+	customTitles := make([]string, len(courses))
+	customCredits := make([]int, len(courses))
+	customSemesters := make([]int, len(courses))
+	mappedCodes := make([]string, len(courses))
+	for i, c := range courses {
+		years[i], semesters[i], codes[i], positions[i] = c.year, int(c.semester), c.code, c.position
+		statuses[i], grades[i], completed[i] = string(c.status), c.grade, c.completed
+		if c.isCustom {
+			customTitles[i], customCredits[i] = c.custom.title, c.custom.credits
+			customSemesters[i], mappedCodes[i] = int(c.custom.semester), c.custom.mappedCode
+		}
+	}
+	return []any{
+		userID, pq.Array(years), pq.Array(semesters), pq.Array(codes), pq.Array(positions), pq.Array(statuses), pq.Array(grades), pq.Array(completed),
+		pq.Array(customTitles), pq.Array(customCredits), pq.Array(customSemesters), pq.Array(mappedCodes),
+	}
+}
+
+// ensureYears appends years to the active scenario until it has at least
+// the given number of years.
+func ensureYears(tx *sqlx.Tx, userID string, years int, errMsg string) error {
+	var yearCount int
+	if err := tx.Get(&yearCount, sqlquery.CountYears, userID); err != nil {
+		return errorx.NewHTTPErr(
+			errorx.AddContext(fmt.Errorf("sqlquery.CountYears: %w", err)),
+			http.StatusInternalServerError,
+			errMsg,
+		)
+	}
+	for ; yearCount < years; yearCount++ {
+		var newYearID int
+		if err := tx.Get(&newYearID, sqlquery.InsertYear, userID); err != nil {
+			return errorx.NewHTTPErr(
+				errorx.AddContext(fmt.Errorf("sqlquery.InsertYear: %w", err)),
+				http.StatusInternalServerError,
+				errMsg,
+			)
+		}
+		if _, err := tx.Exec(sqlquery.InsertSemestersByYear, userID, newYearID); err != nil {
+			return errorx.NewHTTPErr(
+				errorx.AddContext(fmt.Errorf("sqlquery.InsertSemestersByYear: %w", err), errorx.P("year", newYearID)),
+				http.StatusInternalServerError,
+				errMsg,
+			)
+		}
+	}
+	return nil
+}
+
+func (m DBManager) validateImport(data importData, lang language.Language) error {
+	t := texts[lang]
+	if duplicates := data.duplicates(); len(duplicates) > 0 {
+		return errorx.NewHTTPErr(
+			errorx.AddContext(fmt.Errorf("duplicate courses in import"), errorx.P("codes", strings.Join(duplicates, ","))),
+			http.StatusBadRequest,
+			fmt.Sprintf(t.errDuplicateImportCodes, strings.Join(duplicates, ", ")),
+		)
+	}
+	codes := data.codes()
+	var existing []string
+	if err := m.DB.Select(&existing, sqlquery.SelectExistingCodes, pq.Array(codes)); err != nil {
+		return errorx.NewHTTPErr(
+			errorx.AddContext(fmt.Errorf("sqlquery.SelectExistingCodes: %w", err)),
+			http.StatusInternalServerError,
+			t.errCannotImport,
+		)
+	}
+	var unknown []string
+	for _, code := range codes {
+		if !slices.Contains(existing, code) {
+			unknown = append(unknown, code)
+		}
+	}
+	if len(unknown) > 0 {
+		return errorx.NewHTTPErr(
+			errorx.AddContext(fmt.Errorf("unknown courses in import"), errorx.P("codes", strings.Join(unknown, ","))),
+			http.StatusBadRequest,
+			fmt.Sprintf(t.errUnknownImportCodes, strings.Join(unknown, ", ")),
+		)
+	}
+	return nil
+}
+
+//================================================================================
+// Fixes
+//================================================================================
+
+// applyFix applies a single fix proposed by a diagnostic in one transaction
+// together with recording the history.
+func (m DBManager) applyFix(userID string, lang language.Language, version int64, f fix) error {
+	t := texts[lang]
+	fixErr := func(query string, err error, code int, userMsg string) error {
+		return errorx.NewHTTPErr(
+			errorx.AddContext(fmt.Errorf("%s: %w", query, err), errorx.P("fix", f.action), errorx.P("course", f.courseID), errorx.P("code", f.code), errorx.P("year", f.year), errorx.P("semester", f.semester)),
+			code,
+			userMsg,
+		)
+	}
+	return m.withHistory(userID, lang, version, actionApplyFix, t.errCannotApplyFix, func(tx *sqlx.Tx) error {
+		var query, queryName string
+		var args []any
+		switch f.action {
+		case fixMove:
+			query, queryName = sqlquery.AppendCourses, "sqlquery.AppendCourses"
+			args = []any{userID, f.year, int(f.semester), pq.Array([]int{f.courseID})}
+		case fixRemove:
+			query, queryName = sqlquery.RemoveCoursesByID, "sqlquery.RemoveCoursesByID"
+			args = []any{userID, pq.Array([]int{f.courseID})}
+		case fixAdd:
+			var existing []string
+			if err := tx.Select(&existing, sqlquery.SelectExistingCodes, pq.Array([]string{f.code})); err != nil {
+				return fixErr("sqlquery.SelectExistingCodes", err, http.StatusInternalServerError, t.errCannotApplyFix)
+			}
+			if len(existing) == 0 {
+				return fixErr("sqlquery.SelectExistingCodes", fmt.Errorf("unknown course"), http.StatusBadRequest, t.errInvalidFixParam)
+			}
+			query, queryName = sqlquery.ImportCourses, "sqlquery.ImportCourses"
+			args = importCoursesArgs(userID, []importCourse{{year: f.year, semester: f.semester, position: 1, code: f.code, status: statusPlanned}})
+		}
+		res, err := tx.Exec(query, args...)
+		if err != nil {
+			if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == uniqueViolationCode && pqErr.Constraint == duplicateCoursesViolation {
+				return fixErr(queryName, err, http.StatusConflict, t.errDuplicateCourseInBP)
+			}
+			return fixErr(queryName, err, http.StatusInternalServerError, t.errCannotApplyFix)
+		}
+		rowsAffected, err := res.RowsAffected()
+		if err != nil {
+			return fixErr(queryName, err, http.StatusInternalServerError, t.errCannotApplyFix)
+		}
+		if rowsAffected == 0 {
+			// The blueprint has changed since the fix was proposed.
+			return fixErr(queryName, fmt.Errorf("no rows affected"), http.StatusConflict, t.errFixOutdated)
+		}
+		return nil
+	})
+}
+
+//================================================================================
+// Studies
+//================================================================================
+
+// setStartYear stores the year the user started studying. Zero clears it.
+// It is not recorded in the history as it does not change the blueprint.
+func (m DBManager) setStartYear(userID string, lang language.Language, startYear int) error {
+	value := sql.NullInt64{Int64: int64(startYear), Valid: startYear > 0}
+	res, err := m.DB.Exec(sqlquery.UpdateStartYear, userID, value)
+	if err == nil {
+		var rowsAffected int64
+		rowsAffected, err = res.RowsAffected()
+		if err == nil && rowsAffected == 0 {
+			err = fmt.Errorf("no studies of the user")
+		}
+	}
+	if err != nil {
+		return errorx.NewHTTPErr(
+			errorx.AddContext(fmt.Errorf("sqlquery.UpdateStartYear: %w", err), errorx.P("startYear", startYear)),
+			http.StatusInternalServerError,
+			texts[lang].errCannotSetStartYear,
+		)
+	}
+	return nil
+}
+
+//================================================================================
+// Shares
+//================================================================================
+
+type dbShare struct {
+	ID        int       `db:"id"`
+	Nonce     string    `db:"nonce"`
+	CreatedAt time.Time `db:"created_at"`
+	ExpiresAt time.Time `db:"expires_at"`
+}
+
+func (m DBManager) shares(userID string, lang language.Language) ([]share, error) {
+	var records []dbShare
+	if err := m.DB.Select(&records, sqlquery.SelectShares, userID); err != nil {
+		return nil, errorx.NewHTTPErr(
+			errorx.AddContext(fmt.Errorf("sqlquery.SelectShares: %w", err)),
+			http.StatusInternalServerError,
+			texts[lang].errCannotGetShares,
+		)
+	}
+	shares := make([]share, len(records))
+	for i, record := range records {
+		shares[i] = share{
+			id:        record.ID,
+			nonce:     record.Nonce,
+			createdAt: record.CreatedAt,
+			expiresAt: record.ExpiresAt,
+		}
+	}
+	return shares, nil
+}
+
+func (m DBManager) createShare(userID string, lang language.Language, nonce string, expiresAt time.Time) error {
+	t := texts[lang]
+	var shareID int
+	err := m.DB.Get(&shareID, sqlquery.InsertShare, userID, nonce, expiresAt, maxShares)
+	if errors.Is(err, sql.ErrNoRows) {
+		return errorx.NewHTTPErr(
+			errorx.AddContext(fmt.Errorf("sqlquery.InsertShare: %w", err)),
+			http.StatusConflict,
+			fmt.Sprintf(t.errTooManyShares, maxShares),
+		)
+	}
+	if err != nil {
+		return errorx.NewHTTPErr(
+			errorx.AddContext(fmt.Errorf("sqlquery.InsertShare: %w", err)),
+			http.StatusInternalServerError,
+			t.errCannotCreateShare,
+		)
+	}
+	return nil
+}
+
+func (m DBManager) revokeShare(userID string, lang language.Language, shareID int) error {
+	t := texts[lang]
+	res, err := m.DB.Exec(sqlquery.RevokeShare, userID, shareID)
+	if err != nil {
+		return errorx.NewHTTPErr(
+			errorx.AddContext(fmt.Errorf("sqlquery.RevokeShare: %w", err), errorx.P("share", shareID)),
+			http.StatusInternalServerError,
+			t.errCannotRevokeShare,
+		)
+	}
+	rowsAffected, err := res.RowsAffected()
+	if err != nil || rowsAffected == 0 {
+		return errorx.NewHTTPErr(
+			errorx.AddContext(fmt.Errorf("sqlquery.RevokeShare: %w", err), errorx.P("share", shareID)),
+			http.StatusNotFound,
+			t.errShareNotFound,
+		)
+	}
+	return nil
+}
+
+// sharedBlueprint loads the blueprint of the owner of an active share. The
+// owner's user ID never leaves this function.
+func (m DBManager) sharedBlueprint(nonce string, lang language.Language) (*blueprintPage, error) {
+	t := texts[lang]
+	var userID string
+	err := m.DB.Get(&userID, sqlquery.SelectShareOwner, nonce)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, errorx.NewHTTPErr(
+			errorx.AddContext(fmt.Errorf("sqlquery.SelectShareOwner: %w", err)),
+			http.StatusNotFound,
+			t.errShareNotFound,
+		)
+	}
+	if err != nil {
+		return nil, errorx.NewHTTPErr(
+			errorx.AddContext(fmt.Errorf("sqlquery.SelectShareOwner: %w", err)),
+			http.StatusInternalServerError,
+			t.errCannotGetBlueprint,
+		)
+	}
+	bp, err := m.blueprint(userID, lang)
+	if err != nil {
+		return nil, errorx.AddContext(err)
+	}
+	return bp, nil
+}
+
+//================================================================================
+// Auto-schedule
+//================================================================================
+
+func (m DBManager) degreePlanCourses(userID string, lang language.Language) ([]course, error) {
+	var records []dbds.Course
+	if err := m.DB.Select(&records, sqlquery.SelectDegreePlanCourses, userID, lang); err != nil {
+		return nil, errorx.NewHTTPErr(
+			errorx.AddContext(fmt.Errorf("sqlquery.SelectDegreePlanCourses: %w", err)),
+			http.StatusInternalServerError,
+			texts[lang].errCannotSchedule,
+		)
+	}
+	courses := make([]course, len(records))
+	for i, record := range records {
+		courses[i] = intoCourse(&dbBlueprintRecord{Course: record, Status: string(statusPlanned)})
+	}
+	return courses, nil
+}
+
+// applySchedule moves scheduled unassigned courses and adds the remaining
+// scheduled courses (coming from the degree plan) in a single transaction.
+func (m DBManager) applySchedule(userID string, lang language.Language, version int64, placements []importCourse) error {
+	t := texts[lang]
+	codes := importData{courses: placements}.codes()
+	var existing []string
+	if err := m.DB.Select(&existing, sqlquery.SelectExistingCodes, pq.Array(codes)); err != nil {
+		return errorx.NewHTTPErr(
+			errorx.AddContext(fmt.Errorf("sqlquery.SelectExistingCodes: %w", err)),
+			http.StatusInternalServerError,
+			t.errCannotSchedule,
+		)
+	}
+	if len(existing) != len(codes) {
+		return errorx.NewHTTPErr(
+			errorx.AddContext(fmt.Errorf("unknown courses in schedule"), errorx.P("codes", strings.Join(codes, ","))),
+			http.StatusBadRequest,
+			t.errInvalidPlacementParam,
+		)
+	}
+	return m.withHistory(userID, lang, version, actionAutoSchedule, t.errCannotSchedule, func(tx *sqlx.Tx) error {
+		years := 0
+		courses := make([]importCourse, len(placements))
+		codes := make([]string, len(placements))
+		academicYears := make([]int, len(placements))
+		semesters := make([]int, len(placements))
+		for i, p := range placements {
+			years = max(years, p.year)
+			courses[i] = importCourse{year: p.year, semester: p.semester, position: i + 1, code: p.code, status: statusPlanned}
+			codes[i], academicYears[i], semesters[i] = p.code, p.year, int(p.semester)
+		}
+		if err := ensureYears(tx, userID, years, t.errCannotSchedule); err != nil {
+			return errorx.AddContext(err)
+		}
+		if _, err := tx.Exec(sqlquery.ScheduleUnassignedCourses, userID, pq.Array(codes), pq.Array(academicYears), pq.Array(semesters)); err != nil {
+			return errorx.NewHTTPErr(
+				errorx.AddContext(fmt.Errorf("sqlquery.ScheduleUnassignedCourses: %w", err)),
+				http.StatusInternalServerError,
+				t.errCannotSchedule,
+			)
+		}
+		// Courses moved above already exist in the target semester and are skipped.
+		if _, err := tx.Exec(sqlquery.ImportCourses, importCoursesArgs(userID, courses)...); err != nil {
+			return errorx.NewHTTPErr(
+				errorx.AddContext(fmt.Errorf("sqlquery.ImportCourses: %w", err)),
+				http.StatusInternalServerError,
+				t.errCannotSchedule,
+			)
+		}
+		return nil
+	})
+}
+
+//================================================================================
+// Custom Courses
+//================================================================================
+
+// nullMappedCode returns the mapped code of c for the database, NULL if the
+// course is not mapped.
+func (c customCourse) nullMappedCode() sql.NullString {
+	return sql.NullString{String: c.mappedCode, Valid: c.mappedCode != ""}
+}
+
+// checkMappedCourse fails if the custom course is mapped to a course which
+// does not exist in SIS.
+func checkMappedCourse(tx *sqlx.Tx, c customCourse, t text) error {
+	if c.mappedCode == "" {
+		return nil
+	}
+	var existing []string
+	if err := tx.Select(&existing, sqlquery.SelectExistingCodes, pq.Array([]string{c.mappedCode})); err != nil {
+		return errorx.NewHTTPErr(
+			errorx.AddContext(fmt.Errorf("sqlquery.SelectExistingCodes: %w", err), errorx.P("code", c.mappedCode)),
+			http.StatusInternalServerError,
+			t.errCannotSaveCustomCourse,
+		)
+	}
+	if len(existing) == 0 {
+		return errorx.NewHTTPErr(
+			errorx.AddContext(fmt.Errorf("unknown mapped course"), errorx.P("code", c.mappedCode)),
+			http.StatusBadRequest,
+			t.errUnknownMappedCourse,
+		)
+	}
+	return nil
+}
+
+func (m DBManager) addCustomCourse(userID string, lang language.Language, version int64, c customCourse) error {
+	t := texts[lang]
+	return m.withHistory(userID, lang, version, actionAddCustomCourse, t.errCannotSaveCustomCourse, func(tx *sqlx.Tx) error {
+		if err := checkMappedCourse(tx, c, t); err != nil {
+			return err
+		}
+		_, err := tx.Exec(sqlquery.InsertCustomCourse, userID, c.title, c.credits, int(c.semester), c.nullMappedCode())
+		if err != nil {
+			return errorx.NewHTTPErr(
+				errorx.AddContext(fmt.Errorf("sqlquery.InsertCustomCourse: %w", err), errorx.P("title", c.title)),
+				http.StatusInternalServerError,
+				t.errCannotSaveCustomCourse,
+			)
+		}
+		return nil
+	})
+}
+
+func (m DBManager) editCustomCourse(userID string, lang language.Language, version int64, courseID int, c customCourse) error {
+	t := texts[lang]
+	return m.withHistory(userID, lang, version, actionEditCustomCourse, t.errCannotSaveCustomCourse, func(tx *sqlx.Tx) error {
+		if err := checkMappedCourse(tx, c, t); err != nil {
+			return err
+		}
+		res, err := tx.Exec(sqlquery.UpdateCustomCourse, userID, courseID, c.title, c.credits, int(c.semester), c.nullMappedCode())
+		if err != nil {
+			return errorx.NewHTTPErr(
+				errorx.AddContext(fmt.Errorf("sqlquery.UpdateCustomCourse: %w", err), errorx.P("course", courseID)),
+				http.StatusInternalServerError,
+				t.errCannotSaveCustomCourse,
+			)
+		}
+		rowsAffected, err := res.RowsAffected()
+		if err != nil || rowsAffected == 0 {
+			return errorx.NewHTTPErr(
+				errorx.AddContext(fmt.Errorf("sqlquery.UpdateCustomCourse: %w", err), errorx.P("course", courseID)),
+				http.StatusNotFound,
+				t.errCustomCourseNotFound,
+			)
+		}
+		return nil
+	})
+}
+
+//================================================================================
+// Change Impact
+//================================================================================
+
+// commit commits the transaction of a blueprint change. During a dry run (see
+// preview) the transaction is handed over to dryRun instead and rolled back.
+func (m DBManager) commit(tx *sqlx.Tx) error {
+	if m.dryRun != nil {
+		return m.dryRun(tx)
+	}
+	return tx.Commit()
+}
+
+// preview makes the change without committing it and returns the blueprint
+// as it would look afterwards.
+func (m DBManager) preview(userID string, lang language.Language, change func(a Adapter) error) (*blueprintPage, error) {
+	var after *blueprintPage
+	dry := m
+	dry.dryRun = func(tx *sqlx.Tx) error {
+		bp, err := loadBlueprint(tx, userID, lang)
+		if err != nil {
+			return errorx.AddContext(err)
+		}
+		after = bp
+		return nil
+	}
+	if err := change(dry); err != nil {
+		return nil, err
+	}
+	if after == nil {
+		return nil, errorx.NewHTTPErr(
+			errorx.AddContext(fmt.Errorf("change cannot be previewed")),
+			http.StatusBadRequest,
+			texts[lang].errCannotPreview,
+		)
+	}
+	return after, nil
+}
+
+type dbDegreePlanBloc struct {
+	Code    string         `db:"code"`
+	Name    string         `db:"name"`
+	Limit   int            `db:"bloc_limit"`
+	Courses pq.StringArray `db:"courses"`
+}
+
+func (m DBManager) degreePlanBlocs(userID string, lang language.Language) ([]degreePlanBloc, error) {
+	var records []dbDegreePlanBloc
+	if err := m.DB.Select(&records, sqlquery.SelectDegreePlanBlocs, userID, lang); err != nil {
+		return nil, errorx.NewHTTPErr(
+			errorx.AddContext(fmt.Errorf("sqlquery.SelectDegreePlanBlocs: %w", err)),
+			http.StatusInternalServerError,
+			texts[lang].errCannotPreview,
+		)
+	}
+	blocs := make([]degreePlanBloc, len(records))
+	for i, r := range records {
+		blocs[i] = degreePlanBloc{
+			code:    r.Code,
+			name:    r.Name,
+			limit:   r.Limit,
+			courses: r.Courses,
+		}
+	}
+	return blocs, nil
+}
@@ -0,0 +1,245 @@
+package blueprint
+
+import (
+	"cmp"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/a-h/templ"
+	"github.com/michalhercik/RecSIS/errorx"
+	"github.com/michalhercik/RecSIS/language"
+)
+
+//================================================================================
+// Constants
+//================================================================================
+
+// dryRunParam makes a change endpoint only preview the change and render its
+// impact instead of applying it.
+const dryRunParam string = "dry-run"
+
+//================================================================================
+// Data Types and Methods
+//================================================================================
+
+// degreePlanBloc is a bloc of the user's degree plan with a credit limit.
+type degreePlanBloc struct {
+	code    string
+	name    string
+	limit   int
+	courses []string
+}
+
+// credits returns credits of bloc courses assigned to a year of bp. Every
+// course counts once even if it is assigned more times. Custom courses count
+// as the course they are mapped to.
+func (b degreePlanBloc) credits(bp *blueprintPage) int {
+	credits := make(map[string]int)
+	for c := range bp.assignedCourses() {
+		for _, code := range c.course.planCodes() {
+			if slices.Contains(b.courses, code) {
+				credits[code] = c.course.credits
+				break
+			}
+		}
+	}
+	sum := 0
+	for _, c := range credits {
+		sum += c
+	}
+	return sum
+}
+
+// impactWarning is a warning of a course which would appear or disappear.
+type impactWarning struct {
+	code    string
+	title   string
+	message string
+}
+
+// impactBloc is a bloc whose assigned credits would drop below its limit.
+type impactBloc struct {
+	degreePlanBloc
+	before int
+	after  int
+}
+
+// changeRequest is the previewed request which applies the change when sent
+// again without dryRunParam.
+type changeRequest struct {
+	method string
+	url    string
+	values [][2]string
+}
+
+func (cr changeRequest) attrs() templ.Attributes {
+	return templ.Attributes{"hx-" + strings.ToLower(cr.method): cr.url}
+}
+
+// changeImpact lists consequences of a change which was not applied yet.
+type changeImpact struct {
+	newWarnings      []impactWarning
+	resolvedWarnings []impactWarning
+	blocs            []impactBloc
+	request          changeRequest
+}
+
+func (ci *changeImpact) isEmpty() bool {
+	return len(ci.newWarnings) == 0 && len(ci.resolvedWarnings) == 0 && len(ci.blocs) == 0
+}
+
+//================================================================================
+// Impact
+//================================================================================
+
+// buildImpact compares warnings of blueprints before and after the change
+// (both with generated warnings) and finds degree plan blocs which would no
+// longer reach their limit.
+func buildImpact(before, after *blueprintPage, blocs []degreePlanBloc) changeImpact {
+	var result changeImpact
+	warningsBefore := impactWarnings(before)
+	warningsAfter := impactWarnings(after)
+	for key, w := range warningsAfter {
+		if _, ok := warningsBefore[key]; !ok {
+			result.newWarnings = append(result.newWarnings, w)
+		}
+	}
+	for key, w := range warningsBefore {
+		if _, ok := warningsAfter[key]; !ok {
+			result.resolvedWarnings = append(result.resolvedWarnings, w)
+		}
+	}
+	compare := func(a, b impactWarning) int {
+		return cmp.Or(cmp.Compare(a.code, b.code), cmp.Compare(a.message, b.message))
+	}
+	slices.SortFunc(result.newWarnings, compare)
+	slices.SortFunc(result.resolvedWarnings, compare)
+	for _, b := range blocs {
+		creditsBefore, creditsAfter := b.credits(before), b.credits(after)
+		if creditsBefore >= b.limit && creditsAfter < b.limit {
+			result.blocs = append(result.blocs, impactBloc{degreePlanBloc: b, before: creditsBefore, after: creditsAfter})
+		}
+	}
+	return result
+}
+
+// impactWarnings returns warnings of all courses of bp. A warning is
+// identified by the course, its kind and involved courses, so the same
+// problem of a moved course is not reported as new.
+func impactWarnings(bp *blueprintPage) map[string]impactWarning {
+	result := make(map[string]impactWarning)
+	addWarnings := func(c *course) {
+		for _, d := range c.diagnostics {
+			key := fmt.Sprintf("%s|%s|%s", c.rowKey(), d.kind, strings.Join(d.courses, ","))
+			result[key] = impactWarning{code: c.code, title: c.title, message: d.message}
+		}
+	}
+	for c := range bp.unassignedCourses() {
+		addWarnings(c)
+	}
+	for c := range bp.assignedCourses() {
+		addWarnings(c.course)
+	}
+	return result
+}
+
+//================================================================================
+// Handlers
+//================================================================================
+
+// applyChange applies the change and renders the blueprint. With dryRunParam
+// the change is only previewed and its impact is rendered instead.
+func (s Server) applyChange(w http.ResponseWriter, r *http.Request, userID string, lang language.Language, change func(a Adapter) error) {
+	dryRun, err := parseDryRun(r)
+	if err != nil {
+		code, userMsg := errorx.UnwrapError(err, lang)
+		s.Error.Log(errorx.AddContext(err))
+		s.Error.Render(w, r, code, userMsg, lang)
+		return
+	}
+	if dryRun {
+		s.renderImpact(w, r, userID, lang, change)
+		return
+	}
+	if err := change(s.Data); err != nil {
+		s.renderChangeError(w, r, userID, lang, err)
+		return
+	}
+	s.renderBlueprintContent(w, r, userID, lang)
+}
+
+func (s Server) renderImpact(w http.ResponseWriter, r *http.Request, userID string, lang language.Language, change func(a Adapter) error) {
+	t := texts[lang]
+	before, err := s.Data.blueprint(userID, lang)
+	if err != nil {
+		code, userMsg := errorx.UnwrapError(err, lang)
+		s.Error.Log(errorx.AddContext(err))
+		s.Error.Render(w, r, code, userMsg, lang)
+		return
+	}
+	after, err := s.Data.preview(userID, lang, change)
+	if err != nil {
+		s.renderChangeError(w, r, userID, lang, err)
+		return
+	}
+	blocs, err := s.Data.degreePlanBlocs(userID, lang)
+	if err != nil {
+		code, userMsg := errorx.UnwrapError(err, lang)
+		s.Error.Log(errorx.AddContext(err))
+		s.Error.Render(w, r, code, userMsg, lang)
+		return
+	}
+	t = t.withStartYear(before.startYear)
+	generateWarnings(before, t)
+	generateWarnings(after, t)
+	impact := buildImpact(before, after, blocs)
+	impact.request = changeRequestOf(r, t)
+	err = impactContent(&impact, t).Render(r.Context(), w)
+	if err != nil {
+		s.Error.CannotRenderPage(w, r, t.pageTitle, userID, errorx.AddContext(err), lang)
+	}
+}
+
+//================================================================================
+// Parse parameters
+//================================================================================
+
+func parseDryRun(r *http.Request) (bool, error) {
+	value := r.FormValue(dryRunParam)
+	if value == "" {
+		return false, nil
+	}
+	dryRun, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, errorx.NewHTTPErr(
+			errorx.AddContext(fmt.Errorf("unable to parse dry-run parameter to bool: %w", err)),
+			http.StatusBadRequest,
+			texts[language.FromContext(r.Context())].errInvalidDryRunParam,
+		)
+	}
+	return dryRun, nil
+}
+
+// changeRequestOf returns the request r without dryRunParam. Form values are
+// sorted, so the rendered confirmation is stable.
+func changeRequestOf(r *http.Request, t text) changeRequest {
+	result := changeRequest{
+		method: r.Method,
+		url:    t.language.LocalizeURL("/blueprint" + r.URL.Path),
+	}
+	for key, values := range r.Form {
+		if key == dryRunParam {
+			continue
+		}
+		for _, value := range values {
+			result.values = append(result.values, [2]string{key, value})
+		}
+	}
+	slices.SortStableFunc(result.values, func(a, b [2]string) int {
+		return cmp.Compare(a[0], b[0])
+	})
+	return result
+}
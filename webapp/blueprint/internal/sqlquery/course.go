@@ -6,19 +6,37 @@ SELECT
 	bs.semester,
 	bs.folded,
 	bc.id,
-	c.code,
-	c.title,
-	c.start_semester,
+	COALESCE(c.code, '') AS code,
+	COALESCE(c.title, bc.custom_title) AS title,
+	COALESCE(c.start_semester, bc.custom_semester::text) AS start_semester,
 	c.lecture_range_winter,
 	c.lecture_range_summer,
 	c.seminar_range_winter,
 	c.seminar_range_summer,
-	c.exam,
-	c.credits,
+	c.range_unit,
+	COALESCE(c.exam, '') AS exam,
+	COALESCE(c.credits, bc.custom_credits) AS credits,
 	c.guarantors,
 	c.prerequisites,
 	c.corequisites,
-	c.incompatibilities
+	c.incompatibilities,
+	COALESCE(c.valid_from, 0) AS valid_from,
+	COALESCE(c.valid_to, 9999) AS valid_to,
+	COALESCE(c.taught_state, '') AS taught_state,
+	COALESCE(c.taught_state_title, '') AS taught_state_title,
+	bc.status,
+	bc.grade,
+	bc.completed_at,
+	bc.course_code IS NULL AS custom,
+	bc.mapped_course_code,
+	(
+		SELECT mc.interchangeabilities
+		FROM courses mc
+		WHERE mc.code = bc.mapped_course_code
+			AND mc.lang = $2
+		ORDER BY mc.valid_from DESC
+		LIMIT 1
+	) AS mapped_interchangeabilities
 FROM blueprint_years y
 INNER JOIN blueprint_semesters bs
 	ON y.id=bs.blueprint_year_id
@@ -26,7 +44,7 @@ INNER JOIN blueprint_courses bc
 	ON bs.id=bc.blueprint_semester_id
 LEFT JOIN courses c
 	ON bc.course_code=c.code
-WHERE y.user_id = $1
+WHERE y.scenario_id = (SELECT id FROM blueprint_scenarios WHERE user_id = $1 AND active)
 	AND (c.lang = $2 OR c.lang IS NULL)
 ORDER BY y.academic_year, bs.semester, bc.position;
 `
@@ -49,7 +67,7 @@ SELECT
 FROM blueprint_years y
 INNER JOIN blueprint_semesters bs
 	ON y.id=bs.blueprint_year_id
-WHERE y.user_id = $1
+WHERE y.scenario_id = (SELECT id FROM blueprint_scenarios WHERE user_id = $1 AND active)
 ORDER BY y.academic_year, bs.semester;
 `
 
@@ -59,7 +77,7 @@ WITH user_semesters AS (
 	FROM blueprint_years y
 	LEFT JOIN blueprint_semesters bs
 		ON bs.blueprint_year_id = y.id
-	WHERE y.user_id = $1
+	WHERE y.scenario_id = (SELECT id FROM blueprint_scenarios WHERE user_id = $1 AND active)
 ),
 origin_courses AS (
 	SELECT bc.id
@@ -103,7 +121,7 @@ WITH origin AS (
 		ON y.id = bs.blueprint_year_id
 	LEFT JOIN blueprint_courses bc
 		ON bs.id = bc.blueprint_semester_id
-	WHERE y.user_id = $1
+	WHERE y.scenario_id = (SELECT id FROM blueprint_scenarios WHERE user_id = $1 AND active)
 	AND bc.id = any($4)
 ),
 target_semester_position AS (
@@ -113,7 +131,7 @@ target_semester_position AS (
 		ON y.id = bs.blueprint_year_id
 	LEFT JOIN blueprint_courses bc
 		ON bs.id = bc.blueprint_semester_id
-	WHERE y.user_id = $1
+	WHERE y.scenario_id = (SELECT id FROM blueprint_scenarios WHERE user_id = $1 AND active)
 		AND y.academic_year = $2
 		AND bs.semester = $3
 	ORDER BY bc.position DESC
@@ -132,7 +150,7 @@ WITH origin_semester_id AS (
 	FROM blueprint_years y
 	LEFT JOIN blueprint_semesters bs
 		ON y.id = bs.blueprint_year_id
-	WHERE y.user_id = $1
+	WHERE y.scenario_id = (SELECT id FROM blueprint_scenarios WHERE user_id = $1 AND active)
 	AND y.academic_year = $2
 	AND bs.semester = $3
 ),
@@ -143,7 +161,7 @@ unassigned AS (
 		ON y.id = bs.blueprint_year_id
 	LEFT JOIN blueprint_courses bc
 		ON bs.id = bc.blueprint_semester_id
-	WHERE y.user_id = $1
+	WHERE y.scenario_id = (SELECT id FROM blueprint_scenarios WHERE user_id = $1 AND active)
 		AND y.academic_year = 0
 		AND bs.semester = 0
 	ORDER BY bc.position DESC
@@ -164,7 +182,7 @@ WITH target_semesters_id AS (
 		ON y.id = bs.blueprint_year_id
 	LEFT JOIN blueprint_courses bc
 		ON bs.id = bc.blueprint_semester_id
-	WHERE y.user_id = $1
+	WHERE y.scenario_id = (SELECT id FROM blueprint_scenarios WHERE user_id = $1 AND active)
 	AND bc.id = any($2)
 )
 DELETE FROM blueprint_courses bc
@@ -178,7 +196,7 @@ WITH target_semester_id AS (
 	FROM blueprint_years y
 	LEFT JOIN blueprint_semesters bs
 		ON y.id = bs.blueprint_year_id
-	WHERE y.user_id = $1
+	WHERE y.scenario_id = (SELECT id FROM blueprint_scenarios WHERE user_id = $1 AND active)
 	AND y.academic_year = $2
 	AND bs.semester = $3
 )
@@ -186,3 +204,76 @@ DELETE FROM blueprint_courses bc
 USING target_semester_id ts
 WHERE bc.blueprint_semester_id = ts.id;
 `
+
+/*
+Sets completion status, grade and completion date of a blueprint course.
+
+Params:
+
+	$1 student
+	$2 blueprint course ID
+	$3 status
+	$4 grade (nullable)
+	$5 completion date (nullable)
+*/
+const SetCourseStatus = `--sql
+UPDATE blueprint_courses bc
+SET status = $3, grade = $4, completed_at = $5
+FROM blueprint_semesters bs, blueprint_years y
+WHERE bc.blueprint_semester_id = bs.id
+	AND bs.blueprint_year_id = y.id
+	AND y.scenario_id = (SELECT id FROM blueprint_scenarios WHERE user_id = $1 AND active)
+	AND bc.id = $2;
+`
+
+/*
+Appends a custom course to unassigned courses of the active scenario.
+
+Params:
+
+	$1 student
+	$2 title
+	$3 credits
+	$4 teaching semester
+	$5 mapped SIS course code (nullable)
+*/
+const InsertCustomCourse = `--sql
+INSERT INTO blueprint_courses (blueprint_semester_id, position, custom_title, custom_credits, custom_semester, mapped_course_code)
+SELECT
+	bs.id,
+	COALESCE((
+		SELECT MAX(position)
+		FROM blueprint_courses
+		WHERE blueprint_semester_id = bs.id
+	), 0) + 1,
+	$2, $3, $4, $5
+FROM blueprint_years y
+INNER JOIN blueprint_semesters bs
+	ON y.id = bs.blueprint_year_id
+WHERE y.scenario_id = (SELECT id FROM blueprint_scenarios WHERE user_id = $1 AND active)
+	AND y.academic_year = 0;
+`
+
+/*
+Changes a custom course of the active scenario. Courses from SIS are not
+changed.
+
+Params:
+
+	$1 student
+	$2 blueprint course ID
+	$3 title
+	$4 credits
+	$5 teaching semester
+	$6 mapped SIS course code (nullable)
+*/
+const UpdateCustomCourse = `--sql
+UPDATE blueprint_courses bc
+SET custom_title = $3, custom_credits = $4, custom_semester = $5, mapped_course_code = $6
+FROM blueprint_semesters bs, blueprint_years y
+WHERE bc.blueprint_semester_id = bs.id
+	AND bs.blueprint_year_id = y.id
+	AND y.scenario_id = (SELECT id FROM blueprint_scenarios WHERE user_id = $1 AND active)
+	AND bc.id = $2
+	AND bc.course_code IS NULL;
+`
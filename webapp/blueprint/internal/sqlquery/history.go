@@ -0,0 +1,66 @@
+package sqlquery
+
+const RecordHistory = `--sql
+SELECT record_blueprint_history($1, $2);
+`
+
+// Locks the active scenario until the end of the transaction, so that its
+// version cannot change between checking it and recording the change.
+const LockVersion = `--sql
+SELECT version
+FROM blueprint_scenarios
+WHERE user_id = $1
+	AND active
+FOR UPDATE;
+`
+
+const SelectHistory = `--sql
+SELECT
+	h.id,
+	h.action,
+	h.undone,
+	h.created_at,
+	COALESCE(h.version, 0) AS version
+FROM blueprint_history h
+WHERE h.scenario_id = (SELECT id FROM blueprint_scenarios WHERE user_id = $1 AND active)
+ORDER BY h.id DESC;
+`
+
+const Undo = `--sql
+SELECT swap_blueprint_history(h.id)
+FROM (
+	SELECT id
+	FROM blueprint_history
+	WHERE scenario_id = (SELECT id FROM blueprint_scenarios WHERE user_id = $1 AND active)
+		AND NOT undone
+	ORDER BY id DESC
+	LIMIT 1
+) h;
+`
+
+const Redo = `--sql
+SELECT swap_blueprint_history(h.id)
+FROM (
+	SELECT id
+	FROM blueprint_history
+	WHERE scenario_id = (SELECT id FROM blueprint_scenarios WHERE user_id = $1 AND active)
+		AND undone
+	ORDER BY id
+	LIMIT 1
+) h;
+`
+
+const SelectHistorySnapshot = `--sql
+SELECT h.snapshot
+FROM blueprint_history h
+WHERE h.scenario_id = (SELECT id FROM blueprint_scenarios WHERE user_id = $1 AND active)
+	AND h.id = $2
+	AND NOT h.undone;
+`
+
+const RestoreSnapshot = `--sql
+SELECT blueprint_restore(
+	(SELECT id FROM blueprint_scenarios WHERE user_id = $1 AND active),
+	$2::jsonb
+);
+`
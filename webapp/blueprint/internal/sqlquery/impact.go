@@ -0,0 +1,27 @@
+package sqlquery
+
+/*
+Selects blocs of the student's degree plan which have a credit limit together
+with codes of their courses.
+
+Params:
+
+	$1 student
+	$2 language
+*/
+const SelectDegreePlanBlocs = `--sql
+SELECT
+	dpc.bloc_subject_code AS code,
+	COALESCE(MAX(dpc.bloc_name), dpc.bloc_subject_code) AS name,
+	MAX(dpc.bloc_limit) AS bloc_limit,
+	array_agg(DISTINCT dpc.course_code) AS courses
+FROM studies s
+INNER JOIN degree_plan_courses dpc
+	ON s.degree_plan_code = dpc.plan_code
+	AND dpc.lang = $2
+WHERE s.user_id = $1
+	AND dpc.interchangeability IS NULL
+	AND dpc.bloc_limit > 0
+GROUP BY dpc.bloc_subject_code
+ORDER BY MIN(dpc.seq), dpc.bloc_subject_code;
+`
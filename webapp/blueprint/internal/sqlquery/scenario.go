@@ -0,0 +1,126 @@
+package sqlquery
+
+const SelectScenarios = `--sql
+SELECT
+	id,
+	name,
+	active,
+	version
+FROM blueprint_scenarios
+WHERE user_id = $1
+ORDER BY created_at, id;
+`
+
+const SelectScenarioID = `--sql
+SELECT id
+FROM blueprint_scenarios
+WHERE user_id = $1
+	AND id = $2;
+`
+
+const InsertScenario = `--sql
+INSERT INTO blueprint_scenarios (user_id, name)
+VALUES ($1, $2)
+RETURNING id;
+`
+
+const InsertScenarioUnassigned = `--sql
+WITH unassigned_year AS (
+	INSERT INTO blueprint_years (user_id, scenario_id, academic_year)
+	VALUES ($1, $2, 0)
+	RETURNING id
+)
+INSERT INTO blueprint_semesters (blueprint_year_id, semester)
+SELECT id, 0
+FROM unassigned_year;
+`
+
+const CopyScenarioYears = `--sql
+INSERT INTO blueprint_years (user_id, scenario_id, academic_year)
+SELECT y.user_id, $3, y.academic_year
+FROM blueprint_years y
+INNER JOIN blueprint_scenarios s
+	ON s.id = y.scenario_id
+WHERE s.user_id = $1
+	AND s.id = $2;
+`
+
+const CopyScenarioSemesters = `--sql
+INSERT INTO blueprint_semesters (blueprint_year_id, semester, folded)
+SELECT ny.id, bs.semester, bs.folded
+FROM blueprint_years oy
+INNER JOIN blueprint_semesters bs
+	ON oy.id = bs.blueprint_year_id
+INNER JOIN blueprint_years ny
+	ON ny.scenario_id = $2
+	AND ny.academic_year = oy.academic_year
+WHERE oy.scenario_id = $1;
+`
+
+const CopyScenarioCourses = `--sql
+INSERT INTO blueprint_courses (
+	blueprint_semester_id, course_code, course_valid_from, position, secondary_position, status, grade, completed_at,
+	custom_title, custom_credits, custom_semester, mapped_course_code
+)
+SELECT
+	nbs.id, bc.course_code, bc.course_valid_from, bc.position, bc.secondary_position, bc.status, bc.grade, bc.completed_at,
+	bc.custom_title, bc.custom_credits, bc.custom_semester, bc.mapped_course_code
+FROM blueprint_years oy
+INNER JOIN blueprint_semesters obs
+	ON oy.id = obs.blueprint_year_id
+INNER JOIN blueprint_courses bc
+	ON obs.id = bc.blueprint_semester_id
+INNER JOIN blueprint_years ny
+	ON ny.scenario_id = $2
+	AND ny.academic_year = oy.academic_year
+INNER JOIN blueprint_semesters nbs
+	ON ny.id = nbs.blueprint_year_id
+	AND nbs.semester = obs.semester
+WHERE oy.scenario_id = $1;
+`
+
+const RenameScenario = `--sql
+UPDATE blueprint_scenarios
+SET name = $3
+WHERE user_id = $1
+	AND id = $2;
+`
+
+const DeleteScenario = `--sql
+DELETE FROM blueprint_scenarios
+WHERE user_id = $1
+	AND id = $2
+RETURNING active;
+`
+
+const CountScenarios = `--sql
+SELECT COUNT(*)
+FROM blueprint_scenarios
+WHERE user_id = $1;
+`
+
+const DeactivateScenarios = `--sql
+UPDATE blueprint_scenarios
+SET active = false
+WHERE user_id = $1
+	AND active;
+`
+
+const ActivateScenario = `--sql
+UPDATE blueprint_scenarios
+SET active = true
+WHERE user_id = $1
+	AND id = $2;
+`
+
+const ActivateFirstScenario = `--sql
+UPDATE blueprint_scenarios
+SET active = true
+WHERE id = (
+	SELECT id
+	FROM blueprint_scenarios
+	WHERE user_id = $1
+	ORDER BY created_at, id
+	LIMIT 1
+);
+`
@@ -0,0 +1,98 @@
+package sqlquery
+
+/*
+Selects courses of the student's degree plan which have a recommended year,
+ordered by the recommendation.
+
+Params:
+
+	$1 student
+	$2 language
+*/
+const SelectDegreePlanCourses = `--sql
+SELECT
+	c.code,
+	c.title,
+	c.start_semester,
+	c.lecture_range_winter,
+	c.lecture_range_summer,
+	c.seminar_range_winter,
+	c.seminar_range_summer,
+	c.exam,
+	c.credits,
+	c.guarantors,
+	c.prerequisites,
+	c.corequisites,
+	c.incompatibilities
+FROM (
+	SELECT DISTINCT ON (dpc.course_code)
+		dpc.course_code,
+		dpc.recommended_year_from,
+		dpc.recommended_semester
+	FROM studies s
+	INNER JOIN degree_plan_courses dpc
+		ON s.degree_plan_code = dpc.plan_code
+		AND dpc.lang = $2
+	WHERE s.user_id = $1
+		AND dpc.recommended_year_from IS NOT NULL
+		AND dpc.interchangeability IS NULL
+	ORDER BY dpc.course_code, (dpc.recommended_semester IS NULL) ASC
+) pc
+JOIN LATERAL (
+	SELECT *
+	FROM courses
+	WHERE code = pc.course_code
+		AND lang = $2
+	ORDER BY valid_from DESC
+	LIMIT 1
+) c ON TRUE
+ORDER BY pc.recommended_year_from, pc.recommended_semester NULLS LAST, c.code;
+`
+
+/*
+Moves unassigned courses into scheduled semesters. Moved courses are appended
+at the end of their target semesters.
+
+Params:
+
+	$1 student
+	$2 course codes
+	$3 academic years
+	$4 semesters
+*/
+const ScheduleUnassignedCourses = `--sql
+WITH unassigned AS (
+	SELECT bc.id, bc.course_code
+	FROM blueprint_years y
+	INNER JOIN blueprint_semesters bs
+		ON y.id = bs.blueprint_year_id
+	INNER JOIN blueprint_courses bc
+		ON bs.id = bc.blueprint_semester_id
+	WHERE y.scenario_id = (SELECT id FROM blueprint_scenarios WHERE user_id = $1 AND active)
+		AND y.academic_year = 0
+),
+targets AS (
+	SELECT
+		u.id,
+		bs.id AS semester_id,
+		COALESCE((
+			SELECT MAX(position)
+			FROM blueprint_courses
+			WHERE blueprint_semester_id = bs.id
+		), 0) + ROW_NUMBER() OVER (PARTITION BY bs.id ORDER BY i.ord) AS position
+	FROM UNNEST($2::text[], $3::int[], $4::int[]) WITH ORDINALITY AS i(code, academic_year, semester, ord)
+	INNER JOIN unassigned u
+		ON u.course_code = i.code
+	INNER JOIN blueprint_years y
+		ON y.scenario_id = (SELECT id FROM blueprint_scenarios WHERE user_id = $1 AND active)
+		AND y.academic_year = i.academic_year
+	INNER JOIN blueprint_semesters bs
+		ON y.id = bs.blueprint_year_id
+		AND bs.semester = i.semester
+)
+UPDATE blueprint_courses bc
+SET blueprint_semester_id = t.semester_id,
+	position = t.position
+FROM targets t
+WHERE bc.id = t.id;
+`
@@ -4,7 +4,7 @@ const InsertSemestersByYear = `--sql
 WITH target_year_id AS (
 	SELECT id
 	FROM blueprint_years y
-	WHERE y.user_id = $1
+	WHERE y.scenario_id = (SELECT id FROM blueprint_scenarios WHERE user_id = $1 AND active)
 		AND y.id = $2
 )
 INSERT INTO blueprint_semesters (blueprint_year_id, semester)
@@ -18,7 +18,7 @@ UPDATE blueprint_semesters bs
 SET folded = $4
 FROM blueprint_years by
 WHERE bs.blueprint_year_id = by.id
-	AND by.user_id = $1
+	AND by.scenario_id = (SELECT id FROM blueprint_scenarios WHERE user_id = $1 AND active)
 	AND by.academic_year = $2
 	AND bs.semester = $3;
 `
@@ -0,0 +1,69 @@
+package sqlquery
+
+/*
+Selects active (not revoked and not expired) shares of the student.
+
+Params:
+
+	$1 student
+*/
+const SelectShares = `--sql
+SELECT
+	id,
+	nonce,
+	created_at,
+	expires_at
+FROM blueprint_shares
+WHERE user_id = $1
+	AND revoked_at IS NULL
+	AND expires_at > NOW()
+ORDER BY created_at DESC, id DESC;
+`
+
+/*
+Creates a share unless the student already has the maximum number of active
+shares. Returns no rows if the limit is reached.
+
+Params:
+
+	$1 student
+	$2 nonce
+	$3 expiration
+	$4 maximum number of active shares
+*/
+const InsertShare = `--sql
+INSERT INTO blueprint_shares (user_id, nonce, expires_at)
+SELECT $1, $2, $3
+WHERE (
+	SELECT COUNT(*)
+	FROM blueprint_shares
+	WHERE user_id = $1
+		AND revoked_at IS NULL
+		AND expires_at > NOW()
+) < $4
+RETURNING id;
+`
+
+const RevokeShare = `--sql
+UPDATE blueprint_shares
+SET revoked_at = NOW()
+WHERE user_id = $1
+	AND id = $2
+	AND revoked_at IS NULL;
+`
+
+/*
+Selects the owner of an active share. The owner is used only to load the
+blueprint and is never sent to the client.
+
+Params:
+
+	$1 nonce
+*/
+const SelectShareOwner = `--sql
+SELECT user_id
+FROM blueprint_shares
+WHERE nonce = $1
+	AND revoked_at IS NULL
+	AND expires_at > NOW();
+`
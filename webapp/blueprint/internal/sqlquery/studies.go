@@ -0,0 +1,21 @@
+package sqlquery
+
+const SelectStartYear = `--sql
+SELECT start_year
+FROM studies
+WHERE user_id = $1;
+`
+
+/*
+Sets the calendar year in which the student started studying.
+
+Params:
+
+	$1 student
+	$2 start year (NULL if unknown)
+*/
+const UpdateStartYear = `--sql
+UPDATE studies
+SET start_year = $2
+WHERE user_id = $1;
+`
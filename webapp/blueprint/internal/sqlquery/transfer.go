@@ -0,0 +1,104 @@
+package sqlquery
+
+const SelectExistingCodes = `--sql
+SELECT DISTINCT code
+FROM courses
+WHERE code = ANY($1::text[]);
+`
+
+const CountYears = `--sql
+SELECT COUNT(*) - 1
+FROM blueprint_years y
+WHERE y.scenario_id = (SELECT id FROM blueprint_scenarios WHERE user_id = $1 AND active);
+`
+
+const ClearCourses = `--sql
+DELETE FROM blueprint_courses bc
+USING blueprint_semesters bs, blueprint_years y
+WHERE bc.blueprint_semester_id = bs.id
+	AND bs.blueprint_year_id = y.id
+	AND y.scenario_id = (SELECT id FROM blueprint_scenarios WHERE user_id = $1 AND active);
+`
+
+const DeleteYearsAfter = `--sql
+DELETE FROM blueprint_years y
+WHERE y.scenario_id = (SELECT id FROM blueprint_scenarios WHERE user_id = $1 AND active)
+	AND y.academic_year > $2;
+`
+
+/*
+Sets folded state of semesters given as parallel arrays.
+
+Params:
+
+	$1 student
+	$2 academic years
+	$3 semesters
+	$4 folded flags
+*/
+const SetSemestersFolded = `--sql
+UPDATE blueprint_semesters bs
+SET folded = i.folded
+FROM blueprint_years y,
+	UNNEST($2::int[], $3::int[], $4::boolean[]) AS i(academic_year, semester, folded)
+WHERE bs.blueprint_year_id = y.id
+	AND y.scenario_id = (SELECT id FROM blueprint_scenarios WHERE user_id = $1 AND active)
+	AND y.academic_year = i.academic_year
+	AND bs.semester = i.semester;
+`
+
+/*
+Appends imported courses at the end of their semesters. Courses already
+present in the target semester are skipped. Custom courses have an empty code
+and a custom title, they are never skipped.
+
+Params:
+
+	$1 student
+	$2 academic years
+	$3 semesters
+	$4 course codes ('' for custom courses)
+	$5 positions (order within the semester)
+	$6 statuses
+	$7 grades (0 if not graded)
+	$8 completion dates ('' if unknown)
+	$9 custom course titles ('' for courses from SIS)
+	$10 custom course credits
+	$11 custom course semesters (0 for courses from SIS)
+	$12 codes of courses custom courses are mapped to ('' if not mapped)
+*/
+const ImportCourses = `--sql
+INSERT INTO blueprint_courses (
+	blueprint_semester_id, course_code, course_valid_from, position, status, grade, completed_at,
+	custom_title, custom_credits, custom_semester, mapped_course_code
+)
+SELECT
+	bs.id,
+	c.code,
+	c.valid_from,
+	COALESCE((
+		SELECT MAX(position)
+		FROM blueprint_courses
+		WHERE blueprint_semester_id = bs.id
+	), 0) + ROW_NUMBER() OVER (PARTITION BY bs.id ORDER BY i.position),
+	i.status,
+	NULLIF(i.grade, 0),
+	NULLIF(i.completed_at, '')::date,
+	NULLIF(i.custom_title, ''),
+	CASE WHEN i.custom_title <> '' THEN i.custom_credits END,
+	NULLIF(i.custom_semester, 0),
+	NULLIF(i.mapped_code, '')
+FROM UNNEST($2::int[], $3::int[], $4::text[], $5::int[], $6::text[], $7::int[], $8::text[], $9::text[], $10::int[], $11::int[], $12::text[])
+	AS i(academic_year, semester, code, position, status, grade, completed_at, custom_title, custom_credits, custom_semester, mapped_code)
+INNER JOIN blueprint_years y
+	ON y.scenario_id = (SELECT id FROM blueprint_scenarios WHERE user_id = $1 AND active)
+	AND y.academic_year = i.academic_year
+INNER JOIN blueprint_semesters bs
+	ON y.id = bs.blueprint_year_id
+	AND bs.semester = i.semester
+LEFT JOIN LATERAL (
+	SELECT code, valid_from FROM courses WHERE code = i.code ORDER BY valid_from DESC LIMIT 1
+) c ON TRUE
+WHERE c.code IS NOT NULL OR i.custom_title <> ''
+ON CONFLICT (blueprint_semester_id, course_code) DO NOTHING;
+`
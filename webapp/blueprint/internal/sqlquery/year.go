@@ -15,11 +15,12 @@ const InsertYear = `--sql
 WITH max_year AS (
 	SELECT MAX(academic_year) AS max_academic_year
 	FROM blueprint_years b
-	WHERE b.user_id = $1
+	WHERE b.scenario_id = (SELECT id FROM blueprint_scenarios WHERE user_id = $1 AND active)
 )
-INSERT INTO blueprint_years (user_id, academic_year)
+INSERT INTO blueprint_years (user_id, scenario_id, academic_year)
 VALUES (
 	$1,
+	(SELECT id FROM blueprint_scenarios WHERE user_id = $1 AND active),
 	(SELECT max_academic_year + 1 FROM max_year)
 )
 RETURNING id;
@@ -28,11 +29,11 @@ RETURNING id;
 const DeleteLastYear = `--sql
 WITH max_academic_year AS (
 	SELECT MAX(academic_year) AS academic_year FROM blueprint_years b
-	WHERE b.user_id = $1
+	WHERE b.scenario_id = (SELECT id FROM blueprint_scenarios WHERE user_id = $1 AND active)
 )
 DELETE FROM blueprint_years b
 USING max_academic_year m
-WHERE b.user_id = $1
+WHERE b.scenario_id = (SELECT id FROM blueprint_scenarios WHERE user_id = $1 AND active)
 	AND m.academic_year != 0
 	AND b.academic_year = m.academic_year;
 `
@@ -40,14 +41,14 @@ WHERE b.user_id = $1
 const UnassignLastYear = `--sql
 WITH max_academic_year AS (
 	SELECT MAX(academic_year) AS academic_year FROM blueprint_years b
-	WHERE b.user_id = $1
+	WHERE b.scenario_id = (SELECT id FROM blueprint_scenarios WHERE user_id = $1 AND active)
 ),
 origin AS (
 	SELECT bs.id, bs.semester
 	FROM blueprint_years y
 	LEFT JOIN blueprint_semesters bs
 		ON y.id = bs.blueprint_year_id
-	WHERE y.user_id = $1
+	WHERE y.scenario_id = (SELECT id FROM blueprint_scenarios WHERE user_id = $1 AND active)
 		AND y.academic_year = (SELECT academic_year FROM max_academic_year)
 ),
 unassigned AS (
@@ -57,7 +58,7 @@ unassigned AS (
 		ON y.id = bs.blueprint_year_id
 	LEFT JOIN blueprint_courses bc
 		ON bs.id = bc.blueprint_semester_id
-	WHERE y.user_id = $1
+	WHERE y.scenario_id = (SELECT id FROM blueprint_scenarios WHERE user_id = $1 AND active)
 		AND y.academic_year = 0
 		AND bs.semester = 0
 	ORDER BY bc.position DESC
@@ -4,8 +4,13 @@ import (
 	"database/sql"
 	"fmt"
 	"iter"
+	"slices"
 	"strings"
+	"time"
 	"unicode/utf8"
+
+	"github.com/michalhercik/RecSIS/academicyear"
+	"github.com/michalhercik/RecSIS/errorx"
 )
 
 //================================================================================
@@ -42,19 +47,183 @@ const (
 	foldedParam   string = "folded"
 )
 
+const (
+	scenarioIDParam     string = "id"
+	scenarioNameParam   string = "name"
+	scenarioSourceParam string = "from"
+	maxScenarioNameLen  int    = 100
+)
+
+const historyIDParam string = "id"
+
+const (
+	statusParam      string = "status"
+	gradeParam       string = "grade"
+	completedAtParam string = "completed"
+	dateLayout       string = "2006-01-02"
+)
+
+const (
+	scheduleSourceParam string = "source"
+	creditCapParam      string = "cap"
+	scheduleYearsParam  string = "years"
+	placementParam      string = "placement"
+)
+
+const (
+	fixActionParam   string = "fix"
+	fixCourseParam   string = "course"
+	fixCodeParam     string = "code"
+	maxFixCodeLength int    = 20
+)
+
+const (
+	formatParam     string = "format"
+	importModeParam string = "mode"
+	importFileParam string = "file"
+)
+
 const (
 	checkboxName string = "selected"
 )
 
 const recordID string = "id"
 
+const startYearParam string = "start"
+
+// versionHeader carries the version of the blueprint the page was rendered
+// from in every HTMX request (see hx-headers of the blueprint page).
+const versionHeader string = "X-Blueprint-Version"
+
+// taught_state of courses which are currently taught
+const taughtStateTaught = "V"
+
 //================================================================================
 // Data Types and Methods
 //================================================================================
 
 type blueprintPage struct {
+	scenarios  []scenario
+	history    []historyEntry
 	unassigned semester
 	years      assignedYears
+	// startYear is the calendar year the user started studying in, 0 if
+	// unknown (see academicyear package)
+	startYear int
+	// stale is set when a change was rejected because the page was rendered
+	// from an older version, staleChanges are the changes made since then
+	stale        bool
+	staleChanges []historyEntry
+}
+
+type scenario struct {
+	id      int
+	name    string
+	active  bool
+	version int64
+}
+
+// clone returns a copy which can be modified without affecting the original.
+// Course details (teachers, requisites) are shared as they are never modified.
+func (bp *blueprintPage) clone() *blueprintPage {
+	result := &blueprintPage{
+		scenarios:  slices.Clone(bp.scenarios),
+		history:    slices.Clone(bp.history),
+		unassigned: bp.unassigned.clone(),
+		years:      make(assignedYears, len(bp.years)),
+		startYear:  bp.startYear,
+	}
+	for i, year := range bp.years {
+		result.years[i] = academicYear{
+			position: year.position,
+			winter:   year.winter.clone(),
+			summer:   year.summer.clone(),
+		}
+	}
+	return result
+}
+
+func (bp *blueprintPage) canUndo() bool {
+	for _, h := range bp.history {
+		if !h.undone {
+			return true
+		}
+	}
+	return false
+}
+
+func (bp *blueprintPage) canRedo() bool {
+	for _, h := range bp.history {
+		if h.undone {
+			return true
+		}
+	}
+	return false
+}
+
+// startYearOptions returns the start years the user can choose from, the
+// latest first.
+func (bp *blueprintPage) startYearOptions() []int {
+	var years []int
+	for y := academicyear.MaxStartYear(time.Now()); y >= academicyear.MinStartYear; y-- {
+		years = append(years, y)
+	}
+	return years
+}
+
+// version identifies the current state of the active scenario. It changes
+// with every change recorded in the history.
+func (bp *blueprintPage) version() int64 {
+	return bp.activeScenario().version
+}
+
+// markStale marks the blueprint as newer than the version a rejected change
+// was made against and collects the changes made since that version.
+func (bp *blueprintPage) markStale(version int64) {
+	bp.stale = true
+	bp.staleChanges = nil
+	for _, h := range bp.history {
+		if h.version > version {
+			bp.staleChanges = append(bp.staleChanges, h)
+		}
+	}
+}
+
+func (bp *blueprintPage) activeScenario() scenario {
+	for _, s := range bp.scenarios {
+		if s.active {
+			return s
+		}
+	}
+	return scenario{}
+}
+
+// readOnlyView removes data of the blueprint not meant for visitors of a
+// shared link and unfolds all semesters since visitors cannot unfold them.
+func (bp *blueprintPage) readOnlyView() {
+	bp.scenarios = []scenario{bp.activeScenario()}
+	bp.history = nil
+	bp.unassigned.folded = false
+	for i := range bp.years {
+		bp.years[i].winter.folded = false
+		bp.years[i].summer.folded = false
+	}
+}
+
+func (bp *blueprintPage) completedCredits() int {
+	total := bp.unassigned.completedCredits()
+	for _, year := range bp.years {
+		total += year.completedCredits()
+	}
+	return total
+}
+
+func (bp *blueprintPage) grades() gradeSum {
+	sum := bp.unassigned.grades()
+	for _, year := range bp.years {
+		sum = sum.add(year.grades())
+	}
+	return sum
 }
 
 func (bp *blueprintPage) totalCredits() int {
@@ -118,11 +287,30 @@ func (ay academicYear) credits() int {
 	return ay.winter.credits() + ay.summer.credits()
 }
 
+func (ay academicYear) completedCredits() int {
+	return ay.winter.completedCredits() + ay.summer.completedCredits()
+}
+
+func (ay academicYear) grades() gradeSum {
+	return ay.winter.grades().add(ay.summer.grades())
+}
+
 type semester struct {
 	courses []course
 	folded  bool
 }
 
+func (s semester) clone() semester {
+	result := semester{
+		folded:  s.folded,
+		courses: slices.Clone(s.courses),
+	}
+	for i := range result.courses {
+		result.courses[i].diagnostics = slices.Clone(result.courses[i].diagnostics)
+	}
+	return result
+}
+
 func (s semester) credits() int {
 	sum := 0
 	for _, course := range s.courses {
@@ -131,6 +319,100 @@ func (s semester) credits() int {
 	return sum
 }
 
+func (s semester) completedCredits() int {
+	sum := 0
+	for _, course := range s.courses {
+		if course.status == statusPassed {
+			sum += course.credits
+		}
+	}
+	return sum
+}
+
+func (s semester) grades() gradeSum {
+	var sum gradeSum
+	for _, course := range s.courses {
+		if course.grade.Valid && (course.status == statusPassed || course.status == statusFailed) {
+			sum.weighted += int(course.grade.Int64) * course.credits
+			sum.credits += course.credits
+		}
+	}
+	return sum
+}
+
+// gradeSum accumulates grades weighted by credits of graded courses.
+type gradeSum struct {
+	weighted int
+	credits  int
+}
+
+func (g gradeSum) add(other gradeSum) gradeSum {
+	return gradeSum{g.weighted + other.weighted, g.credits + other.credits}
+}
+
+func (g gradeSum) average() (float64, bool) {
+	if g.credits == 0 {
+		return 0, false
+	}
+	return float64(g.weighted) / float64(g.credits), true
+}
+
+func (g gradeSum) string() string {
+	avg, ok := g.average()
+	if !ok {
+		return "---"
+	}
+	return fmt.Sprintf("%.2f", avg)
+}
+
+type courseStatus string
+
+const (
+	statusPlanned  courseStatus = "planned"
+	statusEnrolled courseStatus = "enrolled"
+	statusPassed   courseStatus = "passed"
+	statusFailed   courseStatus = "failed"
+)
+
+var courseStatuses = []courseStatus{statusPlanned, statusEnrolled, statusPassed, statusFailed}
+
+func (cs courseStatus) isValid() bool {
+	return slices.Contains(courseStatuses, cs)
+}
+
+func (cs courseStatus) isFinished() bool {
+	return cs == statusPassed || cs == statusFailed
+}
+
+func (cs courseStatus) string(t text) string {
+	switch cs {
+	case statusEnrolled:
+		return t.statusEnrolled
+	case statusPassed:
+		return t.statusPassed
+	case statusFailed:
+		return t.statusFailed
+	}
+	return t.statusPlanned
+}
+
+func (cs courseStatus) badgeClass() string {
+	switch cs {
+	case statusEnrolled:
+		return "text-bg-primary"
+	case statusPassed:
+		return "text-bg-success"
+	case statusFailed:
+		return "text-bg-danger"
+	}
+	return "text-bg-light"
+}
+
+const (
+	minGrade = 1
+	maxGrade = 4
+)
+
 type course struct {
 	id                 int
 	code               string
@@ -140,17 +422,53 @@ type course struct {
 	seminarRangeWinter sql.NullInt64
 	lectureRangeSummer sql.NullInt64
 	seminarRangeSummer sql.NullInt64
+	rangeUnit          string
 	examType           string
 	credits            int
+	status             courseStatus
+	grade              sql.NullInt64
+	completedAt        sql.NullTime
 	guarantors         teacherSlice
 	prerequisites      requisiteSlice
 	corequisites       requisiteSlice
 	incompatibles      requisiteSlice
-	warnings           []string
+	validFrom          int
+	validTo            int
+	taughtState        string
+	taughtStateTitle   string
+	diagnostics        []diagnostic
+	// custom courses are defined by the user and have no code, they may be
+	// mapped to a SIS course and its interchangeable courses (substitutes)
+	custom      bool
+	mappedCode  string
+	substitutes []string
+}
+
+// planCodes returns codes of SIS courses the course counts as in degree plan
+// blocs. Custom courses count only if they are mapped.
+func (c *course) planCodes() []string {
+	if !c.custom {
+		return []string{c.code}
+	}
+	if c.mappedCode == "" {
+		return nil
+	}
+	return append([]string{c.mappedCode}, c.substitutes...)
+}
+
+// rowKey identifies the course among courses of a semester.
+func (c *course) rowKey() string {
+	if c.custom {
+		return fmt.Sprintf("custom-%d", c.id)
+	}
+	return c.code
 }
 
 func (c *course) winterString() string {
 	winterText := ""
+	if c.custom {
+		return c.customSemesterString(teachingWinterOnly)
+	}
 	if c.semester == teachingWinterOnly || c.semester == teachingBoth {
 		winterText = fmt.Sprintf("%d/%d, %s", c.lectureRangeWinter.Int64, c.seminarRangeWinter.Int64, c.examType)
 	} else {
@@ -161,6 +479,9 @@ func (c *course) winterString() string {
 
 func (c *course) summerString() string {
 	summerText := ""
+	if c.custom {
+		return c.customSemesterString(teachingSummerOnly)
+	}
 	switch c.semester {
 	case teachingSummerOnly:
 		summerText = fmt.Sprintf("%d/%d, %s", c.lectureRangeSummer.Int64, c.seminarRangeSummer.Int64, c.examType)
@@ -172,6 +493,37 @@ func (c *course) summerString() string {
 	return summerText
 }
 
+// customSemesterString marks whether a custom course is taught in the
+// semester, custom courses have no hours nor examination.
+func (c *course) customSemesterString(semester teachingSemester) string {
+	if c.semester == semester || c.semester == teachingBoth {
+		return "✓"
+	}
+	return "---"
+}
+
+func (c *course) warningsString() string {
+	messages := make([]string, len(c.diagnostics))
+	for i, d := range c.diagnostics {
+		messages[i] = d.message
+	}
+	return strings.Join(messages, " ")
+}
+
+// fixes returns fixes proposed by all diagnostics of the course without
+// duplicates.
+func (c *course) fixes() []fix {
+	var result []fix
+	for _, d := range c.diagnostics {
+		for _, f := range d.fixes {
+			if !slices.Contains(result, f) {
+				result = append(result, f)
+			}
+		}
+	}
+	return result
+}
+
 func (c *course) hoursString() string {
 	result := ""
 	winter := c.lectureRangeWinter.Valid && c.seminarRangeWinter.Valid
@@ -284,6 +636,97 @@ const (
 	assignmentSummer
 )
 
+// diagnosticKind identifies the problem reported for a course.
+type diagnosticKind string
+
+const (
+	diagnosticWrongSemester   diagnosticKind = "wrong-semester"
+	diagnosticAlreadyAssigned diagnosticKind = "already-assigned"
+	diagnosticDuplicate       diagnosticKind = "duplicate"
+	diagnosticPrerequisite    diagnosticKind = "prerequisite"
+	diagnosticCorequisite     diagnosticKind = "corequisite"
+	diagnosticIncompatible    diagnosticKind = "incompatible"
+	diagnosticValidity        diagnosticKind = "validity"
+	diagnosticNotTaught       diagnosticKind = "not-taught"
+)
+
+// diagnostic is a problem of a course in the blueprint together with the
+// codes of all courses involved and the fixes proposed to resolve it.
+type diagnostic struct {
+	kind    diagnosticKind
+	message string
+	courses []string
+	fixes   []fix
+}
+
+type fixAction string
+
+const (
+	fixMove   fixAction = "move"
+	fixAdd    fixAction = "add"
+	fixRemove fixAction = "remove"
+)
+
+func (fa fixAction) isValid() bool {
+	return fa == fixMove || fa == fixAdd || fa == fixRemove
+}
+
+// fix is a single change of the blueprint proposed by a diagnostic. Moved
+// and removed courses are identified by their record ID, added courses by
+// their code. Year zero with assignmentNone stands for unassigned courses.
+type fix struct {
+	action   fixAction
+	courseID int
+	code     string
+	year     int
+	semester semesterAssignment
+}
+
+func (f fix) vals() string {
+	return fmt.Sprintf(`{"%s": "%s", "%s": %d, "%s": "%s", %s, %s}`,
+		fixActionParam, f.action, fixCourseParam, f.courseID, fixCodeParam, f.code, vYear(f.year), vSem(int(f.semester)))
+}
+
+// historyAction identifies the change recorded in the blueprint history.
+// Values are stored in the database and are shared with other packages
+// that modify the blueprint (course pages, degree plan).
+type historyAction string
+
+const (
+	actionMoveCourses      historyAction = "move-courses"
+	actionUnassignSemester historyAction = "unassign-semester"
+	actionRemoveCourses    historyAction = "remove-courses"
+	actionAddYear          historyAction = "add-year"
+	actionRemoveYear       historyAction = "remove-year"
+	actionRestore          historyAction = "restore"
+	actionImport           historyAction = "import"
+	actionSetStatus        historyAction = "set-status"
+	actionAutoSchedule     historyAction = "auto-schedule"
+	actionAddCourses       historyAction = "add-courses"
+	actionMergeDegreePlan  historyAction = "merge-degree-plan"
+	actionRewriteBlueprint historyAction = "rewrite-blueprint"
+	actionApplyFix         historyAction = "apply-fix"
+	actionApplyPlanDiff    historyAction = "apply-plan-diff"
+	actionAddCustomCourse  historyAction = "add-custom-course"
+	actionEditCustomCourse historyAction = "edit-custom-course"
+)
+
+type historyEntry struct {
+	id     int
+	action historyAction
+	undone bool
+	// version of the scenario right after the change, 0 if unknown
+	version   int64
+	createdAt time.Time
+}
+
+// versionConflictError is returned by changes made against an older version
+// of the blueprint than the current one.
+type versionConflictError struct {
+	errorx.HTTPError
+	version int64
+}
+
 //================================================================================
 // Helper Functions
 //================================================================================
@@ -299,5 +742,14 @@ func vSem(semester int) string       { return fmt.Sprintf(`"%s": %d`, semesterPa
 func vPos() string                   { return fmt.Sprintf(`"%s": %d`, positionParam, lastPosition) }
 func vUnassign(unassign bool) string { return fmt.Sprintf(`"%s": %t`, unassignParam, unassign) }
 func vFolded(folded bool) string     { return fmt.Sprintf(`"%s": %t`, foldedParam, folded) }
+func vDryRun() string                { return fmt.Sprintf(`"%s": true`, dryRunParam) }
 
 func mergeVals(vals ...string) string { return strings.Join(vals, ", ") }
+
+// withDryRun adds dryRunParam to vals, so the change is previewed first.
+func withDryRun(vals string) string {
+	if vals == "" {
+		return vDryRun()
+	}
+	return mergeVals(vals, vDryRun())
+}
@@ -0,0 +1,87 @@
+package blueprint
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/michalhercik/RecSIS/pdf"
+)
+
+//================================================================================
+// Constants
+//================================================================================
+
+const formatPDF string = "pdf"
+
+//================================================================================
+// PDF
+//================================================================================
+
+// exportPDF writes a printable overview of the blueprint: every year with its
+// semesters, courses and their warnings followed by unassigned courses. The
+// texts t must be labeled with the start year of the blueprint and warnings
+// must already be generated.
+func exportPDF(w io.Writer, bp *blueprintPage, t text) error {
+	doc := pdf.New(t.pageTitle)
+	doc.Title(t.pageTitle)
+	if name := bp.activeScenario().name; name != "" {
+		doc.Text(fmt.Sprintf(t.pdfScenario, name))
+	}
+	doc.Note(fmt.Sprintf(t.pdfCreated, time.Now().Format(time.DateOnly)))
+	doc.Space(4)
+	doc.Text(fmt.Sprintf(t.pdfSummary, bp.years.assignedCredits(), bp.completedCredits(), bp.grades().string()))
+	for _, ay := range bp.years {
+		doc.Heading(fmt.Sprintf("%s – %d %s", t.yearStr(ay.position), ay.credits(), t.creditsShort))
+		printSemester(doc, ay.winter, t.winterLong, t, func(c *course) string { return c.winterString() })
+		printSemester(doc, ay.summer, t.summerLong, t, func(c *course) string { return c.summerString() })
+	}
+	if len(bp.unassigned.courses) > 0 {
+		doc.Heading(t.unassigned)
+		printSemester(doc, bp.unassigned, "", t, func(c *course) string {
+			return c.hoursString() + ", " + c.examType
+		})
+	}
+	_, err := doc.WriteTo(w)
+	return err
+}
+
+// printSemester writes a table of courses of the semester with their
+// warnings. Empty semesters are written as a single note.
+func printSemester(doc *pdf.Document, s semester, name string, t text, hours func(c *course) string) {
+	if len(s.courses) == 0 {
+		doc.Note(name + ": " + t.emptySemester)
+		doc.Space(6)
+		return
+	}
+	firstColumn := t.code
+	if name != "" {
+		firstColumn = name
+	}
+	table := doc.Table(
+		pdf.Column{Title: firstColumn, Width: 1.3},
+		pdf.Column{Title: t.title, Width: 4},
+		pdf.Column{Title: t.creditsShort, Width: 0.6, Align: pdf.AlignRight},
+		pdf.Column{Title: t.pdfHours, Width: 1.5},
+		pdf.Column{Title: t.status, Width: 1.2},
+		pdf.Column{Title: t.grade, Width: 0.8, Align: pdf.AlignRight},
+	)
+	for i := range s.courses {
+		c := &s.courses[i]
+		code := c.code
+		if c.custom {
+			code = t.customCourse
+		}
+		grade := ""
+		if c.grade.Valid {
+			grade = strconv.FormatInt(c.grade.Int64, 10)
+		}
+		table.Row(code, c.title, strconv.Itoa(c.credits), hours(c), c.status.string(t), grade)
+		for _, d := range c.diagnostics {
+			table.Note(t.pdfWarning + d.message)
+		}
+	}
+	table.SumRow(t.total, "", strconv.Itoa(s.credits()), "", "", s.grades().string())
+	table.End()
+}
@@ -0,0 +1,427 @@
+package blueprint
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+//================================================================================
+// Constants
+//================================================================================
+
+const (
+	scheduleUnassigned string = "unassigned"
+	scheduleDegreePlan string = "degree-plan"
+)
+
+const (
+	defaultCreditCap = 30
+	maxCreditCap     = 60
+	maxScheduleYears = 10
+)
+
+//================================================================================
+// Data Types and Methods
+//================================================================================
+
+type scheduleOptions struct {
+	source    string
+	creditCap int
+	years     int
+}
+
+// scheduleCandidate is a course the scheduler tries to place. New candidates
+// come from the degree plan and are not in the blueprint yet.
+type scheduleCandidate struct {
+	course
+	isNew bool
+}
+
+type schedulePlacement struct {
+	candidate scheduleCandidate
+	year      int
+	semester  semesterAssignment
+}
+
+// scheduleProblem explains why a candidate could not be placed.
+type scheduleProblem struct {
+	code   string
+	title  string
+	reason string
+}
+
+// schedulePreview is the proposed change shown to the user before it is
+// applied. Placements are ordered by year and semester.
+type schedulePreview struct {
+	options    scheduleOptions
+	placements []schedulePlacement
+	problems   []scheduleProblem
+}
+
+func (sp *schedulePreview) isEmpty() bool {
+	return len(sp.placements) == 0 && len(sp.problems) == 0
+}
+
+// semesters groups placements by their target semester.
+func (sp *schedulePreview) semesters() [][]schedulePlacement {
+	var result [][]schedulePlacement
+	for i, p := range sp.placements {
+		if i == 0 || p.year != sp.placements[i-1].year || p.semester != sp.placements[i-1].semester {
+			result = append(result, nil)
+		}
+		result[len(result)-1] = append(result[len(result)-1], p)
+	}
+	return result
+}
+
+func (sp *schedulePreview) credits() int {
+	credits := 0
+	for _, p := range sp.placements {
+		credits += p.candidate.credits
+	}
+	return credits
+}
+
+func (p schedulePlacement) value() string {
+	return fmt.Sprintf("%s:%d:%d", p.candidate.code, p.year, p.semester)
+}
+
+//================================================================================
+// Candidates
+//================================================================================
+
+// scheduleCandidates returns courses to be scheduled. Courses already
+// assigned to a semester are never moved. Degree plan courses which are
+// unassigned in the blueprint are moved instead of added again. Custom
+// courses are never scheduled.
+func scheduleCandidates(bp *blueprintPage, source string, planCourses []course) []scheduleCandidate {
+	assigned := buildAssignedCourseMap(bp)
+	var result []scheduleCandidate
+	seen := make(map[string]bool)
+	addCandidate := func(c course, isNew bool) {
+		if _, ok := assigned[c.code]; ok || seen[c.code] || c.custom {
+			return
+		}
+		seen[c.code] = true
+		result = append(result, scheduleCandidate{course: c, isNew: isNew})
+	}
+	switch source {
+	case scheduleUnassigned:
+		for c := range bp.unassignedCourses() {
+			addCandidate(*c, false)
+		}
+	case scheduleDegreePlan:
+		for _, c := range planCourses {
+			isNew := !slices.ContainsFunc(bp.unassigned.courses, func(u course) bool { return u.code == c.code })
+			addCandidate(c, isNew)
+		}
+	}
+	return result
+}
+
+//================================================================================
+// Scheduling
+//================================================================================
+
+// autoSchedule places candidates into the first semester where the teaching
+// semester matches, all prerequisites are placed in an earlier semester, all
+// corequisites are placed in the same or an earlier semester and the credit
+// cap is not exceeded. Courses requiring each other as corequisites are placed
+// into the same semester. Courses with longer chains of dependent courses are
+// placed first. Courses which cannot be placed are explained.
+func autoSchedule(bp *blueprintPage, candidates []scheduleCandidate, opts scheduleOptions, t text) schedulePreview {
+	preview := schedulePreview{options: opts}
+	courseMap := buildAssignedCourseMap(bp)
+	load := make([]int, 2*opts.years)
+	for _, year := range bp.years {
+		if year.position <= opts.years {
+			load[2*(year.position-1)] = year.winter.credits()
+			load[2*(year.position-1)+1] = year.summer.credits()
+		}
+	}
+
+	remaining := slices.Clone(candidates)
+	ranks := dependentChainLengths(candidates)
+	slices.SortStableFunc(remaining, func(a, b scheduleCandidate) int {
+		return cmp.Compare(ranks[b.code], ranks[a.code])
+	})
+
+	for i := range load {
+		if len(remaining) == 0 {
+			break
+		}
+		loc := courseLocation{
+			year:     i/2 + 1,
+			semester: assignmentWinter + semesterAssignment(i%2),
+		}
+		// Repeat until nothing changes so that corequisites placed later in
+		// the same pass can unlock courses earlier in the list.
+		for placed := true; placed; {
+			placed = false
+			for j := 0; j < len(remaining); j++ {
+				group := []int{j}
+				if !canPlace(remaining, group, loc, load[i], opts.creditCap, courseMap) {
+					// Courses which are corequisites of each other can only be
+					// placed together.
+					group = corequisiteGroup(remaining, j)
+					if len(group) == 1 || !canPlace(remaining, group, loc, load[i], opts.creditCap, courseMap) {
+						continue
+					}
+				}
+				for _, k := range group {
+					c := remaining[k]
+					preview.placements = append(preview.placements, schedulePlacement{c, loc.year, loc.semester})
+					courseMap[c.code] = append(courseMap[c.code], loc)
+					load[i] += c.credits
+				}
+				for _, k := range slices.Backward(group) {
+					remaining = slices.Delete(remaining, k, k+1)
+				}
+				placed = true
+				if len(group) > 1 {
+					// indices before j may have shifted, start over
+					break
+				}
+				j--
+			}
+		}
+	}
+
+	preview.problems = explainUnscheduled(bp, candidates, remaining, opts, t)
+	return preview
+}
+
+// canPlace reports whether the group of remaining candidates (given by
+// sorted indices) fits into the semester at loc together.
+func canPlace(remaining []scheduleCandidate, group []int, loc courseLocation, load, creditCap int, courseMap map[string][]courseLocation) bool {
+	for _, k := range group {
+		c := remaining[k]
+		load += c.credits
+		if !isTaughtIn(c.semester, loc.semester) || load > creditCap {
+			return false
+		}
+	}
+	for _, k := range group {
+		code := remaining[k].code
+		courseMap[code] = append(courseMap[code], loc)
+	}
+	ok := true
+	for _, k := range group {
+		c := remaining[k]
+		if !areAllRequisitesMet(c.prerequisites, loc, prerequisiteCondition, courseMap) ||
+			!areAllRequisitesMet(c.corequisites, loc, corequisiteCondition, courseMap) {
+			ok = false
+			break
+		}
+	}
+	for _, k := range group {
+		code := remaining[k].code
+		if courseMap[code] = courseMap[code][:len(courseMap[code])-1]; len(courseMap[code]) == 0 {
+			delete(courseMap, code)
+		}
+	}
+	return ok
+}
+
+// corequisiteGroup returns sorted indices of the remaining candidate j and all
+// remaining candidates it transitively requires as corequisites.
+func corequisiteGroup(remaining []scheduleCandidate, j int) []int {
+	index := make(map[string]int, len(remaining))
+	for k, c := range remaining {
+		index[c.code] = k
+	}
+	group := []int{j}
+	for n := 0; n < len(group); n++ {
+		for _, code := range requisiteCodes(remaining[group[n]].corequisites) {
+			if k, ok := index[code]; ok && !slices.Contains(group, k) {
+				group = append(group, k)
+			}
+		}
+	}
+	slices.Sort(group)
+	return group
+}
+
+func isTaughtIn(taught teachingSemester, semester semesterAssignment) bool {
+	switch taught {
+	case teachingWinterOnly:
+		return semester == assignmentWinter
+	case teachingSummerOnly:
+		return semester == assignmentSummer
+	default:
+		return true
+	}
+}
+
+// requisiteCodes returns codes of all courses mentioned in requisite trees.
+func requisiteCodes(rss ...requisiteSlice) []string {
+	var codes []string
+	for _, rs := range rss {
+		for _, r := range rs {
+			if r.isNode() {
+				if !slices.Contains(codes, r.courseCode) {
+					codes = append(codes, r.courseCode)
+				}
+				continue
+			}
+			for _, code := range requisiteCodes(r.children) {
+				if !slices.Contains(codes, code) {
+					codes = append(codes, code)
+				}
+			}
+		}
+	}
+	return codes
+}
+
+// dependentChainLengths returns for every candidate the length of the longest
+// chain of other candidates depending on it through requisites.
+func dependentChainLengths(candidates []scheduleCandidate) map[string]int {
+	dependents := make(map[string][]string)
+	for _, c := range candidates {
+		for _, code := range requisiteCodes(c.prerequisites, c.corequisites) {
+			dependents[code] = append(dependents[code], c.code)
+		}
+	}
+	ranks := make(map[string]int)
+	visiting := make(map[string]bool)
+	var rank func(code string) int
+	rank = func(code string) int {
+		if r, ok := ranks[code]; ok {
+			return r
+		}
+		if visiting[code] {
+			// requisite cycle, explained later
+			return 0
+		}
+		visiting[code] = true
+		longest := 0
+		for _, dependent := range dependents[code] {
+			longest = max(longest, rank(dependent)+1)
+		}
+		visiting[code] = false
+		ranks[code] = longest
+		return longest
+	}
+	for _, c := range candidates {
+		rank(c.code)
+	}
+	return ranks
+}
+
+//================================================================================
+// Explanations
+//================================================================================
+
+func explainUnscheduled(bp *blueprintPage, candidates, remaining []scheduleCandidate, opts scheduleOptions, t text) []scheduleProblem {
+	if len(remaining) == 0 {
+		return nil
+	}
+	// Pretend every assigned or candidate course is placed before all
+	// semesters to find requisites that cannot be satisfied at all.
+	known := buildAssignedCourseMap(bp)
+	for _, c := range candidates {
+		known[c.code] = append(known[c.code], courseLocation{year: 0, semester: assignmentNone})
+	}
+	isRemaining := make(map[string]bool)
+	for _, c := range remaining {
+		isRemaining[c.code] = true
+	}
+	cycles := requisiteCycles(remaining)
+
+	problems := make([]scheduleProblem, 0, len(remaining))
+	for _, c := range remaining {
+		problem := scheduleProblem{code: c.code, title: c.title}
+		latest := courseLocation{year: max(opts.years, len(bp.years)) + 1, semester: assignmentSummer, course: &c.course}
+		switch {
+		case c.credits > opts.creditCap:
+			problem.reason = fmt.Sprintf(t.sCreditsOverCap, c.credits, opts.creditCap)
+		case !areAllRequisitesMet(c.prerequisites, latest, prerequisiteCondition, known) ||
+			!areAllRequisitesMet(c.corequisites, latest, corequisiteCondition, known):
+			var missing []string
+			for _, code := range requisiteCodes(c.prerequisites, c.corequisites) {
+				if _, ok := known[code]; !ok {
+					missing = append(missing, code)
+				}
+			}
+			problem.reason = fmt.Sprintf(t.sMissingRequisites, strings.Join(missing, ", "))
+		case len(cycles[c.code]) > 0:
+			problem.reason = fmt.Sprintf(t.sRequisiteCycle, strings.Join(cycles[c.code], " → "))
+		default:
+			var blocking []string
+			for _, code := range requisiteCodes(c.prerequisites, c.corequisites) {
+				if isRemaining[code] {
+					blocking = append(blocking, code)
+				}
+			}
+			if len(blocking) > 0 {
+				problem.reason = fmt.Sprintf(t.sBlockedBy, strings.Join(blocking, ", "))
+			} else {
+				problem.reason = fmt.Sprintf(t.sTooFewSemesters, opts.years, opts.creditCap)
+			}
+		}
+		problems = append(problems, problem)
+	}
+	return problems
+}
+
+// requisiteCycles finds cycles among unscheduled courses which cannot be
+// satisfied. A cycle made of corequisites only is satisfiable by placing all
+// courses into the same semester, so at least one prerequisite is required.
+// Returns the cycle (as a closed path of codes) for every course on it.
+func requisiteCycles(remaining []scheduleCandidate) map[string][]string {
+	type edge struct {
+		to     string
+		strict bool
+	}
+	graph := make(map[string][]edge)
+	for _, c := range remaining {
+		for _, code := range requisiteCodes(c.prerequisites) {
+			graph[c.code] = append(graph[c.code], edge{code, true})
+		}
+		for _, code := range requisiteCodes(c.corequisites) {
+			graph[c.code] = append(graph[c.code], edge{code, false})
+		}
+	}
+
+	result := make(map[string][]string)
+	var path []string
+	var strict []bool
+	onPath := make(map[string]int)
+	done := make(map[string]bool)
+	var visit func(code string)
+	visit = func(code string) {
+		onPath[code] = len(path)
+		path = append(path, code)
+		for _, e := range graph[code] {
+			if start, ok := onPath[e.to]; ok {
+				if e.strict || slices.Contains(strict[start:], true) {
+					cycle := append(slices.Clone(path[start:]), e.to)
+					for _, member := range path[start:] {
+						if result[member] == nil {
+							result[member] = cycle
+						}
+					}
+				}
+				continue
+			}
+			if done[e.to] || graph[e.to] == nil {
+				continue
+			}
+			strict = append(strict, e.strict)
+			visit(e.to)
+			strict = strict[:len(strict)-1]
+		}
+		path = path[:len(path)-1]
+		delete(onPath, code)
+		done[code] = true
+	}
+	for _, c := range remaining {
+		if !done[c.code] {
+			visit(c.code)
+		}
+	}
+	return result
+}
@@ -0,0 +1,243 @@
+package blueprint
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"testing"
+
+	"github.com/michalhercik/RecSIS/language"
+)
+
+func testCourse(code string, credits int, taught teachingSemester) course {
+	return course{code: code, title: code, credits: credits, semester: taught}
+}
+
+func testRequisites(codes ...string) requisiteSlice {
+	result := make(requisiteSlice, len(codes))
+	for i, code := range codes {
+		result[i] = requisite{courseCode: code}
+	}
+	return result
+}
+
+func withTestPrerequisites(c course, codes ...string) course {
+	c.prerequisites = testRequisites(codes...)
+	return c
+}
+
+func withTestCorequisites(c course, codes ...string) course {
+	c.corequisites = testRequisites(codes...)
+	return c
+}
+
+func testCandidates(courses ...course) []scheduleCandidate {
+	result := make([]scheduleCandidate, len(courses))
+	for i, c := range courses {
+		result[i] = scheduleCandidate{course: c, isNew: true}
+	}
+	return result
+}
+
+func testBlueprint(years int) *blueprintPage {
+	bp := &blueprintPage{}
+	for i := 1; i <= years; i++ {
+		bp.years = append(bp.years, academicYear{position: i})
+	}
+	return bp
+}
+
+func TestAutoSchedule(t *testing.T) {
+	tt := texts[language.EN]
+	tests := []struct {
+		name           string
+		candidates     []scheduleCandidate
+		wantPlacements []string
+		wantProblems   map[string]string
+	}{
+		{
+			name: "prerequisite chain",
+			candidates: testCandidates(
+				withTestPrerequisites(testCourse("B", 5, teachingBoth), "A"),
+				testCourse("A", 5, teachingBoth),
+			),
+			wantPlacements: []string{"A:1:1", "B:1:2"},
+		},
+		{
+			name: "teaching semester",
+			candidates: testCandidates(
+				testCourse("S", 5, teachingSummerOnly),
+				testCourse("W", 5, teachingWinterOnly),
+			),
+			wantPlacements: []string{"W:1:1", "S:1:2"},
+		},
+		{
+			name: "credit cap fills next semester",
+			candidates: testCandidates(
+				testCourse("A", 20, teachingBoth),
+				testCourse("B", 20, teachingBoth),
+			),
+			wantPlacements: []string{"A:1:1", "B:1:2"},
+		},
+		{
+			name: "corequisite cycle",
+			candidates: testCandidates(
+				withTestCorequisites(testCourse("A", 5, teachingBoth), "B"),
+				withTestCorequisites(testCourse("B", 5, teachingBoth), "A"),
+			),
+			wantPlacements: []string{"A:1:1", "B:1:1"},
+		},
+		{
+			name: "prerequisite cycle",
+			candidates: testCandidates(
+				withTestPrerequisites(testCourse("A", 5, teachingBoth), "B"),
+				withTestPrerequisites(testCourse("B", 5, teachingBoth), "A"),
+			),
+			wantProblems: map[string]string{
+				"A": fmt.Sprintf(tt.sRequisiteCycle, "A → B → A"),
+				"B": fmt.Sprintf(tt.sRequisiteCycle, "A → B → A"),
+			},
+		},
+		{
+			name: "mixed cycle",
+			candidates: testCandidates(
+				withTestPrerequisites(testCourse("A", 5, teachingBoth), "B"),
+				withTestCorequisites(testCourse("B", 5, teachingBoth), "A"),
+			),
+			wantProblems: map[string]string{
+				"A": fmt.Sprintf(tt.sRequisiteCycle, "A → B → A"),
+				"B": fmt.Sprintf(tt.sRequisiteCycle, "A → B → A"),
+			},
+		},
+		{
+			name:         "over credit cap",
+			candidates:   testCandidates(testCourse("A", 40, teachingBoth)),
+			wantProblems: map[string]string{"A": fmt.Sprintf(tt.sCreditsOverCap, 40, defaultCreditCap)},
+		},
+		{
+			name: "missing requisite",
+			candidates: testCandidates(
+				withTestPrerequisites(testCourse("A", 5, teachingBoth), "X"),
+				withTestCorequisites(testCourse("B", 5, teachingBoth), "A"),
+			),
+			wantProblems: map[string]string{
+				"A": fmt.Sprintf(tt.sMissingRequisites, "X"),
+				"B": fmt.Sprintf(tt.sBlockedBy, "A"),
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			opts := scheduleOptions{creditCap: defaultCreditCap, years: 2}
+			preview := autoSchedule(testBlueprint(2), test.candidates, opts, tt)
+			var placements []string
+			for _, p := range preview.placements {
+				placements = append(placements, p.value())
+			}
+			if !slices.Equal(placements, test.wantPlacements) {
+				t.Errorf("autoSchedule() placements = %v, want %v", placements, test.wantPlacements)
+			}
+			problems := make(map[string]string)
+			for _, p := range preview.problems {
+				problems[p.code] = p.reason
+			}
+			if len(problems) == 0 {
+				problems = nil
+			}
+			if !maps.Equal(problems, test.wantProblems) {
+				t.Errorf("autoSchedule() problems = %v, want %v", problems, test.wantProblems)
+			}
+		})
+	}
+}
+
+func TestAutoScheduleKeepsAssignedLoad(t *testing.T) {
+	bp := testBlueprint(1)
+	bp.years[0].winter.courses = []course{testCourse("X", 28, teachingBoth)}
+	candidates := testCandidates(withTestPrerequisites(testCourse("A", 5, teachingBoth), "X"))
+	preview := autoSchedule(bp, candidates, scheduleOptions{creditCap: defaultCreditCap, years: 1}, texts[language.EN])
+	if len(preview.placements) != 1 || preview.placements[0].value() != "A:1:2" {
+		t.Errorf("autoSchedule() placements = %v, want A in summer", preview.placements)
+	}
+}
+
+func TestDependentChainLengths(t *testing.T) {
+	tests := []struct {
+		name       string
+		candidates []scheduleCandidate
+		want       map[string]int
+	}{
+		{
+			name: "chain",
+			candidates: testCandidates(
+				testCourse("A", 5, teachingBoth),
+				withTestPrerequisites(testCourse("B", 5, teachingBoth), "A"),
+				withTestPrerequisites(testCourse("C", 5, teachingBoth), "B"),
+				testCourse("D", 5, teachingBoth),
+			),
+			want: map[string]int{"A": 2, "B": 1, "C": 0, "D": 0},
+		},
+		{
+			name: "longest of branches",
+			candidates: testCandidates(
+				testCourse("A", 5, teachingBoth),
+				withTestPrerequisites(testCourse("B", 5, teachingBoth), "A"),
+				withTestPrerequisites(testCourse("C", 5, teachingBoth), "A", "B"),
+			),
+			want: map[string]int{"A": 2, "B": 1, "C": 0},
+		},
+		{
+			name: "cycle",
+			candidates: testCandidates(
+				withTestPrerequisites(testCourse("A", 5, teachingBoth), "B"),
+				withTestPrerequisites(testCourse("B", 5, teachingBoth), "A"),
+			),
+			want: map[string]int{"A": 2, "B": 1},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := dependentChainLengths(test.candidates)
+			for code, want := range test.want {
+				if got[code] != want {
+					t.Errorf("dependentChainLengths()[%s] = %d, want %d", code, got[code], want)
+				}
+			}
+		})
+	}
+}
+
+func TestRequisiteCycles(t *testing.T) {
+	a, b, c := testCourse("A", 5, teachingBoth), testCourse("B", 5, teachingBoth), testCourse("C", 5, teachingBoth)
+	tests := []struct {
+		name       string
+		prereqs    map[string][]string
+		coreqs     map[string][]string
+		wantCycles []string
+	}{
+		{"no cycle", map[string][]string{"B": {"A"}, "C": {"B"}}, nil, nil},
+		{"prerequisite cycle", map[string][]string{"A": {"C"}, "B": {"A"}, "C": {"B"}}, nil, []string{"A", "B", "C"}},
+		{"corequisite cycle", nil, map[string][]string{"A": {"B"}, "B": {"A"}}, nil},
+		{"mixed cycle", map[string][]string{"A": {"B"}}, map[string][]string{"B": {"A"}}, []string{"A", "B"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var remaining []course
+			for _, c := range []course{a, b, c} {
+				c.prerequisites = testRequisites(test.prereqs[c.code]...)
+				c.corequisites = testRequisites(test.coreqs[c.code]...)
+				remaining = append(remaining, c)
+			}
+			cycles := requisiteCycles(testCandidates(remaining...))
+			got := slices.Sorted(maps.Keys(cycles))
+			if !slices.Equal(got, test.wantCycles) {
+				t.Errorf("requisiteCycles() courses = %v, want %v", got, test.wantCycles)
+			}
+			for code, cycle := range cycles {
+				if cycle[0] != cycle[len(cycle)-1] || !slices.Contains(cycle, code) {
+					t.Errorf("requisiteCycles()[%s] = %v, want closed path through the course", code, cycle)
+				}
+			}
+		})
+	}
+}
@@ -1,12 +1,22 @@
 package blueprint
 
 import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
 	"fmt"
+	"io"
+	"mime"
 	"net/http"
+	"slices"
 	"strconv"
 	"strings"
+	"time"
+	"unicode/utf8"
 
 	"github.com/a-h/templ"
+	"github.com/michalhercik/RecSIS/academicyear"
 	"github.com/michalhercik/RecSIS/errorx"
 	"github.com/michalhercik/RecSIS/language"
 )
@@ -20,6 +30,7 @@ type Server struct {
 	Data   Adapter
 	Error  Error
 	Page   Page
+	Signer ShareSigner
 	router http.Handler
 }
 
@@ -60,14 +71,64 @@ func (s *Server) Init() {
 	router := http.NewServeMux()
 	router.HandleFunc("GET /{$}", s.page)
 	router.HandleFunc(fmt.Sprintf("PATCH /course/{%s}", recordID), s.courseMovement)
+	router.HandleFunc(fmt.Sprintf("PATCH /course/{%s}/status", recordID), s.courseStatusChange)
 	router.HandleFunc("PATCH /courses", s.coursesMovement)
 	router.HandleFunc(fmt.Sprintf("DELETE /course/{%s}", recordID), s.courseRemoval)
 	router.HandleFunc("DELETE /courses", s.coursesRemoval)
 	router.HandleFunc("POST /year", s.yearAddition)
 	router.HandleFunc("DELETE /year", s.yearRemoval)
 	router.HandleFunc("PATCH /fold", s.foldSemester)
+	router.HandleFunc("GET /export", s.export)
+	router.HandleFunc("POST /import", s.importBlueprint)
+	router.HandleFunc("GET /schedule", s.schedulePreview)
+	router.HandleFunc("POST /schedule", s.scheduleAcceptance)
+	router.HandleFunc("GET /workload", s.workload)
+	router.HandleFunc("POST /fix", s.fixApplication)
+	router.HandleFunc("POST /custom", s.customCourseAddition)
+	router.HandleFunc(fmt.Sprintf("PATCH /custom/{%s}", recordID), s.customCourseChange)
+	router.HandleFunc("GET /shares", s.sharesPage)
+	router.HandleFunc("POST /shares", s.shareCreation)
+	router.HandleFunc(fmt.Sprintf("DELETE /shares/{%s}", shareIDParam), s.shareRevocation)
+	router.HandleFunc("POST /undo", s.undo)
+	router.HandleFunc("POST /redo", s.redo)
+	router.HandleFunc(fmt.Sprintf("POST /history/{%s}", historyIDParam), s.historyRestoration)
+	router.HandleFunc("POST /scenario", s.scenarioCreation)
+	router.HandleFunc(fmt.Sprintf("PATCH /scenario/{%s}", scenarioIDParam), s.scenarioRename)
+	router.HandleFunc(fmt.Sprintf("DELETE /scenario/{%s}", scenarioIDParam), s.scenarioRemoval)
+	router.HandleFunc(fmt.Sprintf("PATCH /scenario/{%s}/active", scenarioIDParam), s.scenarioActivation)
+	router.HandleFunc("PATCH /start-year", s.startYearChange)
 	router.HandleFunc("/", s.pageNotFound)
-	s.router = router
+	s.router = s.withVersion(router)
+}
+
+type versionKey struct{}
+
+// withVersion stores the blueprint version sent by the page in the request
+// context (see requestVersion). Requests without the version are not checked
+// against concurrent changes.
+func (s Server) withVersion(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header := r.Header.Get(versionHeader)
+		if header == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		version, err := strconv.ParseInt(header, 10, 64)
+		if err != nil || version <= 0 {
+			lang := language.FromContext(r.Context())
+			s.Error.Log(errorx.AddContext(fmt.Errorf("invalid blueprint version %s", header)))
+			s.Error.Render(w, r, http.StatusBadRequest, texts[lang].errInvalidVersion, lang)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), versionKey{}, version)))
+	})
+}
+
+// requestVersion returns the blueprint version the request was made against
+// or 0 if unknown.
+func requestVersion(r *http.Request) int64 {
+	version, _ := r.Context().Value(versionKey{}).(int64)
+	return version
 }
 
 //================================================================================
@@ -85,6 +146,7 @@ func (s Server) page(w http.ResponseWriter, r *http.Request) {
 		s.Error.RenderPage(w, r, code, userMsg, t.pageTitle, userID, lang)
 		return
 	}
+	t = t.withStartYear(data.startYear)
 	generateWarnings(data, t)
 	main := Content(data, t)
 	page := s.Page.View(main, lang, t.pageTitle, userID)
@@ -126,62 +188,55 @@ func (s Server) courseMovement(w http.ResponseWriter, r *http.Request) {
 		s.Error.Log(errorx.AddContext(err))
 		s.Error.Render(w, r, code, userMsg, lang)
 	}
+	var change func(a Adapter) error
 	if position == lastPosition {
-		err = s.Data.appendCourses(userID, lang, year, semester, course)
+		change = func(a Adapter) error {
+			return a.appendCourses(userID, lang, requestVersion(r), year, semester, course)
+		}
 	} else if position >= 0 {
-		err = s.Data.moveCourses(userID, lang, year, semester, position, course)
+		change = func(a Adapter) error {
+			return a.moveCourses(userID, lang, requestVersion(r), year, semester, position, course)
+		}
 	} else {
 		s.Error.Log(errorx.AddContext(fmt.Errorf("invalid position %d", position)))
 		s.Error.Render(w, r, http.StatusBadRequest, t.errInvalidPositionParam, lang)
 		return
 	}
-	if err != nil {
-		code, userMsg := errorx.UnwrapError(err, lang)
-		s.Error.Log(errorx.AddContext(err))
-		s.Error.Render(w, r, code, userMsg, lang)
-		return
-	}
-	s.renderBlueprintContent(w, r, userID, lang)
+	s.applyChange(w, r, userID, lang, change)
 }
 
 func (s Server) coursesMovement(w http.ResponseWriter, r *http.Request) {
-	var err error
+	var change func(a Adapter) error
 	lang := language.FromContext(r.Context())
 	t := texts[lang]
 	userID := s.Auth.UserID(r)
 	switch r.FormValue(typeParam) {
 	case semesterUnassign:
-		err = s.unassignSemester(r, userID)
+		change = func(a Adapter) error { return unassignSemester(a, r, userID) }
 	case selectedMove:
-		err = s.moveCourses(r, userID)
+		change = func(a Adapter) error { return moveCourses(a, r, userID) }
 	default:
 		s.Error.Log(errorx.AddContext(fmt.Errorf("invalid type %s", r.FormValue(typeParam))))
 		s.Error.Render(w, r, http.StatusBadRequest, t.errInvalidMoveType, lang)
 		return
 	}
-	if err != nil {
-		code, userMsg := errorx.UnwrapError(err, lang)
-		s.Error.Log(errorx.AddContext(err))
-		s.Error.Render(w, r, code, userMsg, lang)
-		return
-	}
-	s.renderBlueprintContent(w, r, userID, lang)
+	s.applyChange(w, r, userID, lang, change)
 }
 
-func (s Server) unassignSemester(r *http.Request, userID string) error {
+func unassignSemester(a Adapter, r *http.Request, userID string) error {
 	lang := language.FromContext(r.Context())
 	year, semester, err := parseYearSemester(r)
 	if err != nil {
 		return errorx.AddContext(err)
 	}
-	err = s.Data.unassignSemester(userID, lang, year, semester)
+	err = a.unassignSemester(userID, lang, requestVersion(r), year, semester)
 	if err != nil {
 		return errorx.AddContext(err)
 	}
 	return nil
 }
 
-func (s Server) moveCourses(r *http.Request, userID string) error {
+func moveCourses(a Adapter, r *http.Request, userID string) error {
 	lang := language.FromContext(r.Context())
 	year, semester, position, err := parseYearSemesterPosition(r)
 	if err != nil {
@@ -192,9 +247,9 @@ func (s Server) moveCourses(r *http.Request, userID string) error {
 		return errorx.AddContext(err)
 	}
 	if position == lastPosition {
-		err = s.Data.appendCourses(userID, lang, year, semester, courses...)
+		err = a.appendCourses(userID, lang, requestVersion(r), year, semester, courses...)
 	} else if position > 0 {
-		err = s.Data.moveCourses(userID, lang, year, semester, position, courses...)
+		err = a.moveCourses(userID, lang, requestVersion(r), year, semester, position, courses...)
 	} else {
 		return errorx.NewHTTPErr(
 			errorx.AddContext(fmt.Errorf("invalid position %d", position)),
@@ -225,61 +280,50 @@ func (s Server) courseRemoval(w http.ResponseWriter, r *http.Request) {
 		s.Error.Render(w, r, http.StatusBadRequest, t.errInvalidCourseID, lang)
 		return
 	}
-	err = s.Data.removeCourses(userID, lang, course)
-	if err != nil {
-		code, userMsg := errorx.UnwrapError(err, lang)
-		s.Error.Log(errorx.AddContext(err))
-		s.Error.Render(w, r, code, userMsg, lang)
-		return
-	}
-	s.renderBlueprintContent(w, r, userID, lang)
+	s.applyChange(w, r, userID, lang, func(a Adapter) error {
+		return a.removeCourses(userID, lang, requestVersion(r), course)
+	})
 }
 
 func (s Server) coursesRemoval(w http.ResponseWriter, r *http.Request) {
 	lang := language.FromContext(r.Context())
 	t := texts[lang]
 	userID := s.Auth.UserID(r)
-	var err error
+	var change func(a Adapter) error
 	switch r.FormValue(typeParam) {
 	case semesterRemove:
-		err = s.removeCoursesBySemester(r, userID)
+		change = func(a Adapter) error { return removeCoursesBySemester(a, r, userID) }
 	case selectedRemove:
-		err = s.removeCourses(r, userID)
+		change = func(a Adapter) error { return removeCourses(a, r, userID) }
 	default:
 		s.Error.Log(errorx.AddContext(fmt.Errorf("invalid type %s", r.FormValue(typeParam))))
 		s.Error.Render(w, r, http.StatusBadRequest, t.errInvalidRemoveType, lang)
 		return
 	}
-	if err != nil {
-		code, userMsg := errorx.UnwrapError(err, lang)
-		s.Error.Log(errorx.AddContext(err))
-		s.Error.Render(w, r, code, userMsg, lang)
-		return
-	}
-	s.renderBlueprintContent(w, r, userID, lang)
+	s.applyChange(w, r, userID, lang, change)
 }
 
-func (s Server) removeCoursesBySemester(r *http.Request, userID string) error {
+func removeCoursesBySemester(a Adapter, r *http.Request, userID string) error {
 	lang := language.FromContext(r.Context())
 	year, semester, err := parseYearSemester(r)
 	if err != nil {
 		return errorx.AddContext(err)
 	}
-	err = s.Data.removeCoursesBySemester(userID, lang, year, semester)
+	err = a.removeCoursesBySemester(userID, lang, requestVersion(r), year, semester)
 	if err != nil {
 		return errorx.AddContext(err)
 	}
 	return nil
 }
 
-func (s Server) removeCourses(r *http.Request, userID string) error {
+func removeCourses(a Adapter, r *http.Request, userID string) error {
 	lang := language.FromContext(r.Context())
 	r.ParseForm()
 	courses, err := atoiSliceCourses(r.Form[checkboxName], lang)
 	if err != nil {
 		return errorx.AddContext(err)
 	}
-	err = s.Data.removeCourses(userID, lang, courses...)
+	err = a.removeCourses(userID, lang, requestVersion(r), courses...)
 	if err != nil {
 		return errorx.AddContext(err)
 	}
@@ -293,10 +337,8 @@ func (s Server) removeCourses(r *http.Request, userID string) error {
 func (s Server) yearAddition(w http.ResponseWriter, r *http.Request) {
 	lang := language.FromContext(r.Context())
 	userID := s.Auth.UserID(r)
-	if err := s.Data.addYear(userID, lang); err != nil {
-		code, userMsg := errorx.UnwrapError(err, lang)
-		s.Error.Log(errorx.AddContext(err))
-		s.Error.Render(w, r, code, userMsg, lang)
+	if err := s.Data.addYear(userID, lang, requestVersion(r)); err != nil {
+		s.renderChangeError(w, r, userID, lang, err)
 		return
 	}
 	s.renderBlueprintContent(w, r, userID, lang)
@@ -318,13 +360,9 @@ func (s Server) yearRemoval(w http.ResponseWriter, r *http.Request) {
 		s.Error.Render(w, r, http.StatusBadRequest, t.errInvalidUnassignParam, lang)
 		return
 	}
-	if err := s.Data.removeYear(userID, lang, shouldUnassign); err != nil {
-		code, userMsg := errorx.UnwrapError(err, lang)
-		s.Error.Log(errorx.AddContext(err))
-		s.Error.Render(w, r, code, userMsg, lang)
-		return
-	}
-	s.renderBlueprintContent(w, r, userID, lang)
+	s.applyChange(w, r, userID, lang, func(a Adapter) error {
+		return a.removeYear(userID, lang, requestVersion(r), shouldUnassign)
+	})
 }
 
 //================================================================================
@@ -364,10 +402,667 @@ func (s Server) foldSemester(w http.ResponseWriter, r *http.Request) {
 	s.renderBlueprintContent(w, r, userID, lang)
 }
 
+//================================================================================
+// Course Status
+//================================================================================
+
+func (s Server) courseStatusChange(w http.ResponseWriter, r *http.Request) {
+	lang := language.FromContext(r.Context())
+	t := texts[lang]
+	userID := s.Auth.UserID(r)
+	courseID, err := strconv.Atoi(r.PathValue(recordID))
+	if err != nil {
+		s.Error.Log(errorx.AddContext(fmt.Errorf("unable to parse course ID to int: %w", err)))
+		s.Error.Render(w, r, http.StatusBadRequest, t.errInvalidCourseID, lang)
+		return
+	}
+	status, grade, completedAt, err := parseCourseStatus(r)
+	if err != nil {
+		s.renderChangeError(w, r, userID, lang, err)
+		return
+	}
+	s.applyChange(w, r, userID, lang, func(a Adapter) error {
+		return a.setCourseStatus(userID, lang, requestVersion(r), courseID, status, grade, completedAt)
+	})
+}
+
+//================================================================================
+// Fixes
+//================================================================================
+
+// fixApplication applies a fix proposed by a diagnostic. The fix is sent by
+// the client, so it is validated again by the database.
+func (s Server) fixApplication(w http.ResponseWriter, r *http.Request) {
+	lang := language.FromContext(r.Context())
+	userID := s.Auth.UserID(r)
+	f, err := parseFix(r)
+	if err != nil {
+		s.renderChangeError(w, r, userID, lang, err)
+		return
+	}
+	s.applyChange(w, r, userID, lang, func(a Adapter) error {
+		return a.applyFix(userID, lang, requestVersion(r), f)
+	})
+}
+
+//================================================================================
+// Export/Import
+//================================================================================
+
+func (s Server) export(w http.ResponseWriter, r *http.Request) {
+	lang := language.FromContext(r.Context())
+	t := texts[lang]
+	userID := s.Auth.UserID(r)
+	format := r.FormValue(formatParam)
+	if format == "" {
+		format = formatJSON
+	}
+	if format != formatJSON && format != formatCSV && format != formatPDF {
+		s.Error.Log(errorx.AddContext(fmt.Errorf("invalid export format %s", format)))
+		s.Error.Render(w, r, http.StatusBadRequest, t.errInvalidExportFormat, lang)
+		return
+	}
+	data, err := s.Data.blueprint(userID, lang)
+	if err != nil {
+		code, userMsg := errorx.UnwrapError(err, lang)
+		s.Error.Log(errorx.AddContext(err))
+		s.Error.Render(w, r, code, userMsg, lang)
+		return
+	}
+	var buf bytes.Buffer
+	switch format {
+	case formatCSV:
+		err = exportCSV(&buf, data)
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	case formatPDF:
+		t = t.withStartYear(data.startYear)
+		generateWarnings(data, t)
+		err = exportPDF(&buf, data, t)
+		w.Header().Set("Content-Type", "application/pdf")
+	default:
+		err = exportJSON(&buf, data)
+		w.Header().Set("Content-Type", "application/json")
+	}
+	if err != nil {
+		s.Error.Log(errorx.AddContext(fmt.Errorf("export %s: %w", format, err)))
+		s.Error.Render(w, r, http.StatusInternalServerError, t.errCannotExport, lang)
+		return
+	}
+	filename := data.activeScenario().name
+	if filename == "" {
+		filename = "blueprint"
+	}
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": filename + "." + format}))
+	w.Write(buf.Bytes())
+}
+
+func (s Server) importBlueprint(w http.ResponseWriter, r *http.Request) {
+	lang := language.FromContext(r.Context())
+	t := texts[lang]
+	userID := s.Auth.UserID(r)
+	mode := r.FormValue(importModeParam)
+	if mode != importMerge && mode != importReplace {
+		s.Error.Log(errorx.AddContext(fmt.Errorf("invalid import mode %s", mode)))
+		s.Error.Render(w, r, http.StatusBadRequest, t.errInvalidImportMode, lang)
+		return
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, maxImportSize)
+	file, _, err := r.FormFile(importFileParam)
+	if err != nil {
+		s.Error.Log(errorx.AddContext(fmt.Errorf("r.FormFile: %w", err)))
+		s.Error.Render(w, r, http.StatusBadRequest, t.errMissingImportFile, lang)
+		return
+	}
+	defer file.Close()
+	content, err := io.ReadAll(file)
+	if err != nil {
+		s.Error.Log(errorx.AddContext(fmt.Errorf("io.ReadAll: %w", err)))
+		s.Error.Render(w, r, http.StatusBadRequest, t.errInvalidImportFile, lang)
+		return
+	}
+	data, err := parseImport(content, t)
+	if err == nil {
+		err = s.Data.importBlueprint(userID, lang, requestVersion(r), data, mode)
+	}
+	if err != nil {
+		s.renderChangeError(w, r, userID, lang, err)
+		return
+	}
+	s.renderBlueprintContent(w, r, userID, lang)
+}
+
+//================================================================================
+// History
+//================================================================================
+
+func (s Server) undo(w http.ResponseWriter, r *http.Request) {
+	lang := language.FromContext(r.Context())
+	userID := s.Auth.UserID(r)
+	if err := s.Data.undo(userID, lang, requestVersion(r)); err != nil {
+		s.renderChangeError(w, r, userID, lang, err)
+		return
+	}
+	s.renderBlueprintContent(w, r, userID, lang)
+}
+
+func (s Server) redo(w http.ResponseWriter, r *http.Request) {
+	lang := language.FromContext(r.Context())
+	userID := s.Auth.UserID(r)
+	if err := s.Data.redo(userID, lang, requestVersion(r)); err != nil {
+		s.renderChangeError(w, r, userID, lang, err)
+		return
+	}
+	s.renderBlueprintContent(w, r, userID, lang)
+}
+
+func (s Server) historyRestoration(w http.ResponseWriter, r *http.Request) {
+	lang := language.FromContext(r.Context())
+	t := texts[lang]
+	userID := s.Auth.UserID(r)
+	historyID, err := strconv.Atoi(r.PathValue(historyIDParam))
+	if err != nil {
+		s.Error.Log(errorx.AddContext(fmt.Errorf("unable to parse history ID to int: %w", err)))
+		s.Error.Render(w, r, http.StatusBadRequest, t.errInvalidHistoryID, lang)
+		return
+	}
+	if err = s.Data.restoreHistory(userID, lang, requestVersion(r), historyID); err != nil {
+		s.renderChangeError(w, r, userID, lang, err)
+		return
+	}
+	s.renderBlueprintContent(w, r, userID, lang)
+}
+
+//================================================================================
+// Scenarios
+//================================================================================
+
+func (s Server) scenarioCreation(w http.ResponseWriter, r *http.Request) {
+	lang := language.FromContext(r.Context())
+	userID := s.Auth.UserID(r)
+	name, err := parseScenarioName(r)
+	if err != nil {
+		code, userMsg := errorx.UnwrapError(err, lang)
+		s.Error.Log(errorx.AddContext(err))
+		s.Error.Render(w, r, code, userMsg, lang)
+		return
+	}
+	if r.FormValue(scenarioSourceParam) == "" {
+		err = s.Data.createScenario(userID, lang, name)
+	} else {
+		var sourceID int
+		sourceID, err = parseScenarioID(r.FormValue(scenarioSourceParam), lang)
+		if err == nil {
+			err = s.Data.cloneScenario(userID, lang, sourceID, name)
+		}
+	}
+	if err != nil {
+		code, userMsg := errorx.UnwrapError(err, lang)
+		s.Error.Log(errorx.AddContext(err))
+		s.Error.Render(w, r, code, userMsg, lang)
+		return
+	}
+	s.renderBlueprintContent(w, r, userID, lang)
+}
+
+func (s Server) scenarioRename(w http.ResponseWriter, r *http.Request) {
+	lang := language.FromContext(r.Context())
+	userID := s.Auth.UserID(r)
+	scenarioID, err := parseScenarioID(r.PathValue(scenarioIDParam), lang)
+	if err != nil {
+		code, userMsg := errorx.UnwrapError(err, lang)
+		s.Error.Log(errorx.AddContext(err))
+		s.Error.Render(w, r, code, userMsg, lang)
+		return
+	}
+	name, err := parseScenarioName(r)
+	if err != nil {
+		code, userMsg := errorx.UnwrapError(err, lang)
+		s.Error.Log(errorx.AddContext(err))
+		s.Error.Render(w, r, code, userMsg, lang)
+		return
+	}
+	if err = s.Data.renameScenario(userID, lang, scenarioID, name); err != nil {
+		code, userMsg := errorx.UnwrapError(err, lang)
+		s.Error.Log(errorx.AddContext(err))
+		s.Error.Render(w, r, code, userMsg, lang)
+		return
+	}
+	s.renderBlueprintContent(w, r, userID, lang)
+}
+
+func (s Server) scenarioRemoval(w http.ResponseWriter, r *http.Request) {
+	lang := language.FromContext(r.Context())
+	userID := s.Auth.UserID(r)
+	scenarioID, err := parseScenarioID(r.PathValue(scenarioIDParam), lang)
+	if err != nil {
+		code, userMsg := errorx.UnwrapError(err, lang)
+		s.Error.Log(errorx.AddContext(err))
+		s.Error.Render(w, r, code, userMsg, lang)
+		return
+	}
+	if err = s.Data.deleteScenario(userID, lang, scenarioID); err != nil {
+		code, userMsg := errorx.UnwrapError(err, lang)
+		s.Error.Log(errorx.AddContext(err))
+		s.Error.Render(w, r, code, userMsg, lang)
+		return
+	}
+	s.renderBlueprintContent(w, r, userID, lang)
+}
+
+func (s Server) scenarioActivation(w http.ResponseWriter, r *http.Request) {
+	lang := language.FromContext(r.Context())
+	userID := s.Auth.UserID(r)
+	scenarioID, err := parseScenarioID(r.PathValue(scenarioIDParam), lang)
+	if err != nil {
+		code, userMsg := errorx.UnwrapError(err, lang)
+		s.Error.Log(errorx.AddContext(err))
+		s.Error.Render(w, r, code, userMsg, lang)
+		return
+	}
+	if err = s.Data.activateScenario(userID, lang, scenarioID); err != nil {
+		code, userMsg := errorx.UnwrapError(err, lang)
+		s.Error.Log(errorx.AddContext(err))
+		s.Error.Render(w, r, code, userMsg, lang)
+		return
+	}
+	s.renderBlueprintContent(w, r, userID, lang)
+}
+
+//================================================================================
+// Start Year
+//================================================================================
+
+func (s Server) startYearChange(w http.ResponseWriter, r *http.Request) {
+	lang := language.FromContext(r.Context())
+	userID := s.Auth.UserID(r)
+	startYear, err := parseStartYear(r)
+	if err != nil {
+		code, userMsg := errorx.UnwrapError(err, lang)
+		s.Error.Log(errorx.AddContext(err))
+		s.Error.Render(w, r, code, userMsg, lang)
+		return
+	}
+	if err = s.Data.setStartYear(userID, lang, startYear); err != nil {
+		code, userMsg := errorx.UnwrapError(err, lang)
+		s.Error.Log(errorx.AddContext(err))
+		s.Error.Render(w, r, code, userMsg, lang)
+		return
+	}
+	s.renderBlueprintContent(w, r, userID, lang)
+}
+
+//================================================================================
+// Auto-schedule
+//================================================================================
+
+// schedulePreview renders the proposed placement of courses without changing
+// the blueprint. The user accepts it by submitting the listed placements.
+func (s Server) schedulePreview(w http.ResponseWriter, r *http.Request) {
+	lang := language.FromContext(r.Context())
+	t := texts[lang]
+	userID := s.Auth.UserID(r)
+	opts, err := parseScheduleOptions(r)
+	if err != nil {
+		code, userMsg := errorx.UnwrapError(err, lang)
+		s.Error.Log(errorx.AddContext(err))
+		s.Error.Render(w, r, code, userMsg, lang)
+		return
+	}
+	data, err := s.Data.blueprint(userID, lang)
+	if err != nil {
+		code, userMsg := errorx.UnwrapError(err, lang)
+		s.Error.Log(errorx.AddContext(err))
+		s.Error.Render(w, r, code, userMsg, lang)
+		return
+	}
+	var planCourses []course
+	if opts.source == scheduleDegreePlan {
+		planCourses, err = s.Data.degreePlanCourses(userID, lang)
+		if err != nil {
+			code, userMsg := errorx.UnwrapError(err, lang)
+			s.Error.Log(errorx.AddContext(err))
+			s.Error.Render(w, r, code, userMsg, lang)
+			return
+		}
+	}
+	if opts.years == 0 {
+		opts.years = min(max(len(data.years), 1), maxScheduleYears)
+	}
+	t = t.withStartYear(data.startYear)
+	preview := autoSchedule(data, scheduleCandidates(data, opts.source, planCourses), opts, t)
+	err = schedulePreviewContent(&preview, t).Render(r.Context(), w)
+	if err != nil {
+		s.Error.CannotRenderPage(w, r, t.pageTitle, userID, errorx.AddContext(err), lang)
+	}
+}
+
+func (s Server) scheduleAcceptance(w http.ResponseWriter, r *http.Request) {
+	lang := language.FromContext(r.Context())
+	userID := s.Auth.UserID(r)
+	placements, err := parsePlacements(r)
+	if err != nil {
+		code, userMsg := errorx.UnwrapError(err, lang)
+		s.Error.Log(errorx.AddContext(err))
+		s.Error.Render(w, r, code, userMsg, lang)
+		return
+	}
+	if err = s.Data.applySchedule(userID, lang, requestVersion(r), placements); err != nil {
+		s.renderChangeError(w, r, userID, lang, err)
+		return
+	}
+	s.renderBlueprintContent(w, r, userID, lang)
+}
+
+//================================================================================
+// Workload
+//================================================================================
+
+// workload renders the workload panel or, with format=json, returns the same
+// data as JSON.
+func (s Server) workload(w http.ResponseWriter, r *http.Request) {
+	lang := language.FromContext(r.Context())
+	t := texts[lang]
+	userID := s.Auth.UserID(r)
+	format := r.FormValue(formatParam)
+	if format != "" && format != formatJSON {
+		s.Error.Log(errorx.AddContext(fmt.Errorf("invalid workload format %s", format)))
+		s.Error.Render(w, r, http.StatusBadRequest, t.errInvalidWorkloadFormat, lang)
+		return
+	}
+	target, err := parseWorkloadTarget(r)
+	if err != nil {
+		code, userMsg := errorx.UnwrapError(err, lang)
+		s.Error.Log(errorx.AddContext(err))
+		s.Error.Render(w, r, code, userMsg, lang)
+		return
+	}
+	data, err := s.Data.blueprint(userID, lang)
+	if err != nil {
+		code, userMsg := errorx.UnwrapError(err, lang)
+		s.Error.Log(errorx.AddContext(err))
+		s.Error.Render(w, r, code, userMsg, lang)
+		return
+	}
+	summary := buildWorkload(data, target)
+	if format == formatJSON {
+		var buf bytes.Buffer
+		if err := exportWorkloadJSON(&buf, &summary); err != nil {
+			s.Error.Log(errorx.AddContext(fmt.Errorf("exportWorkloadJSON: %w", err)))
+			s.Error.Render(w, r, http.StatusInternalServerError, t.errCannotExport, lang)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(buf.Bytes())
+		return
+	}
+	t = t.withStartYear(data.startYear)
+	err = workloadContent(&summary, t).Render(r.Context(), w)
+	if err != nil {
+		s.Error.CannotRenderPage(w, r, t.pageTitle, userID, errorx.AddContext(err), lang)
+	}
+}
+
 //================================================================================
 // Parse parameters
 //================================================================================
 
+// parseScheduleOptions parses scheduler settings. Missing credit cap defaults
+// to defaultCreditCap, missing number of years is returned as zero.
+func parseScheduleOptions(r *http.Request) (scheduleOptions, error) {
+	lang := language.FromContext(r.Context())
+	t := texts[lang]
+	opts := scheduleOptions{
+		source:    r.FormValue(scheduleSourceParam),
+		creditCap: defaultCreditCap,
+	}
+	if opts.source != scheduleUnassigned && opts.source != scheduleDegreePlan {
+		return opts, errorx.NewHTTPErr(
+			errorx.AddContext(fmt.Errorf("invalid schedule source %s", opts.source)),
+			http.StatusBadRequest,
+			t.errInvalidScheduleSource,
+		)
+	}
+	if capString := r.FormValue(creditCapParam); capString != "" {
+		creditCap, err := strconv.Atoi(capString)
+		if err != nil || creditCap < 1 || creditCap > maxCreditCap {
+			return opts, errorx.NewHTTPErr(
+				errorx.AddContext(fmt.Errorf("invalid credit cap %s", capString)),
+				http.StatusBadRequest,
+				fmt.Sprintf(t.errInvalidCreditCap, maxCreditCap),
+			)
+		}
+		opts.creditCap = creditCap
+	}
+	if yearsString := r.FormValue(scheduleYearsParam); yearsString != "" {
+		years, err := strconv.Atoi(yearsString)
+		if err != nil || years < 1 || years > maxScheduleYears {
+			return opts, errorx.NewHTTPErr(
+				errorx.AddContext(fmt.Errorf("invalid number of years %s", yearsString)),
+				http.StatusBadRequest,
+				fmt.Sprintf(t.errInvalidScheduleYears, maxScheduleYears),
+			)
+		}
+		opts.years = years
+	}
+	return opts, nil
+}
+
+// parseWorkloadTarget parses the target credits per semester. Missing target
+// defaults to defaultWorkloadTarget.
+func parseWorkloadTarget(r *http.Request) (int, error) {
+	lang := language.FromContext(r.Context())
+	t := texts[lang]
+	targetString := r.FormValue(workloadTargetParam)
+	if targetString == "" {
+		return defaultWorkloadTarget, nil
+	}
+	target, err := strconv.Atoi(targetString)
+	if err != nil || target < 1 || target > maxCreditCap {
+		return 0, errorx.NewHTTPErr(
+			errorx.AddContext(fmt.Errorf("invalid workload target %s", targetString)),
+			http.StatusBadRequest,
+			fmt.Sprintf(t.errInvalidWorkloadTarget, maxCreditCap),
+		)
+	}
+	return target, nil
+}
+
+// parsePlacements parses accepted placements in the form code:year:semester.
+func parsePlacements(r *http.Request) ([]importCourse, error) {
+	lang := language.FromContext(r.Context())
+	t := texts[lang]
+	r.ParseForm()
+	values := r.Form[placementParam]
+	if len(values) == 0 {
+		return nil, errorx.NewHTTPErr(
+			errorx.AddContext(fmt.Errorf("no placements in the request")),
+			http.StatusBadRequest,
+			t.errInvalidPlacementParam,
+		)
+	}
+	placements := make([]importCourse, 0, len(values))
+	seen := make(map[string]bool)
+	for _, value := range values {
+		invalid := errorx.NewHTTPErr(
+			errorx.AddContext(fmt.Errorf("invalid placement %s", value)),
+			http.StatusBadRequest,
+			t.errInvalidPlacementParam,
+		)
+		parts := strings.Split(value, ":")
+		if len(parts) != 3 || parts[0] == "" || seen[parts[0]] {
+			return nil, invalid
+		}
+		year, err := strconv.Atoi(parts[1])
+		if err != nil || year < 1 || year > maxScheduleYears {
+			return nil, invalid
+		}
+		semester, err := strconv.Atoi(parts[2])
+		if err != nil || (semesterAssignment(semester) != assignmentWinter && semesterAssignment(semester) != assignmentSummer) {
+			return nil, invalid
+		}
+		seen[parts[0]] = true
+		placements = append(placements, importCourse{
+			year:     year,
+			semester: semesterAssignment(semester),
+			position: len(placements) + 1,
+			code:     parts[0],
+			status:   statusPlanned,
+		})
+	}
+	return placements, nil
+}
+
+// parseFix parses a fix. Moved and removed courses require a record ID, added
+// courses require a code. Only added and moved courses have a target semester.
+func parseFix(r *http.Request) (fix, error) {
+	lang := language.FromContext(r.Context())
+	t := texts[lang]
+	invalid := func(err error) error {
+		return errorx.NewHTTPErr(
+			errorx.AddContext(err, errorx.P(fixActionParam, r.FormValue(fixActionParam))),
+			http.StatusBadRequest,
+			t.errInvalidFixParam,
+		)
+	}
+	f := fix{action: fixAction(r.FormValue(fixActionParam))}
+	if !f.action.isValid() {
+		return f, invalid(fmt.Errorf("invalid fix action"))
+	}
+	if f.action == fixAdd {
+		f.code = strings.TrimSpace(r.FormValue(fixCodeParam))
+		if f.code == "" || len(f.code) > maxFixCodeLength {
+			return f, invalid(fmt.Errorf("invalid course code %q", f.code))
+		}
+	} else {
+		courseID, err := strconv.Atoi(r.FormValue(fixCourseParam))
+		if err != nil || courseID <= 0 {
+			return f, invalid(fmt.Errorf("invalid course ID %q", r.FormValue(fixCourseParam)))
+		}
+		f.courseID = courseID
+	}
+	if f.action == fixRemove {
+		return f, nil
+	}
+	year, semester, err := parseYearSemester(r)
+	if err != nil {
+		return f, errorx.AddContext(err)
+	}
+	if (year == 0) != (semester == assignmentNone) {
+		return f, invalid(fmt.Errorf("invalid target year %d and semester %d", year, semester))
+	}
+	f.year, f.semester = year, semester
+	return f, nil
+}
+
+// parseCourseStatus parses status with optional grade and completion date.
+// Grade and date are kept only for finished (passed or failed) courses.
+func parseCourseStatus(r *http.Request) (courseStatus, sql.NullInt64, sql.NullTime, error) {
+	lang := language.FromContext(r.Context())
+	t := texts[lang]
+	var grade sql.NullInt64
+	var completedAt sql.NullTime
+
+	status := courseStatus(r.FormValue(statusParam))
+	if !status.isValid() {
+		return status, grade, completedAt, errorx.NewHTTPErr(
+			errorx.AddContext(fmt.Errorf("invalid status %s", status)),
+			http.StatusBadRequest,
+			t.errInvalidStatusParam,
+		)
+	}
+	if !status.isFinished() {
+		return status, grade, completedAt, nil
+	}
+
+	if gradeString := r.FormValue(gradeParam); gradeString != "" {
+		gradeInt, err := strconv.Atoi(gradeString)
+		if err != nil || gradeInt < minGrade || gradeInt > maxGrade {
+			return status, grade, completedAt, errorx.NewHTTPErr(
+				errorx.AddContext(fmt.Errorf("invalid grade %s", gradeString)),
+				http.StatusBadRequest,
+				t.errInvalidGradeParam,
+			)
+		}
+		grade = sql.NullInt64{Int64: int64(gradeInt), Valid: true}
+	}
+
+	if dateString := r.FormValue(completedAtParam); dateString != "" {
+		date, err := time.Parse(dateLayout, dateString)
+		if err != nil {
+			return status, grade, completedAt, errorx.NewHTTPErr(
+				errorx.AddContext(fmt.Errorf("unable to parse completion date: %w", err)),
+				http.StatusBadRequest,
+				t.errInvalidCompletedParam,
+			)
+		}
+		completedAt = sql.NullTime{Time: date, Valid: true}
+	}
+
+	return status, grade, completedAt, nil
+}
+
+func parseScenarioID(value string, lang language.Language) (int, error) {
+	t := texts[lang]
+	if value == "" {
+		return 0, errorx.NewHTTPErr(
+			errorx.AddContext(fmt.Errorf("scenario ID is missing in the request")),
+			http.StatusBadRequest,
+			t.errMissingScenarioID,
+		)
+	}
+	scenarioID, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, errorx.NewHTTPErr(
+			errorx.AddContext(fmt.Errorf("unable to parse scenario ID to int: %w", err)),
+			http.StatusBadRequest,
+			t.errInvalidScenarioID,
+		)
+	}
+	return scenarioID, nil
+}
+
+func parseScenarioName(r *http.Request) (string, error) {
+	lang := language.FromContext(r.Context())
+	t := texts[lang]
+	name := strings.TrimSpace(r.FormValue(scenarioNameParam))
+	if name == "" {
+		return "", errorx.NewHTTPErr(
+			errorx.AddContext(fmt.Errorf("scenario name is missing in the request form")),
+			http.StatusBadRequest,
+			t.errMissingScenarioName,
+		)
+	}
+	if utf8.RuneCountInString(name) > maxScenarioNameLen {
+		return "", errorx.NewHTTPErr(
+			errorx.AddContext(fmt.Errorf("scenario name is too long"), errorx.P("name", name)),
+			http.StatusBadRequest,
+			t.errScenarioNameTooLong,
+		)
+	}
+	return name, nil
+}
+
+// parseStartYear returns the start year from the request form or 0 if it is
+// empty, meaning the start year is unknown.
+func parseStartYear(r *http.Request) (int, error) {
+	lang := language.FromContext(r.Context())
+	t := texts[lang]
+	startYearString := r.FormValue(startYearParam)
+	if startYearString == "" {
+		return 0, nil
+	}
+	startYear, err := strconv.Atoi(startYearString)
+	maxStartYear := academicyear.MaxStartYear(time.Now())
+	if err != nil || startYear < academicyear.MinStartYear || startYear > maxStartYear {
+		return 0, errorx.NewHTTPErr(
+			errorx.AddContext(fmt.Errorf("invalid start year %s", startYearString)),
+			http.StatusBadRequest,
+			fmt.Sprintf(t.errInvalidStartYear, academicyear.MinStartYear, maxStartYear),
+		)
+	}
+	return startYear, nil
+}
+
 func parseYear(r *http.Request) (int, error) {
 	lang := language.FromContext(r.Context())
 	t := texts[lang]
@@ -510,15 +1205,63 @@ func generateWarnings(bp *blueprintPage, t text) {
 	forCorrectAssignment(bp, t)
 	forDuplicateAssignments(bp, t)
 	forRequisites(bp, t)
+	forValidity(bp, t)
+}
+
+// forValidity warns about planned courses which are no longer taught and,
+// if the start year is known, about courses assigned to an academic year
+// outside of their validity. Finished courses are left alone.
+func forValidity(bp *blueprintPage, t text) {
+	notTaught := func(c *course) {
+		if c.status.isFinished() || c.taughtState == "" || c.taughtState == taughtStateTaught {
+			return
+		}
+		c.diagnostics = append(c.diagnostics, diagnostic{
+			kind:    diagnosticNotTaught,
+			message: fmt.Sprintf(t.wNotTaught, c.taughtStateTitle),
+			courses: []string{c.code},
+		})
+	}
+	for c := range bp.unassignedCourses() {
+		notTaught(c)
+	}
+	for c := range bp.assignedCourses() {
+		notTaught(c.course)
+		year := academicyear.Of(bp.startYear, c.year)
+		if c.course.status.isFinished() || academicyear.IsValid(year, c.course.validFrom, c.course.validTo) {
+			continue
+		}
+		c.course.diagnostics = append(c.course.diagnostics, diagnostic{
+			kind:    diagnosticValidity,
+			message: fmt.Sprintf(t.wOutsideValidity, academicyear.Label(year), t.validityStr(*c.course)),
+			courses: []string{c.course.code},
+		})
+	}
 }
 
 func forCorrectAssignment(bp *blueprintPage, t text) {
 	for c := range bp.assignedCourses() {
+		d := diagnostic{kind: diagnosticWrongSemester, courses: []string{c.course.code}}
 		if c.course.semester == teachingWinterOnly && c.semester == assignmentSummer {
-			c.course.warnings = append(c.course.warnings, t.wWrongAssignSummer)
+			d.message = t.wWrongAssignSummer
+			d.fixes = append(d.fixes, fix{action: fixMove, courseID: c.course.id, code: c.course.code, year: c.year, semester: assignmentWinter})
+			if c.year < len(bp.years) {
+				d.fixes = append(d.fixes, fix{action: fixMove, courseID: c.course.id, code: c.course.code, year: c.year + 1, semester: assignmentWinter})
+			}
 		}
 		if c.course.semester == teachingSummerOnly && c.semester == assignmentWinter {
-			c.course.warnings = append(c.course.warnings, t.wWrongAssignWinter)
+			d.message = t.wWrongAssignWinter
+			d.fixes = append(d.fixes, fix{action: fixMove, courseID: c.course.id, code: c.course.code, year: c.year, semester: assignmentSummer})
+			if c.year > 1 {
+				d.fixes = append(d.fixes, fix{action: fixMove, courseID: c.course.id, code: c.course.code, year: c.year - 1, semester: assignmentSummer})
+			}
+		}
+		if c.course.custom {
+			// fixes identify courses by code in their labels
+			d.fixes = nil
+		}
+		if d.message != "" {
+			c.course.diagnostics = append(c.course.diagnostics, d)
 		}
 	}
 }
@@ -528,16 +1271,25 @@ func forDuplicateAssignments(bp *blueprintPage, t text) {
 
 	for course := range bp.unassignedCourses() {
 		if locations, exists := assignedCourseCodes[course.code]; exists {
-			warning := makeDuplicateWarning(locations, t.wUnassignedButAssigned, t)
-			course.warnings = append(course.warnings, warning)
+			course.diagnostics = append(course.diagnostics, diagnostic{
+				kind:    diagnosticAlreadyAssigned,
+				message: makeDuplicateWarning(locations, t.wUnassignedButAssigned, t),
+				courses: []string{course.code},
+				fixes:   []fix{{action: fixRemove, courseID: course.id, code: course.code}},
+			})
 		}
 	}
 
-	for _, locations := range assignedCourseCodes {
+	for code, locations := range assignedCourseCodes {
 		if len(locations) > 1 {
-			warning := makeDuplicateWarning(locations, t.wAssignedMoreThanOnce, t)
+			d := diagnostic{
+				kind:    diagnosticDuplicate,
+				message: makeDuplicateWarning(locations, t.wAssignedMoreThanOnce, t),
+				courses: []string{code},
+				fixes:   removalFixes(locations),
+			}
 			for _, loc := range locations {
-				loc.course.warnings = append(loc.course.warnings, warning)
+				loc.course.diagnostics = append(loc.course.diagnostics, d)
 			}
 		}
 	}
@@ -571,8 +1323,13 @@ func forRequisites(bp *blueprintPage, t text) {
 		if !reqSlice.isEmpty() {
 			reqIsMet := areAllRequisitesMet(reqSlice, courseLoc, prerequisiteCondition, assignedCourseMap)
 			if !reqIsMet {
-				course.warnings = append(course.warnings, t.wPrerequisiteNotMet)
-				// TODO: better warning message with details
+				missing := unmetRequisiteCodes(reqSlice, courseLoc, prerequisiteCondition, assignedCourseMap)
+				course.diagnostics = append(course.diagnostics, diagnostic{
+					kind:    diagnosticPrerequisite,
+					message: fmt.Sprintf(t.wPrerequisiteNotMet, strings.Join(missing, ", ")),
+					courses: append([]string{course.code}, missing...),
+					fixes:   requisiteFixes(bp, courseLoc, missing, true, assignedCourseMap),
+				})
 			}
 		}
 
@@ -580,8 +1337,13 @@ func forRequisites(bp *blueprintPage, t text) {
 		if !reqSlice.isEmpty() {
 			reqIsMet := areAllRequisitesMet(reqSlice, courseLoc, corequisiteCondition, assignedCourseMap)
 			if !reqIsMet {
-				course.warnings = append(course.warnings, t.wCorequisiteNotMet)
-				// TODO: better warning message with details
+				missing := unmetRequisiteCodes(reqSlice, courseLoc, corequisiteCondition, assignedCourseMap)
+				course.diagnostics = append(course.diagnostics, diagnostic{
+					kind:    diagnosticCorequisite,
+					message: fmt.Sprintf(t.wCorequisiteNotMet, strings.Join(missing, ", ")),
+					courses: append([]string{course.code}, missing...),
+					fixes:   requisiteFixes(bp, courseLoc, missing, false, assignedCourseMap),
+				})
 			}
 		}
 
@@ -589,11 +1351,124 @@ func forRequisites(bp *blueprintPage, t text) {
 		if !reqSlice.isEmpty() {
 			reqIsViolated := isAnyRequisiteMet(reqSlice, courseLoc, incompatibleCondition, assignedCourseMap)
 			if reqIsViolated {
-				course.warnings = append(course.warnings, t.wIncompatiblePresent)
-				// TODO: better warning message with details
+				d := diagnostic{kind: diagnosticIncompatible, courses: []string{course.code}}
+				var present []string
+				for _, code := range requisiteCodes(reqSlice) {
+					if locations, ok := assignedCourseMap[code]; ok {
+						present = append(present, code)
+						d.fixes = append(d.fixes, removalFixes(locations)...)
+					}
+				}
+				d.message = fmt.Sprintf(t.wIncompatiblePresent, strings.Join(present, ", "))
+				d.courses = append(d.courses, present...)
+				course.diagnostics = append(course.diagnostics, d)
+			}
+		}
+	}
+}
+
+// unmetRequisiteCodes returns codes of requisites which are not met. All
+// alternatives of an unmet disjunction are returned.
+func unmetRequisiteCodes(reqs requisiteSlice, originLoc courseLocation, condition requisiteCondition, courseMap map[string][]courseLocation) []string {
+	var codes []string
+	for _, req := range reqs {
+		if areAllRequisitesMet(requisiteSlice{req}, originLoc, condition, courseMap) {
+			continue
+		}
+		if req.isNode() {
+			codes = append(codes, req.courseCode)
+		} else {
+			codes = append(codes, unmetRequisiteCodes(req.children, originLoc, condition, courseMap)...)
+		}
+	}
+	slices.Sort(codes)
+	return slices.Compact(codes)
+}
+
+func removalFixes(locations []courseLocation) []fix {
+	fixes := make([]fix, len(locations))
+	for i, loc := range locations {
+		fixes[i] = fix{action: fixRemove, courseID: loc.course.id, code: loc.course.code, year: loc.year, semester: loc.semester}
+	}
+	return fixes
+}
+
+// requisiteFixes proposes for every missing requisite to move it to the
+// latest semester where it satisfies the requisite (strictly before the
+// course for prerequisites) or to add it to unassigned courses if it is not
+// in the blueprint. If the requisite is assigned too late, moving the course
+// itself after the requisite is proposed as well.
+func requisiteFixes(bp *blueprintPage, courseLoc courseLocation, missing []string, strict bool, courseMap map[string][]courseLocation) []fix {
+	slots := semesterSlots(bp)
+	courseSlot := slices.IndexFunc(slots, func(s courseLocation) bool {
+		return s.year == courseLoc.year && s.semester == courseLoc.semester
+	})
+	var fixes []fix
+	addFix := func(f fix) {
+		if !slices.Contains(fixes, f) {
+			fixes = append(fixes, f)
+		}
+	}
+	for _, code := range missing {
+		req := findCourse(bp, code, courseMap)
+		if req == nil {
+			addFix(fix{action: fixAdd, code: code, semester: assignmentNone})
+			continue
+		}
+		last := courseSlot
+		if strict {
+			last--
+		}
+		for i := last; i >= 0; i-- {
+			if isTaughtIn(req.semester, slots[i].semester) {
+				addFix(fix{action: fixMove, courseID: req.id, code: code, year: slots[i].year, semester: slots[i].semester})
+				break
+			}
+		}
+		locations := courseMap[code]
+		if len(locations) == 0 {
+			continue
+		}
+		first := slices.IndexFunc(slots, func(s courseLocation) bool {
+			return s.year == locations[0].year && s.semester == locations[0].semester
+		})
+		if strict {
+			first++
+		}
+		for i := first; i < len(slots); i++ {
+			if isTaughtIn(courseLoc.course.semester, slots[i].semester) {
+				addFix(fix{action: fixMove, courseID: courseLoc.course.id, code: courseLoc.course.code, year: slots[i].year, semester: slots[i].semester})
+				break
 			}
 		}
 	}
+	return fixes
+}
+
+// semesterSlots returns all assigned semesters of the blueprint in order.
+func semesterSlots(bp *blueprintPage) []courseLocation {
+	slots := make([]courseLocation, 0, 2*len(bp.years))
+	for _, year := range bp.years {
+		slots = append(slots,
+			courseLocation{year: year.position, semester: assignmentWinter},
+			courseLocation{year: year.position, semester: assignmentSummer},
+		)
+	}
+	return slots
+}
+
+// findCourse returns the course with the given code, preferring an
+// unassigned record over the earliest assigned one.
+func findCourse(bp *blueprintPage, code string, courseMap map[string][]courseLocation) *course {
+	for c := range bp.unassignedCourses() {
+		if c.code == code {
+			return c
+		}
+	}
+	if locations := courseMap[code]; len(locations) > 0 {
+		return locations[0].course
+	}
+	return nil
 }
 
 func areAllRequisitesMet(courses []requisite, originLoc courseLocation, condition requisiteCondition, courseMap map[string][]courseLocation) bool {
@@ -649,10 +1524,14 @@ func isCourseRequisiteMet(reqNode requisite, originLoc courseLocation, condition
 	return false
 }
 
-// Helper: Build map of assigned courses' codes to their locations
+// Helper: Build map of assigned courses' codes to their locations (custom
+// courses have no code and are left out)
 func buildAssignedCourseMap(bp *blueprintPage) map[string][]courseLocation {
 	courseMap := make(map[string][]courseLocation)
 	for loc := range bp.assignedCourses() {
+		if loc.course.custom {
+			continue
+		}
 		courseMap[loc.course.code] = append(courseMap[loc.course.code], loc)
 	}
 	return courseMap
@@ -662,6 +1541,36 @@ func buildAssignedCourseMap(bp *blueprintPage) map[string][]courseLocation {
 // Render Content
 //================================================================================
 
+// renderChangeError renders the error of a rejected change. If the change was
+// made against an older version of the blueprint, the current blueprint is
+// rendered instead with status 409 together with the changes made since.
+func (s Server) renderChangeError(w http.ResponseWriter, r *http.Request, userID string, lang language.Language, err error) {
+	s.Error.Log(errorx.AddContext(err))
+	var conflict versionConflictError
+	if !errors.As(err, &conflict) {
+		code, userMsg := errorx.UnwrapError(err, lang)
+		s.Error.Render(w, r, code, userMsg, lang)
+		return
+	}
+	t := texts[lang]
+	data, err := s.Data.blueprint(userID, lang)
+	if err != nil {
+		code, userMsg := errorx.UnwrapError(err, lang)
+		s.Error.Log(errorx.AddContext(err))
+		s.Error.Render(w, r, code, userMsg, lang)
+		return
+	}
+	data.markStale(conflict.version)
+	t = t.withStartYear(data.startYear)
+	generateWarnings(data, t)
+	w.Header().Set("HX-Reswap", "outerHTML")
+	w.WriteHeader(http.StatusConflict)
+	err = Content(data, t).Render(r.Context(), w)
+	if err != nil {
+		s.Error.Log(errorx.AddContext(err))
+	}
+}
+
 func (s Server) renderBlueprintContent(w http.ResponseWriter, r *http.Request, userID string, lang language.Language) {
 	t := texts[lang]
 	data, err := s.Data.blueprint(userID, lang)
@@ -671,6 +1580,7 @@ func (s Server) renderBlueprintContent(w http.ResponseWriter, r *http.Request, u
 		s.Error.Render(w, r, code, userMsg, lang)
 		return
 	}
+	t = t.withStartYear(data.startYear)
 	generateWarnings(data, t)
 	view := Content(data, t)
 	err = view.Render(r.Context(), w)
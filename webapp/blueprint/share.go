@@ -0,0 +1,294 @@
+package blueprint
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/michalhercik/RecSIS/errorx"
+	"github.com/michalhercik/RecSIS/language"
+)
+
+//================================================================================
+// Constants
+//================================================================================
+
+const (
+	shareIDParam     string = "id"
+	shareTokenParam  string = "token"
+	shareDaysParam   string = "days"
+	defaultShareDays        = 30
+	maxShareDays            = 365
+	maxShares               = 20
+)
+
+const shareNonceSize = 16
+
+//================================================================================
+// Data Types and Methods
+//================================================================================
+
+// share is an active read-only link to the owner's blueprint. The link
+// always shows the currently active scenario.
+type share struct {
+	id        int
+	nonce     string
+	token     string
+	createdAt time.Time
+	expiresAt time.Time
+}
+
+func (s share) path(lang language.Language) string {
+	return lang.LocalizeURL("/shared/" + s.token)
+}
+
+// ShareSigner signs share tokens so that the nonce and expiration of a link
+// cannot be forged. Tokens do not contain any user data.
+type ShareSigner struct {
+	Secret []byte
+}
+
+// token returns "<nonce>.<expiration>.<signature>".
+func (s ShareSigner) token(nonce string, expiresAt time.Time) string {
+	payload := nonce + "." + strconv.FormatInt(expiresAt.Unix(), 10)
+	return payload + "." + s.signature(payload)
+}
+
+func (s ShareSigner) signature(payload string) string {
+	mac := hmac.New(sha256.New, s.Secret)
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// verify checks the signature and expiration of a token and returns its
+// nonce. Whether the share was revoked must be checked in the database.
+func (s ShareSigner) verify(token string, now time.Time) (string, bool) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return "", false
+	}
+	payload := parts[0] + "." + parts[1]
+	if !hmac.Equal([]byte(parts[2]), []byte(s.signature(payload))) {
+		return "", false
+	}
+	expiresAt, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil || now.Unix() >= expiresAt {
+		return "", false
+	}
+	return parts[0], true
+}
+
+func newShareNonce() (string, error) {
+	nonce := make([]byte, shareNonceSize)
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(nonce), nil
+}
+
+// NewShareSecret returns a random secret for signing share tokens.
+func NewShareSecret() ([]byte, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, err
+	}
+	return secret, nil
+}
+
+type readOnlyKey struct{}
+
+// withReadOnly marks blueprints rendered with the context as read-only, which
+// hides all controls changing the blueprint.
+func withReadOnly(ctx context.Context) context.Context {
+	return context.WithValue(ctx, readOnlyKey{}, true)
+}
+
+func isReadOnly(ctx context.Context) bool {
+	readOnly, _ := ctx.Value(readOnlyKey{}).(bool)
+	return readOnly
+}
+
+//================================================================================
+// Shared Blueprint Server
+//================================================================================
+
+// SharedServer renders blueprints shared by a link. It must be mounted
+// outside of the authentication since visitors do not need to log in.
+type SharedServer struct {
+	Data   SharedAdapter
+	Signer ShareSigner
+	Error  Error
+	Page   Page
+	router http.Handler
+}
+
+type SharedAdapter interface {
+	// Returns the blueprint of the owner of an active share.
+	sharedBlueprint(nonce string, lang language.Language) (*blueprintPage, error)
+}
+
+func (s SharedServer) Router() http.Handler {
+	return s.router
+}
+
+func (s *SharedServer) Init() {
+	router := http.NewServeMux()
+	router.HandleFunc(fmt.Sprintf("GET /{%s}", shareTokenParam), s.page)
+	router.HandleFunc("/", s.pageNotFound)
+	s.router = router
+}
+
+func (s SharedServer) page(w http.ResponseWriter, r *http.Request) {
+	lang := language.FromContext(r.Context())
+	t := texts[lang]
+	nonce, ok := s.Signer.verify(r.PathValue(shareTokenParam), time.Now())
+	if !ok {
+		s.Error.Log(errorx.AddContext(fmt.Errorf("invalid or expired share token")))
+		s.Error.RenderPage(w, r, http.StatusNotFound, t.errShareNotFound, t.sharedTitle, "", lang)
+		return
+	}
+	data, err := s.Data.sharedBlueprint(nonce, lang)
+	if err != nil {
+		code, userMsg := errorx.UnwrapError(err, lang)
+		s.Error.Log(errorx.AddContext(err))
+		s.Error.RenderPage(w, r, code, userMsg, t.sharedTitle, "", lang)
+		return
+	}
+	data.readOnlyView()
+	t = t.withStartYear(data.startYear)
+	generateWarnings(data, t)
+	main := Content(data, t)
+	page := s.Page.View(main, lang, t.sharedTitle, "")
+	err = page.Render(withReadOnly(r.Context()), w)
+	if err != nil {
+		s.Error.CannotRenderPage(w, r, t.sharedTitle, "", errorx.AddContext(err), lang)
+	}
+}
+
+func (s SharedServer) pageNotFound(w http.ResponseWriter, r *http.Request) {
+	lang := language.FromContext(r.Context())
+	t := texts[lang]
+	s.Error.RenderPage(w, r, http.StatusNotFound, t.errShareNotFound, t.sharedTitle, "", lang)
+}
+
+//================================================================================
+// Share Management
+//================================================================================
+
+func (s Server) sharesPage(w http.ResponseWriter, r *http.Request) {
+	lang := language.FromContext(r.Context())
+	t := texts[lang]
+	userID := s.Auth.UserID(r)
+	shares, err := s.shares(userID, lang)
+	if err != nil {
+		code, userMsg := errorx.UnwrapError(err, lang)
+		s.Error.Log(errorx.AddContext(err))
+		s.Error.RenderPage(w, r, code, userMsg, t.sharesTitle, userID, lang)
+		return
+	}
+	main := SharesContent(shares, r.Host, t)
+	page := s.Page.View(main, lang, t.sharesTitle, userID)
+	err = page.Render(r.Context(), w)
+	if err != nil {
+		s.Error.CannotRenderPage(w, r, t.sharesTitle, userID, errorx.AddContext(err), lang)
+	}
+}
+
+func (s Server) shareCreation(w http.ResponseWriter, r *http.Request) {
+	lang := language.FromContext(r.Context())
+	userID := s.Auth.UserID(r)
+	days, err := parseShareDays(r)
+	if err == nil {
+		var nonce string
+		nonce, err = newShareNonce()
+		if err != nil {
+			err = errorx.NewHTTPErr(
+				errorx.AddContext(fmt.Errorf("newShareNonce: %w", err)),
+				http.StatusInternalServerError,
+				texts[lang].errCannotCreateShare,
+			)
+		} else {
+			// Round to seconds as the token carries the expiration in seconds.
+			expiresAt := time.Now().Add(time.Duration(days) * 24 * time.Hour).Truncate(time.Second)
+			err = s.Data.createShare(userID, lang, nonce, expiresAt)
+		}
+	}
+	if err != nil {
+		code, userMsg := errorx.UnwrapError(err, lang)
+		s.Error.Log(errorx.AddContext(err))
+		s.Error.Render(w, r, code, userMsg, lang)
+		return
+	}
+	s.renderSharesContent(w, r, userID, lang)
+}
+
+func (s Server) shareRevocation(w http.ResponseWriter, r *http.Request) {
+	lang := language.FromContext(r.Context())
+	t := texts[lang]
+	userID := s.Auth.UserID(r)
+	shareID, err := strconv.Atoi(r.PathValue(shareIDParam))
+	if err != nil {
+		s.Error.Log(errorx.AddContext(fmt.Errorf("unable to parse share ID to int: %w", err)))
+		s.Error.Render(w, r, http.StatusBadRequest, t.errInvalidShareID, lang)
+		return
+	}
+	if err = s.Data.revokeShare(userID, lang, shareID); err != nil {
+		code, userMsg := errorx.UnwrapError(err, lang)
+		s.Error.Log(errorx.AddContext(err))
+		s.Error.Render(w, r, code, userMsg, lang)
+		return
+	}
+	s.renderSharesContent(w, r, userID, lang)
+}
+
+// shares returns active shares of the user with signed tokens.
+func (s Server) shares(userID string, lang language.Language) ([]share, error) {
+	shares, err := s.Data.shares(userID, lang)
+	if err != nil {
+		return nil, errorx.AddContext(err)
+	}
+	for i := range shares {
+		shares[i].token = s.Signer.token(shares[i].nonce, shares[i].expiresAt)
+	}
+	return shares, nil
+}
+
+func (s Server) renderSharesContent(w http.ResponseWriter, r *http.Request, userID string, lang language.Language) {
+	t := texts[lang]
+	shares, err := s.shares(userID, lang)
+	if err != nil {
+		code, userMsg := errorx.UnwrapError(err, lang)
+		s.Error.Log(errorx.AddContext(err))
+		s.Error.Render(w, r, code, userMsg, lang)
+		return
+	}
+	err = SharesContent(shares, r.Host, t).Render(r.Context(), w)
+	if err != nil {
+		s.Error.CannotRenderPage(w, r, t.sharesTitle, userID, errorx.AddContext(err), lang)
+	}
+}
+
+func parseShareDays(r *http.Request) (int, error) {
+	lang := language.FromContext(r.Context())
+	t := texts[lang]
+	daysString := r.FormValue(shareDaysParam)
+	if daysString == "" {
+		return defaultShareDays, nil
+	}
+	days, err := strconv.Atoi(daysString)
+	if err != nil || days < 1 || days > maxShareDays {
+		return 0, errorx.NewHTTPErr(
+			errorx.AddContext(fmt.Errorf("invalid share expiration %q", daysString)),
+			http.StatusBadRequest,
+			fmt.Sprintf(t.errInvalidShareDays, maxShareDays),
+		)
+	}
+	return days, nil
+}
@@ -1,8 +1,10 @@
 package blueprint
 
 import (
+	"fmt"
 	"strconv"
 
+	"github.com/michalhercik/RecSIS/academicyear"
 	"github.com/michalhercik/RecSIS/language"
 )
 
@@ -26,6 +28,137 @@ type text struct {
 	yearBig          string
 	semester         string
 	numOfYears       string
+	// scenarios
+	scenarios             string
+	scenarioName          string
+	newScenario           string
+	cloneScenario         string
+	cloneScenarioName     string
+	renameScenario        string
+	confirmDeleteScenario string
+	save                  string
+	ttNewScenario         string
+	ttCloneScenario       string
+	ttRenameScenario      string
+	ttDeleteScenario      string
+	// export/import
+	export         string
+	exportJSON     string
+	exportCSV      string
+	exportPDF      string
+	importTitle    string
+	importFile     string
+	importFileHelp string
+	importMerge    string
+	importReplace  string
+	importSubmit   string
+	ttExport       string
+	ttImport       string
+	// course status
+	status             string
+	statusPlanned      string
+	statusEnrolled     string
+	statusPassed       string
+	statusFailed       string
+	grade              string
+	noGrade            string
+	completedAt        string
+	gradeAverage       string
+	ttStatus           string
+	ttCompletedCredits string
+	ttSemesterAverage  string
+	ttYearAverage      string
+	ttOverallAverage   string
+	// printable export
+	pdfScenario string
+	pdfCreated  string
+	pdfSummary  string
+	pdfHours    string
+	pdfWarning  string
+	// custom courses
+	customCourse       string
+	addCustomCourse    string
+	editCustomCourse   string
+	customTitle        string
+	customCredits      string
+	customSemester     string
+	customMapping      string
+	customMappingHelp  string
+	customMappedTo     string
+	ttAddCustomCourse  string
+	ttEditCustomCourse string
+	// auto-schedule
+	autoSchedule             string
+	ttAutoSchedule           string
+	scheduleSource           string
+	scheduleSourceUnassigned string
+	scheduleSourceDegreePlan string
+	creditCap                string
+	scheduleYears            string
+	scheduleYearsHelp        string
+	schedulePreview          string
+	scheduleAccept           string
+	scheduleReject           string
+	scheduleNothing          string
+	scheduleSummary          string
+	scheduleNew              string
+	scheduleMoved            string
+	scheduleUnplaced         string
+	sCreditsOverCap          string
+	sMissingRequisites       string
+	sRequisiteCycle          string
+	sBlockedBy               string
+	sTooFewSemesters         string
+	// workload
+	workload           string
+	ttWorkload         string
+	workloadTarget     string
+	workloadHelp       string
+	workloadShow       string
+	workloadHours      string
+	workloadExams      string
+	workloadCreditOnly string
+	workloadAverage    string
+	workloadOtherUnits string
+	workloadEmpty      string
+	workloadFlagged    string
+	wlAboveTarget      string
+	wlBelowTarget      string
+	wlAboveAverage     string
+	wlBelowAverage     string
+	// change impact
+	impactTitle            string
+	impactNone             string
+	impactNewWarnings      string
+	impactResolvedWarnings string
+	impactBlocs            string
+	impactBlocCredits      string
+	impactConfirm          string
+	// history
+	history           string
+	noHistory         string
+	undone            string
+	restore           string
+	ttUndo            string
+	ttRedo            string
+	ttHistory         string
+	ttRestore         string
+	hMoveCourses      string
+	hUnassignSemester string
+	hRemoveCourses    string
+	hAddYear          string
+	hRemoveYear       string
+	hRestore          string
+	hImport           string
+	hSetStatus        string
+	hAutoSchedule     string
+	hAddCourses       string
+	hMergeDegreePlan  string
+	hRewriteBlueprint string
+	hApplyFix         string
+	hApplyPlanDiff    string
+	hAddCustomCourse  string
+	hEditCustomCourse string
 	// modal
 	modalTitle      string
 	modalContent    string
@@ -49,6 +182,7 @@ type text struct {
 	ttRemoveSummer     string
 	ttAssignedCredits  string
 	ttBlueprintCredits string
+	ttCompare          string
 	// stats
 	ttNumberOfCredits string
 	ttSemesterCredits string
@@ -63,8 +197,42 @@ type text struct {
 	wPrerequisiteNotMet    string
 	wCorequisiteNotMet     string
 	wIncompatiblePresent   string
+	wOutsideValidity       string
+	wNotTaught             string
+	validUntil             string
+	// fixes
+	fMove   string
+	fAdd    string
+	fRemove string
+	ttFixes string
+	// shares
+	sharedTitle        string
+	sharedNotice       string
+	sharesTitle        string
+	sharesIntro        string
+	ttShares           string
+	shareDays          string
+	shareCreate        string
+	shareLink          string
+	shareCreated       string
+	shareExpires       string
+	shareCopy          string
+	shareRevoke        string
+	confirmRevokeShare string
+	noShares           string
+	backToBlueprint    string
+	// concurrent changes
+	staleTitle   string
+	staleNotice  string
+	staleChanges string
+	// start year
+	startYearLabel   string
+	startYearUnknown string
+	ttStartYear      string
 	// language
 	language language.Language
+	// startYear is the year the user started studying, 0 if unknown
+	startYear int
 	// errors
 	errCannotGetBlueprint             string
 	errNoSemestersFound               string
@@ -96,18 +264,190 @@ type text struct {
 	errMissingPositionParam           string
 	errInvalidPositionParam           string
 	errPageNotFound                   string
+	errCannotCreateScenario           string
+	errCannotCloneScenario            string
+	errCannotRenameScenario           string
+	errCannotDeleteScenario           string
+	errCannotActivateScenario         string
+	errCannotDeleteLastScenario       string
+	errDuplicateScenarioName          string
+	errScenarioNotFound               string
+	errMissingScenarioID              string
+	errInvalidScenarioID              string
+	errMissingScenarioName            string
+	errScenarioNameTooLong            string
+	errCannotUndo                     string
+	errCannotRedo                     string
+	errNothingToUndo                  string
+	errNothingToRedo                  string
+	errCannotRestoreHistory           string
+	errHistoryNotFound                string
+	errInvalidHistoryID               string
+	errInvalidExportFormat            string
+	errCannotExport                   string
+	errInvalidImportMode              string
+	errMissingImportFile              string
+	errEmptyImport                    string
+	errInvalidImportFile              string
+	errInvalidImportLine              string
+	errUnsupportedImportVersion       string
+	errDuplicateImportCodes           string
+	errUnknownImportCodes             string
+	errCannotImport                   string
+	errCannotSetStatus                string
+	errInvalidStatusParam             string
+	errInvalidGradeParam              string
+	errInvalidCompletedParam          string
+	errCannotSchedule                 string
+	errInvalidScheduleSource          string
+	errInvalidCreditCap               string
+	errInvalidScheduleYears           string
+	errInvalidWorkloadTarget          string
+	errInvalidWorkloadFormat          string
+	errCannotPreview                  string
+	errInvalidDryRunParam             string
+	errInvalidPlacementParam          string
+	errCannotApplyFix                 string
+	errInvalidCustomTitle             string
+	errInvalidCustomCredits           string
+	errInvalidCustomSemester          string
+	errUnknownMappedCourse            string
+	errCannotSaveCustomCourse         string
+	errCustomCourseNotFound           string
+	errInvalidFixParam                string
+	errFixOutdated                    string
+	errShareNotFound                  string
+	errCannotGetShares                string
+	errCannotCreateShare              string
+	errCannotSetStartYear             string
+	errInvalidStartYear               string
+	errBlueprintChanged               string
+	errInvalidVersion                 string
+	errCannotRevokeShare              string
+	errTooManyShares                  string
+	errInvalidShareDays               string
+	errInvalidShareID                 string
+}
+
+// withStartYear returns texts which label blueprint years with their
+// academic years, e.g. "1. ročník (2025/26)".
+func (t text) withStartYear(startYear int) text {
+	t.startYear = startYear
+	return t
 }
 
 func (t text) yearStr(year int) string {
+	var result string
 	switch t.language {
 	case language.CS:
-		return strconv.Itoa(year) + ". " + t.year
+		result = strconv.Itoa(year) + ". " + t.year
 	case language.EN:
-		return t.year + " " + strconv.Itoa(year)
+		result = t.year + " " + strconv.Itoa(year)
+	}
+	if label := academicyear.PositionLabel(t.startYear, year); label != "" {
+		result += " (" + label + ")"
+	}
+	return result
+}
+
+// validityStr returns the academic years in which a course can be taken,
+// e.g. "2019/20 – 2023/24".
+func (t text) validityStr(c course) string {
+	if c.validTo >= academicyear.Unlimited {
+		return fmt.Sprintf(t.validUntil, academicyear.Label(c.validFrom))
+	}
+	return academicyear.Label(c.validFrom) + " – " + academicyear.Label(c.validTo)
+}
+
+func (t text) semesterStr(year int, semester semesterAssignment) string {
+	switch semester {
+	case assignmentWinter:
+		return t.yearStr(year) + " " + t.winter
+	case assignmentSummer:
+		return t.yearStr(year) + " " + t.summer
+	}
+	return t.yearStr(year)
+}
+
+// gradeInfo returns completed credits and grade averages of a semester and
+// its year formatted as additional lines of the semester credits popover.
+func (t text) gradeInfo(s semester, ay academicYear) string {
+	return fmt.Sprintf("<br>%s: %d<br>%s: %s<br>%s: %s",
+		t.ttCompletedCredits, s.completedCredits(),
+		t.ttSemesterAverage, s.grades().string(),
+		t.ttYearAverage, ay.grades().string(),
+	)
+}
+
+// fixStr describes a fix, e.g. "Move NPRG031 to Year 1 Winter".
+func (t text) fixStr(f fix) string {
+	location := t.unassigned
+	if f.year > 0 {
+		location = t.semesterStr(f.year, f.semester)
+	}
+	switch f.action {
+	case fixMove:
+		return fmt.Sprintf(t.fMove, f.code, location)
+	case fixAdd:
+		return fmt.Sprintf(t.fAdd, f.code, location)
+	case fixRemove:
+		return fmt.Sprintf(t.fRemove, f.code, location)
 	}
 	return ""
 }
 
+func (t text) historyActionStr(action historyAction) string {
+	switch action {
+	case actionMoveCourses:
+		return t.hMoveCourses
+	case actionUnassignSemester:
+		return t.hUnassignSemester
+	case actionRemoveCourses:
+		return t.hRemoveCourses
+	case actionAddYear:
+		return t.hAddYear
+	case actionRemoveYear:
+		return t.hRemoveYear
+	case actionRestore:
+		return t.hRestore
+	case actionImport:
+		return t.hImport
+	case actionSetStatus:
+		return t.hSetStatus
+	case actionAutoSchedule:
+		return t.hAutoSchedule
+	case actionAddCourses:
+		return t.hAddCourses
+	case actionMergeDegreePlan:
+		return t.hMergeDegreePlan
+	case actionRewriteBlueprint:
+		return t.hRewriteBlueprint
+	case actionApplyFix:
+		return t.hApplyFix
+	case actionApplyPlanDiff:
+		return t.hApplyPlanDiff
+	case actionAddCustomCourse:
+		return t.hAddCustomCourse
+	case actionEditCustomCourse:
+		return t.hEditCustomCourse
+	}
+	return string(action)
+}
+
+func (t text) workloadFlagStr(flag workloadFlag) string {
+	switch flag {
+	case flagAboveTarget:
+		return t.wlAboveTarget
+	case flagBelowTarget:
+		return t.wlBelowTarget
+	case flagAboveAverage:
+		return t.wlAboveAverage
+	case flagBelowAverage:
+		return t.wlBelowAverage
+	}
+	return string(flag)
+}
+
 var texts = map[language.Language]text{
 	language.CS: {
 		pageTitle:        "Blueprint",
@@ -129,6 +469,137 @@ var texts = map[language.Language]text{
 		yearBig:          "Ročník",
 		semester:         "Semestr",
 		numOfYears:       "Počet ročníků",
+		// scenarios
+		scenarios:             "Scénáře",
+		scenarioName:          "Název scénáře",
+		newScenario:           "Nový scénář",
+		cloneScenario:         "Duplikovat scénář",
+		cloneScenarioName:     "%s (kopie)",
+		renameScenario:        "Přejmenovat scénář",
+		confirmDeleteScenario: "Opravdu chcete odstranit scénář „%s“?",
+		save:                  "Uložit",
+		ttNewScenario:         "Vytvořit nový prázdný scénář",
+		ttCloneScenario:       "Vytvořit kopii aktuálního scénáře",
+		ttRenameScenario:      "Přejmenovat aktuální scénář",
+		ttDeleteScenario:      "Odstranit aktuální scénář",
+		// export/import
+		export:         "Exportovat",
+		exportJSON:     "JSON (záloha)",
+		exportCSV:      "CSV (tabulka)",
+		exportPDF:      "PDF (k tisku)",
+		importTitle:    "Importovat Blueprint",
+		importFile:     "Soubor JSON nebo CSV",
+		importFileHelp: "Soubor vytvořený exportem z RecSISu.",
+		importMerge:    "Sloučit s aktuálním Blueprintem",
+		importReplace:  "Nahradit aktuální Blueprint",
+		importSubmit:   "Importovat",
+		ttExport:       "Exportovat Blueprint",
+		ttImport:       "Importovat Blueprint ze souboru",
+		// course status
+		status:             "Stav",
+		statusPlanned:      "Plánovaný",
+		statusEnrolled:     "Zapsaný",
+		statusPassed:       "Splněný",
+		statusFailed:       "Nesplněný",
+		grade:              "Známka",
+		noGrade:            "Bez známky",
+		completedAt:        "Datum splnění",
+		gradeAverage:       "Vážený průměr",
+		ttStatus:           "Změnit stav předmětu",
+		ttCompletedCredits: "Získané kredity",
+		ttSemesterAverage:  "Vážený průměr semestru",
+		ttYearAverage:      "Vážený průměr ročníku",
+		ttOverallAverage:   "Celkový vážený průměr (váhou jsou kredity)",
+		// printable export
+		pdfScenario: "Scénář: %s",
+		pdfCreated:  "Vytvořeno %s v RecSISu",
+		pdfSummary:  "Zařazené kredity: %d, získané kredity: %d, vážený průměr: %s",
+		pdfHours:    "Rozsah, zakončení",
+		pdfWarning:  "Upozornění: ",
+		// custom courses
+		customCourse:       "vlastní",
+		addCustomCourse:    "Přidat vlastní předmět",
+		editCustomCourse:   "Upravit vlastní předmět",
+		customTitle:        "Název",
+		customCredits:      "Kredity",
+		customSemester:     "Semestr výuky",
+		customMapping:      "Nahrazuje předmět (nepovinné)",
+		customMappingHelp:  "Kód předmětu SIS, který tento předmět nahrazuje, např. NPRG030. Předmět se pak započítá do plnění skupin studijního plánu místo něj nebo místo předmětů s ním zaměnitelných.",
+		customMappedTo:     "nahrazuje",
+		ttAddCustomCourse:  "Přidat předmět, který není v SIS (např. Erasmus nebo jiná fakulta)",
+		ttEditCustomCourse: "Upravit vlastní předmět",
+		// auto-schedule
+		autoSchedule:             "Automatické rozvržení",
+		ttAutoSchedule:           "Automaticky rozvrhnout předměty do semestrů",
+		scheduleSource:           "Rozvrhnout",
+		scheduleSourceUnassigned: "Nezařazené předměty",
+		scheduleSourceDegreePlan: "Předměty studijního plánu",
+		creditCap:                "Maximum kreditů za semestr",
+		scheduleYears:            "Počet ročníků",
+		scheduleYearsHelp:        "Chybějící ročníky budou přidány.",
+		schedulePreview:          "Zobrazit návrh",
+		scheduleAccept:           "Přijmout",
+		scheduleReject:           "Zamítnout",
+		scheduleNothing:          "Není co rozvrhnout. Všechny předměty jsou již zařazené.",
+		scheduleSummary:          "Bude rozvrženo %d předmětů (%d kreditů).",
+		scheduleNew:              "nový",
+		scheduleMoved:            "z nezařazených",
+		scheduleUnplaced:         "Nelze rozvrhnout",
+		sCreditsOverCap:          "předmět má %d kreditů, což překračuje limit %d kreditů za semestr",
+		sMissingRequisites:       "vyžaduje předměty, které nejsou v Blueprintu ani mezi rozvrhovanými: %s",
+		sRequisiteCycle:          "rekvizity tvoří cyklus: %s",
+		sBlockedBy:               "čeká na nerozvržené předměty: %s",
+		sTooFewSemesters:         "nevejde se do %d ročníků s limitem %d kreditů za semestr, přidejte ročník nebo zvyšte limit",
+		// workload
+		workload:           "Studijní zátěž",
+		ttWorkload:         "Zobrazit studijní zátěž po semestrech",
+		workloadTarget:     "Cíl kreditů za semestr",
+		workloadHelp:       "Označeny jsou semestry, které se od cíle nebo od průměru liší o více než %d %%.",
+		workloadShow:       "Přepočítat",
+		workloadHours:      "Hodin týdně",
+		workloadExams:      "Zkoušky",
+		workloadCreditOnly: "Jen zápočty",
+		workloadAverage:    "Průměr kreditů za neprázdný semestr: %.1f",
+		workloadOtherUnits: "Rozsah %d předmětů není v hodinách týdně a není započten.",
+		workloadEmpty:      "Blueprint nemá žádné ročníky.",
+		workloadFlagged:    "Počet semestrů s neobvyklou zátěží: %d",
+		wlAboveTarget:      "výrazně nad cílem",
+		wlBelowTarget:      "výrazně pod cílem",
+		wlAboveAverage:     "výrazně nad průměrem",
+		wlBelowAverage:     "výrazně pod průměrem",
+		// change impact
+		impactTitle:            "Důsledky změny",
+		impactNone:             "Změna nemá žádné důsledky.",
+		impactNewWarnings:      "Nová upozornění",
+		impactResolvedWarnings: "Vyřešená upozornění",
+		impactBlocs:            "Skupiny studijního plánu, které klesnou pod limit",
+		impactBlocCredits:      "%d → %d z %d kreditů",
+		impactConfirm:          "Provést změnu",
+		// history
+		history:           "Historie změn",
+		noHistory:         "Zatím nebyly provedeny žádné změny.",
+		undone:            "vráceno",
+		restore:           "Obnovit",
+		ttUndo:            "Vrátit poslední změnu",
+		ttRedo:            "Zopakovat vrácenou změnu",
+		ttHistory:         "Historie změn",
+		ttRestore:         "Obnovit stav před touto změnou",
+		hMoveCourses:      "Přesun předmětů",
+		hUnassignSemester: "Přesun předmětů do nezařazených",
+		hRemoveCourses:    "Odstranění předmětů",
+		hAddYear:          "Přidání ročníku",
+		hRemoveYear:       "Odstranění ročníku",
+		hRestore:          "Obnovení dřívějšího stavu",
+		hImport:           "Import ze souboru",
+		hSetStatus:        "Změna stavu předmětu",
+		hAutoSchedule:     "Automatické rozvržení",
+		hAddCourses:       "Přidání předmětů",
+		hMergeDegreePlan:  "Sloučení s doporučeným průchodem",
+		hRewriteBlueprint: "Přepsání doporučeným průchodem",
+		hApplyFix:         "Oprava upozornění",
+		hApplyPlanDiff:    "Rozdíly oproti doporučenému průběhu",
+		hAddCustomCourse:  "Přidání vlastního předmětu",
+		hEditCustomCourse: "Úprava vlastního předmětu",
 		// modal
 		modalTitle:      "Chystáte se odstranit neprázdný ročník",
 		modalContent:    "Ročník, který se chystáte odstranit, obsahuje předměty. Můžete je odstranit, přesunout do nezařazených nebo tuto akci zrušit.",
@@ -152,6 +623,7 @@ var texts = map[language.Language]text{
 		ttRemoveSummer:     "Odstranit všechny předměty z tohoto letního semestru",
 		ttAssignedCredits:  "Počet kreditů přiřazených do ročníků",
 		ttBlueprintCredits: "Počet kreditů v blueprintu",
+		ttCompare:          "Přidat předmět do porovnání",
 		// stats
 		ttNumberOfCredits: "Počet kreditů",
 		ttSemesterCredits: "V tomto semestru",
@@ -163,9 +635,41 @@ var texts = map[language.Language]text{
 		wWrongAssignSummer:     "Předmět je zařazen do letního semestru, ale měl by být v zimním semestru.",
 		wUnassignedButAssigned: "Předmět už je zařazen ",
 		wAssignedMoreThanOnce:  "Předmět je zařazen více než jednou ",
-		wPrerequisiteNotMet:    "Nesplněna prerekvizita pro tento předmět.",
-		wCorequisiteNotMet:     "Nesplněna korekvizita pro tento předmět.",
-		wIncompatiblePresent:   "V blueprintu je přítomen neslučitelný předmět.",
+		wPrerequisiteNotMet:    "Nesplněna prerekvizita pro tento předmět (%s).",
+		wCorequisiteNotMet:     "Nesplněna korekvizita pro tento předmět (%s).",
+		wIncompatiblePresent:   "V blueprintu je přítomen neslučitelný předmět (%s).",
+		wOutsideValidity:       "Předmět v akademickém roce %s neexistuje (platnost %s).",
+		wNotTaught:             "Předmět se nevyučuje (%s).",
+		validUntil:             "od %s",
+		// fixes
+		fMove:   "Přesunout %s do: %s",
+		fAdd:    "Přidat %s do: %s",
+		fRemove: "Odebrat %s z: %s",
+		ttFixes: "Navrhované opravy",
+		// shares
+		sharedTitle:        "Sdílený blueprint",
+		sharedNotice:       "Sdílený blueprint pouze pro čtení",
+		sharesTitle:        "Sdílení blueprintu",
+		sharesIntro:        "Odkaz zobrazí aktuálně aktivní scénář vašeho blueprintu pouze pro čtení a bez přihlášení. Odkaz neobsahuje vaše SIS ID. Platnost odkazu můžete kdykoli zrušit.",
+		ttShares:           "Sdílet odkazem",
+		shareDays:          "Platnost (dny)",
+		shareCreate:        "Vytvořit odkaz",
+		shareLink:          "Odkaz",
+		shareCreated:       "Vytvořeno",
+		shareExpires:       "Platí do",
+		shareCopy:          "Kopírovat",
+		shareRevoke:        "Zrušit",
+		confirmRevokeShare: "Opravdu chcete zrušit tento odkaz? Kdo ho má, už blueprint neuvidí.",
+		noShares:           "Nemáte žádné aktivní odkazy.",
+		backToBlueprint:    "Zpět na blueprint",
+		// concurrent changes
+		staleTitle:   "Blueprint byl změněn jinde",
+		staleNotice:  "Vaše poslední úprava nebyla provedena, protože blueprint byl mezitím změněn v jiném okně nebo zařízení. Zobrazuje se aktuální stav, úpravu můžete zopakovat.",
+		staleChanges: "Změny od posledního načtení:",
+		// start year
+		startYearLabel:   "Začátek studia",
+		startYearUnknown: "neznámý",
+		ttStartYear:      "Akademický rok, ve kterém jste začali studovat. Ročníky pak odpovídají skutečným akademickým rokům.",
 		// language
 		language: language.CS,
 		// errors
@@ -199,6 +703,69 @@ var texts = map[language.Language]text{
 		errMissingPositionParam:           "Chybí parametr pozice",
 		errInvalidPositionParam:           "Neplatný parametr pozice (musí být celé kladné číslo větší nebo rovno -1)",
 		errPageNotFound:                   "Stránka nenalezena",
+		errCannotCreateScenario:           "Nelze vytvořit scénář",
+		errCannotCloneScenario:            "Nelze duplikovat scénář",
+		errCannotRenameScenario:           "Nelze přejmenovat scénář",
+		errCannotDeleteScenario:           "Nelze odstranit scénář",
+		errCannotActivateScenario:         "Nelze přepnout scénář",
+		errCannotDeleteLastScenario:       "Nelze odstranit jediný scénář",
+		errDuplicateScenarioName:          "Scénář s tímto názvem již existuje",
+		errScenarioNotFound:               "Scénář nebyl nalezen",
+		errMissingScenarioID:              "Chybí ID scénáře",
+		errInvalidScenarioID:              "Neplatné ID scénáře (musí být celé kladné číslo)",
+		errMissingScenarioName:            "Chybí název scénáře",
+		errScenarioNameTooLong:            "Název scénáře je příliš dlouhý (maximálně 100 znaků)",
+		errCannotUndo:                     "Nelze vrátit poslední změnu",
+		errCannotRedo:                     "Nelze zopakovat vrácenou změnu",
+		errNothingToUndo:                  "Není co vrátit",
+		errNothingToRedo:                  "Není co zopakovat",
+		errCannotRestoreHistory:           "Nelze obnovit dřívější stav",
+		errHistoryNotFound:                "Záznam v historii nebyl nalezen",
+		errInvalidHistoryID:               "Neplatné ID záznamu v historii (musí být celé kladné číslo)",
+		errInvalidExportFormat:            "Neplatný formát exportu (podporován je json, csv nebo pdf)",
+		errCannotExport:                   "Nelze exportovat Blueprint",
+		errInvalidImportMode:              "Neplatný režim importu (podporován je merge nebo replace)",
+		errMissingImportFile:              "Chybí soubor k importu nebo je příliš velký",
+		errEmptyImport:                    "Soubor k importu je prázdný",
+		errInvalidImportFile:              "Soubor k importu nemá platný formát",
+		errInvalidImportLine:              "Neplatný řádek %d v souboru k importu",
+		errUnsupportedImportVersion:       "Nepodporovaná verze souboru k importu (%d)",
+		errDuplicateImportCodes:           "Soubor obsahuje předměty zařazené vícekrát do stejného semestru: %s",
+		errUnknownImportCodes:             "Soubor obsahuje neznámé předměty: %s",
+		errCannotImport:                   "Nelze importovat Blueprint",
+		errCannotSetStatus:                "Nelze změnit stav předmětu",
+		errInvalidStatusParam:             "Neplatný stav předmětu (planned, enrolled, passed nebo failed)",
+		errInvalidGradeParam:              "Neplatná známka (musí být celé číslo 1 až 4)",
+		errInvalidCompletedParam:          "Neplatné datum splnění (formát RRRR-MM-DD)",
+		errCannotSchedule:                 "Nelze automaticky rozvrhnout předměty",
+		errInvalidScheduleSource:          "Neplatný zdroj předmětů k rozvržení",
+		errInvalidCreditCap:               "Neplatný limit kreditů (musí být celé číslo 1 až %d)",
+		errInvalidScheduleYears:           "Neplatný počet ročníků (musí být celé číslo 1 až %d)",
+		errInvalidWorkloadTarget:          "Neplatný cíl kreditů (musí být celé číslo 1 až %d)",
+		errInvalidWorkloadFormat:          "Neplatný formát (podporován je pouze json)",
+		errCannotPreview:                  "Nelze zjistit důsledky změny",
+		errInvalidDryRunParam:             "Neplatný parametr dry-run (musí být true nebo false)",
+		errInvalidPlacementParam:          "Neplatný návrh rozvržení, zobrazte návrh znovu",
+		errCannotApplyFix:                 "Nelze provést opravu",
+		errInvalidCustomTitle:             "Název předmětu je povinný a může mít nejvýše %d znaků",
+		errInvalidCustomCredits:           "Kredity musí být celé číslo od 0 do %d",
+		errInvalidCustomSemester:          "Neplatný semestr výuky",
+		errUnknownMappedCourse:            "Nahrazovaný předmět v SIS neexistuje",
+		errCannotSaveCustomCourse:         "Nelze uložit vlastní předmět",
+		errCustomCourseNotFound:           "Vlastní předmět nebyl nalezen",
+		errInvalidFixParam:                "Neplatná oprava",
+		errFixOutdated:                    "Blueprint se mezitím změnil, oprava už neplatí",
+		errShareNotFound:                  "Odkaz je neplatný, vypršel nebo byl zrušen",
+		errCannotGetShares:                "Nelze načíst sdílené odkazy",
+		errCannotCreateShare:              "Nelze vytvořit odkaz",
+		errCannotSetStartYear:             "Nelze nastavit začátek studia",
+		errInvalidStartYear:               "Začátek studia musí být rok mezi %d a %d",
+		errBlueprintChanged:               "Blueprint byl mezitím změněn v jiném okně",
+		errInvalidVersion:                 "Neplatná verze blueprintu",
+		errCannotRevokeShare:              "Nelze zrušit odkaz",
+		errTooManyShares:                  "Můžete mít nejvýše %d aktivních odkazů",
+		errInvalidShareDays:               "Platnost odkazu musí být 1 až %d dní",
+		errInvalidShareID:                 "Neplatné ID odkazu",
 	},
 	language.EN: {
 		pageTitle:        "Blueprint",
@@ -220,6 +787,137 @@ var texts = map[language.Language]text{
 		yearBig:          "Year",
 		semester:         "Semester",
 		numOfYears:       "Number of years",
+		// scenarios
+		scenarios:             "Scenarios",
+		scenarioName:          "Scenario name",
+		newScenario:           "New scenario",
+		cloneScenario:         "Duplicate scenario",
+		cloneScenarioName:     "%s (copy)",
+		renameScenario:        "Rename scenario",
+		confirmDeleteScenario: "Do you really want to delete scenario \"%s\"?",
+		save:                  "Save",
+		ttNewScenario:         "Create a new empty scenario",
+		ttCloneScenario:       "Create a copy of the current scenario",
+		ttRenameScenario:      "Rename the current scenario",
+		ttDeleteScenario:      "Delete the current scenario",
+		// export/import
+		export:         "Export",
+		exportJSON:     "JSON (backup)",
+		exportCSV:      "CSV (spreadsheet)",
+		exportPDF:      "PDF (printable)",
+		importTitle:    "Import Blueprint",
+		importFile:     "JSON or CSV file",
+		importFileHelp: "A file created by RecSIS export.",
+		importMerge:    "Merge with the current Blueprint",
+		importReplace:  "Replace the current Blueprint",
+		importSubmit:   "Import",
+		ttExport:       "Export Blueprint",
+		ttImport:       "Import Blueprint from a file",
+		// course status
+		status:             "Status",
+		statusPlanned:      "Planned",
+		statusEnrolled:     "Enrolled",
+		statusPassed:       "Passed",
+		statusFailed:       "Failed",
+		grade:              "Grade",
+		noGrade:            "No grade",
+		completedAt:        "Completion date",
+		gradeAverage:       "Weighted average",
+		ttStatus:           "Change course status",
+		ttCompletedCredits: "Completed credits",
+		ttSemesterAverage:  "Semester weighted average",
+		ttYearAverage:      "Year weighted average",
+		ttOverallAverage:   "Overall weighted average (weighted by credits)",
+		// printable export
+		pdfScenario: "Scenario: %s",
+		pdfCreated:  "Created %s in RecSIS",
+		pdfSummary:  "Assigned credits: %d, completed credits: %d, weighted average: %s",
+		pdfHours:    "Hours, examination",
+		pdfWarning:  "Warning: ",
+		// custom courses
+		customCourse:       "custom",
+		addCustomCourse:    "Add custom course",
+		editCustomCourse:   "Edit custom course",
+		customTitle:        "Title",
+		customCredits:      "Credits",
+		customSemester:     "Teaching semester",
+		customMapping:      "Substitutes course (optional)",
+		customMappingHelp:  "Code of the SIS course this course substitutes, e.g. NPRG030. The course then counts toward degree plan groups in its place or in place of courses interchangeable with it.",
+		customMappedTo:     "substitutes",
+		ttAddCustomCourse:  "Add a course which is not in SIS (e.g. Erasmus or another faculty)",
+		ttEditCustomCourse: "Edit custom course",
+		// auto-schedule
+		autoSchedule:             "Auto-schedule",
+		ttAutoSchedule:           "Automatically schedule courses into semesters",
+		scheduleSource:           "Schedule",
+		scheduleSourceUnassigned: "Unassigned courses",
+		scheduleSourceDegreePlan: "Degree plan courses",
+		creditCap:                "Maximum credits per semester",
+		scheduleYears:            "Number of years",
+		scheduleYearsHelp:        "Missing years will be added.",
+		schedulePreview:          "Preview",
+		scheduleAccept:           "Accept",
+		scheduleReject:           "Reject",
+		scheduleNothing:          "Nothing to schedule. All courses are already assigned.",
+		scheduleSummary:          "%d courses (%d credits) will be scheduled.",
+		scheduleNew:              "new",
+		scheduleMoved:            "from unassigned",
+		scheduleUnplaced:         "Cannot be scheduled",
+		sCreditsOverCap:          "the course has %d credits which exceeds the limit of %d credits per semester",
+		sMissingRequisites:       "requires courses which are neither in the blueprint nor scheduled: %s",
+		sRequisiteCycle:          "requisites form a cycle: %s",
+		sBlockedBy:               "waits for unscheduled courses: %s",
+		sTooFewSemesters:         "does not fit into %d years with the limit of %d credits per semester, add a year or raise the limit",
+		// workload
+		workload:           "Workload",
+		ttWorkload:         "Show workload per semester",
+		workloadTarget:     "Target credits per semester",
+		workloadHelp:       "Semesters which differ from the target or the average by more than %d %% are flagged.",
+		workloadShow:       "Recompute",
+		workloadHours:      "Weekly hours",
+		workloadExams:      "Exams",
+		workloadCreditOnly: "Credit only",
+		workloadAverage:    "Average credits per non-empty semester: %.1f",
+		workloadOtherUnits: "Ranges of %d courses are not in weekly hours and are not included.",
+		workloadEmpty:      "The blueprint has no years.",
+		workloadFlagged:    "Semesters with unusual workload: %d",
+		wlAboveTarget:      "far above target",
+		wlBelowTarget:      "far below target",
+		wlAboveAverage:     "far above average",
+		wlBelowAverage:     "far below average",
+		// change impact
+		impactTitle:            "Consequences of the change",
+		impactNone:             "The change has no consequences.",
+		impactNewWarnings:      "New warnings",
+		impactResolvedWarnings: "Resolved warnings",
+		impactBlocs:            "Degree plan groups which drop below their limit",
+		impactBlocCredits:      "%d → %d of %d credits",
+		impactConfirm:          "Apply the change",
+		// history
+		history:           "History of changes",
+		noHistory:         "No changes have been made yet.",
+		undone:            "undone",
+		restore:           "Restore",
+		ttUndo:            "Undo the last change",
+		ttRedo:            "Redo the undone change",
+		ttHistory:         "History of changes",
+		ttRestore:         "Restore the state before this change",
+		hMoveCourses:      "Courses moved",
+		hUnassignSemester: "Courses unassigned",
+		hRemoveCourses:    "Courses removed",
+		hAddYear:          "Year added",
+		hRemoveYear:       "Year removed",
+		hRestore:          "Earlier state restored",
+		hImport:           "Imported from file",
+		hSetStatus:        "Course status changed",
+		hAutoSchedule:     "Auto-schedule",
+		hAddCourses:       "Courses added",
+		hMergeDegreePlan:  "Merged with recommended plan",
+		hRewriteBlueprint: "Rewritten with recommended plan",
+		hApplyFix:         "Warning fix",
+		hApplyPlanDiff:    "Differences from recommended plan",
+		hAddCustomCourse:  "Custom course added",
+		hEditCustomCourse: "Custom course edited",
 		// modal
 		modalTitle:      "You are about to remove a non-empty year",
 		modalContent:    "The year you are about to remove contains courses. You can remove them, unassign them or cancel this action.",
@@ -243,6 +941,7 @@ var texts = map[language.Language]text{
 		ttRemoveSummer:     "Remove all courses from this summer semester",
 		ttAssignedCredits:  "Number of credits assigned to years",
 		ttBlueprintCredits: "Number of credits in the blueprint",
+		ttCompare:          "Add course to comparison",
 		// stats
 		ttNumberOfCredits: "Number of credits",
 		ttSemesterCredits: "In this semester",
@@ -254,9 +953,41 @@ var texts = map[language.Language]text{
 		wWrongAssignSummer:     "Course is assigned in a summer semester (should be in winter).",
 		wUnassignedButAssigned: "Course is already assigned ",
 		wAssignedMoreThanOnce:  "Course is assigned more than once ",
-		wPrerequisiteNotMet:    "Prerequisite for this course is not met.",
-		wCorequisiteNotMet:     "Corequisite for this course is not met.",
-		wIncompatiblePresent:   "An incompatible course is present in the blueprint.",
+		wPrerequisiteNotMet:    "Prerequisite for this course is not met (%s).",
+		wCorequisiteNotMet:     "Corequisite for this course is not met (%s).",
+		wIncompatiblePresent:   "An incompatible course is present in the blueprint (%s).",
+		wOutsideValidity:       "The course does not exist in academic year %s (valid %s).",
+		wNotTaught:             "The course is not taught (%s).",
+		validUntil:             "from %s",
+		// fixes
+		fMove:   "Move %s to %s",
+		fAdd:    "Add %s to %s",
+		fRemove: "Remove %s from %s",
+		ttFixes: "Suggested fixes",
+		// shares
+		sharedTitle:        "Shared blueprint",
+		sharedNotice:       "Read-only shared blueprint",
+		sharesTitle:        "Blueprint sharing",
+		sharesIntro:        "A link shows the currently active scenario of your blueprint read-only and without login. The link does not contain your SIS ID. You can revoke a link at any time.",
+		ttShares:           "Share by link",
+		shareDays:          "Valid for (days)",
+		shareCreate:        "Create link",
+		shareLink:          "Link",
+		shareCreated:       "Created",
+		shareExpires:       "Expires",
+		shareCopy:          "Copy",
+		shareRevoke:        "Revoke",
+		confirmRevokeShare: "Do you really want to revoke this link? Whoever has it will no longer see your blueprint.",
+		noShares:           "You have no active links.",
+		backToBlueprint:    "Back to blueprint",
+		// concurrent changes
+		staleTitle:   "Blueprint was changed elsewhere",
+		staleNotice:  "Your last change was not applied because the blueprint has been changed in another window or device in the meantime. The current state is shown, you can repeat the change.",
+		staleChanges: "Changes since the last load:",
+		// start year
+		startYearLabel:   "Start of studies",
+		startYearUnknown: "unknown",
+		ttStartYear:      "Academic year in which you started studying. Blueprint years then match real academic years.",
 		// language
 		language: language.EN,
 		// errors
@@ -290,5 +1021,68 @@ var texts = map[language.Language]text{
 		errMissingPositionParam:           "Missing position parameter",
 		errInvalidPositionParam:           "Invalid position parameter (must be a positive integer greater than or equal to -1)",
 		errPageNotFound:                   "Page not found",
+		errCannotCreateScenario:           "Cannot create scenario",
+		errCannotCloneScenario:            "Cannot duplicate scenario",
+		errCannotRenameScenario:           "Cannot rename scenario",
+		errCannotDeleteScenario:           "Cannot delete scenario",
+		errCannotActivateScenario:         "Cannot switch scenario",
+		errCannotDeleteLastScenario:       "Cannot delete the only scenario",
+		errDuplicateScenarioName:          "A scenario with this name already exists",
+		errScenarioNotFound:               "Scenario not found",
+		errMissingScenarioID:              "Missing scenario ID",
+		errInvalidScenarioID:              "Invalid scenario ID (must be a positive integer)",
+		errMissingScenarioName:            "Missing scenario name",
+		errScenarioNameTooLong:            "Scenario name is too long (at most 100 characters)",
+		errCannotUndo:                     "Cannot undo the last change",
+		errCannotRedo:                     "Cannot redo the undone change",
+		errNothingToUndo:                  "Nothing to undo",
+		errNothingToRedo:                  "Nothing to redo",
+		errCannotRestoreHistory:           "Cannot restore the earlier state",
+		errHistoryNotFound:                "History entry not found",
+		errInvalidHistoryID:               "Invalid history entry ID (must be a positive integer)",
+		errInvalidExportFormat:            "Invalid export format (json, csv or pdf is supported)",
+		errCannotExport:                   "Cannot export Blueprint",
+		errInvalidImportMode:              "Invalid import mode (merge or replace is supported)",
+		errMissingImportFile:              "The file to import is missing or too large",
+		errEmptyImport:                    "The file to import is empty",
+		errInvalidImportFile:              "The file to import has an invalid format",
+		errInvalidImportLine:              "Invalid line %d in the file to import",
+		errUnsupportedImportVersion:       "Unsupported version of the file to import (%d)",
+		errDuplicateImportCodes:           "The file contains courses assigned more than once to the same semester: %s",
+		errUnknownImportCodes:             "The file contains unknown courses: %s",
+		errCannotImport:                   "Cannot import Blueprint",
+		errCannotSetStatus:                "Cannot change course status",
+		errInvalidStatusParam:             "Invalid course status (planned, enrolled, passed or failed)",
+		errInvalidGradeParam:              "Invalid grade (must be an integer from 1 to 4)",
+		errInvalidCompletedParam:          "Invalid completion date (format YYYY-MM-DD)",
+		errCannotSchedule:                 "Cannot auto-schedule courses",
+		errInvalidScheduleSource:          "Invalid source of courses to schedule",
+		errInvalidCreditCap:               "Invalid credit limit (must be an integer from 1 to %d)",
+		errInvalidScheduleYears:           "Invalid number of years (must be an integer from 1 to %d)",
+		errInvalidWorkloadTarget:          "Invalid credit target (must be an integer from 1 to %d)",
+		errInvalidWorkloadFormat:          "Invalid format (only json is supported)",
+		errCannotPreview:                  "Unable to find out consequences of the change",
+		errInvalidDryRunParam:             "Invalid dry-run parameter (must be true or false)",
+		errInvalidPlacementParam:          "Invalid schedule, please preview it again",
+		errCannotApplyFix:                 "Cannot apply the fix",
+		errInvalidCustomTitle:             "Course title is required and can have at most %d characters",
+		errInvalidCustomCredits:           "Credits must be an integer from 0 to %d",
+		errInvalidCustomSemester:          "Invalid teaching semester",
+		errUnknownMappedCourse:            "The substituted course does not exist in SIS",
+		errCannotSaveCustomCourse:         "Cannot save the custom course",
+		errCustomCourseNotFound:           "Custom course not found",
+		errInvalidFixParam:                "Invalid fix",
+		errFixOutdated:                    "The blueprint has changed in the meantime, the fix no longer applies",
+		errShareNotFound:                  "The link is invalid, expired or revoked",
+		errCannotGetShares:                "Cannot load shared links",
+		errCannotCreateShare:              "Cannot create the link",
+		errCannotSetStartYear:             "Cannot set the start of studies",
+		errInvalidStartYear:               "The start of studies must be a year between %d and %d",
+		errBlueprintChanged:               "The blueprint has been changed in another window in the meantime",
+		errInvalidVersion:                 "Invalid blueprint version",
+		errCannotRevokeShare:              "Cannot revoke the link",
+		errTooManyShares:                  "You can have at most %d active links",
+		errInvalidShareDays:               "The link must be valid for 1 to %d days",
+		errInvalidShareID:                 "Invalid link ID",
 	},
 }
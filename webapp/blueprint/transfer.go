@@ -0,0 +1,483 @@
+package blueprint
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/michalhercik/RecSIS/errorx"
+)
+
+//================================================================================
+// Constants
+//================================================================================
+
+const transferVersion = 1
+
+const (
+	formatJSON string = "json"
+	formatCSV  string = "csv"
+)
+
+const (
+	importMerge   string = "merge"
+	importReplace string = "replace"
+)
+
+const maxImportSize = 1 << 20
+
+var csvHeader = []string{"year", "semester", "position", "code", "title", "credits", "folded", "status", "grade", "completed", "custom", "custom_title", "custom_semester", "mapped_code"}
+
+//================================================================================
+// Transfer Types
+//================================================================================
+
+// transferBlueprint is the versioned format used for export and import.
+// Course positions are 1-based and define the order within a semester.
+type transferBlueprint struct {
+	Version    int              `json:"version"`
+	Unassigned transferSemester `json:"unassigned"`
+	Years      []transferYear   `json:"years"`
+}
+
+type transferYear struct {
+	Year   int              `json:"year"`
+	Winter transferSemester `json:"winter"`
+	Summer transferSemester `json:"summer"`
+}
+
+type transferSemester struct {
+	Folded  bool             `json:"folded"`
+	Courses []transferCourse `json:"courses"`
+}
+
+type transferCourse struct {
+	Position int    `json:"position"`
+	Code     string `json:"code"`
+	Title    string `json:"title,omitempty"`
+	Credits  int    `json:"credits,omitempty"`
+	// Status is empty for planned courses.
+	Status    string `json:"status,omitempty"`
+	Grade     int    `json:"grade,omitempty"`
+	Completed string `json:"completed,omitempty"`
+	// Custom courses have no code. They are imported from CustomTitle,
+	// Credits, CustomSemester and the optional MappedCode.
+	Custom         bool   `json:"custom,omitempty"`
+	CustomTitle    string `json:"custom_title,omitempty"`
+	CustomSemester int    `json:"custom_semester,omitempty"`
+	MappedCode     string `json:"mapped_code,omitempty"`
+}
+
+// importCourse is a single course placement parsed from an import file.
+// Zero grade and empty completion date mean the value is not set. Custom
+// courses have empty code and are described by custom.
+type importCourse struct {
+	year      int
+	semester  semesterAssignment
+	position  int
+	code      string
+	status    courseStatus
+	grade     int
+	completed string
+	isCustom  bool
+	custom    customCourse
+}
+
+// importSemester holds folded state of a semester parsed from an import file.
+type importSemester struct {
+	year     int
+	semester semesterAssignment
+	folded   bool
+}
+
+type importData struct {
+	years     int
+	semesters []importSemester
+	courses   []importCourse
+}
+
+// codes returns codes of imported courses and courses custom courses are
+// mapped to, so that all of them are checked to exist.
+func (d importData) codes() []string {
+	codes := make([]string, 0, len(d.courses))
+	for _, c := range d.courses {
+		code := c.code
+		if c.isCustom {
+			code = c.custom.mappedCode
+		}
+		if code != "" && !slices.Contains(codes, code) {
+			codes = append(codes, code)
+		}
+	}
+	return codes
+}
+
+// duplicates returns codes placed more than once in the same semester.
+func (d importData) duplicates() []string {
+	type key struct {
+		year     int
+		semester semesterAssignment
+		code     string
+	}
+	seen := make(map[key]bool)
+	var result []string
+	for _, c := range d.courses {
+		if c.isCustom {
+			continue
+		}
+		k := key{c.year, c.semester, c.code}
+		if seen[k] && !slices.Contains(result, c.code) {
+			result = append(result, c.code)
+		}
+		seen[k] = true
+	}
+	return result
+}
+
+//================================================================================
+// Export
+//================================================================================
+
+func intoTransfer(bp *blueprintPage) transferBlueprint {
+	result := transferBlueprint{
+		Version:    transferVersion,
+		Unassigned: intoTransferSemester(bp.unassigned),
+		Years:      make([]transferYear, len(bp.years)),
+	}
+	for i, year := range bp.years {
+		result.Years[i] = transferYear{
+			Year:   year.position,
+			Winter: intoTransferSemester(year.winter),
+			Summer: intoTransferSemester(year.summer),
+		}
+	}
+	return result
+}
+
+func intoTransferSemester(s semester) transferSemester {
+	result := transferSemester{
+		Folded:  s.folded,
+		Courses: make([]transferCourse, len(s.courses)),
+	}
+	for i, c := range s.courses {
+		result.Courses[i] = transferCourse{
+			Position: i + 1,
+			Code:     c.code,
+			Title:    c.title,
+			Credits:  c.credits,
+		}
+		if c.status != statusPlanned {
+			result.Courses[i].Status = string(c.status)
+		}
+		if c.grade.Valid {
+			result.Courses[i].Grade = int(c.grade.Int64)
+		}
+		if c.completedAt.Valid {
+			result.Courses[i].Completed = c.completedAt.Time.Format(dateLayout)
+		}
+		if c.custom {
+			result.Courses[i].Custom = true
+			result.Courses[i].CustomTitle = c.title
+			result.Courses[i].CustomSemester = int(c.semester)
+			result.Courses[i].MappedCode = c.mappedCode
+		}
+	}
+	return result
+}
+
+func exportJSON(w io.Writer, bp *blueprintPage) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(intoTransfer(bp))
+}
+
+// exportCSV writes one row per course. Empty semesters are written as a row
+// without code so that the number of years and folded state are preserved.
+func exportCSV(w io.Writer, bp *blueprintPage) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(csvHeader); err != nil {
+		return err
+	}
+	writeSemester := func(year int, assignment semesterAssignment, s transferSemester) error {
+		folded := strconv.FormatBool(s.Folded)
+		if len(s.Courses) == 0 {
+			return writer.Write([]string{strconv.Itoa(year), strconv.Itoa(int(assignment)), "", "", "", "", folded, "", "", "", "", "", "", ""})
+		}
+		for _, c := range s.Courses {
+			grade := ""
+			if c.Grade != 0 {
+				grade = strconv.Itoa(c.Grade)
+			}
+			customSemester := ""
+			if c.Custom {
+				customSemester = strconv.Itoa(c.CustomSemester)
+			}
+			record := []string{
+				strconv.Itoa(year), strconv.Itoa(int(assignment)), strconv.Itoa(c.Position), c.Code, c.Title, strconv.Itoa(c.Credits), folded, c.Status, grade, c.Completed,
+				strconv.FormatBool(c.Custom), c.CustomTitle, customSemester, c.MappedCode,
+			}
+			if err := writer.Write(record); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	data := intoTransfer(bp)
+	if err := writeSemester(0, assignmentNone, data.Unassigned); err != nil {
+		return err
+	}
+	for _, year := range data.Years {
+		if err := writeSemester(year.Year, assignmentWinter, year.Winter); err != nil {
+			return err
+		}
+		if err := writeSemester(year.Year, assignmentSummer, year.Summer); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+//================================================================================
+// Import
+//================================================================================
+
+// parseImport decodes JSON or CSV content. The format is detected from the
+// first non-whitespace character.
+func parseImport(content []byte, t text) (importData, error) {
+	trimmed := bytes.TrimSpace(content)
+	if len(trimmed) == 0 {
+		return importData{}, errorx.NewHTTPErr(
+			errorx.AddContext(fmt.Errorf("import file is empty")),
+			http.StatusBadRequest,
+			t.errEmptyImport,
+		)
+	}
+	if trimmed[0] == '{' {
+		return parseImportJSON(trimmed, t)
+	}
+	return parseImportCSV(trimmed, t)
+}
+
+func parseImportJSON(content []byte, t text) (importData, error) {
+	var data transferBlueprint
+	if err := json.Unmarshal(content, &data); err != nil {
+		return importData{}, errorx.NewHTTPErr(
+			errorx.AddContext(fmt.Errorf("json.Unmarshal: %w", err)),
+			http.StatusBadRequest,
+			t.errInvalidImportFile,
+		)
+	}
+	if data.Version != transferVersion {
+		return importData{}, errorx.NewHTTPErr(
+			errorx.AddContext(fmt.Errorf("unsupported import version %d", data.Version)),
+			http.StatusBadRequest,
+			fmt.Sprintf(t.errUnsupportedImportVersion, data.Version),
+		)
+	}
+	var result importData
+	addSemester := func(year int, assignment semesterAssignment, s transferSemester) error {
+		result.semesters = append(result.semesters, importSemester{year, assignment, s.Folded})
+		for i, c := range s.Courses {
+			code := strings.TrimSpace(c.Code)
+			if code == "" && !c.Custom {
+				continue
+			}
+			position := c.Position
+			if position <= 0 {
+				position = i + 1
+			}
+			grade := ""
+			if c.Grade != 0 {
+				grade = strconv.Itoa(c.Grade)
+			}
+			course, ok := parseImportCourse(year, assignment, position, code, c.Status, grade, c.Completed)
+			if !ok {
+				return errorx.NewHTTPErr(
+					errorx.AddContext(fmt.Errorf("invalid course status"), errorx.P("code", code)),
+					http.StatusBadRequest,
+					t.errInvalidImportFile,
+				)
+			}
+			if c.Custom {
+				custom := customCourse{
+					title:      strings.TrimSpace(c.CustomTitle),
+					credits:    c.Credits,
+					semester:   teachingSemester(c.CustomSemester),
+					mappedCode: strings.TrimSpace(c.MappedCode),
+				}
+				if err := course.setCustom(custom, t); err != nil {
+					return err
+				}
+			}
+			result.courses = append(result.courses, course)
+		}
+		return nil
+	}
+	if err := addSemester(0, assignmentNone, data.Unassigned); err != nil {
+		return importData{}, err
+	}
+	for i, year := range data.Years {
+		if year.Year != i+1 {
+			return importData{}, errorx.NewHTTPErr(
+				errorx.AddContext(fmt.Errorf("years are not consecutive"), errorx.P("year", year.Year)),
+				http.StatusBadRequest,
+				t.errInvalidImportFile,
+			)
+		}
+		if err := addSemester(year.Year, assignmentWinter, year.Winter); err != nil {
+			return importData{}, err
+		}
+		if err := addSemester(year.Year, assignmentSummer, year.Summer); err != nil {
+			return importData{}, err
+		}
+	}
+	result.years = len(data.Years)
+	return result, nil
+}
+
+func parseImportCSV(content []byte, t text) (importData, error) {
+	reader := csv.NewReader(bytes.NewReader(content))
+	reader.FieldsPerRecord = -1
+	records, err := reader.ReadAll()
+	if err != nil {
+		return importData{}, errorx.NewHTTPErr(
+			errorx.AddContext(fmt.Errorf("csv.ReadAll: %w", err)),
+			http.StatusBadRequest,
+			t.errInvalidImportFile,
+		)
+	}
+	if len(records) > 0 && len(records[0]) > 0 && records[0][0] == csvHeader[0] {
+		records = records[1:]
+	}
+	var result importData
+	for i, record := range records {
+		invalid := errorx.NewHTTPErr(
+			errorx.AddContext(fmt.Errorf("invalid csv record"), errorx.P("line", i+2)),
+			http.StatusBadRequest,
+			fmt.Sprintf(t.errInvalidImportLine, i+2),
+		)
+		if len(record) < 4 {
+			return importData{}, invalid
+		}
+		year, err := strconv.Atoi(record[0])
+		if err != nil || year < 0 {
+			return importData{}, invalid
+		}
+		semesterInt, err := strconv.Atoi(record[1])
+		if err != nil || semesterInt < int(assignmentNone) || semesterInt > int(assignmentSummer) || (year == 0) != (semesterInt == int(assignmentNone)) {
+			return importData{}, invalid
+		}
+		assignment := semesterAssignment(semesterInt)
+		result.years = max(result.years, year)
+		if len(record) >= 7 && record[6] != "" {
+			folded, err := strconv.ParseBool(record[6])
+			if err != nil {
+				return importData{}, invalid
+			}
+			result.semesters = append(result.semesters, importSemester{year, assignment, folded})
+		}
+		field := func(i int) string {
+			if i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+		isCustom := false
+		if field(10) != "" {
+			if isCustom, err = strconv.ParseBool(field(10)); err != nil {
+				return importData{}, invalid
+			}
+		}
+		code := strings.TrimSpace(record[3])
+		if code == "" && !isCustom {
+			continue
+		}
+		position := len(result.courses) + 1
+		if record[2] != "" {
+			if position, err = strconv.Atoi(record[2]); err != nil {
+				return importData{}, invalid
+			}
+		}
+		course, ok := parseImportCourse(year, assignment, position, code, field(7), field(8), field(9))
+		if !ok {
+			return importData{}, invalid
+		}
+		if isCustom {
+			credits, err := strconv.Atoi(field(5))
+			if err != nil {
+				return importData{}, invalid
+			}
+			semester, err := strconv.Atoi(field(12))
+			if err != nil {
+				return importData{}, invalid
+			}
+			custom := customCourse{
+				title:      field(11),
+				credits:    credits,
+				semester:   teachingSemester(semester),
+				mappedCode: field(13),
+			}
+			if err := course.setCustom(custom, t); err != nil {
+				return importData{}, err
+			}
+		}
+		result.courses = append(result.courses, course)
+	}
+	return result, nil
+}
+
+// parseImportCourse validates the optional completion status of an imported
+// course. Grade and completion date are allowed only for finished courses.
+func parseImportCourse(year int, assignment semesterAssignment, position int, code, status, grade, completed string) (importCourse, bool) {
+	result := importCourse{year: year, semester: assignment, position: position, code: code, status: statusPlanned}
+	if status != "" {
+		result.status = courseStatus(status)
+	}
+	if !result.status.isValid() {
+		return result, false
+	}
+	if !result.status.isFinished() {
+		return result, grade == "" && completed == ""
+	}
+	if grade != "" {
+		gradeInt, err := strconv.Atoi(grade)
+		if err != nil || gradeInt < minGrade || gradeInt > maxGrade {
+			return result, false
+		}
+		result.grade = gradeInt
+	}
+	if completed != "" {
+		if _, err := time.Parse(dateLayout, completed); err != nil {
+			return result, false
+		}
+		result.completed = completed
+	}
+	return result, true
+}
+
+// setCustom turns the imported course into the custom course. The course is
+// validated the same way as when it is added by the user and must not have
+// a code.
+func (c *importCourse) setCustom(custom customCourse, t text) error {
+	if c.code != "" {
+		return errorx.NewHTTPErr(
+			errorx.AddContext(fmt.Errorf("custom course has a code"), errorx.P("code", c.code)),
+			http.StatusBadRequest,
+			t.errInvalidImportFile,
+		)
+	}
+	if err := custom.validate(t); err != nil {
+		return errorx.AddContext(err)
+	}
+	c.isCustom = true
+	c.custom = custom
+	return nil
+}
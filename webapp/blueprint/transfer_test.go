@@ -0,0 +1,131 @@
+package blueprint
+
+import (
+	"bytes"
+	"database/sql"
+	"io"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/michalhercik/RecSIS/language"
+)
+
+func testTransferBlueprint() *blueprintPage {
+	return &blueprintPage{
+		unassigned: semester{courses: []course{{
+			title:      "Internship",
+			credits:    6,
+			semester:   teachingBoth,
+			status:     statusPlanned,
+			custom:     true,
+			mappedCode: "NSWI999",
+		}}},
+		years: assignedYears{
+			{
+				position: 1,
+				winter: semester{courses: []course{{
+					code:        "NPRG030",
+					title:       "Programming 1",
+					credits:     5,
+					status:      statusPassed,
+					grade:       sql.NullInt64{Int64: 1, Valid: true},
+					completedAt: sql.NullTime{Time: time.Date(2025, 1, 20, 0, 0, 0, 0, time.UTC), Valid: true},
+				}}},
+				summer: semester{folded: true},
+			},
+			{
+				position: 2,
+				summer:   semester{folded: true},
+			},
+		},
+	}
+}
+
+func testTransferImport() importData {
+	return importData{
+		years: 2,
+		semesters: []importSemester{
+			{0, assignmentNone, false},
+			{1, assignmentWinter, false},
+			{1, assignmentSummer, true},
+			{2, assignmentWinter, false},
+			{2, assignmentSummer, true},
+		},
+		courses: []importCourse{
+			{
+				semester: assignmentNone,
+				position: 1,
+				status:   statusPlanned,
+				isCustom: true,
+				custom:   customCourse{title: "Internship", credits: 6, semester: teachingBoth, mappedCode: "NSWI999"},
+			},
+			{
+				year:      1,
+				semester:  assignmentWinter,
+				position:  1,
+				code:      "NPRG030",
+				status:    statusPassed,
+				grade:     1,
+				completed: "2025-01-20",
+			},
+		},
+	}
+}
+
+func TestTransferRoundTrip(t *testing.T) {
+	tt := texts[language.EN]
+	tests := []struct {
+		name   string
+		export func(io.Writer, *blueprintPage) error
+		parse  func([]byte, text) (importData, error)
+	}{
+		{"json", exportJSON, parseImportJSON},
+		{"csv", exportCSV, parseImportCSV},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := test.export(&buf, testTransferBlueprint()); err != nil {
+				t.Fatalf("export error: %v", err)
+			}
+			got, err := test.parse(buf.Bytes(), tt)
+			if err != nil {
+				t.Fatalf("parse error: %v\n%s", err, buf.String())
+			}
+			if want := testTransferImport(); !reflect.DeepEqual(got, want) {
+				t.Errorf("parse() = %+v, want %+v", got, want)
+			}
+			if codes := got.codes(); !reflect.DeepEqual(codes, []string{"NSWI999", "NPRG030"}) {
+				t.Errorf("codes() = %v, want mapped code included", codes)
+			}
+		})
+	}
+}
+
+func TestParseImportErrors(t *testing.T) {
+	tt := texts[language.EN]
+	tests := []struct {
+		name    string
+		content string
+		parse   func([]byte, text) (importData, error)
+	}{
+		{"csv unassigned with semester", "year,semester,position,code\n0,1,1,NPRG030\n", parseImportCSV},
+		{"csv year without semester", "year,semester,position,code\n1,0,1,NPRG030\n", parseImportCSV},
+		{"csv semester out of range", "year,semester,position,code\n1,3,1,NPRG030\n", parseImportCSV},
+		{"csv negative year", "year,semester,position,code\n-1,1,1,NPRG030\n", parseImportCSV},
+		{"csv custom course with code", "1,1,1,NPRG030,,5,false,,,,true,Internship,3,\n", parseImportCSV},
+		{"csv grade of planned course", "1,1,1,NPRG030,,5,false,,1,,,,,\n", parseImportCSV},
+		{"json years not consecutive", `{"version":1,"years":[{"year":1},{"year":3}]}`, parseImportJSON},
+		{"json years not from one", `{"version":1,"years":[{"year":2}]}`, parseImportJSON},
+		{"json unsupported version", `{"version":2,"years":[]}`, parseImportJSON},
+		{"json custom course without title", `{"version":1,"unassigned":{"courses":[{"custom":true,"credits":5,"custom_semester":3}]}}`, parseImportJSON},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if data, err := test.parse([]byte(test.content), tt); err == nil {
+				t.Errorf("parse(%q) = %+v, want error", test.content, data)
+			}
+		})
+	}
+}
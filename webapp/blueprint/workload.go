@@ -0,0 +1,295 @@
+package blueprint
+
+import (
+	"encoding/json"
+	"io"
+	"math"
+	"strings"
+
+	"github.com/michalhercik/RecSIS/academicyear"
+)
+
+//================================================================================
+// Constants
+//================================================================================
+
+const workloadTargetParam string = "target"
+
+const (
+	defaultWorkloadTarget = 30
+	// workloadTolerance is the relative deviation of semester credits from the
+	// target or the average above which the semester is flagged
+	workloadTolerance = 0.25
+)
+
+// weeklyHoursUnit is the abbreviation of the range unit "hours per week".
+// Ranges in other units are not included in contact hours.
+const weeklyHoursUnit = "HT"
+
+const (
+	flagAboveTarget  workloadFlag = "above-target"
+	flagBelowTarget  workloadFlag = "below-target"
+	flagAboveAverage workloadFlag = "above-average"
+	flagBelowAverage workloadFlag = "below-average"
+)
+
+//================================================================================
+// Data Types and Methods
+//================================================================================
+
+type workloadFlag string
+
+// workloadStats is the workload of a semester or a year. Exams are counted
+// per course, courses finished by a credit or a graded credit only are
+// counted as credit-only. Courses whose ranges are not in weekly hours are
+// counted in otherUnits and not included in hours.
+type workloadStats struct {
+	credits    int
+	hours      int
+	exams      int
+	creditOnly int
+	otherUnits int
+}
+
+func (ws workloadStats) add(other workloadStats) workloadStats {
+	return workloadStats{
+		credits:    ws.credits + other.credits,
+		hours:      ws.hours + other.hours,
+		exams:      ws.exams + other.exams,
+		creditOnly: ws.creditOnly + other.creditOnly,
+		otherUnits: ws.otherUnits + other.otherUnits,
+	}
+}
+
+type workloadSemester struct {
+	workloadStats
+	courses int
+	flags   []workloadFlag
+}
+
+func (ws workloadSemester) isEmpty() bool {
+	return ws.courses == 0
+}
+
+type workloadYear struct {
+	position int
+	winter   workloadSemester
+	summer   workloadSemester
+}
+
+func (wy workloadYear) total() workloadStats {
+	return wy.winter.workloadStats.add(wy.summer.workloadStats)
+}
+
+// workloadSummary is the workload of all assigned semesters. Average is the
+// mean of credits over non-empty semesters, 0 if there are none.
+type workloadSummary struct {
+	target    int
+	average   float64
+	startYear int
+	years     []workloadYear
+}
+
+func (ws *workloadSummary) total() workloadStats {
+	var result workloadStats
+	for _, y := range ws.years {
+		result = result.add(y.total())
+	}
+	return result
+}
+
+func (ws *workloadSummary) flagged() int {
+	count := 0
+	for _, y := range ws.years {
+		if len(y.winter.flags) > 0 {
+			count++
+		}
+		if len(y.summer.flags) > 0 {
+			count++
+		}
+	}
+	return count
+}
+
+//================================================================================
+// Computation
+//================================================================================
+
+// buildWorkload computes the workload of every assigned semester and flags
+// non-empty semesters whose credits deviate from the target or the average
+// by more than workloadTolerance.
+func buildWorkload(bp *blueprintPage, target int) workloadSummary {
+	summary := workloadSummary{
+		target:    target,
+		startYear: bp.startYear,
+		years:     make([]workloadYear, len(bp.years)),
+	}
+	credits, nonEmpty := 0, 0
+	for i, ay := range bp.years {
+		summary.years[i] = workloadYear{
+			position: ay.position,
+			winter:   semesterWorkload(ay.winter, assignmentWinter),
+			summer:   semesterWorkload(ay.summer, assignmentSummer),
+		}
+		for _, s := range []workloadSemester{summary.years[i].winter, summary.years[i].summer} {
+			if !s.isEmpty() {
+				credits += s.credits
+				nonEmpty++
+			}
+		}
+	}
+	if nonEmpty > 0 {
+		summary.average = float64(credits) / float64(nonEmpty)
+	}
+	for i := range summary.years {
+		summary.years[i].winter.flags = workloadFlags(summary.years[i].winter, float64(target), summary.average)
+		summary.years[i].summer.flags = workloadFlags(summary.years[i].summer, float64(target), summary.average)
+	}
+	return summary
+}
+
+func semesterWorkload(s semester, assignment semesterAssignment) workloadSemester {
+	result := workloadSemester{courses: len(s.courses)}
+	for _, c := range s.courses {
+		result.credits += c.credits
+		if c.rangeUnit == "" || c.rangeUnit == weeklyHoursUnit {
+			result.hours += c.weeklyHours(assignment)
+		} else {
+			result.otherUnits++
+		}
+		if c.hasExam(assignment) {
+			result.exams++
+		} else {
+			result.creditOnly++
+		}
+	}
+	return result
+}
+
+func workloadFlags(s workloadSemester, target, average float64) []workloadFlag {
+	if s.isEmpty() {
+		return nil
+	}
+	var flags []workloadFlag
+	credits := float64(s.credits)
+	if target > 0 {
+		if credits > target*(1+workloadTolerance) {
+			flags = append(flags, flagAboveTarget)
+		} else if credits < target*(1-workloadTolerance) {
+			flags = append(flags, flagBelowTarget)
+		}
+	}
+	if average > 0 {
+		if credits > average*(1+workloadTolerance) {
+			flags = append(flags, flagAboveAverage)
+		} else if credits < average*(1-workloadTolerance) {
+			flags = append(flags, flagBelowAverage)
+		}
+	}
+	return flags
+}
+
+// weeklyHours returns lectures and seminars of the course in the semester it
+// is assigned to. Ranges of the other teaching semester are used if the
+// course has none in the assigned one.
+func (c *course) weeklyHours(assignment semesterAssignment) int {
+	winter := c.lectureRangeWinter.Int64 + c.seminarRangeWinter.Int64
+	summer := c.lectureRangeSummer.Int64 + c.seminarRangeSummer.Int64
+	hasWinter := c.lectureRangeWinter.Valid || c.seminarRangeWinter.Valid
+	hasSummer := c.lectureRangeSummer.Valid || c.seminarRangeSummer.Valid
+	if assignment == assignmentSummer && hasSummer || !hasWinter {
+		return int(summer)
+	}
+	return int(winter)
+}
+
+// hasExam reports whether the course is finished by an exam in the semester
+// it is assigned to. Exam types of courses taught in both semesters are
+// separated by " / ", e.g. "Z / Z+Zk".
+func (c *course) hasExam(assignment semesterAssignment) bool {
+	exam := c.examType
+	if parts := strings.Split(exam, " / "); len(parts) == 2 {
+		exam = parts[0]
+		if assignment == assignmentSummer {
+			exam = parts[1]
+		}
+	}
+	// "Zk" (zkouška) and "Ex" (exam) in Czech and English respectively
+	return strings.Contains(exam, "Zk") || strings.Contains(exam, "Ex")
+}
+
+//================================================================================
+// JSON
+//================================================================================
+
+type jsonWorkload struct {
+	Target  int            `json:"target"`
+	Average float64        `json:"average"`
+	Total   jsonStats      `json:"total"`
+	Years   []jsonWorkYear `json:"years"`
+}
+
+type jsonWorkYear struct {
+	Year         int          `json:"year"`
+	AcademicYear string       `json:"academicYear,omitempty"`
+	Total        jsonStats    `json:"total"`
+	Winter       jsonSemester `json:"winter"`
+	Summer       jsonSemester `json:"summer"`
+}
+
+type jsonSemester struct {
+	jsonStats
+	Courses int            `json:"courses"`
+	Flags   []workloadFlag `json:"flags"`
+}
+
+type jsonStats struct {
+	Credits    int `json:"credits"`
+	Hours      int `json:"contactHours"`
+	Exams      int `json:"exams"`
+	CreditOnly int `json:"creditOnly"`
+	OtherUnits int `json:"otherUnits"`
+}
+
+func intoJSONStats(ws workloadStats) jsonStats {
+	return jsonStats{
+		Credits:    ws.credits,
+		Hours:      ws.hours,
+		Exams:      ws.exams,
+		CreditOnly: ws.creditOnly,
+		OtherUnits: ws.otherUnits,
+	}
+}
+
+func intoJSONSemester(ws workloadSemester) jsonSemester {
+	flags := ws.flags
+	if flags == nil {
+		flags = []workloadFlag{}
+	}
+	return jsonSemester{
+		jsonStats: intoJSONStats(ws.workloadStats),
+		Courses:   ws.courses,
+		Flags:     flags,
+	}
+}
+
+func exportWorkloadJSON(w io.Writer, ws *workloadSummary) error {
+	data := jsonWorkload{
+		Target:  ws.target,
+		Average: math.Round(ws.average*100) / 100,
+		Total:   intoJSONStats(ws.total()),
+		Years:   make([]jsonWorkYear, len(ws.years)),
+	}
+	for i, y := range ws.years {
+		data.Years[i] = jsonWorkYear{
+			Year:         y.position,
+			AcademicYear: academicyear.PositionLabel(ws.startYear, y.position),
+			Total:        intoJSONStats(y.total()),
+			Winter:       intoJSONSemester(y.winter),
+			Summer:       intoJSONSemester(y.summer),
+		}
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(data)
+}
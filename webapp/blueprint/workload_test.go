@@ -0,0 +1,140 @@
+package blueprint
+
+import (
+	"database/sql"
+	"slices"
+	"testing"
+)
+
+func TestWorkloadFlags(t *testing.T) {
+	tests := []struct {
+		name    string
+		credits int
+		courses int
+		target  float64
+		average float64
+		want    []workloadFlag
+	}{
+		{"empty semester", 0, 0, 30, 30, nil},
+		{"within tolerance", 30, 5, 30, 30, nil},
+		{"upper tolerance bound", 37, 5, 30, 37, nil},
+		{"above target", 38, 5, 30, 38, []workloadFlag{flagAboveTarget}},
+		{"lower tolerance bound", 23, 5, 30, 23, nil},
+		{"below target", 22, 5, 30, 22, []workloadFlag{flagBelowTarget}},
+		{"above average", 30, 5, 30, 20, []workloadFlag{flagAboveAverage}},
+		{"below target and average", 10, 2, 30, 20, []workloadFlag{flagBelowTarget, flagBelowAverage}},
+		{"no target", 50, 5, 0, 30, []workloadFlag{flagAboveAverage}},
+		{"zero credits", 0, 1, 30, 0, []workloadFlag{flagBelowTarget}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := workloadSemester{workloadStats: workloadStats{credits: tt.credits}, courses: tt.courses}
+			if got := workloadFlags(s, tt.target, tt.average); !slices.Equal(got, tt.want) {
+				t.Errorf("workloadFlags(%d, %v, %v) = %v, want %v", tt.credits, tt.target, tt.average, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildWorkload(t *testing.T) {
+	bp := testBlueprint(2)
+	bp.startYear = 2024
+	bp.years[0].winter.courses = []course{testCourse("A", 20, teachingBoth), testCourse("B", 20, teachingBoth)}
+	bp.years[0].summer.courses = []course{testCourse("C", 30, teachingBoth)}
+	bp.years[1].winter.courses = []course{testCourse("D", 10, teachingBoth)}
+
+	summary := buildWorkload(bp, defaultWorkloadTarget)
+	if want := 80.0 / 3; summary.average != want {
+		t.Errorf("buildWorkload().average = %v, want %v", summary.average, want)
+	}
+	if total := summary.total(); total.credits != 80 {
+		t.Errorf("buildWorkload().total().credits = %d, want 80", total.credits)
+	}
+	semesters := []struct {
+		name string
+		got  workloadSemester
+		want []workloadFlag
+	}{
+		{"1 winter", summary.years[0].winter, []workloadFlag{flagAboveTarget, flagAboveAverage}},
+		{"1 summer", summary.years[0].summer, nil},
+		{"2 winter", summary.years[1].winter, []workloadFlag{flagBelowTarget, flagBelowAverage}},
+		{"2 summer", summary.years[1].summer, nil},
+	}
+	for _, s := range semesters {
+		if !slices.Equal(s.got.flags, s.want) {
+			t.Errorf("buildWorkload() %s flags = %v, want %v", s.name, s.got.flags, s.want)
+		}
+	}
+	if got := summary.flagged(); got != 2 {
+		t.Errorf("buildWorkload().flagged() = %d, want 2", got)
+	}
+}
+
+func TestWeeklyHours(t *testing.T) {
+	hours := func(h int64) sql.NullInt64 { return sql.NullInt64{Int64: h, Valid: true} }
+	tests := []struct {
+		name       string
+		course     course
+		wantWinter int
+		wantSummer int
+	}{
+		{"no ranges", course{}, 0, 0},
+		{"winter only", course{lectureRangeWinter: hours(2), seminarRangeWinter: hours(2)}, 4, 4},
+		{"summer only", course{lectureRangeSummer: hours(2), seminarRangeSummer: hours(1)}, 3, 3},
+		{"both semesters", course{lectureRangeWinter: hours(2), seminarRangeSummer: hours(1)}, 2, 1},
+		{"seminar only", course{seminarRangeWinter: hours(2)}, 2, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.course.weeklyHours(assignmentWinter); got != tt.wantWinter {
+				t.Errorf("weeklyHours(winter) = %d, want %d", got, tt.wantWinter)
+			}
+			if got := tt.course.weeklyHours(assignmentSummer); got != tt.wantSummer {
+				t.Errorf("weeklyHours(summer) = %d, want %d", got, tt.wantSummer)
+			}
+		})
+	}
+}
+
+func TestHasExam(t *testing.T) {
+	tests := []struct {
+		examType   string
+		wantWinter bool
+		wantSummer bool
+	}{
+		{"", false, false},
+		{"Z", false, false},
+		{"KZ", false, false},
+		{"Zk", true, true},
+		{"Z+Zk", true, true},
+		{"C+Ex", true, true},
+		{"Z / Z+Zk", false, true},
+		{"Z+Zk / Z", true, false},
+		{"C / C+Ex", false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.examType, func(t *testing.T) {
+			c := course{examType: tt.examType}
+			if got := c.hasExam(assignmentWinter); got != tt.wantWinter {
+				t.Errorf("hasExam(%q, winter) = %t, want %t", tt.examType, got, tt.wantWinter)
+			}
+			if got := c.hasExam(assignmentSummer); got != tt.wantSummer {
+				t.Errorf("hasExam(%q, summer) = %t, want %t", tt.examType, got, tt.wantSummer)
+			}
+		})
+	}
+}
+
+func TestSemesterWorkload(t *testing.T) {
+	a := testCourse("A", 5, teachingBoth)
+	a.examType = "Z / Z+Zk"
+	a.lectureRangeSummer = sql.NullInt64{Int64: 2, Valid: true}
+	b := testCourse("B", 3, teachingBoth)
+	b.examType = "Z"
+	b.rangeUnit = "HS"
+	got := semesterWorkload(semester{courses: []course{a, b}}, assignmentSummer)
+	want := workloadSemester{workloadStats: workloadStats{credits: 8, hours: 2, exams: 1, creditOnly: 1, otherUnits: 1}, courses: 2}
+	if got.workloadStats != want.workloadStats || got.courses != want.courses {
+		t.Errorf("semesterWorkload() = %+v, want %+v", got, want)
+	}
+}
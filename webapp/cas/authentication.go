@@ -12,6 +12,7 @@ import (
 	"context"
 	"net/http"
 
+	"github.com/michalhercik/RecSIS/academicyear"
 	"github.com/michalhercik/RecSIS/errorx"
 	"github.com/michalhercik/RecSIS/language"
 )
@@ -52,12 +53,13 @@ func (a Authentication) authenticate(next http.Handler) func(w http.ResponseWrit
 			return
 		}
 		lang := language.FromContext(r.Context())
-		userID, err := a.Data.authenticate(sessionID.Value, lang)
+		userID, startYear, err := a.Data.authenticate(sessionID.Value, lang)
 		if err != nil {
 			a.loginPage(w, r)
 			return
 		}
-		r = r.WithContext(context.WithValue(r.Context(), userIDKey{}, userID))
+		ctx := context.WithValue(r.Context(), userIDKey{}, userID)
+		r = r.WithContext(academicyear.WithStartYear(ctx, startYear))
 		next.ServeHTTP(w, r)
 	})
 }
@@ -125,7 +127,7 @@ func (a Authentication) logoutFromUser(w http.ResponseWriter, r *http.Request) {
 		}
 		return
 	}
-	userID, err := a.Data.authenticate(sessionID.Value, lang)
+	userID, _, err := a.Data.authenticate(sessionID.Value, lang)
 	if err != nil {
 		code, userMsg := errorx.UnwrapError(err, lang)
 		a.Error.Log(errorx.AddContext(err))
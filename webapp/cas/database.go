@@ -15,31 +15,39 @@ type DBManager struct {
 	DB *sqlx.DB
 }
 
-func (m DBManager) authenticate(sessionID string, lang language.Language) (string, error) {
-	var userID sql.NullString
+// authenticate returns the user of an active session and their study start
+// year (0 if unknown).
+func (m DBManager) authenticate(sessionID string, lang language.Language) (string, int, error) {
+	var session struct {
+		UserID    sql.NullString `db:"user_id"`
+		StartYear sql.NullInt64  `db:"start_year"`
+	}
 	query := `
 		SELECT
-			user_id
-		FROM sessions
-		WHERE id = $1
-		AND expires_at > NOW();
+			s.user_id,
+			st.start_year
+		FROM sessions s
+		LEFT JOIN studies st
+			ON s.user_id = st.user_id
+		WHERE s.id = $1
+		AND s.expires_at > NOW();
 	`
-	err := m.DB.Get(&userID, query, sessionID)
+	err := m.DB.Get(&session, query, sessionID)
 	if err != nil {
-		return "", errorx.NewHTTPErr(
+		return "", 0, errorx.NewHTTPErr(
 			errorx.AddContext(err),
 			http.StatusInternalServerError,
 			texts[lang].errCannotGetUserIDFromSession,
 		)
 	}
-	if !userID.Valid {
-		return "", errorx.NewHTTPErr(
+	if !session.UserID.Valid {
+		return "", 0, errorx.NewHTTPErr(
 			errorx.AddContext(fmt.Errorf("session %s does not exist in DB - userID is NULL", sessionID)),
 			http.StatusUnauthorized,
 			texts[lang].errUnauthorized,
 		)
 	}
-	return userID.String, nil
+	return session.UserID.String, int(session.StartYear.Int64), nil
 }
 
 func (m DBManager) login(userID, ticket string, lang language.Language) (string, error) {
@@ -128,9 +136,19 @@ func (m DBManager) createUser(userID string, lang language.Language) error {
 			texts[lang].errCannotCreateUser,
 		)
 	}
-	createBlueprintYearQuery := "INSERT INTO blueprint_years (user_id, academic_year) VALUES ($1, 0) RETURNING id"
+	createScenarioQuery := "INSERT INTO blueprint_scenarios (user_id, name, active) VALUES ($1, $2, true) RETURNING id"
+	var scenarioID int
+	err = tx.Get(&scenarioID, createScenarioQuery, userID, texts[lang].defaultScenarioName)
+	if err != nil {
+		return errorx.NewHTTPErr(
+			errorx.AddContext(err),
+			http.StatusInternalServerError,
+			texts[lang].errCannotCreateUser,
+		)
+	}
+	createBlueprintYearQuery := "INSERT INTO blueprint_years (user_id, scenario_id, academic_year) VALUES ($1, $2, 0) RETURNING id"
 	var unassignedYearID int
-	err = tx.Get(&unassignedYearID, createBlueprintYearQuery, userID)
+	err = tx.Get(&unassignedYearID, createBlueprintYearQuery, userID, scenarioID)
 	if err != nil {
 		return errorx.NewHTTPErr(
 			errorx.AddContext(err),
@@ -12,6 +12,7 @@ type text struct {
 	loginContactMessage           string
 	learnMore                     string
 	loginButton                   string
+	defaultScenarioName           string
 	errUnauthorized               string
 	errCannotGetUserIDFromSession string
 	errCannotCreateSession        string
@@ -31,6 +32,7 @@ var texts = map[language.Language]text{
 		loginContactMessage:           "V případě jakýchkoliv problémů kontaktujte podporu na adrese %s.",
 		learnMore:                     "Zjistit více",
 		loginButton:                   "Přihlásit se",
+		defaultScenarioName:           "Můj plán",
 		errUnauthorized:               "Neoprávněný přístup",
 		errCannotGetUserIDFromSession: "Nepodařilo se získat ID uživatele ze session.",
 		errCannotCreateSession:        "Nepodařilo se vytvořit session.",
@@ -48,6 +50,7 @@ var texts = map[language.Language]text{
 		loginContactMessage:           "In case of any problems, contact the support %s.",
 		learnMore:                     "Learn more",
 		loginButton:                   "Log In",
+		defaultScenarioName:           "My plan",
 		errUnauthorized:               "Unauthorized Access",
 		errCannotGetUserIDFromSession: "Failed to retrieve user ID from session.",
 		errCannotCreateSession:        "Failed to create session.",
@@ -50,6 +50,13 @@ type Add struct {
 	DB         *sqlx.DB
 	Templ      func(ViewModel, text) templ.Component
 	HxPostBase string
+	// Cache is notified after courses are added to the blueprint (optional).
+	Cache Invalidator
+}
+
+type Invalidator interface {
+	// Drops cached blueprint data of the user.
+	Invalidate(userID string)
 }
 
 func (b Add) Endpoint() string {
@@ -154,7 +161,23 @@ func (b Add) semesterFromRequest(r *http.Request, t text) (int, error) {
 // TODO: is possible to add course to multiple years/semesters at once?
 func (b Add) Action(userID string, year int, semester int, lang language.Language, courses ...string) ([]int, error) {
 	var courseIDs []int
-	err := b.DB.Select(&courseIDs, sqlquery.InsertCourse, userID, year, semester, pq.StringArray(courses))
+	tx, err := b.DB.Beginx()
+	if err != nil {
+		return []int{}, errorx.NewHTTPErr(
+			errorx.AddContext(fmt.Errorf("DB.Beginx: %w", err)),
+			http.StatusInternalServerError,
+			texts[lang].errAddCourseToBPFailed,
+		)
+	}
+	defer tx.Rollback()
+	if _, err = tx.Exec(sqlquery.RecordHistory, userID, historyActionAddCourses); err != nil {
+		return []int{}, errorx.NewHTTPErr(
+			errorx.AddContext(fmt.Errorf("sqlquery.RecordHistory: %w", err)),
+			http.StatusInternalServerError,
+			texts[lang].errAddCourseToBPFailed,
+		)
+	}
+	err = tx.Select(&courseIDs, sqlquery.InsertCourse, userID, year, semester, pq.StringArray(courses))
 	if err != nil {
 		// Handle unique violation for blueprint_semester_id, course_code
 		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == uniqueViolationCode && pqErr.Constraint == duplicateCoursesViolation {
@@ -181,6 +204,16 @@ func (b Add) Action(userID string, year int, semester int, lang language.Languag
 			texts[lang].errAddCourseToBPFailed,
 		)
 	}
+	if err = tx.Commit(); err != nil {
+		return []int{}, errorx.NewHTTPErr(
+			errorx.AddContext(fmt.Errorf("tx.Commit: %w", err)),
+			http.StatusInternalServerError,
+			texts[lang].errAddCourseToBPFailed,
+		)
+	}
+	if b.Cache != nil {
+		b.Cache.Invalidate(userID)
+	}
 	return courseIDs, nil
 }
 
@@ -10,7 +10,7 @@ const InsertCourse = `--sql
 			ON y.id = bs.blueprint_year_id
 		LEFT JOIN blueprint_courses bc
 			ON bs.id = bc.blueprint_semester_id
-		WHERE y.user_id = $1
+		WHERE y.scenario_id = (SELECT id FROM blueprint_scenarios WHERE user_id = $1 AND active)
 			AND y.academic_year = $2
 			AND bs.semester = $3
 		ORDER BY bc.position DESC
@@ -30,3 +30,7 @@ const InsertCourse = `--sql
 		) c ON TRUE
 	RETURNING id;
 	`
+
+const RecordHistory = `--sql
+	SELECT record_blueprint_history($1, $2);
+	`
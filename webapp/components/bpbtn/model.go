@@ -8,6 +8,9 @@ const (
 
 const endpointPath = "blueprint"
 
+// action recorded in the blueprint history (see blueprint package)
+const historyActionAddCourses = "add-courses"
+
 const (
 	uniqueViolationCode       = "23505"
 	duplicateCoursesViolation = "blueprint_courses_blueprint_semester_id_course_code_key"
@@ -30,8 +30,9 @@ type Page struct {
 	Error                 Error
 	Home                  string
 	NavItems              []NavItem
-	Search                MeiliSearch
+	Search                SearchEngine
 	Param                 string
+	Query                 QueryParser
 	SearchEndpoint        string
 	ResultsDetailEndpoint func(code string) string
 	router                *http.ServeMux
@@ -76,8 +77,16 @@ func (p Page) view(main templ.Component, lang language.Language, title string, s
 func (p Page) quickSearch(w http.ResponseWriter, r *http.Request) {
 	lang := language.FromContext(r.Context())
 	t := texts[lang]
-	query := r.FormValue(p.Param)
-	courses, err := p.Search.quickSearchResult(query, lang)
+	query, filter, hint, err := p.Query.ParseQuery(r.FormValue(p.Param), lang)
+	if err != nil {
+		// shown in the results instead of a floating window so that an
+		// unfinished qualifier does not interrupt typing
+		_, userMsg := errorx.UnwrapError(err, lang)
+		p.Error.Log(errorx.AddContext(err))
+		p.renderQuickResults(w, r, quickResultsModel{t: t, lang: lang, message: userMsg})
+		return
+	}
+	courses, err := p.Search.quickSearchResult(query, filter, lang)
 	if err != nil {
 		code, userMsg := errorx.UnwrapError(err, lang)
 		p.Error.Log(errorx.AddContext(err))
@@ -88,15 +97,26 @@ func (p Page) quickSearch(w http.ResponseWriter, r *http.Request) {
 		t:                    t,
 		lang:                 lang,
 		courses:              courses,
+		hint:                 hint,
 		resultDetailEndpoint: p.ResultsDetailEndpoint,
 	}
-	view := QuickResults(model)
-	err = view.Render(r.Context(), w)
+	p.renderQuickResults(w, r, model)
+}
+
+func (p Page) renderQuickResults(w http.ResponseWriter, r *http.Request, model quickResultsModel) {
+	err := QuickResults(model).Render(r.Context(), w)
 	if err != nil {
-		p.Error.CannotRenderComponent(w, r, err, lang)
+		p.Error.CannotRenderComponent(w, r, err, model.lang)
 	}
 }
 
+type QueryParser interface {
+	// Splits the search query into text and filter expression of the search engine
+	// built from inline qualifiers (e.g. credits>=5 lang:en). The hint lists unknown
+	// qualifiers which were searched as text.
+	ParseQuery(query string, lang language.Language) (string, expression, string, error)
+}
+
 type Error interface {
 	// Logs the provided error.
 	Log(err error)
@@ -126,6 +146,8 @@ type quickResultsModel struct {
 	t                    text
 	lang                 language.Language
 	courses              []quickCourse
+	message              string
+	hint                 string
 	resultDetailEndpoint func(code string) string
 }
 
@@ -7,16 +7,48 @@ import (
 
 	"github.com/meilisearch/meilisearch-go"
 	"github.com/michalhercik/RecSIS/errorx"
+	"github.com/michalhercik/RecSIS/fulltext"
 	"github.com/michalhercik/RecSIS/language"
 )
 
+// expression is a filter expression of the search engines. It is an alias so
+// that parsers from other packages returning the same interface satisfy
+// QueryParser.
+type expression = interface {
+	String() string
+	JSONPath() string
+}
+
+//================================================================================
+// Search Backends
+//================================================================================
+
+// SearchEngine is implemented by every search backend of the quick search.
+type SearchEngine interface {
+	quickSearchResult(query string, filter expression, lang language.Language) ([]quickCourse, error)
+}
+
+// FallbackSearch is the quick search which keeps working with the full-text
+// search when Meilisearch is down.
+type FallbackSearch struct {
+	fulltext.Fallback[SearchEngine]
+}
+
+func (s FallbackSearch) quickSearchResult(query string, filter expression, lang language.Language) ([]quickCourse, error) {
+	return fulltext.Call(s.Fallback, func(e SearchEngine) ([]quickCourse, error) { return e.quickSearchResult(query, filter, lang) })
+}
+
+//================================================================================
+// Meilisearch
+//================================================================================
+
 type MeiliSearch struct {
 	Client meilisearch.ServiceManager
 	Index  string
 	Limit  int64
 }
 
-func (m MeiliSearch) quickSearchResult(query string, lang language.Language) ([]quickCourse, error) {
+func (m MeiliSearch) quickSearchResult(query string, filter expression, lang language.Language) ([]quickCourse, error) {
 	var result quickResponse
 	t := texts[lang]
 	index := m.Client.Index(m.Index)
@@ -24,17 +56,20 @@ func (m MeiliSearch) quickSearchResult(query string, lang language.Language) ([]
 	if err != nil {
 		return nil, errorx.AddContext(err)
 	}
+	if f := filter.String(); f != "" {
+		searchReq.Filter = f
+	}
 	rawResponse, err := index.SearchRaw(query, searchReq)
 	if err != nil {
 		return nil, errorx.NewHTTPErr(
-			errorx.AddContext(err, errorx.P("query", query), errorx.P("lang", lang)),
+			errorx.AddContext(err, errorx.P("query", query), errorx.P("filter", filter), errorx.P("lang", lang)),
 			http.StatusInternalServerError,
 			t.errQuickSearchFailed,
 		)
 	}
 	if err = json.Unmarshal(*rawResponse, &result); err != nil {
 		return nil, errorx.NewHTTPErr(
-			errorx.AddContext(err, errorx.P("query", query), errorx.P("lang", lang)),
+			errorx.AddContext(err, errorx.P("query", query), errorx.P("filter", filter), errorx.P("lang", lang)),
 			http.StatusInternalServerError,
 			t.errQuickSearchFailed,
 		)
@@ -46,19 +81,27 @@ func (m MeiliSearch) buildQuickSearchRequest(lang language.Language) (*meilisear
 	result := &meilisearch.SearchRequest{
 		Limit: m.Limit,
 	}
+	attributes, err := quickSearchAttributes(lang)
+	if err != nil {
+		return result, errorx.AddContext(err)
+	}
+	result.AttributesToRetrieve = attributes
+	return result, nil
+}
+
+func quickSearchAttributes(lang language.Language) ([]string, error) {
 	switch lang {
 	case language.CS:
-		result.AttributesToRetrieve = []string{"code", "title.cs"}
+		return []string{"code", "title.cs"}, nil
 	case language.EN:
-		result.AttributesToRetrieve = []string{"code", "title.en"}
+		return []string{"code", "title.en"}, nil
 	default:
-		return result, errorx.NewHTTPErr(
+		return nil, errorx.NewHTTPErr(
 			errorx.AddContext(fmt.Errorf("unsupported language: %s", lang)),
 			http.StatusBadRequest,
 			texts[language.EN].errUnsupportedLanguage,
 		)
 	}
-	return result, nil
 }
 
 type quickResponse struct {
@@ -96,3 +139,61 @@ type quickCourse struct {
 	code string
 	name string
 }
+
+//================================================================================
+// PostgreSQL Full-Text Search
+//================================================================================
+
+// FullTextSearch searches courses in PostgreSQL when Meilisearch is not
+// available, see fulltext package.
+type FullTextSearch struct {
+	Engine fulltext.Engine
+	Index  string
+	Limit  int
+}
+
+func (s FullTextSearch) quickSearchResult(query string, filter expression, lang language.Language) ([]quickCourse, error) {
+	t := texts[lang]
+	attributes, err := quickSearchAttributes(lang)
+	if err != nil {
+		return nil, errorx.AddContext(err)
+	}
+	res, err := s.Engine.Search(fulltext.Request{
+		Index:      s.Index,
+		Query:      query,
+		Lang:       lang,
+		Filter:     filter.JSONPath(),
+		Attributes: attributes,
+		Limit:      s.Limit,
+	})
+	if err != nil {
+		return nil, errorx.NewHTTPErr(
+			errorx.AddContext(err, errorx.P("query", query), errorx.P("filter", filter.JSONPath()), errorx.P("lang", lang)),
+			http.StatusInternalServerError,
+			t.errQuickSearchFailed,
+		)
+	}
+	result := make([]quickCourse, len(res.Hits))
+	for i, hit := range res.Hits {
+		var course struct {
+			Code string `json:"code"`
+			Name struct {
+				CS string `json:"cs"`
+				EN string `json:"en"`
+			} `json:"title"`
+		}
+		if err := json.Unmarshal(hit, &course); err != nil {
+			return nil, errorx.NewHTTPErr(
+				errorx.AddContext(err, errorx.P("query", query), errorx.P("lang", lang)),
+				http.StatusInternalServerError,
+				t.errQuickSearchFailed,
+			)
+		}
+		result[i].code = course.Code
+		result[i].name = course.Name.CS
+		if result[i].name == "" {
+			result[i].name = course.Name.EN
+		}
+	}
+	return result, nil
+}
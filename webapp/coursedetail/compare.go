@@ -0,0 +1,223 @@
+package coursedetail
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/michalhercik/RecSIS/errorx"
+	"github.com/michalhercik/RecSIS/language"
+)
+
+//================================================================================
+// Handlers
+//================================================================================
+
+// compare shows up to four courses side by side. Courses are given by the
+// codes parameter (comma separated), without it courses of the comparison
+// tray of the user are compared.
+func (s Server) compare(w http.ResponseWriter, r *http.Request) {
+	lang := language.FromContext(r.Context())
+	t := texts[lang]
+	userID := s.Auth.UserID(r)
+	codes, err := s.comparedCodes(r, userID, lang)
+	if err != nil {
+		code, userMsg := errorx.UnwrapError(err, lang)
+		s.Error.Log(errorx.AddContext(err))
+		s.Error.RenderPage(w, r, code, userMsg, t.comparePageTitle, userID, lang)
+		return
+	}
+	comparePage, err := s.comparePage(userID, codes, lang)
+	if err != nil {
+		code, userMsg := errorx.UnwrapError(err, lang)
+		s.Error.Log(errorx.AddContext(err))
+		s.Error.RenderPage(w, r, code, userMsg, t.comparePageTitle, userID, lang)
+		return
+	}
+	main := CompareContent(&comparePage, t)
+	page := s.Page.View(main, lang, t.comparePageTitle, userID)
+	err = page.Render(r.Context(), w)
+	if err != nil {
+		s.Error.CannotRenderPage(w, r, t.comparePageTitle, userID, errorx.AddContext(err), lang)
+	}
+}
+
+func (s Server) comparedCodes(r *http.Request, userID string, lang language.Language) ([]string, error) {
+	codes := parseCodes(r.FormValue(compareCodesParam))
+	if len(codes) == 0 {
+		tray, err := s.Data.compareTray(userID, lang)
+		if err != nil {
+			return nil, errorx.AddContext(err)
+		}
+		codes = tray.codes()
+	}
+	if len(codes) > maxComparedCourses {
+		return nil, errorx.NewHTTPErr(
+			errorx.AddContext(fmt.Errorf("too many courses to compare"), errorx.P(compareCodesParam, strings.Join(codes, ","))),
+			http.StatusBadRequest,
+			texts[lang].errTooManyComparedCourses,
+		)
+	}
+	return codes, nil
+}
+
+func (s Server) comparePage(userID string, codes []string, lang language.Language) (comparePage, error) {
+	var result comparePage
+	if len(codes) == 0 {
+		return result, nil
+	}
+	for _, code := range codes {
+		course, err := s.Data.course(userID, code, lang)
+		if err != nil {
+			return result, errorx.AddContext(err)
+		}
+		result.courses = append(result.courses, comparedCourse{course: course})
+	}
+	counts, err := s.Search.commentCounts(codes, lang)
+	if err != nil {
+		return result, errorx.AddContext(err)
+	}
+	for i := range result.courses {
+		result.courses[i].comments = counts[result.courses[i].code]
+	}
+	return result, nil
+}
+
+func (s Server) compareTray(w http.ResponseWriter, r *http.Request) {
+	lang := language.FromContext(r.Context())
+	userID := s.Auth.UserID(r)
+	tray, err := s.Data.compareTray(userID, lang)
+	if err != nil {
+		code, userMsg := errorx.UnwrapError(err, lang)
+		s.Error.Log(errorx.AddContext(err))
+		s.Error.Render(w, r, code, userMsg, lang)
+		return
+	}
+	content := CompareTray(tray, texts[lang])
+	err = content.Render(r.Context(), w)
+	if err != nil {
+		s.Error.CannotRenderComponent(w, r, errorx.AddContext(err), lang)
+	}
+}
+
+func (s Server) addToCompareTray(w http.ResponseWriter, r *http.Request) {
+	lang := language.FromContext(r.Context())
+	userID := s.Auth.UserID(r)
+	code := r.PathValue(courseCode)
+	tray, err := s.Data.addToCompareTray(userID, code, lang)
+	if err != nil {
+		code, userMsg := errorx.UnwrapError(err, lang)
+		s.Error.Log(errorx.AddContext(err))
+		s.Error.Render(w, r, code, userMsg, lang)
+		return
+	}
+	content := CompareTray(tray, texts[lang])
+	err = content.Render(r.Context(), w)
+	if err != nil {
+		s.Error.CannotRenderComponent(w, r, errorx.AddContext(err), lang)
+	}
+}
+
+func (s Server) removeFromCompareTray(w http.ResponseWriter, r *http.Request) {
+	lang := language.FromContext(r.Context())
+	userID := s.Auth.UserID(r)
+	code := r.PathValue(courseCode)
+	tray, err := s.Data.removeFromCompareTray(userID, code, lang)
+	if err != nil {
+		code, userMsg := errorx.UnwrapError(err, lang)
+		s.Error.Log(errorx.AddContext(err))
+		s.Error.Render(w, r, code, userMsg, lang)
+		return
+	}
+	content := CompareTray(tray, texts[lang])
+	err = content.Render(r.Context(), w)
+	if err != nil {
+		s.Error.CannotRenderComponent(w, r, errorx.AddContext(err), lang)
+	}
+}
+
+// parseCodes splits comma separated course codes leaving out empty and
+// repeated ones.
+func parseCodes(value string) []string {
+	var result []string
+	for _, code := range strings.Split(value, ",") {
+		code = strings.TrimSpace(code)
+		if code != "" && !slices.Contains(result, code) {
+			result = append(result, code)
+		}
+	}
+	return result
+}
+
+func compareURL(codes []string, lang language.Language) string {
+	query := url.Values{}
+	query.Set(compareCodesParam, strings.Join(codes, ","))
+	return fmt.Sprintf("%s?%s", lang.LocalizeURL("/course/compare"), query.Encode())
+}
+
+//================================================================================
+// Data Types and Methods
+//================================================================================
+
+type comparePage struct {
+	courses []comparedCourse
+}
+
+func (p comparePage) codes() []string {
+	result := make([]string, len(p.courses))
+	for i, c := range p.courses {
+		result[i] = c.code
+	}
+	return result
+}
+
+// urlWithout returns the page comparing the other courses.
+func (p comparePage) urlWithout(code string, lang language.Language) string {
+	codes := slices.DeleteFunc(p.codes(), func(c string) bool { return c == code })
+	return compareURL(codes, lang)
+}
+
+// ratingCategories returns the rating categories, which are the same for all
+// courses.
+func (p comparePage) ratingCategories() []courseCategoryRating {
+	if len(p.courses) == 0 {
+		return nil
+	}
+	return p.courses[0].categoryRatings
+}
+
+type comparedCourse struct {
+	*course
+	// number of survey comments
+	comments int
+}
+
+func (c comparedCourse) categoryRating(code int) courseRating {
+	for _, r := range c.categoryRatings {
+		if r.code == code {
+			return r.courseRating
+		}
+	}
+	return courseRating{}
+}
+
+type compareTray []trayCourse
+
+type trayCourse struct {
+	code  string
+	title string
+}
+
+func (ct compareTray) codes() []string {
+	result := make([]string, len(ct))
+	for i, c := range ct {
+		result[i] = c.code
+	}
+	return result
+}
+
+func (ct compareTray) contains(code string) bool {
+	return slices.ContainsFunc(ct, func(c trayCourse) bool { return c.code == code })
+}
@@ -162,6 +162,117 @@ func (db DBManager) deleteRating(userID string, code string, lang language.Langu
 	return intoCourseRating(rating), nil
 }
 
+type dbTrayCourse struct {
+	Code  string `db:"code"`
+	Title string `db:"title"`
+}
+
+func (db DBManager) compareTray(userID string, lang language.Language) (compareTray, error) {
+	var result []dbTrayCourse
+	if err := db.DB.Select(&result, sqlquery.CompareTray, userID, lang); err != nil {
+		return nil, errorx.NewHTTPErr(
+			errorx.AddContext(fmt.Errorf("sqlquery.CompareTray: %w", err), errorx.P("lang", lang)),
+			http.StatusInternalServerError,
+			texts[lang].errCannotGetCompareTray,
+		)
+	}
+	return intoCompareTray(result), nil
+}
+
+func (db DBManager) addToCompareTray(userID string, code string, lang language.Language) (compareTray, error) {
+	tx, err := db.DB.Beginx()
+	if err != nil {
+		return nil, errorx.NewHTTPErr(
+			errorx.AddContext(fmt.Errorf("DB.Beginx: %w", err)),
+			http.StatusInternalServerError,
+			texts[lang].errCannotUpdateCompareTray,
+		)
+	}
+	defer tx.Rollback()
+	if _, err = tx.Exec(sqlquery.LockCompareTray, userID); err != nil {
+		return nil, errorx.NewHTTPErr(
+			errorx.AddContext(fmt.Errorf("sqlquery.LockCompareTray: %w", err)),
+			http.StatusInternalServerError,
+			texts[lang].errCannotUpdateCompareTray,
+		)
+	}
+	res, err := tx.Exec(sqlquery.AddToCompareTray, userID, code, maxComparedCourses)
+	if err != nil {
+		return nil, errorx.NewHTTPErr(
+			errorx.AddContext(fmt.Errorf("sqlquery.AddToCompareTray: %w", err), errorx.P("code", code)),
+			http.StatusInternalServerError,
+			texts[lang].errCannotUpdateCompareTray,
+		)
+	}
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return nil, errorx.NewHTTPErr(
+			errorx.AddContext(fmt.Errorf("sqlquery.AddToCompareTray: %w", err), errorx.P("code", code)),
+			http.StatusInternalServerError,
+			texts[lang].errCannotUpdateCompareTray,
+		)
+	}
+	if err = tx.Commit(); err != nil {
+		return nil, errorx.NewHTTPErr(
+			errorx.AddContext(fmt.Errorf("tx.Commit: %w", err)),
+			http.StatusInternalServerError,
+			texts[lang].errCannotUpdateCompareTray,
+		)
+	}
+	tray, err := db.compareTray(userID, lang)
+	if err != nil {
+		return nil, errorx.AddContext(err)
+	}
+	// nothing inserted and the course is not in the tray already
+	if rowsAffected == 0 && !tray.contains(code) {
+		if len(tray) >= maxComparedCourses {
+			return nil, errorx.NewHTTPErr(
+				errorx.AddContext(errors.New("compare tray is full"), errorx.P("code", code)),
+				http.StatusConflict,
+				texts[lang].errCompareTrayFull,
+			)
+		}
+		return nil, errorx.NewHTTPErr(
+			errorx.AddContext(errors.New("course not found"), errorx.P("code", code)),
+			http.StatusNotFound,
+			fmt.Sprintf("%s%s%s", texts[lang].errCourseNotFoundPre, code, texts[lang].errCourseNotFoundSuf),
+		)
+	}
+	return tray, nil
+}
+
+func (db DBManager) removeFromCompareTray(userID string, code string, lang language.Language) (compareTray, error) {
+	if _, err := db.DB.Exec(sqlquery.RemoveFromCompareTray, userID, code); err != nil {
+		return nil, errorx.NewHTTPErr(
+			errorx.AddContext(fmt.Errorf("sqlquery.RemoveFromCompareTray: %w", err), errorx.P("code", code)),
+			http.StatusInternalServerError,
+			texts[lang].errCannotUpdateCompareTray,
+		)
+	}
+	tray, err := db.compareTray(userID, lang)
+	if err != nil {
+		return nil, errorx.AddContext(err)
+	}
+	return tray, nil
+}
+
+type dbCourseSnapshot struct {
+	AcademicYear int `db:"academic_year"`
+	dbds.Course
+}
+
+func (db DBManager) courseHistory(code string, lang language.Language) (courseHistory, error) {
+	var result []dbCourseSnapshot
+	if err := db.DB.Select(&result, sqlquery.CourseHistory, code, lang); err != nil {
+		return nil, errorx.NewHTTPErr(
+			errorx.AddContext(fmt.Errorf("sqlquery.CourseHistory: %w", err), errorx.P("code", code), errorx.P("lang", lang)),
+			http.StatusInternalServerError,
+			texts[lang].errCannotGetCourseHistory,
+		)
+	}
+	return intoCourseHistory(result), nil
+}
+
 func intoCourse(from *courseDetail) course {
 	return course{
 		code:                   from.Code,
@@ -304,3 +415,47 @@ func intoTeacherSlice(from dbds.TeacherSlice) []teacher {
 	}
 	return result
 }
+
+func intoCompareTray(from []dbTrayCourse) compareTray {
+	result := make(compareTray, len(from))
+	for i, c := range from {
+		result[i] = trayCourse{
+			code:  c.Code,
+			title: c.Title,
+		}
+	}
+	return result
+}
+
+func intoCourseHistory(from []dbCourseSnapshot) courseHistory {
+	result := make(courseHistory, len(from))
+	for i, c := range from {
+		result[i] = courseSnapshot{
+			academicYear: c.AcademicYear,
+			course: course{
+				code:                   c.Code,
+				title:                  c.Title,
+				lectureRangeWinter:     c.LectureRangeWinter,
+				seminarRangeWinter:     c.SeminarRangeWinter,
+				lectureRangeSummer:     c.LectureRangeSummer,
+				seminarRangeSummer:     c.SeminarRangeSummer,
+				rangeUnit:              intoRangeUnit(c.RangeUnit),
+				examType:               c.ExamType,
+				credits:                c.Credits,
+				guarantors:             intoTeacherSlice(c.Guarantors),
+				annotation:             intoNullDesc(c.Annotation),
+				syllabus:               intoNullDesc(c.Syllabus),
+				passingTerms:           intoNullDesc(c.PassingTerms),
+				literature:             intoNullDesc(c.Literature),
+				assessmentRequirements: intoNullDesc(c.AssessmentRequirements),
+				entryRequirements:      intoNullDesc(c.EntryRequirements),
+				aim:                    intoNullDesc(c.Aim),
+				prerequisites:          intoRequisites(c.Prerequisites),
+				corequisites:           intoRequisites(c.Corequisites),
+				incompatibles:          intoRequisites(c.Incompatibilities),
+				interchanges:           intoRequisites(c.Interchanges),
+			},
+		}
+	}
+	return result
+}
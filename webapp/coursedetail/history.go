@@ -0,0 +1,216 @@
+package coursedetail
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/michalhercik/RecSIS/errorx"
+	"github.com/michalhercik/RecSIS/language"
+	"github.com/michalhercik/RecSIS/stringsx"
+	"github.com/michalhercik/RecSIS/textdiff"
+)
+
+//================================================================================
+// Handlers
+//================================================================================
+
+// history renders changes of the course across academic years. It is loaded
+// into the history tab of the course detail page.
+func (s Server) history(w http.ResponseWriter, r *http.Request) {
+	lang := language.FromContext(r.Context())
+	t := texts[lang]
+	code := r.PathValue(courseCode)
+	history, err := s.Data.courseHistory(code, lang)
+	if err != nil {
+		code, userMsg := errorx.UnwrapError(err, lang)
+		s.Error.Log(errorx.AddContext(err))
+		s.Error.Render(w, r, code, userMsg, lang)
+		return
+	}
+	content := CourseHistory(history.entries(t), t)
+	err = content.Render(r.Context(), w)
+	if err != nil {
+		s.Error.CannotRenderComponent(w, r, errorx.AddContext(err), lang)
+	}
+}
+
+//================================================================================
+// Data Types and Methods
+//================================================================================
+
+// courseHistory holds snapshots of a course from the oldest academic year.
+type courseHistory []courseSnapshot
+
+type courseSnapshot struct {
+	academicYear int
+	course
+}
+
+// entries returns changes of each snapshot against the previous one from the
+// newest academic year. The oldest snapshot lists its properties as they were
+// first recorded.
+func (h courseHistory) entries(t text) []historyEntry {
+	result := make([]historyEntry, 0, len(h))
+	for i := len(h) - 1; i >= 0; i-- {
+		entry := historyEntry{academicYear: h[i].academicYear}
+		if i == 0 {
+			entry.first = true
+			for _, p := range h[i].historyProperties(t) {
+				entry.changes = append(entry.changes, historyChange{title: p.title, after: p.value})
+			}
+		} else {
+			entry.changes = h[i].changesSince(&h[i-1].course, t)
+		}
+		result = append(result, entry)
+	}
+	return result
+}
+
+type historyEntry struct {
+	academicYear int
+	first        bool
+	changes      []historyChange
+}
+
+// properties returns changes of properties other than descriptions.
+func (e historyEntry) properties() []historyChange {
+	var result []historyChange
+	for _, c := range e.changes {
+		if !c.isDescription() {
+			result = append(result, c)
+		}
+	}
+	return result
+}
+
+// descriptions returns changes of descriptions.
+func (e historyEntry) descriptions() []historyChange {
+	var result []historyChange
+	for _, c := range e.changes {
+		if c.isDescription() {
+			result = append(result, c)
+		}
+	}
+	return result
+}
+
+type historyChange struct {
+	title  string
+	before string
+	after  string
+	// word diff of the description, nil for other properties
+	diff []textdiff.Op
+}
+
+func (c historyChange) isDescription() bool {
+	return c.diff != nil
+}
+
+type historyProperty struct {
+	title string
+	value string
+}
+
+// historyProperties returns the compared properties of the course as text.
+func (c *course) historyProperties(t text) []historyProperty {
+	credits := t.none
+	if c.credits >= 0 {
+		credits = strconv.Itoa(c.credits)
+	}
+	hours := c.hoursString()
+	if hours != "" && c.rangeUnit.valid {
+		hours += " " + c.rangeUnit.abbr
+	}
+	guarantors := make([]string, len(c.guarantors))
+	for i, g := range c.guarantors {
+		guarantors[i] = g.FirstName + " " + g.LastName
+	}
+	return []historyProperty{
+		{t.eCredits, credits},
+		{t.hoursOfCourse, orNone(hours, t)},
+		{t.examType, orNone(c.examType, t)},
+		{t.guarantors, orNone(strings.Join(guarantors, ", "), t)},
+		{t.prerequisites, c.prerequisites.string(t)},
+		{t.corequisites, c.corequisites.string(t)},
+		{t.incompatible, c.incompatibles.string(t)},
+		{t.interchange, c.interchanges.string(t)},
+	}
+}
+
+func (c *course) historyDescriptions() []nullDescription {
+	return []nullDescription{
+		c.annotation,
+		c.syllabus,
+		c.passingTerms,
+		c.literature,
+		c.assessmentRequirements,
+		c.entryRequirements,
+		c.aim,
+	}
+}
+
+// changesSince returns properties and descriptions of the course which differ
+// from the previous snapshot.
+func (c *course) changesSince(prev *course, t text) []historyChange {
+	var result []historyChange
+	prevProperties := prev.historyProperties(t)
+	for i, p := range c.historyProperties(t) {
+		if p.value != prevProperties[i].value {
+			result = append(result, historyChange{title: p.title, before: prevProperties[i].value, after: p.value})
+		}
+	}
+	prevDescriptions := prev.historyDescriptions()
+	for i, d := range c.historyDescriptions() {
+		before := prevDescriptions[i]
+		if d.content == before.content {
+			continue
+		}
+		title := d.title
+		if !d.valid {
+			title = before.title
+		}
+		result = append(result, historyChange{
+			title: stringsx.Capitalize(title),
+			diff:  textdiff.Words(before.content, d.content),
+		})
+	}
+	return result
+}
+
+// string returns the requisites as rendered by renderRequisite.
+func (rs requisiteSlice) string(t text) string {
+	if len(rs) == 0 {
+		return t.none
+	}
+	result := make([]string, len(rs))
+	for i, r := range rs {
+		result[i] = r.string(t)
+	}
+	return strings.Join(result, ", ")
+}
+
+func (r requisite) string(t text) string {
+	if r.isNode() {
+		return r.courseCode
+	}
+	separator := ", "
+	if r.isDisjunction() {
+		separator = fmt.Sprintf(" %s ", t.or)
+	} else if r.isConjunction() {
+		separator = fmt.Sprintf(" %s ", t.and)
+	}
+	children := make([]string, len(r.children))
+	for i, child := range r.children {
+		children[i] = child.string(t)
+	}
+	return "{" + strings.Join(children, separator) + "}"
+}
+
+func orNone(value string, t text) string {
+	if value == "" {
+		return t.none
+	}
+	return value
+}
@@ -0,0 +1,45 @@
+package sqlquery
+
+// CompareTray returns courses in the comparison tray of the user in the order
+// they were added. Codes of courses no longer in SIS are used as titles.
+const CompareTray = `--sql
+SELECT
+	cc.course_code AS code,
+	COALESCE(c.title, cc.course_code) AS title
+FROM course_comparisons cc
+LEFT JOIN LATERAL (
+	SELECT title FROM courses
+	WHERE code = cc.course_code
+		AND lang = $2
+	ORDER BY valid_from DESC
+	LIMIT 1
+) c ON TRUE
+WHERE cc.user_id = $1
+ORDER BY cc.added_at, cc.course_code
+`
+
+// LockCompareTray locks the user until the end of the transaction, so that
+// concurrent additions cannot exceed the size limit of the comparison tray.
+const LockCompareTray = `--sql
+SELECT id
+FROM users
+WHERE id = $1
+FOR UPDATE
+`
+
+// AddToCompareTray adds the course to the comparison tray of the user unless
+// the course does not exist, is already there or the tray has $3 courses.
+// It has to run after LockCompareTray in the same transaction.
+const AddToCompareTray = `--sql
+INSERT INTO course_comparisons (user_id, course_code)
+SELECT $1, $2
+WHERE EXISTS (SELECT 1 FROM courses WHERE code = $2)
+	AND (SELECT COUNT(*) FROM course_comparisons WHERE user_id = $1) < $3
+ON CONFLICT (user_id, course_code) DO NOTHING
+`
+
+const RemoveFromCompareTray = `--sql
+DELETE FROM course_comparisons
+WHERE user_id = $1
+	AND course_code = $2
+`
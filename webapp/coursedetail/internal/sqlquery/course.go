@@ -34,7 +34,7 @@ user_blueprint_semesters AS (
 		LEFT JOIN blueprint_courses bc
 			ON bs.id = bc.blueprint_semester_id
 			AND bc.course_code = $2
-		WHERE by.user_id = $1
+		WHERE by.scenario_id = (SELECT id FROM blueprint_scenarios WHERE user_id = $1 AND active)
 		ORDER BY by.academic_year, bs.semester
 	)
 )
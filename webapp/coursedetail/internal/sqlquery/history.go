@@ -0,0 +1,33 @@
+package sqlquery
+
+// CourseHistory returns snapshots of the course stored by the ELT, one per
+// academic year, from the oldest.
+const CourseHistory = `--sql
+SELECT
+	academic_year,
+	code,
+	COALESCE(title, '') AS title,
+	lecture_range_winter,
+	seminar_range_winter,
+	lecture_range_summer,
+	seminar_range_summer,
+	range_unit,
+	COALESCE(exam, '') AS exam,
+	COALESCE(credits, -1) AS credits,
+	guarantors,
+	prerequisites,
+	corequisites,
+	incompatibilities,
+	interchangeabilities,
+	annotation,
+	syllabus,
+	terms_of_passing,
+	literature,
+	requirements_of_assessment,
+	entry_requirements,
+	aim
+FROM course_history
+WHERE code = $1
+	AND lang = $2
+ORDER BY academic_year
+`
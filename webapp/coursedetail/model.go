@@ -23,13 +23,15 @@ const (
 	negativeRating      = 0
 	positiveRating      = 1
 	resultsPerPage      = 20
+	maxComparedCourses  = 4
 	ttDelay             = 200 // tooltip delay in ms
 )
 
 const (
-	searchQuery  = "survey-search"
-	surveyOffset = "survey-offset"
-	ratingParam  = "rating"
+	searchQuery       = "survey-search"
+	surveyOffset      = "survey-offset"
+	ratingParam       = "rating"
+	compareCodesParam = "codes"
 )
 
 const (
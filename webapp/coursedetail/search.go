@@ -2,20 +2,54 @@ package coursedetail
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
+	"strings"
 
 	"github.com/meilisearch/meilisearch-go"
 	"github.com/michalhercik/RecSIS/errorx"
+	"github.com/michalhercik/RecSIS/filters"
+	"github.com/michalhercik/RecSIS/fulltext"
 	"github.com/michalhercik/RecSIS/language"
 )
 
-type Search struct {
+//================================================================================
+// Search Backends
+//================================================================================
+
+// SearchEngine is implemented by every search backend of survey comments.
+type SearchEngine interface {
+	comments(r request) (response, error)
+	// Returns number of survey comments of each of the courses.
+	commentCounts(codes []string, lang language.Language) (map[string]int, error)
+}
+
+// FallbackSearch uses the full-text search for survey comments while
+// Meilisearch is unavailable.
+type FallbackSearch struct {
+	fulltext.Fallback[SearchEngine]
+}
+
+func (s FallbackSearch) comments(r request) (response, error) {
+	return fulltext.Call(s.Fallback, func(e SearchEngine) (response, error) { return e.comments(r) })
+}
+
+func (s FallbackSearch) commentCounts(codes []string, lang language.Language) (map[string]int, error) {
+	return fulltext.Call(s.Fallback, func(e SearchEngine) (map[string]int, error) { return e.commentCounts(codes, lang) })
+}
+
+//================================================================================
+// Meilisearch
+//================================================================================
+
+type MeiliSearch struct {
 	Client meilisearch.ServiceManager
 	Survey meilisearch.IndexConfig
 }
 
 // TODO: write own meilisearch client
-func (s Search) comments(r request) (response, error) {
+func (s MeiliSearch) comments(r request) (response, error) {
 	t := texts[r.lang]
 	var result response
 	searchReq := makeMultiSearchRequest(r, s.Survey)
@@ -52,9 +86,61 @@ func (s Search) comments(r request) (response, error) {
 	return result, nil
 }
 
+// commentCounts reads the counts from the facet distribution of course codes.
+func (s MeiliSearch) commentCounts(codes []string, lang language.Language) (map[string]int, error) {
+	t := texts[lang]
+	quoted := make([]string, len(codes))
+	for i, code := range codes {
+		quoted[i] = filters.Quote(code)
+	}
+	searchReq := &meilisearch.MultiSearchRequest{
+		Queries: []*meilisearch.SearchRequest{{
+			IndexUID:             s.Survey.Uid,
+			Limit:                1,
+			AttributesToRetrieve: []string{meiliCourseCode},
+			Filter:               fmt.Sprintf("%s IN [%s]", meiliCourseCode, strings.Join(quoted, ",")),
+			Facets:               []string{meiliCourseCode},
+		}},
+	}
+	response, err := s.Client.MultiSearch(searchReq)
+	if err != nil {
+		return nil, errorx.NewHTTPErr(
+			errorx.AddContext(err),
+			http.StatusInternalServerError,
+			t.errCannotSearchForSurvey,
+		)
+	}
+	rawResponse, err := response.MarshalJSON()
+	if err != nil {
+		return nil, errorx.NewHTTPErr(
+			errorx.AddContext(err),
+			http.StatusInternalServerError,
+			t.errCannotSearchForSurvey,
+		)
+	}
+	multi := multiResponse{}
+	if err = json.Unmarshal(rawResponse, &multi); err != nil {
+		return nil, errorx.NewHTTPErr(
+			errorx.AddContext(err),
+			http.StatusInternalServerError,
+			t.errCannotSearchForSurvey,
+		)
+	}
+	if len(multi.Results) == 0 {
+		return nil, errorx.NewHTTPErr(
+			errorx.AddContext(errors.New("empty multi-search response")),
+			http.StatusInternalServerError,
+			t.errCannotSearchForSurvey,
+		)
+	}
+	return multi.Results[0].FacetDistribution[meiliCourseCode], nil
+}
+
 type expression interface {
 	String() string
 	Except() func(func(string, string) bool)
+	JSONPath() string
+	ExceptJSONPath() func(func(string, string) bool)
 	ConditionsCount() int
 	Append(param string, values ...string)
 }
@@ -139,3 +225,69 @@ func attributesToRetrieve(lang language.Language) []string {
 	}
 	return attrs
 }
+
+//================================================================================
+// PostgreSQL Full-Text Search
+//================================================================================
+
+// FullTextSearch searches survey comments in PostgreSQL when Meilisearch is
+// not available, see fulltext package.
+type FullTextSearch struct {
+	Engine fulltext.Engine
+	Survey string
+}
+
+func (s FullTextSearch) comments(r request) (response, error) {
+	t := texts[r.lang]
+	var result response
+	filter := r.filter.JSONPath()
+	res, err := s.Engine.Search(fulltext.Request{
+		Index:      s.Survey,
+		Query:      r.query,
+		Lang:       r.lang,
+		Filter:     filter,
+		Facets:     fulltext.DisjunctiveFacets(r.facets, filter, r.filter.ExceptJSONPath()),
+		Sort:       []string{r.sort},
+		Attributes: attributesToRetrieve(r.lang),
+		Offset:     r.offset,
+		Limit:      r.limit,
+	})
+	if err != nil {
+		return result, errorx.NewHTTPErr(
+			errorx.AddContext(err),
+			http.StatusInternalServerError,
+			t.errCannotSearchForSurvey,
+		)
+	}
+	result.EstimatedTotalHits = res.TotalHits
+	result.FacetDistribution = res.FacetDistribution
+	result.Survey = make([]survey, len(res.Hits))
+	for i, hit := range res.Hits {
+		if err := json.Unmarshal(hit, &result.Survey[i]); err != nil {
+			return result, errorx.NewHTTPErr(
+				errorx.AddContext(err),
+				http.StatusInternalServerError,
+				t.errCannotSearchForSurvey,
+			)
+		}
+	}
+	return result, nil
+}
+
+func (s FullTextSearch) commentCounts(codes []string, lang language.Language) (map[string]int, error) {
+	filter := fulltext.In(meiliCourseCode, codes...)
+	res, err := s.Engine.Search(fulltext.Request{
+		Index:  s.Survey,
+		Lang:   lang,
+		Filter: filter,
+		Facets: []fulltext.Facet{{Attribute: meiliCourseCode, Filter: filter}},
+	})
+	if err != nil {
+		return nil, errorx.NewHTTPErr(
+			errorx.AddContext(err),
+			http.StatusInternalServerError,
+			texts[lang].errCannotSearchForSurvey,
+		)
+	}
+	return res.FacetDistribution[meiliCourseCode], nil
+}
@@ -22,7 +22,7 @@ type Server struct {
 	Data    DBManager
 	Error   Error
 	Filters filters.Filters
-	Search  Search
+	Search  SearchEngine
 	Page    Page
 	router  http.Handler
 }
@@ -95,6 +95,11 @@ func (s *Server) initRouter() {
 	}
 	endpoints := []routingElement{
 		{"GET /{%s}", s.page, []any{courseCode}},
+		{"GET /compare", s.compare, nil},
+		{"GET /compare/tray", s.compareTray, nil},
+		{"POST /compare/tray/{%s}", s.addToCompareTray, []any{courseCode}},
+		{"DELETE /compare/tray/{%s}", s.removeFromCompareTray, []any{courseCode}},
+		{"GET /history/{%s}", s.history, []any{courseCode}},
 		{"GET /survey/{%s}", s.survey, []any{courseCode}},
 		{"GET /survey/next/{%s}", s.surveyNext, []any{courseCode}},
 		{"PUT /rating/{%s}/{%s}", s.rateCategory, []any{courseCode, ratingCategory}},
@@ -53,6 +53,24 @@ type text struct {
 	detail                  string
 	detailSm                string
 	noDetail                string
+	// comparison
+	comparePageTitle  string
+	compare           string
+	compareTrayTitle  string
+	removeFromCompare string
+	noComparedCourses string
+	hoursOfCourse     string
+	examType          string
+	overallRating     string
+	surveyComments    string
+	none              string
+	// history
+	history       string
+	noHistory     string
+	firstRecorded string
+	noChanges     string
+	changeBefore  string
+	changeAfter   string
 	// language
 	language language.Language
 	// errors
@@ -73,6 +91,11 @@ type text struct {
 	errCannotSearchForSurvey     string
 	errPageTitle                 string
 	errPageNotFound              string
+	errTooManyComparedCourses    string
+	errCompareTrayFull           string
+	errCannotGetCompareTray      string
+	errCannotUpdateCompareTray   string
+	errCannotGetCourseHistory    string
 }
 
 func (t text) yearStr(year int) string {
@@ -132,6 +155,24 @@ var texts = map[language.Language]text{
 		detail:                  "Detailní informace",
 		detailSm:                "Detail",
 		noDetail:                "Pro tento předmět nejsou k dispozici žádné podrobnosti.",
+		// comparison
+		comparePageTitle:  "Porovnání předmětů",
+		compare:           "Porovnat",
+		compareTrayTitle:  "K porovnání",
+		removeFromCompare: "Odebrat z porovnání",
+		noComparedCourses: "Nejsou vybrány žádné předměty k porovnání. Přidejte je ve vyhledávání nebo v Blueprintu.",
+		hoursOfCourse:     "Rozsah",
+		examType:          "Zakončení",
+		overallRating:     "Celkové hodnocení",
+		surveyComments:    "Komentáře v anketě",
+		none:              "Žádné",
+		// history
+		history:       "Historie",
+		noHistory:     "Pro tento předmět zatím není k dispozici žádná historie.",
+		firstRecorded: "První záznam",
+		noChanges:     "Beze změn",
+		changeBefore:  "Dříve",
+		changeAfter:   "Nově",
 		// language
 		language: language.CS,
 		// errors
@@ -152,6 +193,11 @@ var texts = map[language.Language]text{
 		errCannotSearchForSurvey:     "vyhledávání selhalo",
 		errPageTitle:                 "Detail předmětu",
 		errPageNotFound:              "Stránka nenalezena",
+		errTooManyComparedCourses:    "Porovnat lze nejvýše 4 předměty",
+		errCompareTrayFull:           "K porovnání lze přidat nejvýše 4 předměty, nejprve některý odeberte",
+		errCannotGetCompareTray:      "Nebylo možné načíst předměty k porovnání",
+		errCannotUpdateCompareTray:   "Nebylo možné upravit předměty k porovnání",
+		errCannotGetCourseHistory:    "Nebylo možné získat historii předmětu z databáze",
 	},
 	language.EN: {
 		faculty:                 "Faculty",
@@ -200,6 +246,24 @@ var texts = map[language.Language]text{
 		detail:                  "Detailed information",
 		detailSm:                "Detail",
 		noDetail:                "No detailed information available for this course.",
+		// comparison
+		comparePageTitle:  "Compare Courses",
+		compare:           "Compare",
+		compareTrayTitle:  "To compare",
+		removeFromCompare: "Remove from comparison",
+		noComparedCourses: "No courses selected for comparison. Add them in the course search or in the Blueprint.",
+		hoursOfCourse:     "Hours",
+		examType:          "Examination",
+		overallRating:     "Overall rating",
+		surveyComments:    "Survey comments",
+		none:              "None",
+		// history
+		history:       "History",
+		noHistory:     "No history is available for this course yet.",
+		firstRecorded: "First recorded",
+		noChanges:     "No changes",
+		changeBefore:  "Before",
+		changeAfter:   "After",
 		// language
 		language: language.EN,
 		// errors
@@ -220,5 +284,10 @@ var texts = map[language.Language]text{
 		errCannotSearchForSurvey:     "Search failed",
 		errPageTitle:                 "Course Detail",
 		errPageNotFound:              "Page not found",
+		errTooManyComparedCourses:    "At most 4 courses can be compared",
+		errCompareTrayFull:           "At most 4 courses can be added to comparison, remove some first",
+		errCannotGetCompareTray:      "Unable to load courses to compare",
+		errCannotUpdateCompareTray:   "Unable to update courses to compare",
+		errCannotGetCourseHistory:    "Unable to retrieve course history from database",
 	},
 }
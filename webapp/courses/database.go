@@ -1,8 +1,11 @@
 package courses
 
 import (
+	"database/sql"
+	"errors"
 	"fmt"
 	"net/http"
+	"slices"
 	"strings"
 
 	"github.com/jmoiron/sqlx"
@@ -50,6 +53,8 @@ func intoCourses(from courses) []course {
 		result[i].examType = course.ExamType
 		result[i].credits = course.Credits
 		result[i].guarantors = intoTeacherSlice(course.Guarantors)
+		result[i].department = course.Department.Name
+		result[i].language = course.Language.String
 		result[i].blueprintSemesters = course.BlueprintSemesters
 		result[i].blueprintAssignments = intoBlueprintAssignmentSlice(course.BlueprintSemesters)
 		result[i].inDegreePlan = course.InDegreePlan
@@ -98,3 +103,130 @@ func intoBlueprintAssignmentSlice(from pq.BoolArray) []assignment {
 	}
 	return result
 }
+
+func (m DBManager) saveSearch(userID string, search savedSearch, lang language.Language) error {
+	t := texts[lang]
+	tx, err := m.DB.Beginx()
+	if err != nil {
+		return errorx.NewHTTPErr(
+			errorx.AddContext(fmt.Errorf("DB.Beginx: %w", err)),
+			http.StatusInternalServerError,
+			t.errCannotSaveSearch,
+		)
+	}
+	defer tx.Rollback()
+	var id int
+	err = tx.Get(&id, sqlquery.InsertSavedSearch, userID, search.name, search.urlQuery, search.textQuery, search.filter, lang)
+	if errors.Is(err, sql.ErrNoRows) {
+		return errorx.NewHTTPErr(
+			errorx.AddContext(fmt.Errorf("saved search already exists"), errorx.P("name", search.name)),
+			http.StatusConflict,
+			t.errSavedSearchExists,
+		)
+	}
+	if err != nil {
+		return errorx.NewHTTPErr(
+			errorx.AddContext(fmt.Errorf("sqlquery.InsertSavedSearch: %w", err), errorx.P("name", search.name)),
+			http.StatusInternalServerError,
+			t.errCannotSaveSearch,
+		)
+	}
+	if _, err = tx.Exec(sqlquery.InsertSavedSearchMatches, id); err != nil {
+		return errorx.NewHTTPErr(
+			errorx.AddContext(fmt.Errorf("sqlquery.InsertSavedSearchMatches: %w", err), errorx.P("id", id)),
+			http.StatusInternalServerError,
+			t.errCannotSaveSearch,
+		)
+	}
+	if err = tx.Commit(); err != nil {
+		return errorx.NewHTTPErr(
+			errorx.AddContext(fmt.Errorf("tx.Commit: %w", err)),
+			http.StatusInternalServerError,
+			t.errCannotSaveSearch,
+		)
+	}
+	return nil
+}
+
+func (m DBManager) personalContext(userID string, lang language.Language) (personalContext, error) {
+	t := texts[lang]
+	var result personalContext
+	var planned []struct {
+		Code     string `db:"course_code"`
+		Year     int    `db:"year"`
+		Semester int    `db:"semester"`
+	}
+	if err := m.DB.Select(&planned, sqlquery.BlueprintCourses, userID); err != nil {
+		return result, errorx.NewHTTPErr(
+			errorx.AddContext(fmt.Errorf("sqlquery.BlueprintCourses: %w", err)),
+			http.StatusInternalServerError,
+			t.errCannotLoadPersonalFilters,
+		)
+	}
+	result.blueprintCourses = make([]plannedCourse, len(planned))
+	for i, c := range planned {
+		result.blueprintCourses[i] = plannedCourse{code: c.Code, year: c.Year, semester: semesterAssignment(c.Semester)}
+	}
+	if err := m.DB.Get(&result.blueprintYears, sqlquery.BlueprintYears, userID); err != nil {
+		return result, errorx.NewHTTPErr(
+			errorx.AddContext(fmt.Errorf("sqlquery.BlueprintYears: %w", err)),
+			http.StatusInternalServerError,
+			t.errCannotLoadPersonalFilters,
+		)
+	}
+	var blocs []struct {
+		Code     string `db:"course_code"`
+		BlocCode string `db:"bloc_subject_code"`
+		BlocName string `db:"bloc_name"`
+	}
+	if err := m.DB.Select(&blocs, sqlquery.DegreePlanBlocs, userID, lang); err != nil {
+		return result, errorx.NewHTTPErr(
+			errorx.AddContext(fmt.Errorf("sqlquery.DegreePlanBlocs: %w", err), errorx.P("lang", lang)),
+			http.StatusInternalServerError,
+			t.errCannotLoadPersonalFilters,
+		)
+	}
+	blocIndex := make(map[string]int)
+	for _, row := range blocs {
+		i, ok := blocIndex[row.BlocCode]
+		if !ok {
+			i = len(result.blocs)
+			blocIndex[row.BlocCode] = i
+			result.blocs = append(result.blocs, degreePlanBloc{code: row.BlocCode, name: row.BlocName})
+		}
+		result.blocs[i].courses = append(result.blocs[i].courses, row.Code)
+	}
+	var prerequisites []struct {
+		Code          string              `db:"code"`
+		Prerequisites dbds.RequisiteSlice `db:"prerequisites"`
+	}
+	if err := m.DB.Select(&prerequisites, sqlquery.CoursesWithPrerequisites, lang); err != nil {
+		return result, errorx.NewHTTPErr(
+			errorx.AddContext(fmt.Errorf("sqlquery.CoursesWithPrerequisites: %w", err), errorx.P("lang", lang)),
+			http.StatusInternalServerError,
+			t.errCannotLoadPersonalFilters,
+		)
+	}
+	result.prerequisites = make([]coursePrerequisites, len(prerequisites))
+	for i, c := range prerequisites {
+		result.prerequisites[i] = coursePrerequisites{code: c.Code, prerequisites: intoRequisites(c.Prerequisites)}
+	}
+	slices.SortFunc(result.prerequisites, func(a, b coursePrerequisites) int {
+		return strings.Compare(a.code, b.code)
+	})
+	return result, nil
+}
+
+func intoRequisites(from dbds.RequisiteSlice) requisiteSlice {
+	result := make(requisiteSlice, len(from))
+	for i, r := range from {
+		result[i] = requisite{
+			courseCode: r.CourseCode,
+			children:   intoRequisites(r.Children),
+		}
+		if r.Group.Valid {
+			result[i].group = r.Group.String
+		}
+	}
+	return result
+}
@@ -0,0 +1,185 @@
+package courses
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/michalhercik/RecSIS/academicyear"
+	"github.com/michalhercik/RecSIS/errorx"
+	"github.com/michalhercik/RecSIS/language"
+	"github.com/michalhercik/RecSIS/xlsx"
+)
+
+//================================================================================
+// Handlers
+//================================================================================
+
+// export returns all courses matching the search of the request, regardless of
+// pagination, as a CSV or XLSX spreadsheet.
+func (s Server) export(w http.ResponseWriter, r *http.Request) {
+	lang := language.FromContext(r.Context())
+	t := texts[lang]
+	format := r.FormValue(formatParam)
+	if format != formatCSV && format != formatXLSX {
+		s.Error.Log(errorx.AddContext(fmt.Errorf("invalid export format %s", format)))
+		s.Error.Render(w, r, http.StatusBadRequest, t.errInvalidExportFormat, lang)
+		return
+	}
+	req, _, err := s.parseQueryRequest(r)
+	if err != nil {
+		code, userMsg := errorx.UnwrapError(err, lang)
+		userMsg = fmt.Sprintf("%s: %s", t.errCannotSearchCourses, userMsg)
+		s.Error.Log(errorx.AddContext(err))
+		s.Error.Render(w, r, code, userMsg, lang)
+		return
+	}
+	courses, err := s.searchAll(req)
+	if err != nil {
+		code, userMsg := errorx.UnwrapError(err, lang)
+		s.Error.Log(errorx.AddContext(err))
+		s.Error.Render(w, r, code, userMsg, lang)
+		return
+	}
+	var buf bytes.Buffer
+	switch format {
+	case formatXLSX:
+		err = exportXLSX(&buf, courses, academicyear.StartYear(r.Context()), t)
+		w.Header().Set("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+	default:
+		err = exportCSV(&buf, courses, academicyear.StartYear(r.Context()), t)
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	}
+	if err != nil {
+		s.Error.Log(errorx.AddContext(fmt.Errorf("export %s: %w", format, err)))
+		s.Error.Render(w, r, http.StatusInternalServerError, t.errCannotExport, lang)
+		return
+	}
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": "courses." + format}))
+	w.Write(buf.Bytes())
+}
+
+// searchAll runs the search page by page until all matching courses are
+// fetched.
+func (s Server) searchAll(req request) ([]course, error) {
+	var codes []string
+	req, _, _, err := s.withPersonalFilter(req)
+	if err != nil {
+		return nil, errorx.AddContext(err)
+	}
+	req.page = firstPage
+	req.hitsPerPage = exportBatchSize
+	// facets are not exported
+	req.facets = nil
+	for {
+		res, err := s.searchCourses(req)
+		if err != nil {
+			return nil, errorx.AddContext(err, errorx.P("page", req.page))
+		}
+		codes = append(codes, res.Courses...)
+		if req.page >= res.TotalPages || len(res.Courses) == 0 {
+			break
+		}
+		req.page++
+	}
+	result, err := s.Data.courses(req.userID, codes, req.lang)
+	if err != nil {
+		return nil, errorx.AddContext(err)
+	}
+	return result, nil
+}
+
+//================================================================================
+// Spreadsheets
+//================================================================================
+
+func exportHeader(t text) []string {
+	return []string{
+		t.columnCode,
+		t.columnTitle,
+		t.columnCredits,
+		t.columnSemester,
+		t.columnHours,
+		t.columnExamType,
+		t.columnGuarantors,
+		t.columnDepartment,
+		t.columnLanguage,
+		t.columnBlueprint,
+	}
+}
+
+// exportRecord returns cells of the course in the order of exportHeader.
+// Credits are empty if the course has none. Blueprint years are followed by
+// their academic year if startYear is known.
+func exportRecord(c *course, startYear int, t text) []string {
+	credits := ""
+	if c.credits >= 0 {
+		credits = strconv.Itoa(c.credits)
+	}
+	guarantors := make([]string, len(c.guarantors))
+	for i, g := range c.guarantors {
+		guarantors[i] = g.fullName()
+	}
+	assignments := make([]string, len(c.blueprintAssignments))
+	for i, a := range c.blueprintAssignments.Sort() {
+		assignments[i] = a.string(t.language)
+		if label := academicyear.PositionLabel(startYear, a.year); label != "" {
+			assignments[i] += " (" + label + ")"
+		}
+	}
+	return []string{
+		c.code,
+		c.title,
+		credits,
+		c.semester.string(t),
+		c.hoursString(),
+		c.examType,
+		strings.Join(guarantors, ", "),
+		c.department,
+		c.language,
+		strings.Join(assignments, ", "),
+	}
+}
+
+// exportCSV writes the courses as CSV. It starts with UTF-8 byte order mark
+// so that spreadsheet applications do not mistake the encoding.
+func exportCSV(w io.Writer, courses []course, startYear int, t text) error {
+	if _, err := io.WriteString(w, "\ufeff"); err != nil {
+		return err
+	}
+	writer := csv.NewWriter(w)
+	if err := writer.Write(exportHeader(t)); err != nil {
+		return err
+	}
+	for i := range courses {
+		if err := writer.Write(exportRecord(&courses[i], startYear, t)); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// exportXLSX writes the courses as XLSX with credits as numbers.
+func exportXLSX(w io.Writer, courses []course, startYear int, t text) error {
+	sheet := xlsx.New(t.exportSheet, exportHeader(t)...)
+	for i := range courses {
+		record := exportRecord(&courses[i], startYear, t)
+		cells := make([]xlsx.Cell, len(record))
+		for j, value := range record {
+			cells[j] = xlsx.Text(value)
+		}
+		if courses[i].credits >= 0 {
+			// credits column, see exportHeader
+			cells[2] = xlsx.Number(float64(courses[i].credits))
+		}
+		sheet.Row(cells...)
+	}
+	_, err := sheet.WriteTo(w)
+	return err
+}
@@ -7,7 +7,7 @@ WITH user_blueprint_semesters AS (
 		array_agg(bc.course_code IS NOT NULL ORDER BY by.academic_year, bs.semester) AS semesters
 	FROM unnest($2::text[]) t(course_code)
 	LEFT JOIN blueprint_years by
-		ON by.user_id = $1
+		ON by.scenario_id = (SELECT id FROM blueprint_scenarios WHERE user_id = $1 AND active)
 	LEFT JOIN blueprint_semesters bs
 		ON by.id = bs.blueprint_year_id
 	LEFT JOIN blueprint_courses bc
@@ -36,6 +36,8 @@ SELECT
 	COALESCE(c.exam, '') exam,
 	COALESCE(c.credits, -1) credits,
 	c.guarantors,
+	COALESCE(c.department, '{}'::jsonb) department,
+	c.taught_lang,
 	ubs.semesters,
 	dp.course_code IS NOT NULL AS in_degree_plan
 FROM courses c
@@ -0,0 +1,60 @@
+package sqlquery
+
+// BlueprintCourses returns courses of the active blueprint scenario of the
+// user with their year and semester (year 0 are unassigned courses). Custom
+// courses count as the course they are mapped to, failed courses are left
+// out.
+const BlueprintCourses = `--sql
+SELECT
+	COALESCE(bc.course_code, bc.mapped_course_code) AS course_code,
+	by.academic_year AS year,
+	bs.semester
+FROM blueprint_scenarios sc
+INNER JOIN blueprint_years by
+	ON by.scenario_id = sc.id
+INNER JOIN blueprint_semesters bs
+	ON bs.blueprint_year_id = by.id
+INNER JOIN blueprint_courses bc
+	ON bc.blueprint_semester_id = bs.id
+WHERE sc.user_id = $1
+	AND sc.active
+	AND bc.status != 'failed'
+	AND COALESCE(bc.course_code, bc.mapped_course_code) IS NOT NULL
+`
+
+const BlueprintYears = `--sql
+SELECT COALESCE(MAX(by.academic_year), 0)
+FROM blueprint_scenarios sc
+INNER JOIN blueprint_years by
+	ON by.scenario_id = sc.id
+WHERE sc.user_id = $1
+	AND sc.active
+`
+
+// DegreePlanBlocs returns courses of the degree plan selected by the user
+// together with their bloc. Interchangeable courses are left out as in
+// Courses.
+const DegreePlanBlocs = `--sql
+SELECT
+	dpc.course_code,
+	dpc.bloc_subject_code,
+	COALESCE(dpc.bloc_name, dpc.bloc_subject_code) AS bloc_name
+FROM studies s
+INNER JOIN degree_plan_courses dpc
+	ON dpc.plan_code = s.degree_plan_code
+	AND dpc.lang = $2
+WHERE s.user_id = $1
+	AND dpc.interchangeability IS NULL
+ORDER BY dpc.seq, dpc.bloc_subject_code
+`
+
+const CoursesWithPrerequisites = `--sql
+SELECT
+	c.code,
+	c.prerequisites
+FROM courses c
+WHERE c.lang = $1
+	AND c.valid_to = 9999
+	AND jsonb_typeof(c.prerequisites) = 'array'
+	AND jsonb_array_length(c.prerequisites) > 0
+`
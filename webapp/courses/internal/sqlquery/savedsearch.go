@@ -0,0 +1,19 @@
+package sqlquery
+
+// InsertSavedSearch returns no row if the user already has a saved search of
+// the same name.
+const InsertSavedSearch = `--sql
+	INSERT INTO saved_searches (user_id, name, url_query, text_query, filter, lang)
+	VALUES ($1, $2, $3, $4, $5, $6)
+	ON CONFLICT (user_id, name) DO NOTHING
+	RETURNING id
+`
+
+// InsertSavedSearchMatches stores courses matching the saved search at the
+// moment, so that only courses matching it after the next ELT load are new.
+// The ELT matches courses by the same saved_search_courses function.
+const InsertSavedSearchMatches = `--sql
+	INSERT INTO saved_search_matches (saved_search_id, course_code, is_new)
+	SELECT $1, course_code, FALSE
+	FROM saved_search_courses($1)
+`
@@ -4,8 +4,9 @@ import (
 	"database/sql"
 	"fmt"
 	"iter"
+	"maps"
+	"net/url"
 	"sort"
-	"strings"
 	"unicode/utf8"
 
 	"github.com/michalhercik/RecSIS/filters"
@@ -17,15 +18,43 @@ import (
 //================================================================================
 
 const (
-	pageParam        = "page"
-	hitsPerPageParam = "hitsPerPage"
+	pageParam            = "page"
+	hitsPerPageParam     = "hitsPerPage"
+	sortParam            = "sort"
+	savedSearchNameParam = "saved-search-name"
+	formatParam          = "format"
 )
+const (
+	formatCSV  = "csv"
+	formatXLSX = "xlsx"
+)
+
+// exportBatchSize is the number of courses fetched from the search engine at
+// once when exporting search results.
+const exportBatchSize = 1000
+const maxSavedSearchNameLength = 100
 const (
 	defaultCoursesPerPage = 24
 	firstPage             = 1
 )
 const courseIndex = "courses"
 
+// QualifierAliases are short names of filter categories usable in inline
+// qualifiers of search query, e.g. "sem:winter lang:en". Qualifiers mapped to
+// empty string are searched as text because teachers are searchable but there
+// is no filter for them.
+var QualifierAliases = map[string]string{
+	"sem":       "start_semester",
+	"semester":  "start_semester",
+	"lang":      "taught_lang",
+	"language":  "taught_lang",
+	"dept":      "department",
+	"fac":       "faculty",
+	"state":     "taught_state",
+	"teacher":   "",
+	"guarantor": "",
+}
+
 //================================================================================
 // Data Types and Methods
 //================================================================================
@@ -36,9 +65,108 @@ type coursesPage struct {
 	pageSize    int
 	totalPages  int
 	search      string
+	sort        sortOption
 	facets      iter.Seq[filters.FacetIterator]
 	searchParam string
+	query       url.Values
+	personal    []personalFacet
 	bpBtn       PartialBlueprintAdd
+	// hint about unknown qualifiers of the search searched as text
+	hint string
+}
+
+// personalFiltersURL returns URL of personal facets counted for the search.
+func (cp *coursesPage) personalFiltersURL(lang language.Language) string {
+	return fmt.Sprintf("%s?%s", lang.LocalizeURL("/courses/personal-filters"), cp.query.Encode())
+}
+
+// exportURL returns URL of the export of all courses matching the search in
+// the format.
+func (cp *coursesPage) exportURL(format string, lang language.Language) string {
+	query := maps.Clone(cp.query)
+	if query == nil {
+		query = url.Values{}
+	}
+	query.Del(pageParam)
+	query.Del(hitsPerPageParam)
+	query.Set(formatParam, format)
+	return fmt.Sprintf("%s?%s", lang.LocalizeURL("/courses/export"), query.Encode())
+}
+
+// savedSearch is a course search saved by the user to be re-run from the home
+// page. Besides the URL query of the search page it keeps the full-text query
+// and SQL/JSON path filter the ELT uses to find courses newly matching it.
+type savedSearch struct {
+	name      string
+	urlQuery  string
+	textQuery string
+	filter    string
+}
+
+// sortOption is the order of search results selected by the user. The zero
+// value keeps the relevance order of the search engine.
+type sortOption string
+
+const (
+	sortRelevance   sortOption = ""
+	sortCreditsDesc sortOption = "credits-desc"
+	sortCreditsAsc  sortOption = "credits-asc"
+	sortCode        sortOption = "code"
+	sortTitle       sortOption = "title"
+	sortRating      sortOption = "rating"
+	sortRatingCount sortOption = "rating-count"
+	sortNewest      sortOption = "newest"
+	sortHoursDesc   sortOption = "hours-desc"
+	sortHoursAsc    sortOption = "hours-asc"
+)
+
+var sortOptions = []sortOption{
+	sortRelevance,
+	sortCreditsDesc,
+	sortCreditsAsc,
+	sortCode,
+	sortTitle,
+	sortRating,
+	sortRatingCount,
+	sortNewest,
+	sortHoursDesc,
+	sortHoursAsc,
+}
+
+func parseSortOption(value string) (sortOption, bool) {
+	for _, option := range sortOptions {
+		if string(option) == value {
+			return option, true
+		}
+	}
+	return sortRelevance, false
+}
+
+func (so sortOption) string(t text) string {
+	switch so {
+	case sortRelevance:
+		return t.sortRelevance
+	case sortCreditsDesc:
+		return t.sortCreditsDesc
+	case sortCreditsAsc:
+		return t.sortCreditsAsc
+	case sortCode:
+		return t.sortCode
+	case sortTitle:
+		return t.sortTitle
+	case sortRating:
+		return t.sortRating
+	case sortRatingCount:
+		return t.sortRatingCount
+	case sortNewest:
+		return t.sortNewest
+	case sortHoursDesc:
+		return t.sortHoursDesc
+	case sortHoursAsc:
+		return t.sortHoursAsc
+	default:
+		return ""
+	}
 }
 
 type course struct {
@@ -53,6 +181,8 @@ type course struct {
 	examType             string
 	credits              int
 	guarantors           teacherSlice
+	department           string
+	language             string
 	blueprintAssignments assignmentSlice
 	blueprintSemesters   []bool
 	inDegreePlan         bool
@@ -114,17 +244,6 @@ func (ts teachingSemester) string(t text) string {
 
 type teacherSlice []teacher
 
-func (ts teacherSlice) string(t text) string {
-	names := []string{}
-	for _, teacher := range ts {
-		names = append(names, teacher.string())
-	}
-	if len(names) == 0 {
-		return t.noGuarantors
-	}
-	return strings.Join(names, ", ")
-}
-
 type teacher struct {
 	sisID       string
 	firstName   string
@@ -138,6 +257,10 @@ func (t teacher) string() string {
 	return fmt.Sprintf("%c. %s", firstRune, t.lastName)
 }
 
+func (t teacher) fullName() string {
+	return t.firstName + " " + t.lastName
+}
+
 type assignmentSlice []assignment
 
 func (a assignmentSlice) Sort() assignmentSlice {
@@ -0,0 +1,455 @@
+package courses
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/michalhercik/RecSIS/errorx"
+	"github.com/michalhercik/RecSIS/filters"
+	"github.com/michalhercik/RecSIS/fulltext"
+	"github.com/michalhercik/RecSIS/language"
+)
+
+// Personal facets filter courses by data of the user (blueprint and degree
+// plan) which are not attributes of search documents. Selected values are
+// resolved from PostgreSQL into sets of course codes which are added to the
+// filter of the search (see codeCondition). Counts of the values are searched
+// separately (see Count method of SearchEngine).
+
+//================================================================================
+// Constants
+//================================================================================
+
+// URL query keys of personal facets. They must not clash with keys of
+// filters, see filters package.
+const (
+	myBlueprintParam   = "my-blueprint"
+	myDegreePlanParam  = "my-degree-plan"
+	prerequisitesParam = "prerequisites-before"
+)
+
+var personalParams = []string{myBlueprintParam, myDegreePlanParam, prerequisitesParam}
+
+// values of personal facets, blocs of the degree plan are identified by their
+// code and semesters by year and semester, e.g. 2-1 for winter of year 2
+const (
+	inBlueprint     = "in"
+	notInBlueprint  = "out"
+	wholeDegreePlan = "all"
+)
+
+// course code attribute of course documents, it has to be filterable
+const meiliCourseCode = "code"
+
+//================================================================================
+// Conditions
+//================================================================================
+
+// codeCondition restricts search results to courses with (or without if
+// negated) one of the codes.
+type codeCondition struct {
+	codes   []string
+	negated bool
+}
+
+// String returns the condition as a Meilisearch filter. It is empty if the
+// condition matches all courses. Conditions matching no course have to be
+// checked by matchesNothing as they cannot be written as a filter.
+func (c codeCondition) String() string {
+	if c.matchesAll() {
+		return ""
+	}
+	quoted := make([]string, len(c.codes))
+	for i, code := range c.codes {
+		quoted[i] = filters.Quote(code)
+	}
+	operator := "IN"
+	if c.negated {
+		operator = "NOT IN"
+	}
+	return fmt.Sprintf("%s %s [%s]", meiliCourseCode, operator, strings.Join(quoted, ","))
+}
+
+// JSONPath returns the condition as a SQL/JSON path filter, see fulltext
+// package.
+func (c codeCondition) JSONPath() string {
+	if c.matchesAll() {
+		return ""
+	}
+	filter := fulltext.In(meiliCourseCode, c.codes...)
+	if c.negated {
+		filter = fulltext.Not(filter)
+	}
+	return filter
+}
+
+func (c codeCondition) matchesAll() bool {
+	return c.negated && len(c.codes) == 0
+}
+
+func (c codeCondition) matchesNothing() bool {
+	return !c.negated && len(c.codes) == 0
+}
+
+// union returns condition matching courses matched by c or other.
+func (c codeCondition) union(other codeCondition) codeCondition {
+	switch {
+	case !c.negated && !other.negated:
+		return codeCondition{codes: mergeCodes(c.codes, other.codes)}
+	case c.negated && other.negated:
+		return codeCondition{codes: intersectCodes(c.codes, other.codes), negated: true}
+	case c.negated:
+		return codeCondition{codes: subtractCodes(c.codes, other.codes), negated: true}
+	default:
+		return codeCondition{codes: subtractCodes(other.codes, c.codes), negated: true}
+	}
+}
+
+//================================================================================
+// Selection
+//================================================================================
+
+// personalSelection maps params of personal facets to selected values.
+type personalSelection map[string][]string
+
+// parsePersonalSelection parses selected values of personal facets. Blocs of
+// the degree plan are checked later against the degree plan of the user, see
+// personalContext.conditions.
+func parsePersonalSelection(query url.Values, lang language.Language) (personalSelection, error) {
+	result := personalSelection{}
+	for _, param := range personalParams {
+		for _, value := range query[param] {
+			if value == "" {
+				continue
+			}
+			valid := true
+			switch param {
+			case myBlueprintParam:
+				valid = value == inBlueprint || value == notInBlueprint
+			case prerequisitesParam:
+				_, valid = parseSemesterPosition(value)
+			}
+			if !valid {
+				return nil, invalidPersonalFilterErr(param, value, lang)
+			}
+			result[param] = append(result[param], value)
+		}
+	}
+	return result, nil
+}
+
+func invalidPersonalFilterErr(param, value string, lang language.Language) error {
+	return errorx.NewHTTPErr(
+		errorx.AddContext(fmt.Errorf("invalid personal filter %s=%s", param, value), errorx.P("param", param), errorx.P("value", value)),
+		http.StatusBadRequest,
+		texts[lang].errInvalidPersonalFilter,
+	)
+}
+
+// semesterPosition is a semester of the blueprint.
+type semesterPosition struct {
+	year     int
+	semester semesterAssignment
+}
+
+func parseSemesterPosition(value string) (semesterPosition, bool) {
+	var result semesterPosition
+	year, semester, found := strings.Cut(value, "-")
+	if !found {
+		return result, false
+	}
+	y, err := strconv.Atoi(year)
+	if err != nil || y < 1 {
+		return result, false
+	}
+	s, err := strconv.Atoi(semester)
+	if err != nil || (semesterAssignment(s) != assignmentWinter && semesterAssignment(s) != assignmentSummer) {
+		return result, false
+	}
+	return semesterPosition{year: y, semester: semesterAssignment(s)}, true
+}
+
+func (p semesterPosition) String() string {
+	return fmt.Sprintf("%d-%d", p.year, p.semester)
+}
+
+// isAfter reports whether p is after the semester of the year.
+func (p semesterPosition) isAfter(year int, semester semesterAssignment) bool {
+	return year < p.year || (year == p.year && semester < p.semester)
+}
+
+//================================================================================
+// User Data
+//================================================================================
+
+// personalContext holds data of the user personal facets are resolved from.
+type personalContext struct {
+	blueprintCourses []plannedCourse
+	blueprintYears   int
+	blocs            []degreePlanBloc
+	// prerequisites of courses having any, sorted by course code
+	prerequisites []coursePrerequisites
+}
+
+type plannedCourse struct {
+	code     string
+	year     int
+	semester semesterAssignment
+}
+
+type degreePlanBloc struct {
+	code    string
+	name    string
+	courses []string
+}
+
+type coursePrerequisites struct {
+	code          string
+	prerequisites requisiteSlice
+}
+
+// requisiteSlice is met if all its requisites are met.
+type requisiteSlice []requisite
+
+type requisite struct {
+	courseCode string
+	children   requisiteSlice
+	// empty for a single course, otherwise OR or AND group of children
+	group string
+}
+
+// groups of requisites as in SIS
+const (
+	requisiteOrGroup  = "M"
+	requisiteAndGroup = "V"
+)
+
+func (rs requisiteSlice) metBy(planned map[string]bool) bool {
+	for _, r := range rs {
+		if !r.metBy(planned) {
+			return false
+		}
+	}
+	return true
+}
+
+func (r requisite) metBy(planned map[string]bool) bool {
+	switch r.group {
+	case "":
+		return planned[r.courseCode]
+	case requisiteOrGroup:
+		return slices.ContainsFunc(r.children, func(child requisite) bool {
+			return child.metBy(planned)
+		})
+	case requisiteAndGroup:
+		return r.children.metBy(planned)
+	default:
+		// unknown groups do not restrict the course, as in blueprint
+		return true
+	}
+}
+
+// personalOption is a value of a personal facet.
+type personalOption struct {
+	id        string
+	title     string
+	condition codeCondition
+}
+
+// options returns values of the personal facet available to the user.
+func (pc personalContext) options(param string, t text) []personalOption {
+	switch param {
+	case myBlueprintParam:
+		codes := make([]string, len(pc.blueprintCourses))
+		for i, c := range pc.blueprintCourses {
+			codes[i] = c.code
+		}
+		codes = mergeCodes(codes)
+		return []personalOption{
+			{id: inBlueprint, title: t.inMyBlueprint, condition: codeCondition{codes: codes}},
+			{id: notInBlueprint, title: t.notInMyBlueprint, condition: codeCondition{codes: codes, negated: true}},
+		}
+	case myDegreePlanParam:
+		if len(pc.blocs) == 0 {
+			return nil
+		}
+		whole := codeCondition{}
+		result := []personalOption{{id: wholeDegreePlan, title: t.wholeDegreePlan}}
+		for _, bloc := range pc.blocs {
+			condition := codeCondition{codes: mergeCodes(bloc.courses)}
+			whole = whole.union(condition)
+			result = append(result, personalOption{id: bloc.code, title: bloc.name, condition: condition})
+		}
+		result[0].condition = whole
+		return result
+	case prerequisitesParam:
+		var result []personalOption
+		for year := 1; year <= pc.blueprintYears; year++ {
+			for _, semester := range []semesterAssignment{assignmentWinter, assignmentSummer} {
+				position := semesterPosition{year: year, semester: semester}
+				title := fmt.Sprintf(t.prerequisitesBeforeWinter, year)
+				if semester == assignmentSummer {
+					title = fmt.Sprintf(t.prerequisitesBeforeSummer, year)
+				}
+				result = append(result, personalOption{
+					id:        position.String(),
+					title:     title,
+					condition: codeCondition{codes: pc.unsatisfiedBefore(position), negated: true},
+				})
+			}
+		}
+		return result
+	default:
+		return nil
+	}
+}
+
+// unsatisfiedBefore returns codes of courses whose prerequisites are not all
+// planned before the semester.
+func (pc personalContext) unsatisfiedBefore(position semesterPosition) []string {
+	planned := make(map[string]bool)
+	for _, c := range pc.blueprintCourses {
+		if c.year > 0 && position.isAfter(c.year, c.semester) {
+			planned[c.code] = true
+		}
+	}
+	var result []string
+	for _, c := range pc.prerequisites {
+		if !c.prerequisites.metBy(planned) {
+			result = append(result, c.code)
+		}
+	}
+	return result
+}
+
+// conditions resolves selected values into conditions, one for every personal
+// facet with a selected value. Values of a facet are joined by OR as values of
+// other facets.
+func (pc personalContext) conditions(selection personalSelection, lang language.Language) (map[string]codeCondition, error) {
+	t := texts[lang]
+	result := make(map[string]codeCondition, len(selection))
+	for param, values := range selection {
+		options := pc.options(param, t)
+		var condition codeCondition
+		for _, value := range values {
+			i := slices.IndexFunc(options, func(o personalOption) bool { return o.id == value })
+			if i < 0 {
+				return nil, invalidPersonalFilterErr(param, value, lang)
+			}
+			condition = condition.union(options[i].condition)
+		}
+		result[param] = condition
+	}
+	return result, nil
+}
+
+//================================================================================
+// Facets
+//================================================================================
+
+type personalFacet struct {
+	param  string
+	title  string
+	desc   string
+	values []personalValue
+}
+
+type personalValue struct {
+	id      string
+	title   string
+	count   int
+	checked bool
+}
+
+func (f personalFacet) active() bool {
+	return slices.ContainsFunc(f.values, func(v personalValue) bool { return v.checked })
+}
+
+// personalFacets counts values of personal facets. As with other facets, a
+// value is counted with the filter of the request without the condition of
+// its own facet.
+func (s Server) personalFacets(req request, pc personalContext, selected map[string]codeCondition) ([]personalFacet, error) {
+	t := texts[req.lang]
+	var result []personalFacet
+	var counted []request
+	var countIndex [][2]int
+	for _, param := range personalParams {
+		options := pc.options(param, t)
+		if len(options) == 0 {
+			continue
+		}
+		facet := personalFacet{param: param}
+		switch param {
+		case myBlueprintParam:
+			facet.title = t.myBlueprint
+		case myDegreePlanParam:
+			facet.title = t.myDegreePlan
+		case prerequisitesParam:
+			facet.title = t.prerequisitesSatisfied
+			facet.desc = t.prerequisitesSatisfiedDesc
+		}
+		for _, o := range options {
+			value := personalValue{
+				id:      o.id,
+				title:   o.title,
+				checked: slices.Contains(req.selected[param], o.id),
+			}
+			countReq := req
+			countReq.personal = []codeCondition{o.condition}
+			for p, c := range selected {
+				if p != param {
+					countReq.personal = append(countReq.personal, c)
+				}
+			}
+			if !countReq.matchesNothing() {
+				counted = append(counted, countReq)
+				countIndex = append(countIndex, [2]int{len(result), len(facet.values)})
+			}
+			facet.values = append(facet.values, value)
+		}
+		result = append(result, facet)
+	}
+	counts, err := s.Search.Count(counted)
+	if err != nil {
+		return nil, errorx.AddContext(err)
+	}
+	for i, count := range counts {
+		result[countIndex[i][0]].values[countIndex[i][1]].count = count
+	}
+	return result, nil
+}
+
+//================================================================================
+// Helper Functions
+//================================================================================
+
+// mergeCodes returns sorted codes without duplicates.
+func mergeCodes(codes ...[]string) []string {
+	result := slices.Concat(codes...)
+	slices.Sort(result)
+	return slices.Compact(result)
+}
+
+func intersectCodes(a, b []string) []string {
+	var result []string
+	for _, code := range mergeCodes(a) {
+		if slices.Contains(b, code) {
+			result = append(result, code)
+		}
+	}
+	return result
+}
+
+func subtractCodes(a, b []string) []string {
+	var result []string
+	for _, code := range mergeCodes(a) {
+		if !slices.Contains(b, code) {
+			result = append(result, code)
+		}
+	}
+	return result
+}
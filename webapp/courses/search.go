@@ -2,18 +2,26 @@ package courses
 
 import (
 	"encoding/json"
+	"fmt"
 	"maps"
 	"net/http"
+	"net/url"
+	"slices"
+	"strings"
 
 	"github.com/meilisearch/meilisearch-go"
 	"github.com/michalhercik/RecSIS/errorx"
+	"github.com/michalhercik/RecSIS/fulltext"
 	"github.com/michalhercik/RecSIS/language"
 )
 
 type expression interface {
 	String() string
 	Except() func(func(string, string) bool)
+	JSONPath() string
+	ExceptJSONPath() func(func(string, string) bool)
 	ConditionsCount() int
+	URLValues() url.Values
 }
 
 type request struct {
@@ -25,6 +33,43 @@ type request struct {
 	lang        language.Language
 	filter      expression
 	facets      []string
+	sort        sortOption
+	// selected values of personal facets and their resolved conditions which
+	// are added to filter, see personal.go
+	selected personalSelection
+	personal []codeCondition
+	// hint about unknown qualifiers of the query, see filters.InlineQuery
+	hint string
+}
+
+// meiliFilter returns the Meilisearch filter with personal conditions of the
+// request added.
+func (r request) meiliFilter(filter string) string {
+	var parts []string
+	if filter != "" {
+		parts = append(parts, filter)
+	}
+	for _, c := range r.personal {
+		if f := c.String(); f != "" {
+			parts = append(parts, f)
+		}
+	}
+	return strings.Join(parts, " AND ")
+}
+
+// jsonPathFilter is the same as meiliFilter for SQL/JSON path filters.
+func (r request) jsonPathFilter(filter string) string {
+	filters := []string{filter}
+	for _, c := range r.personal {
+		filters = append(filters, c.JSONPath())
+	}
+	return fulltext.And(filters...)
+}
+
+// matchesNothing reports whether there is a personal condition no course
+// satisfies, so the search does not have to be run.
+func (r request) matchesNothing() bool {
+	return slices.ContainsFunc(r.personal, codeCondition.matchesNothing)
 }
 
 type response struct {
@@ -107,11 +152,40 @@ func (r *quickResponse) UnmarshalJSON(data []byte) error {
 	return nil
 }
 
-type searchEngine interface {
+//================================================================================
+// Search Backends
+//================================================================================
+
+// SearchEngine is implemented by every search backend of courses.
+type SearchEngine interface {
 	Search(r request) (response, error)
 	QuickSearch(r quickRequest) (quickResponse, error)
+	// Count returns the number of courses matching each of the requests.
+	Count(r []request) ([]int, error)
 }
 
+// FallbackSearch searches courses with Meilisearch and while it is
+// unavailable with the full-text search.
+type FallbackSearch struct {
+	fulltext.Fallback[SearchEngine]
+}
+
+func (s FallbackSearch) Search(r request) (response, error) {
+	return fulltext.Call(s.Fallback, func(e SearchEngine) (response, error) { return e.Search(r) })
+}
+
+func (s FallbackSearch) QuickSearch(r quickRequest) (quickResponse, error) {
+	return fulltext.Call(s.Fallback, func(e SearchEngine) (quickResponse, error) { return e.QuickSearch(r) })
+}
+
+func (s FallbackSearch) Count(r []request) ([]int, error) {
+	return fulltext.Call(s.Fallback, func(e SearchEngine) ([]int, error) { return e.Count(r) })
+}
+
+//================================================================================
+// Meilisearch
+//================================================================================
+
 type MeiliSearch struct {
 	Client  meilisearch.ServiceManager
 	Courses meilisearch.IndexConfig
@@ -128,8 +202,9 @@ func newRequestWithDisjunctiveFaceting(r request, index meilisearch.IndexConfig)
 		Page:                 int64(r.page),
 		HitsPerPage:          int64(r.hitsPerPage),
 		AttributesToRetrieve: []string{"code"},
-		Filter:               r.filter.String(),
+		Filter:               r.meiliFilter(r.filter.String()),
 		Facets:               r.facets,
+		Sort:                 sortRules(r.sort, r.lang),
 	})
 	for param, filter := range r.filter.Except() {
 		result.Queries = append(result.Queries, &meilisearch.SearchRequest{
@@ -137,13 +212,41 @@ func newRequestWithDisjunctiveFaceting(r request, index meilisearch.IndexConfig)
 			Query:                r.query,
 			Limit:                0,          // not working returns more than zero, probably bug in meilisearch-go -> write own client...
 			AttributesToRetrieve: []string{}, // not working returns more than zero, probably bug in meilisearch-go -> write own client...
-			Filter:               filter,
+			Filter:               r.meiliFilter(filter),
 			Facets:               []string{param},
 		})
 	}
 	return result
 }
 
+// sortRules translates the sort option into sort rules over sortable
+// attributes of the course index. Ties are broken by relevance. Sortable
+// attributes are set by the ELT.
+func sortRules(sort sortOption, lang language.Language) []string {
+	switch sort {
+	case sortCreditsDesc:
+		return []string{"credits:desc"}
+	case sortCreditsAsc:
+		return []string{"credits:asc"}
+	case sortCode:
+		return []string{"code:asc"}
+	case sortTitle:
+		return []string{fmt.Sprintf("title.%s:asc", lang)}
+	case sortRating:
+		return []string{"rating_average:desc", "rating_count:desc"}
+	case sortRatingCount:
+		return []string{"rating_count:desc"}
+	case sortNewest:
+		return []string{"valid_from:desc"}
+	case sortHoursDesc:
+		return []string{"weekly_hours:desc"}
+	case sortHoursAsc:
+		return []string{"weekly_hours:asc"}
+	default:
+		return nil
+	}
+}
+
 // TODO: write own meilisearch client
 func (s MeiliSearch) Search(r request) (response, error) {
 	t := texts[r.lang]
@@ -180,6 +283,55 @@ func (s MeiliSearch) Search(r request) (response, error) {
 	return result, nil
 }
 
+func (s MeiliSearch) Count(r []request) ([]int, error) {
+	if len(r) == 0 {
+		return nil, nil
+	}
+	searchReq := &meilisearch.MultiSearchRequest{
+		Queries: make([]*meilisearch.SearchRequest, len(r)),
+	}
+	for i, req := range r {
+		// pagination makes total hits exact
+		searchReq.Queries[i] = &meilisearch.SearchRequest{
+			IndexUID:             s.Courses.Uid,
+			Query:                req.query,
+			Page:                 1,
+			HitsPerPage:          1,
+			AttributesToRetrieve: []string{"code"},
+			Filter:               req.meiliFilter(req.filter.String()),
+		}
+	}
+	response, err := s.Client.MultiSearch(searchReq)
+	if err != nil {
+		return nil, errorx.NewHTTPErr(
+			errorx.AddContext(err, errorx.P("queries", len(r))),
+			http.StatusInternalServerError,
+			texts[r[0].lang].errCannotSearchCourses,
+		)
+	}
+	rawResponse, err := response.MarshalJSON()
+	if err != nil {
+		return nil, errorx.NewHTTPErr(
+			errorx.AddContext(err, errorx.P("queries", len(r))),
+			http.StatusInternalServerError,
+			texts[r[0].lang].errCannotSearchCourses,
+		)
+	}
+	multi := multiResponse{}
+	if err = json.Unmarshal(rawResponse, &multi); err != nil {
+		return nil, errorx.NewHTTPErr(
+			errorx.AddContext(err, errorx.P("queries", len(r))),
+			http.StatusInternalServerError,
+			texts[r[0].lang].errCannotSearchCourses,
+		)
+	}
+	result := make([]int, len(multi.Results))
+	for i, res := range multi.Results {
+		result[i] = res.TotalHits
+	}
+	return result, nil
+}
+
 func (s MeiliSearch) QuickSearch(r quickRequest) (quickResponse, error) {
 	var result quickResponse
 	index := s.Client.Index(r.indexUID)
@@ -209,3 +361,121 @@ func buildQuickSearchRequest(r quickRequest) *meilisearch.SearchRequest {
 	}
 	return result
 }
+
+//================================================================================
+// PostgreSQL Full-Text Search
+//================================================================================
+
+// FullTextSearch searches courses in PostgreSQL when Meilisearch is not
+// available, see fulltext package.
+type FullTextSearch struct {
+	Engine  fulltext.Engine
+	Courses string
+}
+
+func (s FullTextSearch) Search(r request) (response, error) {
+	t := texts[r.lang]
+	var result response
+	filter := r.jsonPathFilter(r.filter.JSONPath())
+	except := func(yield func(string, string) bool) {
+		for param, f := range r.filter.ExceptJSONPath() {
+			if !yield(param, r.jsonPathFilter(f)) {
+				return
+			}
+		}
+	}
+	res, err := s.Engine.Search(fulltext.Request{
+		Index:      s.Courses,
+		Query:      r.query,
+		Lang:       r.lang,
+		Filter:     filter,
+		Facets:     fulltext.DisjunctiveFacets(r.facets, filter, except),
+		Sort:       sortRules(r.sort, r.lang),
+		Attributes: []string{"code"},
+		Offset:     (r.page - 1) * r.hitsPerPage,
+		Limit:      r.hitsPerPage,
+	})
+	if err != nil {
+		return result, errorx.NewHTTPErr(
+			errorx.AddContext(err, errorx.P("index", r.indexUID), errorx.P("query", r.query)),
+			http.StatusInternalServerError,
+			t.errCannotSearchCourses,
+		)
+	}
+	result.TotalHits = res.TotalHits
+	result.TotalPages = (res.TotalHits + r.hitsPerPage - 1) / r.hitsPerPage
+	result.FacetDistribution = res.FacetDistribution
+	result.Courses = make([]string, len(res.Hits))
+	for i, hit := range res.Hits {
+		var course struct {
+			Code string `json:"code"`
+		}
+		if err := json.Unmarshal(hit, &course); err != nil {
+			return result, errorx.NewHTTPErr(
+				errorx.AddContext(err, errorx.P("index", r.indexUID), errorx.P("query", r.query)),
+				http.StatusInternalServerError,
+				t.errCannotSearchCourses,
+			)
+		}
+		result.Courses[i] = course.Code
+	}
+	return result, nil
+}
+
+func (s FullTextSearch) Count(r []request) ([]int, error) {
+	result := make([]int, len(r))
+	for i, req := range r {
+		res, err := s.Engine.Search(fulltext.Request{
+			Index:      s.Courses,
+			Query:      req.query,
+			Lang:       req.lang,
+			Filter:     req.jsonPathFilter(req.filter.JSONPath()),
+			Attributes: []string{"code"},
+			Limit:      0,
+		})
+		if err != nil {
+			return nil, errorx.NewHTTPErr(
+				errorx.AddContext(err, errorx.P("query", req.query)),
+				http.StatusInternalServerError,
+				texts[req.lang].errCannotSearchCourses,
+			)
+		}
+		result[i] = res.TotalHits
+	}
+	return result, nil
+}
+
+func (s FullTextSearch) QuickSearch(r quickRequest) (quickResponse, error) {
+	var result quickResponse
+	res, err := s.Engine.Search(fulltext.Request{
+		Index:      r.indexUID,
+		Query:      r.query,
+		Lang:       r.lang,
+		Attributes: buildQuickSearchRequest(r).AttributesToRetrieve,
+		Offset:     int(r.offset),
+		Limit:      int(r.limit),
+	})
+	if err != nil {
+		return result, errorx.AddContext(err, errorx.P("index", r.indexUID), errorx.P("query", r.query))
+	}
+	result.approxHits = res.TotalHits
+	result.courses = make([]quickCourse, len(res.Hits))
+	for i, hit := range res.Hits {
+		var course struct {
+			Code  string `json:"code"`
+			Title struct {
+				CS string `json:"cs"`
+				EN string `json:"en"`
+			} `json:"title"`
+		}
+		if err := json.Unmarshal(hit, &course); err != nil {
+			return result, errorx.AddContext(err, errorx.P("index", r.indexUID), errorx.P("query", r.query))
+		}
+		result.courses[i].code = course.Code
+		result.courses[i].name = course.Title.CS
+		if result.courses[i].name == "" {
+			result.courses[i].name = course.Title.EN
+		}
+	}
+	return result, nil
+}
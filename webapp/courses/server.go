@@ -6,10 +6,13 @@ import (
 	"net/http"
 	"net/url"
 	"strconv"
+	"strings"
+	"unicode/utf8"
 
 	"github.com/a-h/templ"
 	"github.com/michalhercik/RecSIS/errorx"
 	"github.com/michalhercik/RecSIS/filters"
+	"github.com/michalhercik/RecSIS/fulltext"
 	"github.com/michalhercik/RecSIS/language"
 )
 
@@ -25,7 +28,7 @@ type Server struct {
 	Filters filters.Filters
 	Page    Page
 	router  http.Handler
-	Search  searchEngine
+	Search  SearchEngine
 }
 
 func (s *Server) Init() {
@@ -94,6 +97,9 @@ func (s *Server) initRouter() {
 	router := http.NewServeMux()
 	router.HandleFunc("GET /{$}", s.page)
 	router.HandleFunc("GET /search", s.content)
+	router.HandleFunc("GET /personal-filters", s.personalFiltersContent)
+	router.HandleFunc("POST /saved-searches", s.saveSearch)
+	router.HandleFunc("GET /export", s.export)
 	router.HandleFunc(s.BpBtn.Endpoint(), s.addCourseToBlueprint)
 	router.HandleFunc("/", s.pageNotFound)
 	s.router = router
@@ -107,7 +113,7 @@ func (s Server) page(w http.ResponseWriter, r *http.Request) {
 	lang := language.FromContext(r.Context())
 	t := texts[lang]
 	userID := s.Auth.UserID(r)
-	req, err := s.parseQueryRequest(r)
+	req, query, err := s.parseQueryRequest(r)
 	if err != nil {
 		code, userMsg := errorx.UnwrapError(err, lang)
 		userMsg = fmt.Sprintf("%s: %s", t.errCannotSearchCourses, userMsg)
@@ -115,13 +121,17 @@ func (s Server) page(w http.ResponseWriter, r *http.Request) {
 		s.Error.RenderPage(w, r, code, userMsg, t.pageTitle, userID, lang)
 		return
 	}
-	result, err := s.search(req, r)
+	result, err := s.search(req, query)
 	if err != nil {
 		code, userMsg := errorx.UnwrapError(err, lang)
 		s.Error.Log(errorx.AddContext(err))
 		s.Error.RenderPage(w, r, code, userMsg, t.pageTitle, userID, lang)
 		return
 	}
+	if req.query != r.FormValue(s.Page.SearchParam()) {
+		// qualifiers were moved from the search query to filters
+		w.Header().Set("HX-Push-Url", s.parseUrl(query, lang))
+	}
 	result.bpBtn = s.BpBtn.PartialComponent(lang)
 	main := Content(&result, t)
 	view := s.Page.View(main, lang, t.pageTitle, req.query, userID)
@@ -134,7 +144,7 @@ func (s Server) page(w http.ResponseWriter, r *http.Request) {
 func (s Server) content(w http.ResponseWriter, r *http.Request) {
 	lang := language.FromContext(r.Context())
 	t := texts[lang]
-	req, err := s.parseQueryRequest(r)
+	req, query, err := s.parseQueryRequest(r)
 	if err != nil {
 		code, userMsg := errorx.UnwrapError(err, lang)
 		userMsg = fmt.Sprintf("%s: %s", t.errCannotSearchCourses, userMsg)
@@ -142,7 +152,7 @@ func (s Server) content(w http.ResponseWriter, r *http.Request) {
 		s.Error.Render(w, r, code, userMsg, lang)
 		return
 	}
-	coursesPage, err := s.search(req, r)
+	coursesPage, err := s.search(req, query)
 	if err != nil {
 		code, userMsg := errorx.UnwrapError(err, lang)
 		s.Error.Log(errorx.AddContext(err))
@@ -150,7 +160,7 @@ func (s Server) content(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	w.Header().Set("HX-Push-Url", s.parseUrl(r.URL.Query(), lang))
+	w.Header().Set("HX-Push-Url", s.parseUrl(query, lang))
 
 	coursesPage.bpBtn = s.BpBtn.PartialComponent(lang)
 	err = Content(&coursesPage, t).Render(r.Context(), w)
@@ -159,18 +169,67 @@ func (s Server) content(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-func (s Server) parseQueryRequest(r *http.Request) (request, error) {
+// personalFiltersContent renders personal facets with counts of the search.
+// They are loaded only when the user expands them, as they need data of the
+// user and a count search for every value.
+func (s Server) personalFiltersContent(w http.ResponseWriter, r *http.Request) {
+	lang := language.FromContext(r.Context())
+	t := texts[lang]
+	req, _, err := s.parseQueryRequest(r)
+	if err != nil {
+		code, userMsg := errorx.UnwrapError(err, lang)
+		s.Error.Log(errorx.AddContext(err))
+		s.Error.Render(w, r, code, userMsg, lang)
+		return
+	}
+	personal, err := s.Data.personalContext(req.userID, lang)
+	if err != nil {
+		code, userMsg := errorx.UnwrapError(err, lang)
+		s.Error.Log(errorx.AddContext(err))
+		s.Error.Render(w, r, code, userMsg, lang)
+		return
+	}
+	req, selected, err := applyPersonalFilter(req, personal)
+	if err != nil {
+		code, userMsg := errorx.UnwrapError(err, lang)
+		s.Error.Log(errorx.AddContext(err))
+		s.Error.Render(w, r, code, userMsg, lang)
+		return
+	}
+	facets, err := s.personalFacets(req, personal, selected)
+	if err != nil {
+		code, userMsg := errorx.UnwrapError(err, lang)
+		s.Error.Log(errorx.AddContext(err))
+		s.Error.Render(w, r, code, userMsg, lang)
+		return
+	}
+	err = personalFacetList(facets, t).Render(r.Context(), w)
+	if err != nil {
+		s.Error.CannotRenderComponent(w, r, errorx.AddContext(err), lang)
+	}
+}
+
+// parseQueryRequest parses the search request. Inline qualifiers of the search
+// query (e.g. credits>=5 lang:en) are moved to filters, so the returned URL
+// query contains them as filter params and the search param without them.
+func (s Server) parseQueryRequest(r *http.Request) (request, url.Values, error) {
 	var req request
 	var err error
 	userID := s.Auth.UserID(r)
 	lang := language.FromContext(r.Context())
-	query := r.FormValue(s.Page.SearchParam())
+	inline, err := s.Filters.ParseInlineQuery(r.FormValue(s.Page.SearchParam()), QualifierAliases, lang)
+	if err != nil {
+		return req, nil, errorx.AddContext(err)
+	}
+	// r.Form has also the body of POST requests (e.g. saving the search)
+	values := inline.Merge(r.Form)
+	values.Set(s.Page.SearchParam(), inline.Text)
 	page := firstPage
 	unparsedPageNumber := r.FormValue(pageParam)
 	if unparsedPageNumber != "" {
 		page, err = strconv.Atoi(unparsedPageNumber)
 		if err != nil || page < firstPage {
-			return req, errorx.NewHTTPErr(
+			return req, nil, errorx.NewHTTPErr(
 				errorx.AddContext(fmt.Errorf("invalid page number: '%s'", unparsedPageNumber)),
 				http.StatusBadRequest,
 				texts[lang].errInvalidPageNumber,
@@ -182,37 +241,65 @@ func (s Server) parseQueryRequest(r *http.Request) (request, error) {
 	if unparsedHitsPerPage != "" {
 		hitsPerPage, err = strconv.Atoi(unparsedHitsPerPage)
 		if err != nil || hitsPerPage < 1 {
-			return req, errorx.NewHTTPErr(
+			return req, nil, errorx.NewHTTPErr(
 				errorx.AddContext(fmt.Errorf("invalid number of courses per page: %d", hitsPerPage)),
 				http.StatusBadRequest,
 				texts[lang].errInvalidNumberOfCourses,
 			)
 		}
 	}
-	filter, err := s.Filters.ParseURLQuery(r.URL.Query(), lang)
+	sort, ok := parseSortOption(r.FormValue(sortParam))
+	if !ok {
+		return req, nil, errorx.NewHTTPErr(
+			errorx.AddContext(fmt.Errorf("invalid sort: '%s'", r.FormValue(sortParam))),
+			http.StatusBadRequest,
+			texts[lang].errInvalidSort,
+		)
+	}
+	filter, err := s.Filters.ParseURLQuery(values, lang)
 	if err != nil {
-		return req, errorx.AddContext(err)
+		return req, nil, errorx.AddContext(err)
+	}
+	selected, err := parsePersonalSelection(values, lang)
+	if err != nil {
+		return req, nil, errorx.AddContext(err)
 	}
 
 	req = request{
 		userID:      userID,
-		query:       query,
+		query:       inline.Text,
 		indexUID:    courseIndex,
 		page:        page,
 		hitsPerPage: hitsPerPage,
 		lang:        lang,
 		filter:      filter,
 		facets:      s.Filters.Facets(),
+		sort:        sort,
+		selected:    selected,
+		hint:        inline.Hint,
 	}
-	return req, nil
+	return req, values, nil
 }
 
-func (s Server) search(req request, httpReq *http.Request) (coursesPage, error) {
+func (s Server) search(req request, query url.Values) (coursesPage, error) {
 	var result coursesPage
-	searchResponse, err := s.Search.Search(req)
+	req, personal, selected, err := s.withPersonalFilter(req)
 	if err != nil {
 		return result, errorx.AddContext(err)
 	}
+	searchResponse, err := s.searchCourses(req)
+	if err != nil {
+		return result, errorx.AddContext(err)
+	}
+	// personal facets are counted here only if a value is selected,
+	// otherwise they are loaded when expanded, see personalFiltersContent
+	var personalFacets []personalFacet
+	if len(selected) > 0 {
+		personalFacets, err = s.personalFacets(req, personal, selected)
+		if err != nil {
+			return result, errorx.AddContext(err)
+		}
+	}
 	coursesData, err := s.Data.courses(req.userID, searchResponse.Courses, req.lang)
 	if err != nil {
 		return result, errorx.AddContext(err)
@@ -224,11 +311,58 @@ func (s Server) search(req request, httpReq *http.Request) (coursesPage, error)
 		searchParam: s.Page.SearchParam(),
 		totalPages:  searchResponse.TotalPages,
 		search:      req.query,
-		facets:      s.Filters.IterFiltersWithFacets(searchResponse.FacetDistribution, httpReq.URL.Query(), req.lang),
+		sort:        req.sort,
+		facets:      s.Filters.IterFiltersWithFacets(searchResponse.FacetDistribution, query, req.lang),
+		query:       query,
+		personal:    personalFacets,
+		hint:        req.hint,
 	}
 	return result, nil
 }
 
+// withPersonalFilter resolves selected values of personal facets of the
+// request into conditions on course codes, see personal.go. It returns also
+// the user data they were resolved from and the condition of every facet.
+// User data are loaded only if a value is selected.
+func (s Server) withPersonalFilter(req request) (request, personalContext, map[string]codeCondition, error) {
+	var personal personalContext
+	if len(req.selected) == 0 {
+		return req, personal, nil, nil
+	}
+	personal, err := s.Data.personalContext(req.userID, req.lang)
+	if err != nil {
+		return req, personal, nil, errorx.AddContext(err)
+	}
+	req, selected, err := applyPersonalFilter(req, personal)
+	if err != nil {
+		return req, personal, nil, errorx.AddContext(err)
+	}
+	return req, personal, selected, nil
+}
+
+func applyPersonalFilter(req request, personal personalContext) (request, map[string]codeCondition, error) {
+	selected, err := personal.conditions(req.selected, req.lang)
+	if err != nil {
+		return req, nil, errorx.AddContext(err)
+	}
+	req.personal = make([]codeCondition, 0, len(selected))
+	for _, param := range personalParams {
+		if c, ok := selected[param]; ok {
+			req.personal = append(req.personal, c)
+		}
+	}
+	return req, selected, nil
+}
+
+// searchCourses searches unless a personal condition of the request matches
+// no course.
+func (s Server) searchCourses(req request) (response, error) {
+	if req.matchesNothing() {
+		return response{FacetDistribution: map[string]map[string]int{}}, nil
+	}
+	return s.Search.Search(req)
+}
+
 func (s Server) parseUrl(queryValues url.Values, lang language.Language) string {
 	// exclude default values from the URL
 	if queryValues.Get(s.Page.SearchParam()) == "" {
@@ -237,9 +371,56 @@ func (s Server) parseUrl(queryValues url.Values, lang language.Language) string
 	if queryValues.Get(pageParam) == fmt.Sprintf("%d", firstPage) {
 		queryValues.Del(pageParam)
 	}
+	if queryValues.Get(sortParam) == string(sortRelevance) {
+		queryValues.Del(sortParam)
+	}
 	return fmt.Sprintf("%s?%s", lang.LocalizeURL("/courses/"), queryValues.Encode())
 }
 
+// saveSearch saves the search of the request (text query, filters and sort)
+// under the given name.
+func (s Server) saveSearch(w http.ResponseWriter, r *http.Request) {
+	lang := language.FromContext(r.Context())
+	t := texts[lang]
+	userID := s.Auth.UserID(r)
+	name := strings.TrimSpace(r.FormValue(savedSearchNameParam))
+	if name == "" || utf8.RuneCountInString(name) > maxSavedSearchNameLength {
+		s.Error.Log(errorx.AddContext(fmt.Errorf("invalid saved search name"), errorx.P("name", name)))
+		s.Error.Render(w, r, http.StatusBadRequest, t.errInvalidSavedSearchName, lang)
+		return
+	}
+	req, _, err := s.parseQueryRequest(r)
+	if err != nil {
+		code, userMsg := errorx.UnwrapError(err, lang)
+		s.Error.Log(errorx.AddContext(err))
+		s.Error.Render(w, r, code, userMsg, lang)
+		return
+	}
+	values := req.filter.URLValues()
+	if req.query != "" {
+		values.Set(s.Page.SearchParam(), req.query)
+	}
+	if req.sort != sortRelevance {
+		values.Set(sortParam, string(req.sort))
+	}
+	search := savedSearch{
+		name:      name,
+		urlQuery:  values.Encode(),
+		textQuery: fulltext.TSQuery(req.query),
+		filter:    req.filter.JSONPath(),
+	}
+	if err = s.Data.saveSearch(userID, search, lang); err != nil {
+		code, userMsg := errorx.UnwrapError(err, lang)
+		s.Error.Log(errorx.AddContext(err))
+		s.Error.Render(w, r, code, userMsg, lang)
+		return
+	}
+	err = SaveSearchForm(name, t).Render(r.Context(), w)
+	if err != nil {
+		s.Error.CannotRenderComponent(w, r, errorx.AddContext(err), lang)
+	}
+}
+
 func (s Server) addCourseToBlueprint(w http.ResponseWriter, r *http.Request) {
 	lang := language.FromContext(r.Context())
 	t := texts[lang]
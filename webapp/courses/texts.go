@@ -38,13 +38,62 @@ type text struct {
 	page                         string
 	of                           string
 	noCoursesFound               string
+	sortBy                       string
+	sortRelevance                string
+	sortCreditsDesc              string
+	sortCreditsAsc               string
+	sortCode                     string
+	sortTitle                    string
+	sortRating                   string
+	sortRatingCount              string
+	sortNewest                   string
+	sortHoursDesc                string
+	sortHoursAsc                 string
+	saveSearch                   string
+	savedSearchName              string
+	searchSaved                  string
+	showOnHomePage               string
+	export                       string
+	exportCSV                    string
+	exportXLSX                   string
+	addToCompare                 string
+	exportSheet                  string
+	columnCode                   string
+	columnTitle                  string
+	columnCredits                string
+	columnSemester               string
+	columnHours                  string
+	columnExamType               string
+	columnGuarantors             string
+	columnDepartment             string
+	columnLanguage               string
+	columnBlueprint              string
+	myBlueprint                  string
+	inMyBlueprint                string
+	notInMyBlueprint             string
+	myDegreePlan                 string
+	wholeDegreePlan              string
+	prerequisitesSatisfied       string
+	prerequisitesSatisfiedDesc   string
+	prerequisitesBeforeWinter    string
+	prerequisitesBeforeSummer    string
+	showPersonalFilters          string
+	hidePersonalFilters          string
 	language                     language.Language
 	errUnexpectedNumberOfCourses string
 	errCannotLoadCourses         string
 	errCannotSearchCourses       string
 	errInvalidPageNumber         string
 	errInvalidNumberOfCourses    string
+	errInvalidSort               string
 	errPageNotFound              string
+	errInvalidSavedSearchName    string
+	errSavedSearchExists         string
+	errCannotSaveSearch          string
+	errInvalidExportFormat       string
+	errCannotExport              string
+	errInvalidPersonalFilter     string
+	errCannotLoadPersonalFilters string
 }
 
 func (t text) showMore(rest int) string {
@@ -90,13 +139,62 @@ var texts = map[language.Language]text{
 		page:                         "Strana",
 		of:                           "z",
 		noCoursesFound:               "Žádné předměty nebyly nalezeny.",
+		sortBy:                       "Řadit podle",
+		sortRelevance:                "Relevance",
+		sortCreditsDesc:              "Kredity (od nejvyšších)",
+		sortCreditsAsc:               "Kredity (od nejnižších)",
+		sortCode:                     "Kód",
+		sortTitle:                    "Název",
+		sortRating:                   "Průměrné hodnocení",
+		sortRatingCount:              "Počet hodnocení",
+		sortNewest:                   "Nejnovější",
+		sortHoursDesc:                "Hodiny týdně (od nejvíce)",
+		sortHoursAsc:                 "Hodiny týdně (od nejméně)",
+		saveSearch:                   "Uložit hledání",
+		savedSearchName:              "Název hledání",
+		searchSaved:                  "Hledání bylo uloženo jako",
+		showOnHomePage:               "Zobrazit na domovské stránce",
+		export:                       "Exportovat výsledky",
+		exportCSV:                    "CSV",
+		exportXLSX:                   "Excel (XLSX)",
+		addToCompare:                 "Přidat do porovnání",
+		exportSheet:                  "Předměty",
+		columnCode:                   "Kód",
+		columnTitle:                  "Název",
+		columnCredits:                "Kredity",
+		columnSemester:               "Semestr",
+		columnHours:                  "Rozsah",
+		columnExamType:               "Zakončení",
+		columnGuarantors:             "Garanti",
+		columnDepartment:             "Katedra",
+		columnLanguage:               "Jazyk výuky",
+		columnBlueprint:              "Blueprint",
+		myBlueprint:                  "Můj blueprint",
+		inMyBlueprint:                "V mém blueprintu",
+		notInMyBlueprint:             "Mimo můj blueprint",
+		myDegreePlan:                 "Můj studijní plán",
+		wholeDegreePlan:              "Celý studijní plán",
+		prerequisitesSatisfied:       "Splněné prerekvizity",
+		prerequisitesSatisfiedDesc:   "Předměty, jejichž prerekvizity jsou všechny naplánované v blueprintu před zvoleným semestrem.",
+		prerequisitesBeforeWinter:    "Před ZS %d. ročníku",
+		prerequisitesBeforeSummer:    "Před LS %d. ročníku",
+		showPersonalFilters:          "Zobrazit filtry podle mých dat",
+		hidePersonalFilters:          "Skrýt filtry podle mých dat",
 		language:                     language.CS,
 		errUnexpectedNumberOfCourses: "Neočekávaný počet předmětů pro přiřazení do Blueprintu",
 		errCannotLoadCourses:         "Nelze načíst předměty z databáze",
 		errCannotSearchCourses:       "Nelze vyhledat předměty",
 		errInvalidPageNumber:         "neplatné číslo stránky: musí být celé kladné číslo",
 		errInvalidNumberOfCourses:    "neplatný počet předmětů na stránku: musí být celé kladné číslo",
+		errInvalidSort:               "neplatné řazení",
 		errPageNotFound:              "Stránka nenalezena",
+		errInvalidSavedSearchName:    "Název hledání musí mít 1 až 100 znaků",
+		errSavedSearchExists:         "Hledání s tímto názvem už máte uložené",
+		errCannotSaveSearch:          "Nelze uložit hledání",
+		errInvalidExportFormat:       "Nepodporovaný formát exportu",
+		errCannotExport:              "Nelze exportovat výsledky hledání",
+		errInvalidPersonalFilter:     "neplatný osobní filtr",
+		errCannotLoadPersonalFilters: "Nelze načíst osobní filtry",
 	},
 	language.EN: {
 		pageTitle:                    "Search",
@@ -132,12 +230,61 @@ var texts = map[language.Language]text{
 		page:                         "Page",
 		of:                           "of",
 		noCoursesFound:               "No courses found.",
+		sortBy:                       "Sort by",
+		sortRelevance:                "Relevance",
+		sortCreditsDesc:              "Credits (highest first)",
+		sortCreditsAsc:               "Credits (lowest first)",
+		sortCode:                     "Code",
+		sortTitle:                    "Title",
+		sortRating:                   "Average rating",
+		sortRatingCount:              "Number of ratings",
+		sortNewest:                   "Newest",
+		sortHoursDesc:                "Weekly hours (most first)",
+		sortHoursAsc:                 "Weekly hours (fewest first)",
+		saveSearch:                   "Save search",
+		savedSearchName:              "Search name",
+		searchSaved:                  "The search was saved as",
+		showOnHomePage:               "Show on home page",
+		export:                       "Export results",
+		exportCSV:                    "CSV",
+		exportXLSX:                   "Excel (XLSX)",
+		addToCompare:                 "Add to comparison",
+		exportSheet:                  "Courses",
+		columnCode:                   "Code",
+		columnTitle:                  "Title",
+		columnCredits:                "Credits",
+		columnSemester:               "Semester",
+		columnHours:                  "Hours",
+		columnExamType:               "Exam type",
+		columnGuarantors:             "Guarantors",
+		columnDepartment:             "Department",
+		columnLanguage:               "Language",
+		columnBlueprint:              "Blueprint",
+		myBlueprint:                  "My blueprint",
+		inMyBlueprint:                "In my blueprint",
+		notInMyBlueprint:             "Not in my blueprint",
+		myDegreePlan:                 "My degree plan",
+		wholeDegreePlan:              "Whole degree plan",
+		prerequisitesSatisfied:       "Prerequisites satisfied",
+		prerequisitesSatisfiedDesc:   "Courses whose prerequisites are all planned in the blueprint before the selected semester.",
+		prerequisitesBeforeWinter:    "Before winter of year %d",
+		prerequisitesBeforeSummer:    "Before summer of year %d",
+		showPersonalFilters:          "Show filters by my data",
+		hidePersonalFilters:          "Hide filters by my data",
 		language:                     language.EN,
 		errUnexpectedNumberOfCourses: "Unexpected number of courses for Blueprint assignment",
 		errCannotLoadCourses:         "Cannot load courses from the database",
 		errCannotSearchCourses:       "Cannot search for courses",
 		errInvalidPageNumber:         "Invalid page number: must be a whole positive number",
 		errInvalidNumberOfCourses:    "Invalid number of courses per page: must be a whole positive number",
+		errInvalidSort:               "Invalid sort order",
 		errPageNotFound:              "Page not found",
+		errInvalidSavedSearchName:    "Search name must have 1 to 100 characters",
+		errSavedSearchExists:         "You already have a saved search with this name",
+		errCannotSaveSearch:          "Cannot save the search",
+		errInvalidExportFormat:       "Unsupported export format",
+		errCannotExport:              "Cannot export search results",
+		errInvalidPersonalFilter:     "Invalid personal filter",
+		errCannotLoadPersonalFilters: "Cannot load personal filters",
 	},
 }
@@ -20,8 +20,28 @@ const (
 	notNullViolationCode    = "23502"
 )
 
+// actions recorded in the blueprint history (see blueprint package)
+const (
+	historyActionMerge   = "merge-degree-plan"
+	historyActionRewrite = "rewrite-blueprint"
+	historyActionDiff    = "apply-plan-diff"
+)
+
 type DBManager struct {
 	DB *sqlx.DB
+	// BlueprintCache is notified after the blueprint is changed (optional).
+	BlueprintCache Invalidator
+}
+
+type Invalidator interface {
+	// Drops cached blueprint data of the user.
+	Invalidate(userID string)
+}
+
+func (m DBManager) blueprintChanged(uid string) {
+	if m.BlueprintCache != nil {
+		m.BlueprintCache.Invalidate(uid)
+	}
 }
 
 type dbDegreePlanRecord struct {
@@ -37,6 +57,7 @@ type dbDegreePlanRecord struct {
 	RecommendedSemester sql.NullInt64 `db:"recommended_semester"`
 	CourseIsSupported   bool          `db:"course_is_supported"`
 	BlueprintSemesters  pq.BoolArray  `db:"semesters"`
+	Completed           bool          `db:"completed"`
 }
 
 func (m DBManager) userHasSelectedDegreePlan(uid string) bool {
@@ -133,6 +154,16 @@ func (m DBManager) mergeRecommendedPlanWithBlueprint(uid, dpCode string, maxYear
 	}
 	defer tx.Rollback()
 
+	// record current blueprint so the change can be undone
+	_, err = tx.Exec(sqlquery.RecordBlueprintHistory, uid, historyActionMerge)
+	if err != nil {
+		return errorx.NewHTTPErr(
+			errorx.AddContext(fmt.Errorf("sqlquery.RecordBlueprintHistory: %w", err), errorx.P("dpCode", dpCode), errorx.P("lang", lang)),
+			http.StatusInternalServerError,
+			texts[lang].errCannotMergeToBlueprint,
+		)
+	}
+
 	// get current count of blueprint years
 	var bpYearCount int
 	err = tx.Get(&bpYearCount, sqlquery.CountBlueprintYears, uid)
@@ -192,6 +223,7 @@ func (m DBManager) mergeRecommendedPlanWithBlueprint(uid, dpCode string, maxYear
 			texts[lang].errCannotMergeToBlueprint,
 		)
 	}
+	m.blueprintChanged(uid)
 
 	return nil
 }
@@ -207,6 +239,16 @@ func (m DBManager) rewriteBlueprintWithRecommendedPlan(uid, dpCode string, maxYe
 	}
 	defer tx.Rollback()
 
+	// record current blueprint so the change can be undone
+	_, err = tx.Exec(sqlquery.RecordBlueprintHistory, uid, historyActionRewrite)
+	if err != nil {
+		return errorx.NewHTTPErr(
+			errorx.AddContext(fmt.Errorf("sqlquery.RecordBlueprintHistory: %w", err), errorx.P("dpCode", dpCode), errorx.P("lang", lang)),
+			http.StatusInternalServerError,
+			texts[lang].errCannotRewriteBlueprint,
+		)
+	}
+
 	// remove all current blueprint courses
 	_, err = tx.Exec(sqlquery.ClearBlueprintCourses, uid)
 	if err != nil {
@@ -284,6 +326,121 @@ func (m DBManager) rewriteBlueprintWithRecommendedPlan(uid, dpCode string, maxYe
 			texts[lang].errCannotRewriteBlueprint,
 		)
 	}
+	m.blueprintChanged(uid)
+
+	return nil
+}
+
+func (m DBManager) blueprintCourses(uid string, lang language.Language) ([]blueprintCourse, error) {
+	var courses []blueprintCourse
+	if err := m.DB.Select(&courses, sqlquery.BlueprintCourses, uid, lang); err != nil {
+		return nil, errorx.NewHTTPErr(
+			errorx.AddContext(fmt.Errorf("sqlquery.BlueprintCourses: %w", err), errorx.P("lang", lang)),
+			http.StatusInternalServerError,
+			texts[lang].errCannotGetDiff,
+		)
+	}
+	return courses, nil
+}
+
+// applyPlanDiff removes extra courses, moves courses and adds missing courses
+// in a single transaction. Missing blueprint years are created.
+func (m DBManager) applyPlanDiff(uid string, items []diffItem, lang language.Language) error {
+	var removed []int
+	var movedIDs, movedYears, movedSemesters []int
+	var addedCodes []string
+	var addedYears, addedSemesters []int
+	maxYear := 0
+	for _, item := range items {
+		switch item.kind {
+		case diffExtra:
+			removed = append(removed, item.blueprintID)
+		case diffMoved:
+			movedIDs = append(movedIDs, item.blueprintID)
+			movedYears = append(movedYears, item.year)
+			movedSemesters = append(movedSemesters, item.semester)
+			maxYear = max(maxYear, item.year)
+		case diffMissing:
+			addedCodes = append(addedCodes, item.code)
+			addedYears = append(addedYears, item.year)
+			addedSemesters = append(addedSemesters, item.semester)
+			maxYear = max(maxYear, item.year)
+		}
+	}
+
+	tx, err := m.DB.Beginx()
+	if err != nil {
+		return errorx.NewHTTPErr(
+			errorx.AddContext(fmt.Errorf("failed to begin transaction: %w", err), errorx.P("lang", lang)),
+			http.StatusInternalServerError,
+			texts[lang].errCannotApplyDiff,
+		)
+	}
+	defer tx.Rollback()
+
+	// record current blueprint so the change can be undone
+	_, err = tx.Exec(sqlquery.RecordBlueprintHistory, uid, historyActionDiff)
+	if err != nil {
+		return errorx.NewHTTPErr(
+			errorx.AddContext(fmt.Errorf("sqlquery.RecordBlueprintHistory: %w", err), errorx.P("lang", lang)),
+			http.StatusInternalServerError,
+			texts[lang].errCannotApplyDiff,
+		)
+	}
+
+	var bpYearCount int
+	err = tx.Get(&bpYearCount, sqlquery.CountBlueprintYears, uid)
+	if err != nil {
+		return errorx.NewHTTPErr(
+			errorx.AddContext(fmt.Errorf("sqlquery.CountBlueprintYears: %w", err), errorx.P("lang", lang)),
+			http.StatusInternalServerError,
+			texts[lang].errCannotApplyDiff,
+		)
+	}
+	if bpYearCount < maxYear {
+		if _, err = tx.Exec(sqlquery.InsertMissingBlueprintYears, uid, bpYearCount+1, maxYear); err == nil {
+			_, err = tx.Exec(sqlquery.InsertMissingBlueprintSemesters, uid, bpYearCount+1, maxYear)
+		}
+		if err != nil {
+			return errorx.NewHTTPErr(
+				errorx.AddContext(fmt.Errorf("insert missing blueprint years: %w", err), errorx.P("maxYear", maxYear), errorx.P("lang", lang)),
+				http.StatusInternalServerError,
+				texts[lang].errCannotApplyDiff,
+			)
+		}
+	}
+
+	steps := []struct {
+		name  string
+		query string
+		args  []any
+		skip  bool
+	}{
+		{"sqlquery.RemoveBlueprintCourses", sqlquery.RemoveBlueprintCourses, []any{uid, pq.Array(removed)}, len(removed) == 0},
+		{"sqlquery.MoveCoursesToSemesters", sqlquery.MoveCoursesToSemesters, []any{uid, pq.Array(movedIDs), pq.Array(movedYears), pq.Array(movedSemesters)}, len(movedIDs) == 0},
+		{"sqlquery.AddCoursesToSemesters", sqlquery.AddCoursesToSemesters, []any{uid, pq.Array(addedCodes), pq.Array(addedYears), pq.Array(addedSemesters)}, len(addedCodes) == 0},
+	}
+	for _, step := range steps {
+		if step.skip {
+			continue
+		}
+		if _, err = tx.Exec(step.query, step.args...); err != nil {
+			return errorx.NewHTTPErr(
+				errorx.AddContext(fmt.Errorf("%s: %w", step.name, err), errorx.P("lang", lang)),
+				http.StatusInternalServerError,
+				texts[lang].errCannotApplyDiff,
+			)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return errorx.NewHTTPErr(
+			errorx.AddContext(fmt.Errorf("failed to commit transaction: %w", err), errorx.P("lang", lang)),
+			http.StatusInternalServerError,
+			texts[lang].errCannotApplyDiff,
+		)
+	}
+	m.blueprintChanged(uid)
 
 	return nil
 }
@@ -372,6 +529,7 @@ func intoCourse(from dbDegreePlanRecord) course {
 		guarantors:         intoTeacherSlice(from.Guarantors),
 		isSupported:        from.CourseIsSupported,
 		blueprintSemesters: from.BlueprintSemesters,
+		completed:          from.Completed,
 	}
 }
 
@@ -0,0 +1,340 @@
+package degreeplandetail
+
+import (
+	"cmp"
+	"fmt"
+	"net/http"
+	"slices"
+
+	"github.com/michalhercik/RecSIS/errorx"
+	"github.com/michalhercik/RecSIS/language"
+)
+
+//================================================================================
+// Constants
+//================================================================================
+
+const diffItemParam = "diff-item"
+
+const (
+	diffMissing diffKind = "missing"
+	diffMoved   diffKind = "moved"
+	diffExtra   diffKind = "extra"
+)
+
+// blueprint course statuses (see blueprint package)
+const (
+	statusPassed = "passed"
+	statusFailed = "failed"
+)
+
+//================================================================================
+// Data Types and Methods
+//================================================================================
+
+type blueprintCourse struct {
+	ID       int    `db:"id"`
+	Code     string `db:"course_code"`
+	Title    string `db:"title"`
+	Credits  int    `db:"credits"`
+	Year     int    `db:"academic_year"`
+	Semester int    `db:"semester"`
+	Status   string `db:"status"`
+}
+
+// isMovable reports whether the diff may move or remove the course. Finished
+// courses stay where they are.
+func (bc blueprintCourse) isMovable() bool {
+	return bc.Status != statusPassed && bc.Status != statusFailed
+}
+
+type diffKind string
+
+type diffSlot struct {
+	year     int
+	semester int
+}
+
+// diffItem is a single difference between the blueprint and the recommended
+// plan. Year and semester are the recommended ones for missing and moved
+// courses and the current ones for extra courses.
+type diffItem struct {
+	kind        diffKind
+	code        string
+	title       string
+	credits     int
+	blueprintID int
+	from        diffSlot
+	diffSlot
+}
+
+// key identifies the item between rendering the diff and applying it.
+func (d diffItem) key() string {
+	return fmt.Sprintf("%s:%s:%d:%d", d.kind, d.code, d.year, d.semester)
+}
+
+// isDefaultSelected reports whether the item is selected in a fresh diff.
+// Extra courses are often intentional (electives), so they are opt-in.
+func (d diffItem) isDefaultSelected() bool {
+	return d.kind != diffExtra
+}
+
+type diffSemester struct {
+	diffSlot
+	matched []blueprintCourse
+	items   []diffItem
+}
+
+type planDiff struct {
+	planCode  string
+	planTitle string
+	semesters []diffSemester
+}
+
+func (pd *planDiff) isEmpty() bool {
+	for _, s := range pd.semesters {
+		if len(s.items) > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func (pd *planDiff) items() []diffItem {
+	var items []diffItem
+	for _, s := range pd.semesters {
+		items = append(items, s.items...)
+	}
+	return items
+}
+
+// selected returns the items with the given keys. It fails if any key is not
+// part of the diff, e.g. because the blueprint was changed in the meantime.
+func (pd *planDiff) selected(keys []string) ([]diffItem, bool) {
+	items := pd.items()
+	result := make([]diffItem, 0, len(keys))
+	for _, key := range keys {
+		i := slices.IndexFunc(items, func(d diffItem) bool { return d.key() == key })
+		if i < 0 {
+			return nil, false
+		}
+		if !slices.ContainsFunc(result, func(d diffItem) bool { return d.key() == key }) {
+			result = append(result, items[i])
+		}
+	}
+	return result, true
+}
+
+//================================================================================
+// Diff
+//================================================================================
+
+// buildPlanDiff lines up blueprint courses against the recommended plan
+// semester by semester. A recommended course is matched by a blueprint course
+// in the same semester, otherwise a movable blueprint course of the same code
+// (unassigned ones first) is moved there, otherwise the course is missing.
+// Passed courses fulfil the recommendation wherever they are. Movable
+// blueprint courses left over are extra.
+func buildPlanDiff(dp *degreePlanPage, blueprint []blueprintCourse) planDiff {
+	pd := planDiff{planCode: dp.code, planTitle: dp.title}
+	semesters := make(map[diffSlot]*diffSemester)
+	semester := func(slot diffSlot) *diffSemester {
+		if s, ok := semesters[slot]; ok {
+			return s
+		}
+		s := &diffSemester{diffSlot: slot}
+		semesters[slot] = s
+		return s
+	}
+
+	var codes []string
+	recommended := make(map[string][]diffSlot)
+	info := make(map[string]course)
+	for i, y := range dp.recommendedPlan.years {
+		for j, courses := range [][]course{y.winter, y.summer} {
+			slot := diffSlot{year: i + 1, semester: j + 1}
+			semester(slot)
+			for _, c := range courses {
+				if !c.isSupported {
+					continue
+				}
+				if _, ok := info[c.code]; !ok {
+					codes = append(codes, c.code)
+					info[c.code] = c
+				}
+				recommended[c.code] = append(recommended[c.code], slot)
+			}
+		}
+	}
+
+	byCode := make(map[string][]blueprintCourse)
+	for _, bc := range blueprint {
+		if _, ok := info[bc.Code]; ok {
+			byCode[bc.Code] = append(byCode[bc.Code], bc)
+		} else if bc.Year > 0 && bc.isMovable() {
+			extra := semester(diffSlot{bc.Year, bc.Semester})
+			extra.items = append(extra.items, extraItem(bc))
+		}
+	}
+
+	for _, code := range codes {
+		entries := byCode[code]
+		used := make([]bool, len(entries))
+		var open []diffSlot
+		for _, slot := range recommended[code] {
+			i := slices.IndexFunc(entries, func(bc blueprintCourse) bool {
+				return bc.Year == slot.year && bc.Semester == slot.semester
+			})
+			if i >= 0 {
+				used[i] = true
+				semester(slot).matched = append(semester(slot).matched, entries[i])
+				continue
+			}
+			open = append(open, slot)
+		}
+		for i, bc := range entries {
+			if len(open) > 0 && !used[i] && bc.Status == statusPassed {
+				used[i] = true
+				open = open[1:]
+			}
+		}
+		for _, slot := range open {
+			item := diffItem{kind: diffMissing, code: code, title: info[code].title, credits: info[code].credits, diffSlot: slot}
+			for i, bc := range entries {
+				if !used[i] && bc.isMovable() {
+					used[i] = true
+					item.kind = diffMoved
+					item.blueprintID = bc.ID
+					item.from = diffSlot{bc.Year, bc.Semester}
+					break
+				}
+			}
+			semester(slot).items = append(semester(slot).items, item)
+		}
+		for i, bc := range entries {
+			if !used[i] && bc.Year > 0 && bc.isMovable() {
+				slot := diffSlot{bc.Year, bc.Semester}
+				semester(slot).items = append(semester(slot).items, extraItem(bc))
+			}
+		}
+	}
+
+	kinds := []diffKind{diffMissing, diffMoved, diffExtra}
+	for _, s := range semesters {
+		slices.SortStableFunc(s.items, func(a, b diffItem) int {
+			return cmp.Compare(slices.Index(kinds, a.kind), slices.Index(kinds, b.kind))
+		})
+		pd.semesters = append(pd.semesters, *s)
+	}
+	slices.SortFunc(pd.semesters, func(a, b diffSemester) int {
+		return cmp.Or(cmp.Compare(a.year, b.year), cmp.Compare(a.semester, b.semester))
+	})
+	return pd
+}
+
+func extraItem(bc blueprintCourse) diffItem {
+	return diffItem{
+		kind:        diffExtra,
+		code:        bc.Code,
+		title:       bc.Title,
+		credits:     bc.Credits,
+		blueprintID: bc.ID,
+		diffSlot:    diffSlot{bc.Year, bc.Semester},
+	}
+}
+
+//================================================================================
+// Handlers
+//================================================================================
+
+func (s Server) diffPage(w http.ResponseWriter, r *http.Request) {
+	lang := language.FromContext(r.Context())
+	t := texts[lang]
+	userID := s.Auth.UserID(r)
+	pd, err := s.planDiff(userID, r.PathValue(dpCode), lang)
+	if err != nil {
+		code, userMsg := errorx.UnwrapError(err, lang)
+		s.Error.Log(errorx.AddContext(err))
+		s.Error.RenderPage(w, r, code, userMsg, t.diffTitle, userID, lang)
+		return
+	}
+	main := DiffContent(pd, t)
+	page := s.Page.View(main, lang, t.diffTitle, userID)
+	err = page.Render(r.Context(), w)
+	if err != nil {
+		s.Error.CannotRenderPage(w, r, t.diffTitle, userID, errorx.AddContext(err), lang)
+	}
+}
+
+func (s Server) diffApplication(w http.ResponseWriter, r *http.Request) {
+	lang := language.FromContext(r.Context())
+	t := texts[lang]
+	userID := s.Auth.UserID(r)
+	planCode := r.PathValue(dpCode)
+	pd, err := s.planDiff(userID, planCode, lang)
+	if err == nil {
+		var items []diffItem
+		items, err = parseDiffItems(r, pd)
+		if err == nil {
+			err = s.Data.applyPlanDiff(userID, items, lang)
+		}
+	}
+	if err == nil {
+		pd, err = s.planDiff(userID, planCode, lang)
+	}
+	if err != nil {
+		code, userMsg := errorx.UnwrapError(err, lang)
+		s.Error.Log(errorx.AddContext(err))
+		s.Error.Render(w, r, code, userMsg, lang)
+		return
+	}
+	err = DiffContent(pd, t).Render(r.Context(), w)
+	if err != nil {
+		s.Error.CannotRenderComponent(w, r, errorx.AddContext(err), lang)
+	}
+}
+
+func (s Server) planDiff(userID, planCode string, lang language.Language) (planDiff, error) {
+	dp, err := s.Data.degreePlan(userID, planCode, lang)
+	if err != nil {
+		return planDiff{}, errorx.AddContext(err)
+	}
+	blueprint, err := s.Data.blueprintCourses(userID, lang)
+	if err != nil {
+		return planDiff{}, errorx.AddContext(err)
+	}
+	return buildPlanDiff(dp, blueprint), nil
+}
+
+//================================================================================
+// Parse parameters
+//================================================================================
+
+func parseDiffItems(r *http.Request, pd planDiff) ([]diffItem, error) {
+	lang := language.FromContext(r.Context())
+	t := texts[lang]
+	if err := r.ParseForm(); err != nil {
+		return nil, errorx.NewHTTPErr(
+			errorx.AddContext(fmt.Errorf("unable to parse form: %w", err)),
+			http.StatusBadRequest,
+			t.errNoDiffSelected,
+		)
+	}
+	keys := r.Form[diffItemParam]
+	if len(keys) == 0 {
+		return nil, errorx.NewHTTPErr(
+			errorx.AddContext(fmt.Errorf("no diff item selected")),
+			http.StatusBadRequest,
+			t.errNoDiffSelected,
+		)
+	}
+	items, ok := pd.selected(keys)
+	if !ok {
+		return nil, errorx.NewHTTPErr(
+			errorx.AddContext(fmt.Errorf("selected diff items are outdated"), errorx.P("keys", keys)),
+			http.StatusConflict,
+			t.errDiffOutdated,
+		)
+	}
+	return items, nil
+}
@@ -10,17 +10,37 @@ const UserDegreePlan = `--sql
 WITH user_blueprint_semesters AS (
 	SELECT DISTINCT
 		dpc.course_code,
-		array_agg(bc.course_code IS NOT NULL ORDER BY by.academic_year, bs.semester) AS semesters
+		array_agg(COALESCE(bc.in_semester, false) ORDER BY by.academic_year, bs.semester) AS semesters,
+		COALESCE(bool_or(bc.completed), false) AS completed
 	FROM studies s
 	LEFT JOIN degree_plan_courses dpc
 		ON s.degree_plan_code = dpc.plan_code
+	LEFT JOIN blueprint_scenarios sc
+		ON sc.user_id = s.user_id
+		AND sc.active
 	LEFT JOIN blueprint_years by
-		ON by.user_id = s.user_id
+		ON by.scenario_id = sc.id
 	LEFT JOIN blueprint_semesters bs
 		ON by.id = bs.blueprint_year_id
-	LEFT JOIN blueprint_courses bc
-		ON bs.id = bc.blueprint_semester_id
-		AND bc.course_code = dpc.course_code
+	LEFT JOIN LATERAL (
+		-- custom courses count in place of the course they are mapped to or
+		-- of courses interchangeable with it
+		SELECT
+			count(*) > 0 AS in_semester,
+			bool_or(bc.status = 'passed') AS completed
+		FROM blueprint_courses bc
+		WHERE bc.blueprint_semester_id = bs.id
+			AND (
+				bc.course_code = dpc.course_code
+				OR bc.mapped_course_code = dpc.course_code
+				OR EXISTS (
+					SELECT 1
+					FROM courses mc
+					WHERE mc.code = bc.mapped_course_code
+						AND jsonb_path_exists(mc.interchangeabilities, '$.** ? (@ == $code)', jsonb_build_object('code', dpc.course_code))
+				)
+			)
+	) bc ON true
 	WHERE s.user_id = $1
 		AND dpc.lang = $2
 	GROUP BY dpc.course_code, dpc.bloc_subject_code
@@ -57,7 +77,8 @@ SELECT
 	dpc.recommended_year_to,
 	dpc.recommended_semester,
 	c.credits IS NOT NULL as course_is_supported,
-	ubs.semesters
+	ubs.semesters,
+	COALESCE(ubs.completed, false) AS completed
 FROM studies s
 LEFT JOIN degree_plans dp
 	ON s.degree_plan_code = dp.plan_code
@@ -79,15 +100,32 @@ const DegreePlan = `--sql
 WITH user_blueprint_semesters AS (
 	SELECT DISTINCT
 		dpc.course_code,
-		array_agg(bc.course_code IS NOT NULL ORDER BY by.academic_year, bs.semester) AS semesters
+		array_agg(COALESCE(bc.in_semester, false) ORDER BY by.academic_year, bs.semester) AS semesters,
+		COALESCE(bool_or(bc.completed), false) AS completed
 	FROM degree_plan_courses dpc
 	LEFT JOIN blueprint_years by
-		ON by.user_id = $1
+		ON by.scenario_id = (SELECT id FROM blueprint_scenarios WHERE user_id = $1 AND active)
 	LEFT JOIN blueprint_semesters bs
 		ON by.id = bs.blueprint_year_id
-	LEFT JOIN blueprint_courses bc
-		ON bs.id = bc.blueprint_semester_id
-		AND bc.course_code = dpc.course_code
+	LEFT JOIN LATERAL (
+		-- custom courses count in place of the course they are mapped to or
+		-- of courses interchangeable with it
+		SELECT
+			count(*) > 0 AS in_semester,
+			bool_or(bc.status = 'passed') AS completed
+		FROM blueprint_courses bc
+		WHERE bc.blueprint_semester_id = bs.id
+			AND (
+				bc.course_code = dpc.course_code
+				OR bc.mapped_course_code = dpc.course_code
+				OR EXISTS (
+					SELECT 1
+					FROM courses mc
+					WHERE mc.code = bc.mapped_course_code
+						AND jsonb_path_exists(mc.interchangeabilities, '$.** ? (@ == $code)', jsonb_build_object('code', dpc.course_code))
+				)
+			)
+	) bc ON true
 	WHERE dpc.plan_code = $2
 		AND dpc.lang = $3
 	GROUP BY dpc.course_code, dpc.bloc_subject_code
@@ -124,7 +162,8 @@ SELECT
 	dpc.recommended_year_to,
 	dpc.recommended_semester,
 	c.credits IS NOT NULL as course_is_supported,
-	ubs.semesters
+	ubs.semesters,
+	COALESCE(ubs.completed, false) AS completed
 FROM degree_plans dp
 LEFT JOIN degree_plan_courses dpc
 	ON dp.plan_code = dpc.plan_code
@@ -167,17 +206,20 @@ DELETE FROM blueprint_courses
 USING blueprint_semesters bs, blueprint_years by
 WHERE blueprint_courses.blueprint_semester_id = bs.id
 AND bs.blueprint_year_id = by.id
-AND by.user_id = $1
+AND by.scenario_id = (SELECT id FROM blueprint_scenarios WHERE user_id = $1 AND active)
 `
 
 const CountBlueprintYears = `--sql
 SELECT COALESCE(MAX(academic_year), 0) FROM blueprint_years
-WHERE user_id = $1
+WHERE scenario_id = (SELECT id FROM blueprint_scenarios WHERE user_id = $1 AND active)
 `
 
 const InsertMissingBlueprintYears = `--sql
-INSERT INTO blueprint_years (user_id, academic_year)
-SELECT $1 AS user_id, generate_series($2::int, $3::int) AS academic_year
+INSERT INTO blueprint_years (user_id, scenario_id, academic_year)
+SELECT
+	$1 AS user_id,
+	(SELECT id FROM blueprint_scenarios WHERE user_id = $1 AND active) AS scenario_id,
+	generate_series($2::int, $3::int) AS academic_year
 `
 
 const InsertMissingBlueprintSemesters = `--sql
@@ -185,7 +227,7 @@ INSERT INTO blueprint_semesters (blueprint_year_id, semester)
 SELECT by.id, s.semester
 FROM blueprint_years by
 CROSS JOIN (VALUES (1), (2)) AS s(semester)
-WHERE by.user_id = $1
+WHERE by.scenario_id = (SELECT id FROM blueprint_scenarios WHERE user_id = $1 AND active)
 AND by.academic_year BETWEEN $2::int AND $3::int
 `
 
@@ -220,7 +262,7 @@ years_and_semesters AS (
         rc.valid_from
     FROM recommended_courses rc
     CROSS JOIN LATERAL generate_series(rc.recommended_year_from, rc.recommended_year_to) AS y
-    LEFT JOIN blueprint_years by ON by.user_id = $1 AND by.academic_year = y
+    LEFT JOIN blueprint_years by ON by.scenario_id = (SELECT id FROM blueprint_scenarios WHERE user_id = $1 AND active) AND by.academic_year = y
     LEFT JOIN blueprint_semesters bs ON bs.blueprint_year_id = by.id AND bs.semester = rc.semester
 )
 SELECT 
@@ -262,7 +304,7 @@ years_and_semesters AS (
         rc.valid_from
     FROM recommended_courses rc
     CROSS JOIN LATERAL generate_series(rc.recommended_year_from, rc.recommended_year_to) AS y
-    LEFT JOIN blueprint_years by ON by.user_id = $1 AND by.academic_year = y
+    LEFT JOIN blueprint_years by ON by.scenario_id = (SELECT id FROM blueprint_scenarios WHERE user_id = $1 AND active) AND by.academic_year = y
     LEFT JOIN blueprint_semesters bs ON bs.blueprint_year_id = by.id AND bs.semester = rc.semester
 ),
 max_positions AS (
@@ -289,3 +331,7 @@ SELECT
 FROM with_positions
 ON CONFLICT (blueprint_semester_id, course_code) DO NOTHING
 `
+
+const RecordBlueprintHistory = `--sql
+SELECT record_blueprint_history($1, $2);
+`
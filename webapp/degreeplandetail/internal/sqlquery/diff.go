@@ -0,0 +1,99 @@
+package sqlquery
+
+/* === Blueprint diff === */
+
+// Params: $1 user, $2 language
+const BlueprintCourses = `--sql
+SELECT
+	bc.id,
+	bc.course_code,
+	COALESCE(c.title, bc.course_code) AS title,
+	COALESCE(c.credits, 0) AS credits,
+	y.academic_year,
+	bs.semester,
+	bc.status
+FROM blueprint_years y
+INNER JOIN blueprint_semesters bs
+	ON y.id = bs.blueprint_year_id
+INNER JOIN blueprint_courses bc
+	ON bs.id = bc.blueprint_semester_id
+LEFT JOIN courses c
+	ON bc.course_code = c.code
+	AND bc.course_valid_from = c.valid_from
+	AND c.lang = $2
+WHERE y.scenario_id = (SELECT id FROM blueprint_scenarios WHERE user_id = $1 AND active)
+	AND bc.course_code IS NOT NULL
+ORDER BY y.academic_year, bs.semester, bc.position, bc.secondary_position;
+`
+
+// Params: $1 user, $2 course codes, $3 academic years, $4 semesters
+const AddCoursesToSemesters = `--sql
+INSERT INTO blueprint_courses (blueprint_semester_id, course_code, course_valid_from, position)
+SELECT
+	bs.id,
+	i.code,
+	c.valid_from,
+	COALESCE((
+		SELECT MAX(position)
+		FROM blueprint_courses
+		WHERE blueprint_semester_id = bs.id
+	), 0) + ROW_NUMBER() OVER (PARTITION BY bs.id ORDER BY i.ord)
+FROM UNNEST($2::text[], $3::int[], $4::int[]) WITH ORDINALITY AS i(code, academic_year, semester, ord)
+INNER JOIN blueprint_years y
+	ON y.scenario_id = (SELECT id FROM blueprint_scenarios WHERE user_id = $1 AND active)
+	AND y.academic_year = i.academic_year
+INNER JOIN blueprint_semesters bs
+	ON y.id = bs.blueprint_year_id
+	AND bs.semester = i.semester
+JOIN LATERAL (
+	SELECT valid_from
+	FROM courses
+	WHERE code = i.code
+	ORDER BY valid_from DESC
+	LIMIT 1
+) c ON TRUE
+ON CONFLICT (blueprint_semester_id, course_code) DO NOTHING
+`
+
+// Params: $1 user, $2 blueprint course IDs, $3 academic years, $4 semesters
+const MoveCoursesToSemesters = `--sql
+WITH targets AS (
+	SELECT
+		bc.id,
+		bs.id AS semester_id,
+		COALESCE((
+			SELECT MAX(position)
+			FROM blueprint_courses
+			WHERE blueprint_semester_id = bs.id
+		), 0) + ROW_NUMBER() OVER (PARTITION BY bs.id ORDER BY i.ord) AS position
+	FROM UNNEST($2::int[], $3::int[], $4::int[]) WITH ORDINALITY AS i(id, academic_year, semester, ord)
+	INNER JOIN blueprint_courses bc
+		ON bc.id = i.id
+	INNER JOIN blueprint_semesters obs
+		ON bc.blueprint_semester_id = obs.id
+	INNER JOIN blueprint_years oy
+		ON obs.blueprint_year_id = oy.id
+		AND oy.scenario_id = (SELECT id FROM blueprint_scenarios WHERE user_id = $1 AND active)
+	INNER JOIN blueprint_years y
+		ON y.scenario_id = oy.scenario_id
+		AND y.academic_year = i.academic_year
+	INNER JOIN blueprint_semesters bs
+		ON y.id = bs.blueprint_year_id
+		AND bs.semester = i.semester
+)
+UPDATE blueprint_courses bc
+SET blueprint_semester_id = t.semester_id,
+	position = t.position
+FROM targets t
+WHERE bc.id = t.id;
+`
+
+// Params: $1 user, $2 blueprint course IDs
+const RemoveBlueprintCourses = `--sql
+DELETE FROM blueprint_courses
+USING blueprint_semesters bs, blueprint_years by
+WHERE blueprint_courses.blueprint_semester_id = bs.id
+AND bs.blueprint_year_id = by.id
+AND by.scenario_id = (SELECT id FROM blueprint_scenarios WHERE user_id = $1 AND active)
+AND blueprint_courses.id = ANY($2)
+`
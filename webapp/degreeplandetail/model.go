@@ -153,8 +153,7 @@ func (b *bloc) isCompleted() bool {
 func (b *bloc) completedCredits() int {
 	credits := 0
 	for _, c := range b.courses {
-		// TODO: add course completion status -> change `false` to `course.Completed`
-		if false {
+		if c.completed {
 			credits += c.credits
 		}
 	}
@@ -201,6 +200,7 @@ type course struct {
 	examType           string
 	isSupported        bool
 	blueprintSemesters []bool
+	completed          bool
 }
 
 func (c *course) isInBlueprint() bool {
@@ -232,8 +232,7 @@ func (c *course) isUnassigned() bool {
 }
 
 func (c *course) statusBackgroundColor() string {
-	// TODO: add course completion status -> change `false` to `course.Completed`
-	if false {
+	if c.completed {
 		return "bg-success"
 	} else if c.isAssigned() {
 		return "bg-blueprint"
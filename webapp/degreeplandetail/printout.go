@@ -0,0 +1,106 @@
+package degreeplandetail
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+
+	"github.com/michalhercik/RecSIS/errorx"
+	"github.com/michalhercik/RecSIS/language"
+	"github.com/michalhercik/RecSIS/pdf"
+)
+
+//================================================================================
+// Handlers
+//================================================================================
+
+// pdfExport returns a printable overview of the degree plan with progress of
+// the user in every bloc.
+func (s Server) pdfExport(w http.ResponseWriter, r *http.Request) {
+	lang := language.FromContext(r.Context())
+	t := texts[lang]
+	userID := s.Auth.UserID(r)
+	planCode := r.PathValue(dpCode)
+	dp, err := s.Data.degreePlan(userID, planCode, lang)
+	if err != nil {
+		code, userMsg := errorx.UnwrapError(err, lang)
+		s.Error.Log(errorx.AddContext(err))
+		s.Error.Render(w, r, code, userMsg, lang)
+		return
+	}
+	var buf bytes.Buffer
+	if err := exportPDF(&buf, dp, t); err != nil {
+		s.Error.Log(errorx.AddContext(fmt.Errorf("exportPDF: %w", err), errorx.P("dpCode", planCode)))
+		s.Error.Render(w, r, http.StatusInternalServerError, t.errCannotExportPDF, lang)
+		return
+	}
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": dp.code + ".pdf"}))
+	w.Write(buf.Bytes())
+}
+
+//================================================================================
+// PDF
+//================================================================================
+
+// exportPDF writes an overview of bloc progress followed by courses of every
+// bloc with their status.
+func exportPDF(w io.Writer, dp *degreePlanPage, t text) error {
+	doc := pdf.New(fmt.Sprintf("%s – %s", dp.code, dp.title))
+	doc.Title(fmt.Sprintf("%s – %s", dp.code, dp.title))
+	doc.Text(fmt.Sprintf("%s: %s", t.studyField, dp.fieldTitle))
+	doc.Text(fmt.Sprintf("%s: %d - %d", t.validity, dp.validFrom, dp.validTo))
+	doc.Text(fmt.Sprintf("%s: %d, %s: %d, %s: %d",
+		t.totalCredits, dp.totalCredits,
+		t.requiredCredits, dp.requiredCredits,
+		t.requiredElectiveCredits, dp.requiredElectiveCredits,
+	))
+	doc.Heading(t.pdfProgress)
+	progress := doc.Table(
+		pdf.Column{Title: t.pdfBloc, Width: 5},
+		pdf.Column{Title: t.pdfLimit, Width: 0.8, Align: pdf.AlignRight},
+		pdf.Column{Title: t.completed, Width: 1, Align: pdf.AlignRight},
+		pdf.Column{Title: t.pdfAssigned, Width: 1, Align: pdf.AlignRight},
+		pdf.Column{Title: t.blueprint, Width: 1, Align: pdf.AlignRight},
+		pdf.Column{Title: t.status, Width: 1.4},
+	)
+	for i := range dp.blocs {
+		b := &dp.blocs[i]
+		limit := "---"
+		if b.hasLimit() {
+			limit = strconv.Itoa(b.limit)
+		}
+		progress.Row(
+			b.name,
+			limit,
+			strconv.Itoa(b.completedCredits()),
+			strconv.Itoa(b.assignedCredits()),
+			strconv.Itoa(b.blueprintCredits()),
+			t.blocStatusStr(b),
+		)
+	}
+	progress.End()
+	doc.Note(t.pdfLegend)
+	for i := range dp.blocs {
+		b := &dp.blocs[i]
+		doc.Heading(b.name)
+		courses := doc.Table(
+			pdf.Column{Title: t.code, Width: 1.2},
+			pdf.Column{Title: t.title, Width: 4},
+			pdf.Column{Title: t.creditsShort, Width: 0.6, Align: pdf.AlignRight},
+			pdf.Column{Title: t.winter, Width: 1.3},
+			pdf.Column{Title: t.summer, Width: 1.3},
+			pdf.Column{Title: t.status, Width: 1.3},
+		)
+		for j := range b.courses {
+			c := &b.courses[j]
+			courses.Row(c.code, c.title, c.creditsString(), c.winterString(), c.summerString(), t.courseStatusStr(c))
+		}
+		courses.End()
+	}
+	_, err := doc.WriteTo(w)
+	return err
+}
@@ -95,6 +95,9 @@ func (s *Server) Init() {
 		{"%s", s.addCourseToBlueprint, []any{s.BpBtn.Endpoint()}},
 		{"PATCH /plan-to-blueprint/{%s}", s.mergeRecPlanWithBlueprint, []any{dpCode}},
 		{"PUT /plan-to-blueprint/{%s}", s.rewriteBlueprintWithRecPlan, []any{dpCode}},
+		{"GET /diff/{%s}", s.diffPage, []any{dpCode}},
+		{"POST /diff/{%s}", s.diffApplication, []any{dpCode}},
+		{"GET /pdf/{%s}", s.pdfExport, []any{dpCode}},
 		{"/", s.pageNotFound, nil},
 	}
 	router := http.NewServeMux()
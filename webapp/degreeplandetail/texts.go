@@ -68,6 +68,24 @@ type text struct {
 	year                    string
 	winterAssign            string
 	summerAssign            string
+	// diff
+	diffTitle         string
+	diffIntro         string
+	diffBtn           string
+	diffMatched       string
+	diffMissing       string
+	diffMoved         string
+	diffExtra         string
+	diffApply         string
+	diffNoDifferences string
+	diffBackToPlan    string
+	// printable export
+	pdfBtn      string
+	pdfProgress string
+	pdfBloc     string
+	pdfLimit    string
+	pdfAssigned string
+	pdfLegend   string
 	// language
 	language language.Language
 	// errors
@@ -81,6 +99,11 @@ type text struct {
 	errCannotMergeToBlueprint string
 	errDPNotFound             string
 	errPageNotFound           string
+	errCannotGetDiff          string
+	errCannotApplyDiff        string
+	errDiffOutdated           string
+	errNoDiffSelected         string
+	errCannotExportPDF        string
 	// tooltips
 	ttUncheckAll       string
 	ttAssignedCredits  string
@@ -98,6 +121,43 @@ func (t text) yearStr(year int) string {
 	return ""
 }
 
+func (t text) slotStr(slot diffSlot) string {
+	switch {
+	case slot.year == 0:
+		return t.unassigned
+	case slot.semester == int(teachingWinterOnly):
+		return t.yearStr(slot.year) + " " + t.winter
+	default:
+		return t.yearStr(slot.year) + " " + t.summer
+	}
+}
+
+// blocStatusStr returns the status of the bloc as shown by its status icon,
+// empty for blocs without a limit.
+func (t text) blocStatusStr(b *bloc) string {
+	switch {
+	case b.isCompleted():
+		return t.completed
+	case b.isAssigned():
+		return t.blueprint
+	case b.hasLimit():
+		return t.notCompleted
+	}
+	return ""
+}
+
+func (t text) courseStatusStr(c *course) string {
+	switch {
+	case c.completed:
+		return t.completed
+	case c.isAssigned():
+		return t.blueprint
+	case c.isUnassigned():
+		return t.unassigned
+	}
+	return t.notCompleted
+}
+
 var texts = map[language.Language]text{
 	language.CS: {
 		pageTitle:               "Studijní plán",
@@ -161,6 +221,24 @@ var texts = map[language.Language]text{
 		year:                    "ročník",
 		winterAssign:            "ZS",
 		summerAssign:            "LS",
+		// diff
+		diffTitle:         "Porovnání s blueprintem",
+		diffIntro:         "Porovnání vašeho blueprintu s doporučeným průběhem studia po semestrech. Vyberte rozdíly, které chcete do blueprintu promítnout. Kurzy navíc nejsou ve výchozím stavu vybrány, splněné kurzy se nikdy nepřesouvají.",
+		diffBtn:           "Porovnat s blueprintem",
+		diffMatched:       "Odpovídá",
+		diffMissing:       "Chybí",
+		diffMoved:         "Přesunout z: %s",
+		diffExtra:         "Navíc",
+		diffApply:         "Použít vybrané",
+		diffNoDifferences: "Blueprint odpovídá doporučenému průběhu.",
+		diffBackToPlan:    "Zpět na studijní plán",
+		// printable export
+		pdfBtn:      "Stáhnout PDF",
+		pdfProgress: "Plnění skupin",
+		pdfBloc:     "Skupina",
+		pdfLimit:    "Limit",
+		pdfAssigned: "V ročnících",
+		pdfLegend:   "Splněno: kredity za splněné předměty. V ročnících: kredity předmětů zařazených v blueprintu do ročníků. Blueprint: kredity všech předmětů v blueprintu včetně nezařazených.",
 		// language
 		language:           language.CS,
 		errCannotGetUserDP: "Nebylo možné získat studijní plán uživatele",
@@ -174,6 +252,11 @@ var texts = map[language.Language]text{
 		errCannotMergeToBlueprint: "Nebylo možné sloučit doporučený průběh s blueprintem",
 		errDPNotFound:             "Studijní plán nenalezen",
 		errPageNotFound:           "Stránka nenalezena",
+		errCannotGetDiff:          "Nebylo možné porovnat blueprint s doporučeným průběhem",
+		errCannotApplyDiff:        "Nebylo možné použít vybrané rozdíly",
+		errDiffOutdated:           "Blueprint se mezitím změnil, zkontrolujte prosím rozdíly znovu",
+		errNoDiffSelected:         "Nebyl vybrán žádný rozdíl",
+		errCannotExportPDF:        "Nebylo možné vytvořit PDF studijního plánu",
 		// tooltips
 		ttUncheckAll:       "Zrušit zaškrtnutí všech kurzů",
 		ttAssignedCredits:  "počet kreditů přiřazených do ročníků / limit skupiny",
@@ -242,6 +325,24 @@ var texts = map[language.Language]text{
 		year:                    "Year",
 		winterAssign:            "Winter",
 		summerAssign:            "Summer",
+		// diff
+		diffTitle:         "Comparison with blueprint",
+		diffIntro:         "Your blueprint compared with the recommended study plan semester by semester. Select the differences you want to apply to the blueprint. Extra courses are not selected by default and completed courses are never moved.",
+		diffBtn:           "Compare with blueprint",
+		diffMatched:       "Matches",
+		diffMissing:       "Missing",
+		diffMoved:         "Move from: %s",
+		diffExtra:         "Extra",
+		diffApply:         "Apply selected",
+		diffNoDifferences: "The blueprint matches the recommended plan.",
+		diffBackToPlan:    "Back to degree plan",
+		// printable export
+		pdfBtn:      "Download PDF",
+		pdfProgress: "Group progress",
+		pdfBloc:     "Group",
+		pdfLimit:    "Limit",
+		pdfAssigned: "In years",
+		pdfLegend:   "Completed: credits of completed courses. In years: credits of courses assigned to years in the blueprint. Blueprint: credits of all courses in the blueprint including unassigned ones.",
 		// language
 		language: language.EN,
 		// errors
@@ -255,6 +356,11 @@ var texts = map[language.Language]text{
 		errCannotMergeToBlueprint: "Unable to merge recommended plan to blueprint",
 		errDPNotFound:             "Degree plan not found",
 		errPageNotFound:           "Page not found",
+		errCannotGetDiff:          "Unable to compare blueprint with recommended plan",
+		errCannotApplyDiff:        "Unable to apply selected differences",
+		errDiffOutdated:           "The blueprint has changed in the meantime, please review the differences again",
+		errNoDiffSelected:         "No difference selected",
+		errCannotExportPDF:        "Unable to create PDF of the degree plan",
 		// tooltips
 		ttUncheckAll:       "Uncheck all courses",
 		ttAssignedCredits:  "sum of credits assigned to years / group limit",
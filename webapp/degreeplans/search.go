@@ -7,12 +7,17 @@ import (
 
 	"github.com/meilisearch/meilisearch-go"
 	"github.com/michalhercik/RecSIS/errorx"
+	"github.com/michalhercik/RecSIS/fulltext"
 	"github.com/michalhercik/RecSIS/language"
 )
 
+const maxHits = 200
+
 type expression interface {
 	String() string
 	Except() func(func(string, string) bool)
+	JSONPath() string
+	ExceptJSONPath() func(func(string, string) bool)
 	ConditionsCount() int
 }
 
@@ -58,10 +63,29 @@ type multiResponse struct {
 	Results []response `json:"results"`
 }
 
-type searchEngine interface {
+//================================================================================
+// Search Backends
+//================================================================================
+
+// SearchEngine is implemented by every search backend of degree plans.
+type SearchEngine interface {
 	Search(r request) (response, error)
 }
 
+// FallbackSearch searches degree plans with the full-text search while
+// Meilisearch is unavailable.
+type FallbackSearch struct {
+	fulltext.Fallback[SearchEngine]
+}
+
+func (s FallbackSearch) Search(r request) (response, error) {
+	return fulltext.Call(s.Fallback, func(e SearchEngine) (response, error) { return e.Search(r) })
+}
+
+//================================================================================
+// Meilisearch
+//================================================================================
+
 type MeiliSearch struct {
 	Client      meilisearch.ServiceManager
 	DegreePlans meilisearch.IndexConfig
@@ -76,7 +100,7 @@ func newRequestWithDisjunctiveFaceting(r request, index meilisearch.IndexConfig)
 		IndexUID:             index.Uid,
 		Query:                r.query,
 		Page:                 1,
-		HitsPerPage:          200,
+		HitsPerPage:          maxHits,
 		AttributesToRetrieve: []string{"code"}, // TODO might get all data from MeiliSearch and avoid extra DB query
 		Filter:               r.filter.String(),
 		Facets:               r.facets,
@@ -129,3 +153,54 @@ func (s MeiliSearch) Search(r request) (response, error) {
 	}
 	return result, nil
 }
+
+//================================================================================
+// PostgreSQL Full-Text Search
+//================================================================================
+
+// FullTextSearch searches degree plans in PostgreSQL when Meilisearch is not
+// available, see fulltext package.
+type FullTextSearch struct {
+	Engine      fulltext.Engine
+	DegreePlans string
+}
+
+func (s FullTextSearch) Search(r request) (response, error) {
+	t := texts[r.lang]
+	var result response
+	filter := r.filter.JSONPath()
+	res, err := s.Engine.Search(fulltext.Request{
+		Index:      s.DegreePlans,
+		Query:      r.query,
+		Lang:       r.lang,
+		Filter:     filter,
+		Facets:     fulltext.DisjunctiveFacets(r.facets, filter, r.filter.ExceptJSONPath()),
+		Attributes: []string{"code"},
+		Limit:      maxHits,
+	})
+	if err != nil {
+		return result, errorx.NewHTTPErr(
+			errorx.AddContext(err, errorx.P("index", r.indexUID), errorx.P("query", r.query)),
+			http.StatusInternalServerError,
+			t.errFailedDPSearch,
+		)
+	}
+	result.TotalHits = res.TotalHits
+	result.TotalPages = (res.TotalHits + maxHits - 1) / maxHits
+	result.FacetDistribution = res.FacetDistribution
+	result.DegreePlanCodes = make([]string, len(res.Hits))
+	for i, hit := range res.Hits {
+		var dp struct {
+			Code string `json:"code"`
+		}
+		if err := json.Unmarshal(hit, &dp); err != nil {
+			return result, errorx.NewHTTPErr(
+				errorx.AddContext(err, errorx.P("index", r.indexUID), errorx.P("query", r.query)),
+				http.StatusInternalServerError,
+				t.errFailedDPSearch,
+			)
+		}
+		result.DegreePlanCodes[i] = dp.Code
+	}
+	return result, nil
+}
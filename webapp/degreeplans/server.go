@@ -24,7 +24,7 @@ type Server struct {
 	Filters       filters.Filters
 	Page          Page
 	router        http.Handler
-	Search        searchEngine
+	Search        SearchEngine
 	compareServer *compare.Server
 }
 
@@ -118,6 +118,12 @@ func NewHTTPErr(err error, code int, userMsg string) HTTPError {
 	}
 }
 
+// Unwrap returns the wrapped error, so that errors.Is and errors.As can
+// inspect the cause.
+func (he HTTPError) Unwrap() error {
+	return he.Err
+}
+
 func (he HTTPError) StatusCode() int {
 	return he.Code
 }
@@ -2,9 +2,14 @@ package filters
 
 import (
 	"fmt"
+	"net/url"
+	"strconv"
 	"strings"
 )
 
+// expression is a conjunction of conditions. Conditions themselves may be OR
+// groups, so an expression can describe any AND of ORs of (negated) value and
+// range conditions.
 type expression []condition
 
 func (e *expression) Append(param string, values ...string) {
@@ -15,6 +20,7 @@ func (e *expression) Append(param string, values ...string) {
 	*e = append(*e, c)
 }
 
+// String returns the expression as a Meilisearch filter.
 func (e expression) String() string {
 	var sb strings.Builder
 	if len(e) == 0 {
@@ -28,26 +34,62 @@ func (e expression) String() string {
 	return sb.String()
 }
 
+// Except iterates over facet params the expression filters by together with
+// the expression without conditions on that param. It is used for disjunctive
+// faceting so that facet counts of a param are not narrowed by the param
+// itself. OR groups mixing several params are kept in every filter.
 func (e expression) Except() func(func(string, string) bool) {
 	return e.except
 }
 
 func (e expression) except(yield func(string, string) bool) {
+	seen := make(map[string]bool, len(e))
+	for _, c := range e {
+		param := c.getParam()
+		if param == "" || seen[param] {
+			continue
+		}
+		seen[param] = true
+		if !yield(param, e.without(param).String()) {
+			return
+		}
+	}
+}
+
+func (e expression) without(param string) expression {
+	result := make(expression, 0, len(e))
+	for _, c := range e {
+		if c.getParam() != param {
+			result = append(result, c)
+		}
+	}
+	return result
+}
+
+// JSONPath returns the expression as a SQL/JSON path filter of a document,
+// e.g. $ ? (exists(@."credits"[*] ? (@ == 5 || @ == "5"))), so that other
+// search backends than Meilisearch can filter by it. Empty expression
+// results in empty string.
+func (e expression) JSONPath() string {
 	if len(e) == 0 {
-		return
+		return ""
 	}
-	if len(e) == 1 {
-		yield(e[0].getParam(), "")
-		return
+	predicates := make([]string, len(e))
+	for i, c := range e {
+		predicates[i] = c.jsonPath()
 	}
-	exceptExpr := make(expression, 0, len(e)-1)
-	for i := range e.ConditionsCount() {
-		exceptExpr = append(exceptExpr, e[:i]...)
-		exceptExpr = append(exceptExpr, e[i+1:]...)
-		if !yield(e[i].getParam(), exceptExpr.String()) {
-			return
+	return fmt.Sprintf("$ ? (%s)", strings.Join(predicates, " && "))
+}
+
+// ExceptJSONPath is the same as Except but the filters are SQL/JSON paths
+// (see JSONPath).
+func (e expression) ExceptJSONPath() func(func(string, string) bool) {
+	return func(yield func(string, string) bool) {
+		for param := range e.Except() {
+			if !yield(param, e.without(param).JSONPath()) {
+				return
+			}
 		}
-		exceptExpr = exceptExpr[:0]
 	}
 }
 
@@ -55,16 +97,38 @@ func (e expression) ConditionsCount() int {
 	return len(e)
 }
 
+// URLValues encodes the expression into URL query parameters which
+// ParseURLQuery decodes back. Conditions not created from a URL (see Append)
+// are not encoded.
+func (e expression) URLValues() url.Values {
+	result := url.Values{}
+	for _, c := range e {
+		c.encode(result, "")
+	}
+	return result
+}
+
 // condition interface
 type condition interface {
+	// getParam returns the facet param the condition filters by or empty
+	// string if it filters by several params.
 	getParam() string
 	String() string
+	// jsonPath returns the condition as a predicate of SQL/JSON path filter
+	// expression where @ is the filtered document.
+	jsonPath() string
+	// encode adds the condition to URL values. Conditions of an OR group are
+	// encoded with the group ID.
+	encode(values url.Values, group string)
 }
 
 // general IN condition
 type inCondition struct {
-	param  string
-	values []string
+	param    string
+	values   []string
+	category string
+	ids      []string
+	negated  bool
 }
 
 func (c inCondition) getParam() string {
@@ -72,15 +136,44 @@ func (c inCondition) getParam() string {
 }
 
 func (c inCondition) String() string {
-	result := fmt.Sprintf("%s IN [\"%s\"]", c.param, strings.Join(c.values, "\",\""))
+	quoted := make([]string, len(c.values))
+	for i, v := range c.values {
+		quoted[i] = Quote(v)
+	}
+	operator := "IN"
+	if c.negated {
+		operator = "NOT IN"
+	}
+	return fmt.Sprintf("%s %s [%s]", c.param, operator, strings.Join(quoted, ","))
+}
+
+func (c inCondition) jsonPath() string {
+	result := fmt.Sprintf("exists(%s ? (%s))", jsonPathAttribute(c.param), jsonPathAnyOf(c.values))
+	if c.negated {
+		result = fmt.Sprintf("!(%s)", result)
+	}
 	return result
 }
 
+func (c inCondition) encode(values url.Values, group string) {
+	if c.category == "" {
+		return
+	}
+	key := urlKey(group, prefix, c.category)
+	if c.negated {
+		key = urlKey(group, notPrefix, c.category)
+	}
+	values[key] = append(values[key], c.ids...)
+}
+
 // custom condition
 type customCondition struct {
 	condition string
 	param     string
 	values    []string
+	category  string
+	ids       []string
+	negated   bool
 }
 
 func (c customCondition) getParam() string {
@@ -90,7 +183,235 @@ func (c customCondition) getParam() string {
 func (c customCondition) String() string {
 	var conditions []string
 	for _, v := range c.values {
-		conditions = append(conditions, strings.ReplaceAll(c.condition, "{VAL}", v))
+		conditions = append(conditions, strings.ReplaceAll(c.condition, "{VAL}", literal(v)))
+	}
+	result := fmt.Sprintf("(%s)", strings.Join(conditions, " OR "))
+	if c.negated {
+		result = "NOT " + result
+	}
+	return result
+}
+
+// jsonPath translates the condition which is written in Meilisearch syntax,
+// see meiliToJSONPath.
+func (c customCondition) jsonPath() string {
+	condition := meiliToJSONPath(c.condition)
+	var conditions []string
+	for _, v := range c.values {
+		// literals are written alike in both syntaxes
+		conditions = append(conditions, strings.ReplaceAll(condition, "{VAL}", literal(v)))
+	}
+	result := fmt.Sprintf("(%s)", strings.Join(conditions, " || "))
+	if c.negated {
+		result = "!" + result
+	}
+	return result
+}
+
+func (c customCondition) encode(values url.Values, group string) {
+	if c.category == "" {
+		return
+	}
+	key := urlKey(group, prefix, c.category)
+	if c.negated {
+		key = urlKey(group, notPrefix, c.category)
+	}
+	values[key] = append(values[key], c.ids...)
+}
+
+// numeric range condition, at least one of the bounds is set
+type rangeCondition struct {
+	param    string
+	category string
+	min      float64
+	max      float64
+	hasMin   bool
+	hasMax   bool
+}
+
+func (c rangeCondition) getParam() string {
+	return c.param
+}
+
+func (c rangeCondition) String() string {
+	switch {
+	case c.hasMin && c.hasMax:
+		return fmt.Sprintf("%s %s TO %s", c.param, formatNumber(c.min), formatNumber(c.max))
+	case c.hasMin:
+		return fmt.Sprintf("%s >= %s", c.param, formatNumber(c.min))
+	default:
+		return fmt.Sprintf("%s <= %s", c.param, formatNumber(c.max))
+	}
+}
+
+func (c rangeCondition) jsonPath() string {
+	var bounds []string
+	if c.hasMin {
+		bounds = append(bounds, "@ >= "+formatNumber(c.min))
+	}
+	if c.hasMax {
+		bounds = append(bounds, "@ <= "+formatNumber(c.max))
+	}
+	return fmt.Sprintf("exists(%s ? (%s))", jsonPathAttribute(c.param), strings.Join(bounds, " && "))
+}
+
+func (c rangeCondition) encode(values url.Values, group string) {
+	if c.hasMin {
+		values.Set(urlKey(group, minPrefix, c.category), formatNumber(c.min))
+	}
+	if c.hasMax {
+		values.Set(urlKey(group, maxPrefix, c.category), formatNumber(c.max))
+	}
+}
+
+// OR group of conditions
+type orGroup struct {
+	id         string
+	conditions []condition
+}
+
+func (g orGroup) getParam() string {
+	if len(g.conditions) == 0 {
+		return ""
+	}
+	param := g.conditions[0].getParam()
+	for _, c := range g.conditions[1:] {
+		if c.getParam() != param {
+			return ""
+		}
+	}
+	return param
+}
+
+func (g orGroup) String() string {
+	parts := make([]string, len(g.conditions))
+	for i, c := range g.conditions {
+		parts[i] = c.String()
+	}
+	return fmt.Sprintf("(%s)", strings.Join(parts, " OR "))
+}
+
+func (g orGroup) jsonPath() string {
+	parts := make([]string, len(g.conditions))
+	for i, c := range g.conditions {
+		parts[i] = c.jsonPath()
+	}
+	return fmt.Sprintf("(%s)", strings.Join(parts, " || "))
+}
+
+func (g orGroup) encode(values url.Values, group string) {
+	// nested groups are flattened as OR is associative
+	if group == "" {
+		group = g.id
+	}
+	for _, c := range g.conditions {
+		c.encode(values, group)
+	}
+}
+
+//================================================================================
+// Helper Functions
+//================================================================================
+
+func urlKey(group, keyPrefix, category string) string {
+	if group == "" {
+		return keyPrefix + category
+	}
+	return groupPrefix + group + groupSeparator + keyPrefix + category
+}
+
+// Quote returns s as a Meilisearch filter string literal. It is used also for
+// SQL/JSON path string literals which are escaped the same way.
+func Quote(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	return `"` + s + `"`
+}
+
+// literal returns numbers as they are so that they can be compared and
+// everything else quoted.
+func literal(s string) string {
+	if isNumber(s) {
+		return s
+	}
+	return Quote(s)
+}
+
+// isNumber reports whether s is a plain decimal number. Unlike
+// strconv.ParseFloat it rejects "Inf", "NaN", exponents and hexadecimal
+// notation.
+func isNumber(s string) bool {
+	if strings.Trim(s, "-.0123456789") != "" {
+		return false
+	}
+	_, err := strconv.ParseFloat(s, 64)
+	return err == nil
+}
+
+func formatNumber(n float64) string {
+	return strconv.FormatFloat(n, 'f', -1, 64)
+}
+
+// jsonPathAttribute returns path of the attribute (possibly nested, e.g.
+// teacher.id) relative to the filtered document. Arrays are unwrapped so
+// that the path yields their elements, which is how Meilisearch treats
+// arrays.
+func jsonPathAttribute(attribute string) string {
+	var sb strings.Builder
+	sb.WriteString("@")
+	for _, key := range strings.Split(attribute, ".") {
+		sb.WriteString(".")
+		sb.WriteString(Quote(key))
+	}
+	sb.WriteString("[*]")
+	return sb.String()
+}
+
+// jsonPathAnyOf returns predicate matching any of the values. Facet values
+// are strings, so numbers also match numeric attributes as in Meilisearch.
+func jsonPathAnyOf(values []string) string {
+	var predicates []string
+	for _, v := range values {
+		if isNumber(v) {
+			predicates = append(predicates, "@ == "+v)
+		}
+		predicates = append(predicates, "@ == "+Quote(v))
+	}
+	if len(predicates) == 0 {
+		// there is no false literal in SQL/JSON path
+		return "1 == 0"
+	}
+	return strings.Join(predicates, " || ")
+}
+
+// meiliToJSONPath translates a simple Meilisearch filter (comparisons joined
+// by AND, OR and NOT, e.g. validity.from <= {VAL} AND validity.to >= {VAL})
+// into SQL/JSON path predicate. Tokens which are neither operators nor
+// values are taken as attributes.
+func meiliToJSONPath(filter string) string {
+	operators := map[string]string{
+		"AND": "&&",
+		"OR":  "||",
+		"NOT": "!",
+		"=":   "==",
+		"!=":  "!=",
+		"<":   "<",
+		"<=":  "<=",
+		">":   ">",
+		">=":  ">=",
+		"(":   "(",
+		")":   ")",
+	}
+	tokens := strings.Fields(filter)
+	for i, token := range tokens {
+		switch {
+		case operators[token] != "":
+			tokens[i] = operators[token]
+		case token == "{VAL}", isNumber(token), strings.HasPrefix(token, `"`):
+			// values are kept as they are
+		default:
+			tokens[i] = strings.TrimSuffix(jsonPathAttribute(token), "[*]")
+		}
 	}
-	return fmt.Sprintf("(%s)", strings.Join(conditions, " OR "))
+	return strings.Join(tokens, " ")
 }
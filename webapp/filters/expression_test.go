@@ -0,0 +1,253 @@
+package filters
+
+import (
+	"database/sql"
+	"net/url"
+	"testing"
+
+	"github.com/michalhercik/RecSIS/language"
+)
+
+func testFilters() filters {
+	cb := categoryBuilder{}
+	cb.category(makeFilterIdentity("1", "credits", language.LangString{}, language.LangString{}), sql.NullString{}, 5)
+	cb.value(makeFilterIdentity("10", "4", language.LangString{}, language.LangString{}))
+	cb.value(makeFilterIdentity("11", "5", language.LangString{}, language.LangString{}))
+	cb.value(makeFilterIdentity("12", "6", language.LangString{}, language.LangString{}))
+	cb.category(makeFilterIdentity("2", "taught_lang", language.LangString{}, language.LangString{}), sql.NullString{}, 5)
+	cb.value(makeFilterIdentity("20", "CZE", language.LangString{}, language.LangString{}))
+	cb.value(makeFilterIdentity("21", "ENG", language.LangString{}, language.LangString{}))
+	cb.category(makeFilterIdentity("3", "department", language.LangString{}, language.LangString{}), sql.NullString{}, 5)
+	cb.value(makeFilterIdentity("30", `32-"KSVI"\`, language.LangString{}, language.LangString{}))
+	cb.category(makeFilterIdentity("4", "guarantors", language.LangString{}, language.LangString{}), sql.NullString{}, 5)
+	cb.value(makeFilterIdentity("40", "Mareš", language.LangString{}, language.LangString{}))
+	cb.category(makeFilterIdentity("5", "validity", language.LangString{}, language.LangString{}), sql.NullString{String: "validity.from <= {VAL} AND validity.to >= {VAL}", Valid: true}, 5)
+	cb.value(makeFilterIdentity("50", "2024", language.LangString{}, language.LangString{}))
+	categories := cb.build()
+	return filters{
+		categories:   categories,
+		idToCategory: initIDToCategory(categories),
+		idToValue:    initIDToValue(categories),
+		facets:       initFacets(categories),
+	}
+}
+
+func TestConditionString(t *testing.T) {
+	tests := []struct {
+		name string
+		cond condition
+		want string
+	}{
+		{"in", inCondition{param: "taught_lang", values: []string{"CZE", "ENG"}}, `taught_lang IN ["CZE","ENG"]`},
+		{"not in", inCondition{param: "taught_lang", values: []string{"ENG"}, negated: true}, `taught_lang NOT IN ["ENG"]`},
+		{"escaped", inCondition{param: "department", values: []string{`a"b\c`}}, `department IN ["a\"b\\c"]`},
+		{"custom", customCondition{condition: "validity.from <= {VAL}", param: "validity", values: []string{"2023", "2024"}}, "(validity.from <= 2023 OR validity.from <= 2024)"},
+		{"custom quoted", customCondition{condition: "x = {VAL}", param: "x", values: []string{`1 OR y = "2"`}}, `(x = "1 OR y = \"2\"")`},
+		{"negated custom", customCondition{condition: "x = {VAL}", param: "x", values: []string{"1"}, negated: true}, "NOT (x = 1)"},
+		{"range", rangeCondition{param: "credits", min: 4, max: 6, hasMin: true, hasMax: true}, "credits 4 TO 6"},
+		{"range min", rangeCondition{param: "credits", min: 4.5, hasMin: true}, "credits >= 4.5"},
+		{"range max", rangeCondition{param: "credits", max: 6, hasMax: true}, "credits <= 6"},
+		{"or group", orGroup{conditions: []condition{
+			inCondition{param: "department", values: []string{"32-KSVI"}},
+			inCondition{param: "guarantors", values: []string{"Mareš"}},
+		}}, `(department IN ["32-KSVI"] OR guarantors IN ["Mareš"])`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.cond.String(); got != tt.want {
+				t.Errorf("String() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConditionJSONPath(t *testing.T) {
+	tests := []struct {
+		name string
+		cond condition
+		want string
+	}{
+		{"in", inCondition{param: "taught_lang", values: []string{"CZE", "ENG"}}, `exists(@."taught_lang"[*] ? (@ == "CZE" || @ == "ENG"))`},
+		{"in number", inCondition{param: "credits", values: []string{"5"}}, `exists(@."credits"[*] ? (@ == 5 || @ == "5"))`},
+		{"not in", inCondition{param: "taught_lang", values: []string{"ENG"}, negated: true}, `!(exists(@."taught_lang"[*] ? (@ == "ENG")))`},
+		{"nested", inCondition{param: "teacher.id", values: []string{"x"}}, `exists(@."teacher"."id"[*] ? (@ == "x"))`},
+		{"escaped", inCondition{param: "department", values: []string{`a"b\c`}}, `exists(@."department"[*] ? (@ == "a\"b\\c"))`},
+		{"custom", customCondition{condition: "validity.from <= {VAL} AND validity.to >= {VAL}", param: "validity", values: []string{"2024"}}, `(@."validity"."from" <= 2024 && @."validity"."to" >= 2024)`},
+		{"negated custom", customCondition{condition: "x = {VAL}", param: "x", values: []string{"1", "a"}, negated: true}, `!(@."x" == 1 || @."x" == "a")`},
+		{"range", rangeCondition{param: "credits", min: 4, max: 6, hasMin: true, hasMax: true}, `exists(@."credits"[*] ? (@ >= 4 && @ <= 6))`},
+		{"range max", rangeCondition{param: "credits", max: 6.5, hasMax: true}, `exists(@."credits"[*] ? (@ <= 6.5))`},
+		{"or group", orGroup{conditions: []condition{
+			inCondition{param: "department", values: []string{"32-KSVI"}},
+			rangeCondition{param: "credits", min: 4, hasMin: true},
+		}}, `(exists(@."department"[*] ? (@ == "32-KSVI")) || exists(@."credits"[*] ? (@ >= 4)))`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.cond.jsonPath(); got != tt.want {
+				t.Errorf("jsonPath() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExpressionJSONPath(t *testing.T) {
+	var e expression
+	if got := e.JSONPath(); got != "" {
+		t.Errorf("JSONPath() of empty expression = %s, want empty", got)
+	}
+	e = expression{
+		inCondition{param: "credits", values: []string{"5"}},
+		inCondition{param: "taught_lang", values: []string{"ENG"}, negated: true},
+	}
+	want := `$ ? (exists(@."credits"[*] ? (@ == 5 || @ == "5")) && !(exists(@."taught_lang"[*] ? (@ == "ENG"))))`
+	if got := e.JSONPath(); got != want {
+		t.Errorf("JSONPath() = %s, want %s", got, want)
+	}
+	got := map[string]string{}
+	for param, filter := range e.ExceptJSONPath() {
+		got[param] = filter
+	}
+	if got["credits"] != `$ ? (!(exists(@."taught_lang"[*] ? (@ == "ENG"))))` || got["taught_lang"] != `$ ? (exists(@."credits"[*] ? (@ == 5 || @ == "5")))` {
+		t.Errorf("ExceptJSONPath() = %v", got)
+	}
+}
+
+func TestExpressionExcept(t *testing.T) {
+	e := expression{
+		inCondition{param: "credits", values: []string{"5"}},
+		rangeCondition{param: "credits", min: 4, hasMin: true},
+		inCondition{param: "taught_lang", values: []string{"ENG"}, negated: true},
+		orGroup{conditions: []condition{
+			inCondition{param: "department", values: []string{"A"}},
+			inCondition{param: "guarantors", values: []string{"X"}},
+		}},
+	}
+	want := map[string]string{
+		"credits":     `taught_lang NOT IN ["ENG"] AND (department IN ["A"] OR guarantors IN ["X"])`,
+		"taught_lang": `credits IN ["5"] AND credits >= 4 AND (department IN ["A"] OR guarantors IN ["X"])`,
+	}
+	got := map[string]string{}
+	for param, filter := range e.Except() {
+		got[param] = filter
+	}
+	if len(got) != len(want) {
+		t.Fatalf("Except() yielded %v, want %v", got, want)
+	}
+	for param, filter := range want {
+		if got[param] != filter {
+			t.Errorf("Except() for %s = %s, want %s", param, got[param], filter)
+		}
+	}
+}
+
+func TestExpressionExceptSingleCondition(t *testing.T) {
+	e := expression{inCondition{param: "credits", values: []string{"5"}}}
+	for param, filter := range e.Except() {
+		if param != "credits" || filter != "" {
+			t.Errorf("Except() = (%s, %s), want (credits, )", param, filter)
+		}
+	}
+}
+
+func TestParseURLQuery(t *testing.T) {
+	f := testFilters()
+	tests := []struct {
+		name  string
+		query string
+		want  string
+	}{
+		{"empty", "", ""},
+		{"other params", "search=lorem&page=2&order=asc", ""},
+		{"in", "par2=20&par2=21", `taught_lang IN ["CZE","ENG"]`},
+		{"not", "not2=21", `taught_lang NOT IN ["ENG"]`},
+		{"range", "min1=4&max1=6", "credits 4 TO 6"},
+		{"range min only", "min1=4&max1=", "credits >= 4"},
+		{"empty range", "min1=&max1=", ""},
+		{"escaped value", "par3=30", `department IN ["32-\"KSVI\"\\"]`},
+		{"custom", "not5=50", "NOT (validity.from <= 2024 AND validity.to >= 2024)"},
+		{"or group", "or1.par3=30&or1.par4=40&par1=11", `credits IN ["5"] AND (department IN ["32-\"KSVI\"\\"] OR guarantors IN ["Mareš"])`},
+		{"or group with range", "or1.min1=5&or1.not2=21", `(credits >= 5 OR taught_lang NOT IN ["ENG"])`},
+		{"two groups", "or1.par2=20&or1.par4=40&or2.max1=4&or2.par3=30", `(taught_lang IN ["CZE"] OR guarantors IN ["Mareš"]) AND (credits <= 4 OR department IN ["32-\"KSVI\"\\"])`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, err := url.ParseQuery(tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			e, err := f.ParseURLQuery(query, language.EN)
+			if err != nil {
+				t.Fatalf("ParseURLQuery(%s) error: %v", tt.query, err)
+			}
+			if got := e.String(); got != tt.want {
+				t.Errorf("ParseURLQuery(%s) = %s, want %s", tt.query, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseURLQueryErrors(t *testing.T) {
+	f := testFilters()
+	tests := []struct {
+		name  string
+		query string
+	}{
+		{"unknown category", "par9=10"},
+		{"unknown value", "par2=lorem"},
+		{"range of non-numeric category", "min2=1"},
+		{"non-numeric bound", "min1=lorem"},
+		{"infinite bound", "max1=Inf"},
+		{"min greater than max", "min1=6&max1=4"},
+		{"empty group", "or.par2=20"},
+		{"group without filter", "or1.lorem=20"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, err := url.ParseQuery(tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if e, err := f.ParseURLQuery(query, language.EN); err == nil {
+				t.Errorf("ParseURLQuery(%s) = %s, want error", tt.query, e.String())
+			}
+		})
+	}
+}
+
+func TestURLValuesRoundTrip(t *testing.T) {
+	f := testFilters()
+	queries := []string{
+		"par2=20&par2=21",
+		"not2=21",
+		"max1=6&min1=4.5",
+		"not5=50",
+		"or1.par3=30&or1.par4=40&par1=11",
+		"or1.min1=5&or1.not2=21&or2.par2=20&or2.par4=40",
+	}
+	for _, q := range queries {
+		t.Run(q, func(t *testing.T) {
+			query, err := url.ParseQuery(q)
+			if err != nil {
+				t.Fatal(err)
+			}
+			e, err := f.ParseURLQuery(query, language.EN)
+			if err != nil {
+				t.Fatalf("ParseURLQuery(%s) error: %v", q, err)
+			}
+			if got := e.URLValues().Encode(); got != query.Encode() {
+				t.Errorf("URLValues() = %s, want %s", got, query.Encode())
+			}
+		})
+	}
+}
+
+func TestAppendIsNotEncoded(t *testing.T) {
+	var e expression
+	e.Append("code", "NPRG030")
+	if got := e.String(); got != `code IN ["NPRG030"]` {
+		t.Errorf("String() = %s", got)
+	}
+	if got := e.URLValues().Encode(); got != "" {
+		t.Errorf("URLValues() = %s, want empty", got)
+	}
+}
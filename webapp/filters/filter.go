@@ -11,8 +11,11 @@ Typical usage involves creating a Filters instance using MakeFilters and injecti
 import (
 	"database/sql"
 	"fmt"
+	"maps"
 	"net/http"
 	"net/url"
+	"slices"
+	"strconv"
 	"strings"
 
 	"github.com/jmoiron/sqlx"
@@ -21,8 +24,17 @@ import (
 	"github.com/michalhercik/RecSIS/language"
 )
 
+// URL query keys of filters are a prefix followed by category ID, e.g.
+// par12=3&par12=4 (value 3 or 4), not12=5 (not value 5), min7=4&max7=6
+// (numeric range). Keys prefixed with group ID, e.g. or1.par12=3&or1.par15=8,
+// form an OR group.
 const (
-	prefix = "par"
+	prefix         = "par"
+	notPrefix      = "not"
+	minPrefix      = "min"
+	maxPrefix      = "max"
+	groupPrefix    = "or"
+	groupSeparator = "."
 )
 
 type Filters struct {
@@ -83,37 +95,141 @@ func (f filters) FiltersMapWithFacets(facets Facets, query url.Values, lang lang
 
 func (f filters) ParseURLQuery(query url.Values, lang language.Language) (expression, error) {
 	var result expression
-	conditions := make([]condition, 0, len(query))
-	for k, v := range query {
-		if strings.HasPrefix(k, prefix) {
-			cond, err := f.parseParams(k, v, lang)
-			if err != nil {
-				return nil, errorx.AddContext(err)
+	var groups []*orGroup
+	groupByID := make(map[string]*orGroup)
+	ranges := make(map[string]*rangeCondition)
+	// sorted for a stable filter string
+	for _, k := range slices.Sorted(maps.Keys(query)) {
+		v := query[k]
+		group, key, err := splitGroup(k, lang)
+		if err != nil {
+			return nil, errorx.AddContext(err)
+		}
+		var cond condition
+		switch {
+		case !f.isFilterKey(key):
+			if group != "" {
+				return nil, errorx.AddContext(invalidGroupErr(k, lang))
+			}
+			continue
+		case strings.HasPrefix(key, prefix):
+			cond, err = f.parseParams(key[len(prefix):], v, false, lang)
+		case strings.HasPrefix(key, notPrefix):
+			cond, err = f.parseParams(key[len(notPrefix):], v, true, lang)
+		case strings.HasPrefix(key, minPrefix), strings.HasPrefix(key, maxPrefix):
+			rangeKey := group + groupSeparator + key[len(minPrefix):]
+			r, found := ranges[rangeKey]
+			if !found {
+				r = &rangeCondition{}
 			}
-			conditions = append(conditions, cond)
+			err = f.parseRange(r, key, v, lang)
+			if found || err != nil || (!r.hasMin && !r.hasMax) {
+				// bounds are merged into already added condition or empty
+				break
+			}
+			ranges[rangeKey] = r
+			cond = r
+		}
+		if err != nil {
+			return nil, errorx.AddContext(err)
+		}
+		if cond == nil {
+			continue
+		}
+		if group == "" {
+			result = append(result, cond)
+			continue
+		}
+		g, ok := groupByID[group]
+		if !ok {
+			g = &orGroup{id: group}
+			groupByID[group] = g
+			groups = append(groups, g)
 		}
+		g.conditions = append(g.conditions, cond)
+	}
+	for _, r := range ranges {
+		if r.hasMin && r.hasMax && r.min > r.max {
+			return nil, errorx.NewHTTPErr(
+				errorx.AddContext(
+					fmt.Errorf("invalid range %s: %v > %v", r.category, r.min, r.max),
+					errorx.P("categoryID", r.category),
+				),
+				http.StatusBadRequest,
+				texts[lang].errInvalidRange,
+			)
+		}
+	}
+	for _, g := range groups {
+		result = append(result, g)
 	}
-	result = expression(conditions)
 	return result, nil
 }
 
-func (f filters) parseParams(k string, v []string, lang language.Language) (condition, error) {
-	t := texts[lang]
-	var result condition
-	categoryID := k[len(prefix):]
+// isFilterKey reports whether the URL query key (without group) is a filter.
+// Keys with prefix par are always filters for backward compatibility, other
+// prefixes only with existing category so that they do not clash with other
+// params of the page (e.g. maxYear).
+func (f filters) isFilterKey(key string) bool {
+	if strings.HasPrefix(key, prefix) {
+		return true
+	}
+	for _, p := range []string{notPrefix, minPrefix, maxPrefix} {
+		if rest, ok := strings.CutPrefix(key, p); ok {
+			_, exists := f.idToCategory[rest]
+			return exists
+		}
+	}
+	return false
+}
+
+// splitGroup splits URL query key into ID of OR group (empty if the key does
+// not belong to any group) and the rest of the key.
+func splitGroup(k string, lang language.Language) (string, string, error) {
+	if !strings.HasPrefix(k, groupPrefix) {
+		return "", k, nil
+	}
+	group, key, ok := strings.Cut(k[len(groupPrefix):], groupSeparator)
+	if !ok {
+		// e.g. order, not a filter
+		return "", k, nil
+	}
+	if group == "" || key == "" {
+		return "", "", invalidGroupErr(k, lang)
+	}
+	return group, key, nil
+}
+
+func invalidGroupErr(k string, lang language.Language) error {
+	return errorx.NewHTTPErr(
+		errorx.AddContext(fmt.Errorf("invalid filter group key %s", k), errorx.P("k", k)),
+		http.StatusBadRequest,
+		texts[lang].errInvalidGroup,
+	)
+}
+
+func (f filters) category(categoryID string, lang language.Language) (category, error) {
 	category, ok := f.idToCategory[categoryID]
 	if !ok {
-		return result, errorx.NewHTTPErr(
+		return category, errorx.NewHTTPErr(
 			errorx.AddContext(
 				fmt.Errorf("category %s not found", categoryID),
-				errorx.P("k", k),
-				errorx.P("v", strings.Join(v, ",")),
 				errorx.P("categoryID", categoryID),
 			),
 			http.StatusBadRequest,
-			t.errCategoryNotFound,
+			texts[lang].errCategoryNotFound,
 		)
 	}
+	return category, nil
+}
+
+func (f filters) parseParams(categoryID string, v []string, negated bool, lang language.Language) (condition, error) {
+	t := texts[lang]
+	var result condition
+	category, err := f.category(categoryID, lang)
+	if err != nil {
+		return result, errorx.AddContext(err, errorx.P("v", strings.Join(v, ",")))
+	}
 	param := category.facetID
 	values := make([]string, len(v))
 	for i, value := range v {
@@ -122,7 +238,6 @@ func (f filters) parseParams(k string, v []string, lang language.Language) (cond
 			return result, errorx.NewHTTPErr(
 				errorx.AddContext(
 					fmt.Errorf("value %s not found in category %s", value, categoryID),
-					errorx.P("k", k),
 					errorx.P("v", strings.Join(v, ",")),
 					errorx.P("categoryID", categoryID),
 					errorx.P("value", value),
@@ -139,17 +254,64 @@ func (f filters) parseParams(k string, v []string, lang language.Language) (cond
 			condition: category.condition.String,
 			param:     param,
 			values:    values,
+			category:  categoryID,
+			ids:       v,
+			negated:   negated,
 		}
 		return result, nil
 	} else {
 		result = &inCondition{
-			param:  param,
-			values: values,
+			param:    param,
+			values:   values,
+			category: categoryID,
+			ids:      v,
+			negated:  negated,
 		}
 	}
 	return result, nil
 }
 
+// parseRange sets lower or upper bound of r from min or max URL query key.
+// Empty values are ignored so that forms can submit empty inputs. Only
+// categories with numeric values can be filtered by range.
+func (f filters) parseRange(r *rangeCondition, key string, v []string, lang language.Language) error {
+	t := texts[lang]
+	categoryID := key[len(minPrefix):]
+	category, err := f.category(categoryID, lang)
+	if err != nil {
+		return errorx.AddContext(err)
+	}
+	if !category.numeric() {
+		return errorx.NewHTTPErr(
+			errorx.AddContext(fmt.Errorf("category %s is not numeric", categoryID), errorx.P("categoryID", categoryID)),
+			http.StatusBadRequest,
+			t.errRangeNotSupported,
+		)
+	}
+	r.param = category.facetID
+	r.category = categoryID
+	if len(v) == 0 || v[0] == "" {
+		return nil
+	}
+	bound, err := strconv.ParseFloat(v[0], 64)
+	if err != nil || len(v) > 1 || !isNumber(v[0]) {
+		return errorx.NewHTTPErr(
+			errorx.AddContext(
+				fmt.Errorf("invalid range bound %s=%s", key, strings.Join(v, ",")),
+				errorx.P("categoryID", categoryID),
+			),
+			http.StatusBadRequest,
+			t.errInvalidRange,
+		)
+	}
+	if strings.HasPrefix(key, minPrefix) {
+		r.min, r.hasMin = bound, true
+	} else {
+		r.max, r.hasMax = bound, true
+	}
+	return nil
+}
+
 func fromRecords(rec []record) []category {
 	fb := categoryBuilder{}
 	for _, row := range rec {
@@ -223,6 +385,20 @@ type category struct {
 	values              []value
 }
 
+// numeric reports whether all values of the category are numbers, so that the
+// category can be filtered by range.
+func (c category) numeric() bool {
+	if len(c.values) == 0 {
+		return false
+	}
+	for _, v := range c.values {
+		if !isNumber(v.facetID) {
+			return false
+		}
+	}
+	return true
+}
+
 type categoryBuilder struct {
 	categories []category
 }
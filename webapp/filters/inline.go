@@ -0,0 +1,344 @@
+package filters
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/michalhercik/RecSIS/errorx"
+	"github.com/michalhercik/RecSIS/language"
+)
+
+//================================================================================
+// Constants
+//================================================================================
+
+const (
+	rangeSeparator = ".."
+	valueSeparator = ","
+	// maxListedValues limits number of values listed in the message about
+	// unknown qualifier value.
+	maxListedValues = 10
+)
+
+// qualifierPattern matches e.g. credits>=5, sem:winter, -lang:en or
+// teacher:"Jan Novák".
+var qualifierPattern = regexp.MustCompile(`^(-?)([\p{L}_][\p{L}\d_.]*)(:|>=|<=|=|>|<)(.+)$`)
+
+//================================================================================
+// Data Types and Methods
+//================================================================================
+
+// InlineQuery is a search query split into free text and filters written
+// inline as qualifiers, e.g. "credits>=5 sem:winter lang:en graphs".
+type InlineQuery struct {
+	// Text is the rest of the query which should be searched as text.
+	Text string
+	// Values are URL query parameters of the qualifiers accepted by
+	// ParseURLQuery.
+	Values url.Values
+	// Hint lists unknown qualifiers and values which were searched as text
+	// together with known qualifiers. It is empty if all qualifiers are known.
+	Hint string
+}
+
+// Merge adds the qualifiers to URL query values. Already present values are
+// not duplicated.
+func (q InlineQuery) Merge(values url.Values) url.Values {
+	result := url.Values{}
+	for k, v := range values {
+		result[k] = slices.Clone(v)
+	}
+	for k, v := range q.Values {
+		for _, value := range v {
+			if !slices.Contains(result[k], value) {
+				result.Add(k, value)
+			}
+		}
+	}
+	return result
+}
+
+// queryFilter is the filter expression returned by InlineParser. It is an
+// alias of the interface literal so that InlineParser satisfies interfaces
+// of packages using it (e.g. page.QueryParser) which declare the same one.
+type queryFilter = interface {
+	String() string
+	JSONPath() string
+}
+
+// InlineParser parses inline qualifiers of search queries.
+type InlineParser struct {
+	Filters Filters
+	// Aliases map qualifier names to facet IDs of filter categories, e.g.
+	// "lang" to "taught_lang". Qualifiers mapped to empty string are searched
+	// as text, e.g. "teacher" as teacher names are searchable but not
+	// filterable.
+	Aliases map[string]string
+}
+
+// ParseQuery splits the query into text and filter expression. The hint is
+// the Hint of InlineQuery.
+func (p InlineParser) ParseQuery(query string, lang language.Language) (string, queryFilter, string, error) {
+	inline, err := p.Filters.ParseInlineQuery(query, p.Aliases, lang)
+	if err != nil {
+		return "", nil, "", errorx.AddContext(err)
+	}
+	filter, err := p.Filters.ParseURLQuery(inline.Values, lang)
+	if err != nil {
+		return "", nil, "", errorx.AddContext(err)
+	}
+	return inline.Text, filter, inline.Hint, nil
+}
+
+// ParseInlineQuery pulls qualifiers out of the query. A qualifier is a name
+// of filter category (facet ID, title or alias) followed by an operator and
+// a value:
+//
+//	lang:en         value by its ID or title (or their prefix)
+//	lang:en,cs      any of the values
+//	-lang:en        anything but the value
+//	credits>=5      lower bound (<= for upper bound)
+//	credits:4..6    range
+//
+// Values with spaces can be quoted, e.g. dept:"Department of Software
+// Engineering". Unknown qualifiers are kept in the text, as they are often
+// not meant as qualifiers (e.g. http://… or Python:3), and listed in Hint.
+// The same holds for unknown values, for which Hint lists known values.
+func (f filters) ParseInlineQuery(query string, aliases map[string]string, lang language.Language) (InlineQuery, error) {
+	result := InlineQuery{Values: url.Values{}}
+	var text, unknown, hints []string
+	for _, token := range splitQuery(query) {
+		match := qualifierPattern.FindStringSubmatch(token)
+		if match == nil {
+			text = append(text, token)
+			continue
+		}
+		negated, name, operator, value := match[1] == "-", strings.ToLower(match[2]), match[3], unquote(match[4])
+		facetID, isAlias := aliases[name]
+		if isAlias && facetID == "" {
+			text = append(text, value)
+			continue
+		}
+		if !isAlias {
+			facetID = name
+		}
+		category, ok := f.categoryByName(facetID)
+		if !ok {
+			text = append(text, token)
+			unknown = append(unknown, match[2])
+			continue
+		}
+		err := f.addQualifier(result.Values, category, negated, operator, value, lang)
+		var unknownValue unknownValueError
+		if errors.As(err, &unknownValue) {
+			text = append(text, token)
+			hints = append(hints, unknownValue.hint(lang))
+			continue
+		}
+		if err != nil {
+			return result, errorx.AddContext(err, errorx.P("qualifier", token))
+		}
+	}
+	result.Text = strings.Join(text, " ")
+	if len(unknown) > 0 {
+		hints = append([]string{f.unknownQualifierHint(unknown, aliases, lang)}, hints...)
+	}
+	result.Hint = strings.Join(hints, ". ")
+	return result, nil
+}
+
+func (f filters) addQualifier(values url.Values, c category, negated bool, operator, value string, lang language.Language) error {
+	t := texts[lang]
+	switch operator {
+	case ">", "<":
+		return errorx.NewHTTPErr(
+			errorx.AddContext(fmt.Errorf("unsupported operator %s", operator)),
+			http.StatusBadRequest,
+			fmt.Sprintf(t.errInvalidQualifierOperator, operator),
+		)
+	case ">=", "<=":
+		if negated {
+			return errorx.NewHTTPErr(
+				errorx.AddContext(fmt.Errorf("negated range")),
+				http.StatusBadRequest,
+				fmt.Sprintf(t.errNegatedQualifierRange, c.title.String(lang)),
+			)
+		}
+		key := minPrefix + c.id
+		if operator == "<=" {
+			key = maxPrefix + c.id
+		}
+		return f.addRangeBound(values, c, key, value, lang)
+	}
+	if from, to, ok := strings.Cut(value, rangeSeparator); ok {
+		if negated {
+			return errorx.NewHTTPErr(
+				errorx.AddContext(fmt.Errorf("negated range")),
+				http.StatusBadRequest,
+				fmt.Sprintf(t.errNegatedQualifierRange, c.title.String(lang)),
+			)
+		}
+		if err := f.addRangeBound(values, c, minPrefix+c.id, from, lang); err != nil {
+			return err
+		}
+		return f.addRangeBound(values, c, maxPrefix+c.id, to, lang)
+	}
+	key := prefix + c.id
+	if negated {
+		key = notPrefix + c.id
+	}
+	var matched []string
+	for _, v := range strings.Split(value, valueSeparator) {
+		ids := c.matchValues(strings.TrimSpace(v))
+		if len(ids) == 0 {
+			return unknownValueError{value: v, category: c}
+		}
+		matched = append(matched, ids...)
+	}
+	for _, id := range matched {
+		if !slices.Contains(values[key], id) {
+			values.Add(key, id)
+		}
+	}
+	return nil
+}
+
+// unknownValueError is returned by addQualifier if a value is not found in
+// the category. The whole qualifier is then searched as text.
+type unknownValueError struct {
+	value    string
+	category category
+}
+
+func (e unknownValueError) Error() string {
+	return fmt.Sprintf("value %s not found in category %s", e.value, e.category.id)
+}
+
+func (e unknownValueError) hint(lang language.Language) string {
+	return fmt.Sprintf(texts[lang].unknownQualifierValue, e.value, e.category.title.String(lang), e.category.valuesList(lang))
+}
+
+// addRangeBound validates the bound the same way as ParseURLQuery would so
+// that the user gets the error together with the qualifier.
+func (f filters) addRangeBound(values url.Values, c category, key, bound string, lang language.Language) error {
+	var r rangeCondition
+	if err := f.parseRange(&r, key, []string{bound}, lang); err != nil {
+		return errorx.AddContext(err)
+	}
+	if bound != "" {
+		values.Set(key, bound)
+	}
+	return nil
+}
+
+// categoryByName finds category by its facet ID or title in any language,
+// case insensitive.
+func (f filters) categoryByName(name string) (category, bool) {
+	for _, c := range f.categories {
+		if strings.EqualFold(c.facetID, name) {
+			return c, true
+		}
+	}
+	for _, c := range f.categories {
+		if strings.EqualFold(c.title.CS, name) || strings.EqualFold(c.title.EN, name) {
+			return c, true
+		}
+	}
+	return category{}, false
+}
+
+func (f filters) unknownQualifierHint(names []string, aliases map[string]string, lang language.Language) string {
+	known := make([]string, 0, len(f.categories)+len(aliases))
+	for alias := range aliases {
+		known = append(known, alias)
+	}
+	for _, c := range f.categories {
+		known = append(known, c.facetID)
+	}
+	slices.Sort(known)
+	return fmt.Sprintf(texts[lang].unknownQualifiers, strings.Join(names, ", "), strings.Join(slices.Compact(known), ", "))
+}
+
+// matchValues returns IDs of values whose facet ID or title equals v. If
+// there is none, values whose facet ID or title starts with v are returned.
+func (c category) matchValues(v string) []string {
+	if v == "" {
+		return nil
+	}
+	var exact, prefixed []string
+	for _, value := range c.values {
+		candidates := []string{value.facetID, value.title.CS, value.title.EN}
+		for _, candidate := range candidates {
+			if strings.EqualFold(candidate, v) {
+				exact = append(exact, value.id)
+				break
+			}
+			if hasPrefixFold(candidate, v) {
+				prefixed = append(prefixed, value.id)
+				break
+			}
+		}
+	}
+	if len(exact) > 0 {
+		return exact
+	}
+	return prefixed
+}
+
+func (c category) valuesList(lang language.Language) string {
+	titles := make([]string, 0, min(len(c.values), maxListedValues))
+	for i, v := range c.values {
+		if i == maxListedValues {
+			titles = append(titles, "…")
+			break
+		}
+		titles = append(titles, v.title.String(lang))
+	}
+	return strings.Join(titles, ", ")
+}
+
+//================================================================================
+// Helper Functions
+//================================================================================
+
+// splitQuery splits the query by white space outside of double quotes.
+func splitQuery(query string) []string {
+	var result []string
+	var sb strings.Builder
+	quoted := false
+	for _, r := range query {
+		switch {
+		case r == '"':
+			quoted = !quoted
+			sb.WriteRune(r)
+		case unicode.IsSpace(r) && !quoted:
+			if sb.Len() > 0 {
+				result = append(result, sb.String())
+				sb.Reset()
+			}
+		default:
+			sb.WriteRune(r)
+		}
+	}
+	if sb.Len() > 0 {
+		result = append(result, sb.String())
+	}
+	return result
+}
+
+func unquote(s string) string {
+	if len(s) >= 2 && strings.HasPrefix(s, `"`) && strings.HasSuffix(s, `"`) {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
+
+func hasPrefixFold(s, prefix string) bool {
+	return len(s) >= len(prefix) && strings.EqualFold(s[:len(prefix)], prefix)
+}
@@ -0,0 +1,131 @@
+package filters
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/michalhercik/RecSIS/language"
+)
+
+var testAliases = map[string]string{
+	"lang":    "taught_lang",
+	"dept":    "department",
+	"teacher": "",
+}
+
+func TestSplitQuery(t *testing.T) {
+	got := splitQuery(`  graphs  teacher:"Jan Novák"	lang:en `)
+	want := []string{"graphs", `teacher:"Jan Novák"`, "lang:en"}
+	if !slices.Equal(got, want) {
+		t.Errorf("splitQuery() = %q, want %q", got, want)
+	}
+}
+
+func TestParseInlineQuery(t *testing.T) {
+	f := testFilters()
+	tests := []struct {
+		name     string
+		query    string
+		wantText string
+		want     string
+	}{
+		{"text only", "graphs and networks", "graphs and networks", ""},
+		{"value", "lang:ENG graphs", "graphs", `taught_lang IN ["ENG"]`},
+		{"case insensitive prefix", "LANG:en", "", `taught_lang IN ["ENG"]`},
+		{"facet ID", "taught_lang=cze", "", `taught_lang IN ["CZE"]`},
+		{"several values", "lang:cze,eng", "", `taught_lang IN ["CZE","ENG"]`},
+		{"negated", "-lang:eng", "", `taught_lang NOT IN ["ENG"]`},
+		{"min", "credits>=5", "", "credits >= 5"},
+		{"min and max", "credits>=4 credits<=6", "", "credits 4 TO 6"},
+		{"range", "credits:4..6", "", "credits 4 TO 6"},
+		{"exact number", "credits:5", "", `credits IN ["5"]`},
+		{"text qualifier", `teacher:"Jan Novák" graphs`, "Jan Novák graphs", ""},
+		{"quoted value", `guarantors:"Mareš"`, "", `guarantors IN ["Mareš"]`},
+		{"everything", "credits>=5 teacher:Mareš lang:en graphs", "Mareš graphs", `credits >= 5 AND taught_lang IN ["ENG"]`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inline, err := f.ParseInlineQuery(tt.query, testAliases, language.EN)
+			if err != nil {
+				t.Fatalf("ParseInlineQuery(%s) error: %v", tt.query, err)
+			}
+			if inline.Text != tt.wantText {
+				t.Errorf("ParseInlineQuery(%s).Text = %q, want %q", tt.query, inline.Text, tt.wantText)
+			}
+			e, err := f.ParseURLQuery(inline.Values, language.EN)
+			if err != nil {
+				t.Fatalf("ParseURLQuery(%s) error: %v", inline.Values.Encode(), err)
+			}
+			if got := e.String(); got != tt.want {
+				t.Errorf("ParseInlineQuery(%s) filter = %s, want %s", tt.query, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseInlineQueryErrors(t *testing.T) {
+	f := testFilters()
+	tests := []struct {
+		name  string
+		query string
+	}{
+		{"strict operator", "credits>5"},
+		{"range of non-numeric category", "lang>=5"},
+		{"non-numeric bound", "credits>=five"},
+		{"negated range", "-credits>=5"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if inline, err := f.ParseInlineQuery(tt.query, testAliases, language.EN); err == nil {
+				t.Errorf("ParseInlineQuery(%s) = %v, want error", tt.query, inline)
+			}
+		})
+	}
+}
+
+func TestParseInlineQueryUnknownQualifier(t *testing.T) {
+	f := testFilters()
+	inline, err := f.ParseInlineQuery("https://example.com Python:3 lang:en", testAliases, language.EN)
+	if err != nil {
+		t.Fatalf("ParseInlineQuery() error: %v", err)
+	}
+	if want := "https://example.com Python:3"; inline.Text != want {
+		t.Errorf("ParseInlineQuery().Text = %q, want %q", inline.Text, want)
+	}
+	if !strings.Contains(inline.Hint, "https, Python") {
+		t.Errorf("ParseInlineQuery().Hint = %q, want unknown qualifiers listed", inline.Hint)
+	}
+	if got := inline.Values.Encode(); got != "par2=21" {
+		t.Errorf("ParseInlineQuery().Values = %s", got)
+	}
+}
+
+func TestParseInlineQueryUnknownValue(t *testing.T) {
+	f := testFilters()
+	inline, err := f.ParseInlineQuery("lang:en,de graphs", testAliases, language.EN)
+	if err != nil {
+		t.Fatalf("ParseInlineQuery() error: %v", err)
+	}
+	if want := "lang:en,de graphs"; inline.Text != want {
+		t.Errorf("ParseInlineQuery().Text = %q, want %q", inline.Text, want)
+	}
+	if !strings.Contains(inline.Hint, `"de"`) {
+		t.Errorf("ParseInlineQuery().Hint = %q, want unknown value listed", inline.Hint)
+	}
+	if len(inline.Values) != 0 {
+		t.Errorf("ParseInlineQuery().Values = %s, want none", inline.Values.Encode())
+	}
+}
+
+func TestInlineQueryMerge(t *testing.T) {
+	f := testFilters()
+	inline, err := f.ParseInlineQuery("lang:cze,eng", testAliases, language.EN)
+	if err != nil {
+		t.Fatal(err)
+	}
+	values := inline.Merge(map[string][]string{"par2": {"20"}, "search": {"graphs"}})
+	if got := values.Encode(); got != "par2=20&par2=21&search=graphs" {
+		t.Errorf("Merge() = %s", got)
+	}
+}
@@ -5,17 +5,41 @@ import (
 )
 
 type text struct {
-	errCategoryNotFound string
-	errValueNotFound    string
+	errCategoryNotFound  string
+	errValueNotFound     string
+	errInvalidRange      string
+	errRangeNotSupported string
+	errInvalidGroup      string
+	// inline query qualifiers
+	unknownQualifiers           string
+	unknownQualifierValue       string
+	errInvalidQualifierOperator string
+	errNegatedQualifierRange    string
 }
 
 var texts = map[language.Language]text{
 	language.CS: {
-		errCategoryNotFound: "kategorie nenalezena",
-		errValueNotFound:    "hodnota nenalezena v kategorii",
+		errCategoryNotFound:  "kategorie nenalezena",
+		errValueNotFound:     "hodnota nenalezena v kategorii",
+		errInvalidRange:      "neplatný rozsah hodnot",
+		errRangeNotSupported: "kategorie nepodporuje filtrování rozsahem",
+		errInvalidGroup:      "neplatná skupina filtrů",
+		// inline query qualifiers
+		unknownQualifiers:           "Neznámé filtry byly hledány jako text: %s. Dostupné filtry: %s",
+		unknownQualifierValue:       "Hodnota \"%s\" ve filtru %s neexistuje a byla hledána jako text. Možné hodnoty: %s",
+		errInvalidQualifierOperator: "Operátor %s není podporován, použijte >= nebo <=",
+		errNegatedQualifierRange:    "Rozsah ve filtru %s nelze negovat",
 	},
 	language.EN: {
-		errCategoryNotFound: "Category not found",
-		errValueNotFound:    "Value not found in category",
+		errCategoryNotFound:  "Category not found",
+		errValueNotFound:     "Value not found in category",
+		errInvalidRange:      "Invalid range of values",
+		errRangeNotSupported: "Category does not support filtering by range",
+		errInvalidGroup:      "Invalid filter group",
+		// inline query qualifiers
+		unknownQualifiers:           "Unknown filters were searched as text: %s. Available filters: %s",
+		unknownQualifierValue:       "Value \"%s\" does not exist in filter %s and was searched as text. Possible values: %s",
+		errInvalidQualifierOperator: "Operator %s is not supported, use >= or <=",
+		errNegatedQualifierRange:    "Range in filter %s cannot be negated",
 	},
 }
@@ -0,0 +1,72 @@
+package fulltext
+
+import (
+	"errors"
+	"log"
+	"net"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/meilisearch/meilisearch-go"
+)
+
+// Backoff is how long Meilisearch is not used after it was found
+// unavailable.
+const Backoff = 30 * time.Second
+
+// Fallback is a pair of search backends of type E (usually an interface of
+// a page package): Primary using Meilisearch and Secondary using the
+// full-text search. Use Call to search with it. Copies of Fallback share the
+// state of Primary, so it can be passed by value.
+type Fallback[E any] struct {
+	Primary   E
+	Secondary E
+	// name of the search in log messages
+	name string
+	// Unix time in nanoseconds until which Primary is not used
+	skipUntil *atomic.Int64
+}
+
+// NewFallback returns Fallback of the search (e.g. "course search") with the
+// backends.
+func NewFallback[E any](name string, primary, secondary E) Fallback[E] {
+	return Fallback[E]{
+		Primary:   primary,
+		Secondary: secondary,
+		name:      name,
+		skipUntil: &atomic.Int64{},
+	}
+}
+
+// Call searches with Primary unless it failed during the last Backoff. If
+// Meilisearch is unavailable (see Unavailable), the search is repeated with
+// Secondary. Other errors, e.g. of an invalid request, are returned.
+func Call[E, T any](f Fallback[E], search func(E) (T, error)) (T, error) {
+	if f.skipUntil == nil || time.Now().UnixNano() >= f.skipUntil.Load() {
+		result, err := search(f.Primary)
+		if err == nil || !Unavailable(err) {
+			return result, err
+		}
+		log.Printf("WARNING: %s failed, falling back to full-text search for %s: %v", f.name, Backoff, err)
+		if f.skipUntil != nil {
+			f.skipUntil.Store(time.Now().Add(Backoff).UnixNano())
+		}
+	}
+	return search(f.Secondary)
+}
+
+// Unavailable reports whether err means that Meilisearch cannot be reached
+// (transport error or timeout) or failed with a server error.
+func Unavailable(err error) bool {
+	var meiliErr *meilisearch.Error
+	if errors.As(err, &meiliErr) {
+		switch meiliErr.ErrCode {
+		case meilisearch.MeilisearchCommunicationError, meilisearch.MeilisearchTimeoutError, meilisearch.MeilisearchMaxRetriesExceeded:
+			return true
+		}
+		return meiliErr.StatusCode >= http.StatusInternalServerError
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
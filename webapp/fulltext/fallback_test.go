@@ -0,0 +1,77 @@
+package fulltext
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"testing"
+
+	"github.com/meilisearch/meilisearch-go"
+	"github.com/michalhercik/RecSIS/errorx"
+)
+
+func TestUnavailable(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"communication", &meilisearch.Error{ErrCode: meilisearch.MeilisearchCommunicationError}, true},
+		{"timeout", &meilisearch.Error{ErrCode: meilisearch.MeilisearchTimeoutError}, true},
+		{"server error", &meilisearch.Error{ErrCode: meilisearch.MeilisearchApiError, StatusCode: http.StatusServiceUnavailable}, true},
+		{"bad request", &meilisearch.Error{ErrCode: meilisearch.MeilisearchApiError, StatusCode: http.StatusBadRequest}, false},
+		{"network", &net.OpError{Op: "dial", Err: errors.New("connection refused")}, true},
+		{"other", errors.New("invalid filter"), false},
+		{"wrapped", errorx.NewHTTPErr(
+			errorx.AddContext(fmt.Errorf("search: %w", &meilisearch.Error{ErrCode: meilisearch.MeilisearchCommunicationError})),
+			http.StatusInternalServerError,
+			"",
+		), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Unavailable(tt.err); got != tt.want {
+				t.Errorf("Unavailable(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+type testBackend struct {
+	calls *int
+	err   error
+}
+
+func (b testBackend) search() (string, error) {
+	*b.calls++
+	if b.err != nil {
+		return "", b.err
+	}
+	return "result", nil
+}
+
+func TestCall(t *testing.T) {
+	search := func(b testBackend) (string, error) { return b.search() }
+	var primaryCalls, secondaryCalls int
+	down := &meilisearch.Error{ErrCode: meilisearch.MeilisearchCommunicationError}
+	f := NewFallback("test search", testBackend{&primaryCalls, down}, testBackend{&secondaryCalls, nil})
+	for range 2 {
+		if result, err := Call(f, search); err != nil || result != "result" {
+			t.Fatalf("Call() = %q, %v, want result of secondary", result, err)
+		}
+	}
+	if primaryCalls != 1 || secondaryCalls != 2 {
+		t.Errorf("primary called %d times and secondary %d times, want 1 and 2", primaryCalls, secondaryCalls)
+	}
+
+	primaryCalls, secondaryCalls = 0, 0
+	invalid := errors.New("invalid filter")
+	f = NewFallback("test search", testBackend{&primaryCalls, invalid}, testBackend{&secondaryCalls, nil})
+	if _, err := Call(f, search); !errors.Is(err, invalid) {
+		t.Errorf("Call() error = %v, want %v", err, invalid)
+	}
+	if secondaryCalls != 0 {
+		t.Errorf("secondary called %d times on invalid request, want 0", secondaryCalls)
+	}
+}
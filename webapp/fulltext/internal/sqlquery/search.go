@@ -0,0 +1,46 @@
+package sqlquery
+
+// Queries below are formatted with the text search vector column of the
+// language (search_cs or search_en) before use. Parameters shared by all of
+// them are:
+//   - $1 index UID
+//   - $2 text search query, empty string matches all documents
+//   - $3 text search configuration of the language
+//
+// Hits and Count filter documents by SQL/JSON path $4 ($ matches all
+// documents). Hits is further formatted with ORDER BY clause.
+const Hits = `--sql
+	SELECT doc
+	FROM search_documents
+	WHERE index_uid = $1
+	AND ($2 = '' OR %[1]s @@ to_tsquery($3::regconfig, $2))
+	AND doc @? $4::jsonpath
+	ORDER BY %[2]s
+	LIMIT $5 OFFSET $6
+`
+
+const Count = `--sql
+	SELECT COUNT(*)
+	FROM search_documents
+	WHERE index_uid = $1
+	AND ($2 = '' OR %[1]s @@ to_tsquery($3::regconfig, $2))
+	AND doc @? $4::jsonpath
+`
+
+// FacetDistribution counts documents by values of attributes $4 found on
+// SQL/JSON paths $5. Each attribute is counted among documents matching its
+// own filter $6, which makes disjunctive faceting possible.
+const FacetDistribution = `--sql
+	SELECT
+		f.attribute,
+		v.value #>> '{}' AS value,
+		COUNT(DISTINCT d.id) AS count
+	FROM UNNEST($4::text[], $5::text[], $6::text[]) f(attribute, path, filter)
+	INNER JOIN search_documents d
+		ON d.index_uid = $1
+		AND ($2 = '' OR d.%[1]s @@ to_tsquery($3::regconfig, $2))
+		AND d.doc @? f.filter::jsonpath
+	CROSS JOIN LATERAL jsonb_path_query(d.doc, f.path::jsonpath) v(value)
+	WHERE jsonb_typeof(v.value) IN ('string', 'number', 'boolean')
+	GROUP BY f.attribute, v.value #>> '{}'
+`
@@ -0,0 +1,302 @@
+package fulltext
+
+/** PACKAGE DESCRIPTION
+
+The fulltext package provides search over documents stored in PostgreSQL. It is an alternative to Meilisearch which keeps the application usable without it - either as the only search backend or as a fallback when Meilisearch is down. The documents are the same as the documents of Meilisearch indexes; the ELT stores them in the search_documents table together with their text search vectors (Czech and English text search configurations), so the results can be read the same way as results of Meilisearch.
+
+Typical usage involves creating an Engine with a database connection in main.go and injecting it into full-text implementations of search in page packages. Those build a Request with the text query, a filter (see JSONPath method of filter expressions in the filters package), facets, sorting and pagination and decode the returned hits into their own types. Unlike Meilisearch, words of the query are matched by prefix and all of them have to be present in a document; there is no typo tolerance.
+
+Search backends using Meilisearch and the full-text search are switched by Fallback. It uses the full-text search only while Meilisearch is unavailable, i.e. it cannot be reached or fails with a server error, and does not ask Meilisearch again for a while after such a failure.
+
+*/
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/michalhercik/RecSIS/errorx"
+	"github.com/michalhercik/RecSIS/fulltext/internal/sqlquery"
+	"github.com/michalhercik/RecSIS/language"
+)
+
+const matchAll = "$"
+
+// text search vector column and configuration for each language
+var textSearch = map[language.Language]struct {
+	column string
+	config string
+}{
+	language.CS: {column: "search_cs", config: "webapp.czech"},
+	language.EN: {column: "search_en", config: "english"},
+}
+
+type Engine struct {
+	DB *sqlx.DB
+}
+
+type Request struct {
+	Index string
+	Query string
+	Lang  language.Language
+	// SQL/JSON path filter, empty matches all documents
+	Filter string
+	Facets []Facet
+	// attribute:asc or attribute:desc as in Meilisearch, ties are broken by
+	// relevance
+	Sort []string
+	// attributes (possibly nested, e.g. title.cs) of documents in hits, empty
+	// means all attributes
+	Attributes []string
+	Offset     int
+	Limit      int
+}
+
+// Facet is counted among documents matching the text query and Filter, which
+// is usually the filter of the request without conditions on the facet
+// itself.
+type Facet struct {
+	Attribute string
+	Filter    string
+}
+
+// DisjunctiveFacets counts each facet among documents matching the filter
+// without conditions on the facet itself, as given by except (see
+// ExceptJSONPath method of filter expressions), so that selecting a value of
+// a facet does not hide its other values.
+func DisjunctiveFacets(facets []string, filter string, except func(func(string, string) bool)) []Facet {
+	exceptFilters := map[string]string{}
+	for attribute, f := range except {
+		exceptFilters[attribute] = f
+	}
+	result := make([]Facet, len(facets))
+	for i, attribute := range facets {
+		f, ok := exceptFilters[attribute]
+		if !ok {
+			f = filter
+		}
+		result[i] = Facet{Attribute: attribute, Filter: f}
+	}
+	return result
+}
+
+type Response struct {
+	Hits              []json.RawMessage
+	TotalHits         int
+	FacetDistribution map[string]map[string]int
+}
+
+func (e Engine) Search(r Request) (Response, error) {
+	var result Response
+	ts, ok := textSearch[r.Lang]
+	if !ok {
+		ts = textSearch[language.CS]
+	}
+	query := TSQuery(r.Query)
+	filter := orMatchAll(r.Filter)
+	params := []any{r.Index, query, ts.config, filter, r.Limit, r.Offset}
+	order, sortParams, err := orderBy(r.Sort, query, ts.column, len(params))
+	if err != nil {
+		return result, errorx.AddContext(err, errorx.P("sort", r.Sort))
+	}
+	var docs []string
+	hits := fmt.Sprintf(sqlquery.Hits, ts.column, order)
+	if err := e.DB.Select(&docs, hits, append(params, sortParams...)...); err != nil {
+		return result, errorx.AddContext(fmt.Errorf("sqlquery.Hits: %w", err), errorx.P("index", r.Index), errorx.P("query", r.Query), errorx.P("filter", r.Filter))
+	}
+	result.Hits = make([]json.RawMessage, len(docs))
+	for i, doc := range docs {
+		if result.Hits[i], err = project(doc, r.Attributes); err != nil {
+			return result, errorx.AddContext(err, errorx.P("index", r.Index))
+		}
+	}
+	count := fmt.Sprintf(sqlquery.Count, ts.column)
+	if err := e.DB.Get(&result.TotalHits, count, r.Index, query, ts.config, filter); err != nil {
+		return result, errorx.AddContext(fmt.Errorf("sqlquery.Count: %w", err), errorx.P("index", r.Index), errorx.P("query", r.Query), errorx.P("filter", r.Filter))
+	}
+	if result.FacetDistribution, err = e.facetDistribution(r, query, ts.column, ts.config); err != nil {
+		return result, errorx.AddContext(err)
+	}
+	return result, nil
+}
+
+func (e Engine) facetDistribution(r Request, query, column, config string) (map[string]map[string]int, error) {
+	result := make(map[string]map[string]int, len(r.Facets))
+	if len(r.Facets) == 0 {
+		return result, nil
+	}
+	attributes := make([]string, len(r.Facets))
+	paths := make([]string, len(r.Facets))
+	filters := make([]string, len(r.Facets))
+	for i, f := range r.Facets {
+		attributes[i] = f.Attribute
+		paths[i] = jsonPath(f.Attribute) + "[*]"
+		filters[i] = orMatchAll(f.Filter)
+		result[f.Attribute] = map[string]int{}
+	}
+	var rows []struct {
+		Attribute string `db:"attribute"`
+		Value     string `db:"value"`
+		Count     int    `db:"count"`
+	}
+	facets := fmt.Sprintf(sqlquery.FacetDistribution, column)
+	if err := e.DB.Select(&rows, facets, r.Index, query, config, pq.Array(attributes), pq.Array(paths), pq.Array(filters)); err != nil {
+		return nil, errorx.AddContext(fmt.Errorf("sqlquery.FacetDistribution: %w", err), errorx.P("index", r.Index), errorx.P("query", r.Query), errorx.P("facets", attributes))
+	}
+	for _, row := range rows {
+		result[row.Attribute][row.Value] = row.Count
+	}
+	return result, nil
+}
+
+// Equals returns a filter matching documents whose attribute (or any of its
+// elements if it is an array) is equal to the string value.
+func Equals(attribute, value string) string {
+	quoted, _ := json.Marshal(value)
+	return fmt.Sprintf("$ ? (exists(@%s[*] ? (@ == %s)))", strings.TrimPrefix(jsonPath(attribute), "$"), quoted)
+}
+
+// In returns a filter matching documents whose attribute (or any of its
+// elements if it is an array) is equal to any of the string values. Without
+// values it matches no document.
+func In(attribute string, values ...string) string {
+	predicates := make([]string, len(values))
+	for i, v := range values {
+		quoted, _ := json.Marshal(v)
+		predicates[i] = "@ == " + string(quoted)
+	}
+	if len(predicates) == 0 {
+		// there is no false literal in SQL/JSON path
+		predicates = []string{"1 == 0"}
+	}
+	return fmt.Sprintf("$ ? (exists(@%s[*] ? (%s)))", strings.TrimPrefix(jsonPath(attribute), "$"), strings.Join(predicates, " || "))
+}
+
+// Not returns a filter matching documents which do not match the filter. The
+// filter has to be of the form $ ? (predicate), empty filter is returned as
+// it is.
+func Not(filter string) string {
+	if filter == "" {
+		return ""
+	}
+	return "$ ? (!" + strings.TrimPrefix(filter, "$ ? ") + ")"
+}
+
+// And returns a filter matching documents which match all the filters. The
+// filters have to be of the form $ ? (predicate) as returned by Equals or
+// JSONPath method of filter expressions; empty filters are skipped.
+func And(filters ...string) string {
+	predicates := make([]string, 0, len(filters))
+	for _, f := range filters {
+		if f != "" {
+			predicates = append(predicates, strings.TrimPrefix(f, "$ ? "))
+		}
+	}
+	if len(predicates) == 0 {
+		return ""
+	}
+	return "$ ? (" + strings.Join(predicates, " && ") + ")"
+}
+
+//================================================================================
+// Helper Functions
+//================================================================================
+
+// TSQuery turns the search query into a text search query matching documents
+// with all words of the query, each of them as a prefix, e.g. "prog jaz"
+// becomes "prog:* & jaz:*". Anything but letters and digits separates words,
+// so the result is always a valid query.
+func TSQuery(query string) string {
+	words := strings.FieldsFunc(query, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for i, w := range words {
+		words[i] = w + ":*"
+	}
+	return strings.Join(words, " & ")
+}
+
+func orMatchAll(filter string) string {
+	if filter == "" {
+		return matchAll
+	}
+	return filter
+}
+
+// orderBy builds ORDER BY clause from Meilisearch sort rules. Attributes are
+// passed as query parameters numbered after the first paramCount ones.
+func orderBy(sort []string, query, column string, paramCount int) (string, []any, error) {
+	var clauses []string
+	var params []any
+	for _, rule := range sort {
+		attribute, direction, found := strings.Cut(rule, ":")
+		if !found || (direction != "asc" && direction != "desc") {
+			return "", nil, fmt.Errorf("invalid sort rule %q", rule)
+		}
+		params = append(params, pq.Array(strings.Split(attribute, ".")))
+		clauses = append(clauses, fmt.Sprintf("doc #> $%d::text[] %s NULLS LAST", paramCount+len(params), strings.ToUpper(direction)))
+	}
+	if query != "" {
+		clauses = append(clauses, fmt.Sprintf("ts_rank(%s, to_tsquery($3::regconfig, $2)) DESC", column))
+	}
+	clauses = append(clauses, "id")
+	return strings.Join(clauses, ", "), params, nil
+}
+
+// jsonPath returns SQL/JSON path of the attribute, e.g. $."teacher"."id" for
+// teacher.id.
+func jsonPath(attribute string) string {
+	var sb strings.Builder
+	sb.WriteString("$")
+	for _, key := range strings.Split(attribute, ".") {
+		quoted, _ := json.Marshal(key)
+		sb.WriteString(".")
+		sb.Write(quoted)
+	}
+	return sb.String()
+}
+
+// project keeps only the given attributes of the document. Nested attributes
+// (e.g. title.cs) are kept within their parent objects.
+func project(doc string, attributes []string) (json.RawMessage, error) {
+	if len(attributes) == 0 {
+		return json.RawMessage(doc), nil
+	}
+	var src map[string]any
+	if err := json.Unmarshal([]byte(doc), &src); err != nil {
+		return nil, fmt.Errorf("project: %w", err)
+	}
+	dst := map[string]any{}
+	for _, attribute := range attributes {
+		copyAttribute(dst, src, strings.Split(attribute, "."))
+	}
+	result, err := json.Marshal(dst)
+	if err != nil {
+		return nil, fmt.Errorf("project: %w", err)
+	}
+	return result, nil
+}
+
+func copyAttribute(dst, src map[string]any, path []string) {
+	value, ok := src[path[0]]
+	if !ok {
+		return
+	}
+	if len(path) == 1 {
+		dst[path[0]] = value
+		return
+	}
+	srcChild, ok := value.(map[string]any)
+	if !ok {
+		return
+	}
+	dstChild, ok := dst[path[0]].(map[string]any)
+	if !ok {
+		dstChild = map[string]any{}
+		dst[path[0]] = dstChild
+	}
+	copyAttribute(dstChild, srcChild, path[1:])
+}
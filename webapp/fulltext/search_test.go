@@ -0,0 +1,115 @@
+package fulltext
+
+import (
+	"testing"
+)
+
+func TestTSQuery(t *testing.T) {
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"", ""},
+		{"   ", ""},
+		{"prog", "prog:*"},
+		{"Programování v C++", "Programování:* & v:* & C:*"},
+		{"NSWI120", "NSWI120:*"},
+		{"a'b & !c:*", "a:* & b:* & c:*"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			if got := TSQuery(tt.query); got != tt.want {
+				t.Errorf("TSQuery(%q) = %q, want %q", tt.query, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOrderBy(t *testing.T) {
+	got, params, err := orderBy([]string{"credits:desc", "title.cs:asc"}, "prog:*", "search_cs", 6)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "doc #> $7::text[] DESC NULLS LAST, doc #> $8::text[] ASC NULLS LAST, ts_rank(search_cs, to_tsquery($3::regconfig, $2)) DESC, id"
+	if got != want || len(params) != 2 {
+		t.Errorf("orderBy() = %s with %d params, want %s with 2 params", got, len(params), want)
+	}
+	if got, _, _ := orderBy(nil, "", "search_cs", 6); got != "id" {
+		t.Errorf("orderBy() without sort and query = %s, want id", got)
+	}
+	for _, rule := range []string{"credits", "credits:up", "credits:desc; DROP TABLE courses"} {
+		if _, _, err := orderBy([]string{rule}, "", "search_cs", 6); err == nil {
+			t.Errorf("orderBy(%s) want error", rule)
+		}
+	}
+}
+
+func TestProject(t *testing.T) {
+	doc := `{"code":"NSWI120","title":{"cs":"Principy","en":"Principles"},"credits":5}`
+	tests := []struct {
+		name       string
+		attributes []string
+		want       string
+	}{
+		{"all", nil, doc},
+		{"top level", []string{"code"}, `{"code":"NSWI120"}`},
+		{"nested", []string{"code", "title.en"}, `{"code":"NSWI120","title":{"en":"Principles"}}`},
+		{"missing", []string{"lorem", "credits.cs"}, `{}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := project(doc, tt.attributes)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("project() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAnd(t *testing.T) {
+	teacher := Equals("teacher.id", "12345")
+	if want := `$ ? (exists(@."teacher"."id"[*] ? (@ == "12345")))`; teacher != want {
+		t.Errorf("Equals() = %s, want %s", teacher, want)
+	}
+	tests := []struct {
+		name    string
+		filters []string
+		want    string
+	}{
+		{"none", nil, ""},
+		{"empty", []string{"", ""}, ""},
+		{"one", []string{"", teacher}, `$ ? ((exists(@."teacher"."id"[*] ? (@ == "12345"))))`},
+		{"two", []string{teacher, `$ ? (@."a" == 1)`}, `$ ? ((exists(@."teacher"."id"[*] ? (@ == "12345"))) && (@."a" == 1))`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := And(tt.filters...); got != tt.want {
+				t.Errorf("And() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInAndNot(t *testing.T) {
+	tests := []struct {
+		name string
+		got  string
+		want string
+	}{
+		{"in", In("code", "NSWI120", "NPRG030"), `$ ? (exists(@."code"[*] ? (@ == "NSWI120" || @ == "NPRG030")))`},
+		{"in none", In("code"), `$ ? (exists(@."code"[*] ? (1 == 0)))`},
+		{"not", Not(In("code", "NSWI120")), `$ ? (!(exists(@."code"[*] ? (@ == "NSWI120"))))`},
+		{"not empty", Not(""), ""},
+		{"and not", And(Equals("a", "1"), Not(In("code", "X"))), `$ ? ((exists(@."a"[*] ? (@ == "1"))) && (!(exists(@."code"[*] ? (@ == "X")))))`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.got != tt.want {
+				t.Errorf("got %s, want %s", tt.got, tt.want)
+			}
+		})
+	}
+}
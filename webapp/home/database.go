@@ -66,3 +66,62 @@ func intoTeacherSlice(from dbds.TeacherSlice) []teacher {
 	}
 	return result
 }
+
+type dbSavedSearch struct {
+	ID         int                `db:"id"`
+	Name       string             `db:"name"`
+	URLQuery   string             `db:"url_query"`
+	NewCourses savedSearchCourses `db:"new_courses"`
+}
+
+func (m DBManager) savedSearches(userID string, lang language.Language) ([]savedSearch, error) {
+	var records []dbSavedSearch
+	if err := m.DB.Select(&records, sqlquery.SavedSearches, userID, lang); err != nil {
+		return nil, errorx.NewHTTPErr(
+			errorx.AddContext(fmt.Errorf("sqlquery.SavedSearches: %w", err), errorx.P("lang", lang)),
+			http.StatusInternalServerError,
+			texts[lang].errCannotLoadSavedSearches,
+		)
+	}
+	result := make([]savedSearch, len(records))
+	for i, record := range records {
+		result[i] = savedSearch{
+			id:         record.ID,
+			name:       record.Name,
+			urlQuery:   record.URLQuery,
+			newCourses: record.NewCourses,
+		}
+	}
+	return result, nil
+}
+
+func (m DBManager) markSavedSearchSeen(userID string, id int, lang language.Language) error {
+	if _, err := m.DB.Exec(sqlquery.MarkSavedSearchSeen, userID, id); err != nil {
+		return errorx.NewHTTPErr(
+			errorx.AddContext(fmt.Errorf("sqlquery.MarkSavedSearchSeen: %w", err), errorx.P("id", id)),
+			http.StatusInternalServerError,
+			texts[lang].errCannotUpdateSavedSearch,
+		)
+	}
+	return nil
+}
+
+func (m DBManager) deleteSavedSearch(userID string, id int, lang language.Language) error {
+	t := texts[lang]
+	res, err := m.DB.Exec(sqlquery.DeleteSavedSearch, userID, id)
+	if err != nil {
+		return errorx.NewHTTPErr(
+			errorx.AddContext(fmt.Errorf("sqlquery.DeleteSavedSearch: %w", err), errorx.P("id", id)),
+			http.StatusInternalServerError,
+			t.errCannotUpdateSavedSearch,
+		)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return errorx.NewHTTPErr(
+			errorx.AddContext(fmt.Errorf("saved search not found"), errorx.P("id", id)),
+			http.StatusNotFound,
+			t.errSavedSearchNotFound,
+		)
+	}
+	return nil
+}
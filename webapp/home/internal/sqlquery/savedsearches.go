@@ -0,0 +1,41 @@
+package sqlquery
+
+const SavedSearches = `--sql
+	SELECT
+		s.id,
+		s.name,
+		s.url_query,
+		COALESCE(
+			JSONB_AGG(
+				JSONB_BUILD_OBJECT('code', c.code, 'title', c.title)
+				ORDER BY c.code
+			) FILTER (WHERE c.code IS NOT NULL),
+			'[]'
+		) AS new_courses
+	FROM saved_searches s
+	LEFT JOIN saved_search_matches m
+		ON m.saved_search_id = s.id
+		AND m.is_new
+	LEFT JOIN courses c
+		ON c.code = m.course_code
+		AND c.lang = $2
+		AND c.valid_to = 9999
+	WHERE s.user_id = $1
+	GROUP BY s.id
+	ORDER BY s.created_at
+`
+
+const MarkSavedSearchSeen = `--sql
+	UPDATE saved_search_matches m
+	SET is_new = FALSE
+	FROM saved_searches s
+	WHERE m.saved_search_id = s.id
+		AND s.user_id = $1
+		AND s.id = $2
+`
+
+const DeleteSavedSearch = `--sql
+	DELETE FROM saved_searches
+	WHERE user_id = $1
+		AND id = $2
+`
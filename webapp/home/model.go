@@ -6,13 +6,50 @@ import (
 	"fmt"
 	"strings"
 	"unicode/utf8"
+
+	"github.com/michalhercik/RecSIS/language"
 )
 
 type homePage struct {
+	savedSearches      []savedSearch
 	recommendedCourses []course
 	newCourses         []course
 }
 
+const savedSearchID = "id"
+
+// savedSearch is a course search saved on the courses page. New courses are
+// courses found by the ELT to match the search since the user last marked
+// them as seen.
+type savedSearch struct {
+	id         int
+	name       string
+	urlQuery   string
+	newCourses []savedSearchCourse
+}
+
+func (s savedSearch) url(lang language.Language) string {
+	return lang.LocalizeURL("/courses/") + "?" + s.urlQuery
+}
+
+type savedSearchCourse struct {
+	Code  string `json:"code"`
+	Title string `json:"title"`
+}
+
+type savedSearchCourses []savedSearchCourse
+
+func (sc *savedSearchCourses) Scan(val interface{}) error {
+	switch v := val.(type) {
+	case []byte:
+		return json.Unmarshal(v, sc)
+	case string:
+		return json.Unmarshal([]byte(v), sc)
+	default:
+		return fmt.Errorf("unsupported type: %T", v)
+	}
+}
+
 type course struct {
 	Code               string           `json:"code"`
 	Title              string           `json:"title"`
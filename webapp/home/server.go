@@ -1,7 +1,9 @@
 package home
 
 import (
+	"fmt"
 	"net/http"
+	"strconv"
 
 	"github.com/a-h/templ"
 	"github.com/michalhercik/RecSIS/errorx"
@@ -39,6 +41,9 @@ type Error interface {
 
 	// Renders a fallback error page when a regular page cannot be rendered due to an error.
 	CannotRenderPage(w http.ResponseWriter, r *http.Request, title string, userID string, err error, lang language.Language)
+
+	// Renders a floating window with error when any component cannot be rendered due to an error.
+	CannotRenderComponent(w http.ResponseWriter, r *http.Request, err error, lang language.Language)
 }
 
 type Page interface {
@@ -63,6 +68,8 @@ func (s *Server) Init() {
 	router := http.NewServeMux()
 	router.HandleFunc("GET /{$}", s.page)
 	router.HandleFunc("GET /home/{$}", s.page)
+	router.HandleFunc(fmt.Sprintf("POST /home/saved-searches/{%s}/seen", savedSearchID), s.markSavedSearchSeen)
+	router.HandleFunc(fmt.Sprintf("DELETE /home/saved-searches/{%s}", savedSearchID), s.deleteSavedSearch)
 	router.HandleFunc("/", s.pageNotFound)
 	s.router = router
 }
@@ -77,6 +84,13 @@ func (s Server) page(w http.ResponseWriter, r *http.Request) {
 
 	userID := s.Auth.UserID(r)
 
+	savedSearches, err := s.Data.savedSearches(userID, lang)
+	if err != nil {
+		code, userMsg := errorx.UnwrapError(err, lang)
+		s.Error.Log(errorx.AddContext(err))
+		s.Error.RenderPage(w, r, code, userMsg, t.pageTitle, userID, lang)
+		return
+	}
 	recommended, err := s.recommended(userID, lang)
 	if err != nil {
 		code, userMsg := errorx.UnwrapError(err, lang)
@@ -93,6 +107,7 @@ func (s Server) page(w http.ResponseWriter, r *http.Request) {
 	}
 
 	content := homePage{
+		savedSearches:      savedSearches,
 		recommendedCourses: recommended,
 		newCourses:         newest,
 	}
@@ -106,6 +121,45 @@ func (s Server) page(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+func (s Server) markSavedSearchSeen(w http.ResponseWriter, r *http.Request) {
+	s.updateSavedSearch(w, r, s.Data.markSavedSearchSeen)
+}
+
+func (s Server) deleteSavedSearch(w http.ResponseWriter, r *http.Request) {
+	s.updateSavedSearch(w, r, s.Data.deleteSavedSearch)
+}
+
+// updateSavedSearch applies the update to the saved search of the request and
+// renders all saved searches again.
+func (s Server) updateSavedSearch(w http.ResponseWriter, r *http.Request, update func(userID string, id int, lang language.Language) error) {
+	lang := language.FromContext(r.Context())
+	t := texts[lang]
+	userID := s.Auth.UserID(r)
+	id, err := strconv.Atoi(r.PathValue(savedSearchID))
+	if err != nil {
+		s.Error.Log(errorx.AddContext(err, errorx.P(savedSearchID, r.PathValue(savedSearchID))))
+		s.Error.Render(w, r, http.StatusBadRequest, t.errInvalidSavedSearch, lang)
+		return
+	}
+	if err = update(userID, id, lang); err != nil {
+		code, userMsg := errorx.UnwrapError(err, lang)
+		s.Error.Log(errorx.AddContext(err))
+		s.Error.Render(w, r, code, userMsg, lang)
+		return
+	}
+	savedSearches, err := s.Data.savedSearches(userID, lang)
+	if err != nil {
+		code, userMsg := errorx.UnwrapError(err, lang)
+		s.Error.Log(errorx.AddContext(err))
+		s.Error.Render(w, r, code, userMsg, lang)
+		return
+	}
+	err = SavedSearches(savedSearches, t).Render(r.Context(), w)
+	if err != nil {
+		s.Error.CannotRenderComponent(w, r, errorx.AddContext(err), lang)
+	}
+}
+
 func (s Server) recommended(userID string, lang language.Language) ([]course, error) {
 	courses, err := s.ForYou.Recommend(userID)
 	if err != nil {
@@ -5,53 +5,77 @@ import (
 )
 
 type text struct {
-	pageTitle                 string
-	welcome                   string
-	recsisIntro               string
-	recommendedCourses        string
-	newCourses                string
-	winter                    string
-	summer                    string
-	both                      string
-	credits                   string
-	noGuarantors              string
-	language                  language.Language
-	errRecommenderUnavailable string
-	errCannotLoadCourses      string
-	errPageNotFound           string
+	pageTitle                  string
+	welcome                    string
+	recsisIntro                string
+	savedSearches              string
+	newMatches                 string
+	markSeen                   string
+	removeSavedSearch          string
+	recommendedCourses         string
+	newCourses                 string
+	winter                     string
+	summer                     string
+	both                       string
+	credits                    string
+	noGuarantors               string
+	language                   language.Language
+	errRecommenderUnavailable  string
+	errCannotLoadCourses       string
+	errPageNotFound            string
+	errCannotLoadSavedSearches string
+	errCannotUpdateSavedSearch string
+	errSavedSearchNotFound     string
+	errInvalidSavedSearch      string
 }
 
 var texts = map[language.Language]text{
 	language.CS: {
-		pageTitle:                 "Domů",
-		welcome:                   "Vítejte!",
-		recsisIntro:               "RecSIS je systém pro plánování studia, kontrolování studijních povinností a doporučování kurzů.",
-		recommendedCourses:        "Doporučené kurzy přímo pro vás",
-		newCourses:                "Nové kurzy",
-		winter:                    "ZS",
-		summer:                    "LS",
-		both:                      "Oba",
-		credits:                   "Kredity",
-		noGuarantors:              "Žádní garanti",
-		language:                  language.CS,
-		errRecommenderUnavailable: "Nelze se připojit k doporučovacímu systému",
-		errCannotLoadCourses:      "Nelze načíst kurzy na stránce",
-		errPageNotFound:           "Stránka nenalezena",
+		pageTitle:                  "Domů",
+		welcome:                    "Vítejte!",
+		recsisIntro:                "RecSIS je systém pro plánování studia, kontrolování studijních povinností a doporučování kurzů.",
+		savedSearches:              "Uložená hledání",
+		newMatches:                 "Nové předměty: %d",
+		markSeen:                   "Označit jako zobrazené",
+		removeSavedSearch:          "Odstranit uložené hledání",
+		recommendedCourses:         "Doporučené kurzy přímo pro vás",
+		newCourses:                 "Nové kurzy",
+		winter:                     "ZS",
+		summer:                     "LS",
+		both:                       "Oba",
+		credits:                    "Kredity",
+		noGuarantors:               "Žádní garanti",
+		language:                   language.CS,
+		errRecommenderUnavailable:  "Nelze se připojit k doporučovacímu systému",
+		errCannotLoadCourses:       "Nelze načíst kurzy na stránce",
+		errPageNotFound:            "Stránka nenalezena",
+		errCannotLoadSavedSearches: "Nelze načíst uložená hledání",
+		errCannotUpdateSavedSearch: "Nelze upravit uložené hledání",
+		errSavedSearchNotFound:     "Uložené hledání nenalezeno",
+		errInvalidSavedSearch:      "Neplatné uložené hledání",
 	},
 	language.EN: {
-		pageTitle:                 "Home",
-		welcome:                   "Welcome!",
-		recsisIntro:               "RecSIS is a system for study planning, monitoring study obligations, and recommending courses.",
-		recommendedCourses:        "Recommended courses just for you",
-		newCourses:                "New courses",
-		winter:                    "Winter",
-		summer:                    "Summer",
-		both:                      "Both",
-		credits:                   "Credits",
-		noGuarantors:              "No guarantors",
-		language:                  language.EN,
-		errRecommenderUnavailable: "Cannot connect to recommender system",
-		errCannotLoadCourses:      "Cannot load courses on the page",
-		errPageNotFound:           "Page not found",
+		pageTitle:                  "Home",
+		welcome:                    "Welcome!",
+		recsisIntro:                "RecSIS is a system for study planning, monitoring study obligations, and recommending courses.",
+		savedSearches:              "Saved searches",
+		newMatches:                 "New courses: %d",
+		markSeen:                   "Mark as seen",
+		removeSavedSearch:          "Remove saved search",
+		recommendedCourses:         "Recommended courses just for you",
+		newCourses:                 "New courses",
+		winter:                     "Winter",
+		summer:                     "Summer",
+		both:                       "Both",
+		credits:                    "Credits",
+		noGuarantors:               "No guarantors",
+		language:                   language.EN,
+		errRecommenderUnavailable:  "Cannot connect to recommender system",
+		errCannotLoadCourses:       "Cannot load courses on the page",
+		errPageNotFound:            "Page not found",
+		errCannotLoadSavedSearches: "Cannot load saved searches",
+		errCannotUpdateSavedSearch: "Cannot update the saved search",
+		errSavedSearchNotFound:     "Saved search not found",
+		errInvalidSavedSearch:      "Invalid saved search",
 	},
 }
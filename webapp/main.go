@@ -8,6 +8,7 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
+	"time"
 
 	"github.com/BurntSushi/toml"
 
@@ -16,6 +17,7 @@ import (
 	"github.com/michalhercik/RecSIS/components/page"
 	"github.com/michalhercik/RecSIS/errorx"
 	"github.com/michalhercik/RecSIS/filters"
+	"github.com/michalhercik/RecSIS/fulltext"
 	"github.com/michalhercik/RecSIS/language"
 	"github.com/michalhercik/RecSIS/recommend"
 
@@ -25,6 +27,7 @@ import (
 	"github.com/michalhercik/RecSIS/degreeplandetail"
 	"github.com/michalhercik/RecSIS/degreeplans"
 	"github.com/michalhercik/RecSIS/home"
+	"github.com/michalhercik/RecSIS/teacher"
 
 	"github.com/jmoiron/sqlx"
 	_ "github.com/lib/pq"
@@ -91,10 +94,11 @@ func configFrom(configPath string) config {
 func setupHandler(conf config) http.Handler {
 	db := setupDB(conf)
 	meiliClient := meiliServiceManager(conf)
+	fullText := fulltext.Engine{DB: db}
 
 	errorHandler := errorx.ErrorHandler{}
 
-	pageTempl := pageTemplate(errorHandler, meiliClient)
+	pageTempl := pageTemplate(db, errorHandler, meiliClient, fullText)
 
 	errorHandler.Page = page.PageWithNoFiltersAndForgetsSearchQueryOnRefresh{Page: pageTempl}
 
@@ -103,19 +107,30 @@ func setupHandler(conf config) http.Handler {
 		log.Fatalf("Failed to get executable path: %v", err)
 	}
 
+	// shared by all servers changing the blueprint to keep it consistent
+	bpCache := &blueprint.Cache{
+		Source:     blueprint.DBManager{DB: db},
+		TTL:        conf.BlueprintCache.TTL,
+		MaxEntries: conf.BlueprintCache.MaxEntries,
+	}
+
+	shareSigner := blueprint.ShareSigner{Secret: shareSecret(conf)}
+
 	s := servers{
 		pageTempl:              pageTempl.Router(),
-		homeServer:             homeServer(db, conf, errorHandler, pageTempl, meiliClient),
-		blueprintServer:        blueprintServer(db, errorHandler, pageTempl),
-		coursedetailServer:     courseDetailServer(db, errorHandler, pageTempl, meiliClient),
-		coursesServer:          coursesServer(db, errorHandler, pageTempl, meiliClient),
-		degreePlanDetailServer: degreePlanDetailServer(db, errorHandler, pageTempl),
-		degreePlansServer:      degreePlansServer(db, errorHandler, pageTempl, meiliClient),
+		homeServer:             homeServer(db, conf, errorHandler, pageTempl, meiliClient, fullText),
+		blueprintServer:        blueprintServer(bpCache, shareSigner, errorHandler, pageTempl),
+		sharedBlueprintServer:  sharedBlueprintServer(db, shareSigner, errorHandler, pageTempl),
+		coursedetailServer:     courseDetailServer(db, bpCache, errorHandler, pageTempl, meiliClient, fullText),
+		coursesServer:          coursesServer(db, bpCache, errorHandler, pageTempl, meiliClient, fullText),
+		degreePlanDetailServer: degreePlanDetailServer(db, bpCache, errorHandler, pageTempl),
+		degreePlansServer:      degreePlansServer(db, errorHandler, pageTempl, meiliClient, fullText),
+		teacherServer:          teacherServer(db, errorHandler, pageTempl, meiliClient, fullText),
 		static:                 http.FileServer(http.Dir(filepath.Join(filepath.Dir(exePath), "static"))),
 	}
 	handler := protectedHandler(s)
 	handler = authenticationHandler(handler, db, errorHandler, conf)
-	handler = unprotectedHandler(handler, s.static)
+	handler = unprotectedHandler(handler, s.static, s.sharedBlueprintServer)
 	return handler
 }
 
@@ -133,16 +148,63 @@ func setupDB(conf config) *sqlx.DB {
 	return db
 }
 
+// shareSecret returns the secret used to sign shared blueprint links. Without
+// RECSIS_SHARE_SECRET a random secret is used in development, so links stop
+// working after restart.
+func shareSecret(conf config) []byte {
+	if secret := os.Getenv("RECSIS_SHARE_SECRET"); secret != "" {
+		return []byte(secret)
+	}
+	if conf.Environment == productionEnvironment {
+		log.Fatal("RECSIS_SHARE_SECRET is required in production")
+	}
+	secret, err := blueprint.NewShareSecret()
+	if err != nil {
+		log.Fatalf("Failed to generate share secret: %v", err)
+	}
+	log.Println("WARNING: RECSIS_SHARE_SECRET is not set, shared blueprint links are valid until restart.")
+	return secret
+}
+
+// meiliServiceManager returns nil if search backend is PostgreSQL full-text
+// search. Otherwise Meilisearch is used even if it is not healthy at the
+// moment and full-text search is used as a fallback until it is.
 func meiliServiceManager(conf config) meilisearch.ServiceManager {
+	switch conf.Search.Backend {
+	case "", meilisearchBackend:
+	case postgresBackend:
+		log.Println("INFO: Using PostgreSQL full-text search.")
+		return nil
+	default:
+		log.Fatalf("Invalid search backend: %s", conf.Search.Backend)
+	}
 	key := os.Getenv("MEILI_MASTER_KEY")
 	ms := meilisearch.New(conf.MeiliSearch.Host, meilisearch.WithAPIKey(key))
 	if !ms.IsHealthy() {
-		log.Fatalf("MeiliSearch connection failed")
+		log.Println("WARNING: MeiliSearch connection failed, falling back to PostgreSQL full-text search.")
 	}
 	return ms
 }
 
-func pageTemplate(errorHandler page.Error, meiliClient meilisearch.ServiceManager) page.Page {
+func pageTemplate(db *sqlx.DB, errorHandler page.Error, meiliClient meilisearch.ServiceManager, fullText fulltext.Engine) page.Page {
+	var search page.SearchEngine = page.FullTextSearch{
+		Engine: fullText,
+		Index:  "courses",
+		Limit:  5,
+	}
+	if meiliClient != nil {
+		search = page.FallbackSearch{
+			Fallback: fulltext.NewFallback[page.SearchEngine]("quick search", page.MeiliSearch{
+				Client: meiliClient,
+				Index:  "courses",
+				Limit:  5,
+			}, search),
+		}
+	}
+	queryFilters := filters.MakeFilters(db, "courses")
+	if err := queryFilters.Init(); err != nil {
+		log.Fatalf("Quick search filters failed: %v", err)
+	}
 	pageTempl := page.Page{
 		Error: errorHandler,
 		Home:  homeRoot,
@@ -152,12 +214,12 @@ func pageTemplate(errorHandler page.Error, meiliClient meilisearch.ServiceManage
 			{Title: language.MakeLangString("Blueprint", "Blueprint"), Path: blueprintRoot, Skeleton: blueprint.Skeleton, Indicator: "#blueprint-skeleton"},
 			{Title: language.MakeLangString("Studijní plán", "Degree plan"), Path: degreePlanDetailRoot, Skeleton: degreeplandetail.Skeleton, Indicator: "#degreeplan-skeleton"},
 		},
-		Search: page.MeiliSearch{
-			Client: meiliClient,
-			Index:  "courses",
-			Limit:  5,
+		Search: search,
+		Param:  "search",
+		Query: filters.InlineParser{
+			Filters: queryFilters,
+			Aliases: courses.QualifierAliases,
 		},
-		Param:          "search",
 		SearchEndpoint: coursesRoot,
 		ResultsDetailEndpoint: func(code string) string {
 			return courseDetailRoot + code
@@ -168,19 +230,28 @@ func pageTemplate(errorHandler page.Error, meiliClient meilisearch.ServiceManage
 	return pageTempl
 }
 
-func homeServer(db *sqlx.DB, conf config, errorHandler home.Error, pageTempl page.Page, meiliClient meilisearch.ServiceManager) http.Handler {
+func homeServer(db *sqlx.DB, conf config, errorHandler home.Error, pageTempl page.Page, meiliClient meilisearch.ServiceManager, fullText fulltext.Engine) http.Handler {
+	var forYou home.Recommender = recommend.FullTextSimilarToBlueprint{
+		SearchIndex: "courses",
+		DB:          fullText.DB,
+	}
+	if meiliClient != nil {
+		forYou = recommend.Fallback{
+			Fallback: fulltext.NewFallback[recommend.Recommender]("recommendation", recommend.MeiliSearchSimilarToBlueprint{
+				Search:      meiliClient,
+				SearchIndex: meilisearch.IndexConfig{Uid: "courses"},
+				QueryPrefix: "Give me recommendations for similar courses like: ",
+				Embedder:    "bert",
+				DB:          db,
+			}, forYou),
+		}
+	}
 	home := home.Server{
 		Auth:  cas.UserIDFromContext{},
 		Error: errorHandler,
 		Page:  page.PageWithNoFiltersAndForgetsSearchQueryOnRefresh{Page: pageTempl},
 		// Recommender: fmt.Sprintf("http://%s:%d", conf.Recommender.Host, conf.Recommender.Port),
-		ForYou: recommend.MeiliSearchSimilarToBlueprint{
-			Search:      meiliClient,
-			SearchIndex: meilisearch.IndexConfig{Uid: "courses"},
-			QueryPrefix: "Give me recommendations for similar courses like: ",
-			Embedder:    "bert",
-			DB:          db,
-		},
+		ForYou: forYou,
 		Newest: recommend.NewCourses{
 			DB: db,
 		},
@@ -192,60 +263,117 @@ func homeServer(db *sqlx.DB, conf config, errorHandler home.Error, pageTempl pag
 	return home.Router()
 }
 
-func blueprintServer(db *sqlx.DB, errorHandler blueprint.Error, pageTempl page.Page) http.Handler {
+func blueprintServer(bpCache *blueprint.Cache, shareSigner blueprint.ShareSigner, errorHandler blueprint.Error, pageTempl page.Page) http.Handler {
 	blueprint := blueprint.Server{
-		Auth:  cas.UserIDFromContext{},
-		Data:  blueprint.Cache{Source: blueprint.DBManager{DB: db}},
-		Error: errorHandler,
-		Page:  page.PageWithNoFiltersAndForgetsSearchQueryOnRefresh{Page: pageTempl},
+		Auth:   cas.UserIDFromContext{},
+		Data:   bpCache,
+		Error:  errorHandler,
+		Page:   page.PageWithNoFiltersAndForgetsSearchQueryOnRefresh{Page: pageTempl},
+		Signer: shareSigner,
 	}
 	blueprint.Init()
 	return blueprint.Router()
 }
 
-func courseDetailServer(db *sqlx.DB, errorHandler coursedetail.Error, pageTempl page.Page, meiliClient meilisearch.ServiceManager) http.Handler {
+func sharedBlueprintServer(db *sqlx.DB, shareSigner blueprint.ShareSigner, errorHandler blueprint.Error, pageTempl page.Page) http.Handler {
+	shared := blueprint.SharedServer{
+		Data:   blueprint.DBManager{DB: db},
+		Signer: shareSigner,
+		Error:  errorHandler,
+		Page:   page.PageWithNoFiltersAndForgetsSearchQueryOnRefresh{Page: pageTempl},
+	}
+	shared.Init()
+	return shared.Router()
+}
+
+func courseDetailServer(db *sqlx.DB, bpCache *blueprint.Cache, errorHandler coursedetail.Error, pageTempl page.Page, meiliClient meilisearch.ServiceManager, fullText fulltext.Engine) http.Handler {
+	var search coursedetail.SearchEngine = coursedetail.FullTextSearch{
+		Engine: fullText,
+		Survey: "survey",
+	}
+	if meiliClient != nil {
+		search = coursedetail.FallbackSearch{
+			Fallback: fulltext.NewFallback[coursedetail.SearchEngine]("course survey search", coursedetail.MeiliSearch{
+				Client: meiliClient,
+				Survey: meilisearch.IndexConfig{Uid: "survey"},
+			}, search),
+		}
+	}
 	coursedetail := coursedetail.Server{
 		Auth: cas.UserIDFromContext{},
 		BpBtn: bpbtn.Add{
 			DB:         db,
 			Templ:      bpbtn.AddBtn,
 			HxPostBase: courseDetailRoot,
+			Cache:      bpCache,
 		},
 		Data:    coursedetail.DBManager{DB: db},
 		Error:   errorHandler,
 		Filters: filters.MakeFilters(db, "course-survey"),
 		Page:    page.PageWithNoFiltersAndForgetsSearchQueryOnRefresh{Page: pageTempl},
-		Search: coursedetail.Search{
-			Client: meiliClient,
-			Survey: meilisearch.IndexConfig{Uid: "survey"},
-		},
+		Search:  search,
 	}
 	coursedetail.Init()
 	return coursedetail.Router()
 }
 
-func coursesServer(db *sqlx.DB, errorHandler courses.Error, pageTempl page.Page, meiliClient meilisearch.ServiceManager) http.Handler {
+func teacherServer(db *sqlx.DB, errorHandler teacher.Error, pageTempl page.Page, meiliClient meilisearch.ServiceManager, fullText fulltext.Engine) http.Handler {
+	var search teacher.SearchEngine = teacher.FullTextSearch{
+		Engine: fullText,
+		Survey: "survey",
+	}
+	if meiliClient != nil {
+		search = teacher.FallbackSearch{
+			Fallback: fulltext.NewFallback[teacher.SearchEngine]("teacher survey search", teacher.MeiliSearch{
+				Client: meiliClient,
+				Survey: meilisearch.IndexConfig{Uid: "survey"},
+			}, search),
+		}
+	}
+	teacher := teacher.Server{
+		Auth:    cas.UserIDFromContext{},
+		Data:    teacher.DBManager{DB: db},
+		Error:   errorHandler,
+		Filters: filters.MakeFilters(db, "course-survey"),
+		Page:    page.PageWithNoFiltersAndForgetsSearchQueryOnRefresh{Page: pageTempl},
+		Search:  search,
+	}
+	teacher.Init()
+	return teacher.Router()
+}
+
+func coursesServer(db *sqlx.DB, bpCache *blueprint.Cache, errorHandler courses.Error, pageTempl page.Page, meiliClient meilisearch.ServiceManager, fullText fulltext.Engine) http.Handler {
+	var search courses.SearchEngine = courses.FullTextSearch{
+		Engine:  fullText,
+		Courses: "courses",
+	}
+	if meiliClient != nil {
+		search = courses.FallbackSearch{
+			Fallback: fulltext.NewFallback[courses.SearchEngine]("course search", courses.MeiliSearch{
+				Client:  meiliClient,
+				Courses: meilisearch.IndexConfig{Uid: "courses"},
+			}, search),
+		}
+	}
 	courses := courses.Server{
 		Auth: cas.UserIDFromContext{},
 		BpBtn: bpbtn.Add{
 			DB:         db,
 			Templ:      bpbtn.AddBtn,
 			HxPostBase: coursesRoot,
+			Cache:      bpCache,
 		},
 		Data:    courses.DBManager{DB: db},
 		Error:   errorHandler,
 		Filters: filters.MakeFilters(db, "courses"),
 		Page:    pageTempl,
-		Search: courses.MeiliSearch{
-			Client:  meiliClient,
-			Courses: meilisearch.IndexConfig{Uid: "courses"},
-		},
+		Search:  search,
 	}
 	courses.Init()
 	return courses.Router()
 }
 
-func degreePlanDetailServer(db *sqlx.DB, errorHandler degreeplandetail.Error, pageTempl page.Page) http.Handler {
+func degreePlanDetailServer(db *sqlx.DB, bpCache *blueprint.Cache, errorHandler degreeplandetail.Error, pageTempl page.Page) http.Handler {
 	degreePlanDetail := degreeplandetail.Server{
 		Auth: cas.UserIDFromContext{},
 		BpBtn: bpbtn.AddWithTwoTemplComponents{
@@ -253,10 +381,11 @@ func degreePlanDetailServer(db *sqlx.DB, errorHandler degreeplandetail.Error, pa
 				DB:         db,
 				Templ:      bpbtn.PlusSignBtn,
 				HxPostBase: degreePlanDetailRoot,
+				Cache:      bpCache,
 			},
 			TemplSecond: bpbtn.PlusSignBtnChecked,
 		},
-		Data:                degreeplandetail.DBManager{DB: db},
+		Data:                degreeplandetail.DBManager{DB: db, BlueprintCache: bpCache},
 		Error:               errorHandler,
 		SearchRedirectPath:  degreePlansRoot,
 		ComparePlanUrlParam: degreeplans.CompareUrlParam,
@@ -266,17 +395,26 @@ func degreePlanDetailServer(db *sqlx.DB, errorHandler degreeplandetail.Error, pa
 	return degreePlanDetail.Router()
 }
 
-func degreePlansServer(db *sqlx.DB, errorHandler degreeplans.Error, pageTempl page.Page, meiliClient meilisearch.ServiceManager) http.Handler {
+func degreePlansServer(db *sqlx.DB, errorHandler degreeplans.Error, pageTempl page.Page, meiliClient meilisearch.ServiceManager, fullText fulltext.Engine) http.Handler {
+	var search degreeplans.SearchEngine = degreeplans.FullTextSearch{
+		Engine:      fullText,
+		DegreePlans: degreeplans.SearchIndex,
+	}
+	if meiliClient != nil {
+		search = degreeplans.FallbackSearch{
+			Fallback: fulltext.NewFallback[degreeplans.SearchEngine]("degree plan search", degreeplans.MeiliSearch{
+				Client:      meiliClient,
+				DegreePlans: meilisearch.IndexConfig{Uid: degreeplans.SearchIndex},
+			}, search),
+		}
+	}
 	degreePlans := degreeplans.Server{
 		Auth:    cas.UserIDFromContext{},
 		Data:    degreeplans.DBManager{DB: db},
 		Filters: filters.MakeFilters(db, degreeplans.SearchIndex),
 		Error:   errorHandler,
 		Page:    page.PageWithNoFiltersAndForgetsSearchQueryOnRefresh{Page: pageTempl},
-		Search: degreeplans.MeiliSearch{
-			Client:      meiliClient,
-			DegreePlans: meilisearch.IndexConfig{Uid: degreeplans.SearchIndex},
-		},
+		Search:  search,
 	}
 	degreePlans.Init()
 	return degreePlans.Router()
@@ -291,9 +429,8 @@ func protectedHandler(s servers) http.Handler {
 	handle(protectedRouter, coursesRoot, s.coursesServer)
 	handle(protectedRouter, degreePlanDetailRoot, s.degreePlanDetailServer)
 	handle(protectedRouter, degreePlansRoot, s.degreePlansServer)
+	handle(protectedRouter, teacherRoot, s.teacherServer)
 	protectedRouter.Handle("GET /logo.svg", s.static)
-	protectedRouter.Handle("GET /style.css", s.static)
-	protectedRouter.Handle("GET /js/", s.static)
 
 	return protectedRouter
 }
@@ -312,12 +449,16 @@ func authenticationHandler(prev http.Handler, db *sqlx.DB, errorHandler cas.Erro
 	return authenticationHandler
 }
 
-func unprotectedHandler(prev http.Handler, static http.Handler) http.Handler {
+func unprotectedHandler(prev http.Handler, static http.Handler, sharedBlueprint http.Handler) http.Handler {
 	unprotectedRouter := http.NewServeMux()
 	unprotectedRouter.Handle("/", prev)
 	unprotectedRouter.Handle("GET /favicon.ico", static)
 	unprotectedRouter.Handle("GET /logo.svg", static)
 	unprotectedRouter.Handle("GET /help/", static)
+	// needed by shared blueprints which are visible without login
+	unprotectedRouter.Handle("GET /style.css", static)
+	unprotectedRouter.Handle("GET /js/", static)
+	handle(unprotectedRouter, sharedBlueprintRoot, sharedBlueprint)
 
 	var unprotectedHandler http.Handler
 	unprotectedHandler = unprotectedRouter
@@ -341,10 +482,12 @@ type servers struct {
 	homeServer             http.Handler
 	pageTempl              http.Handler
 	blueprintServer        http.Handler
+	sharedBlueprintServer  http.Handler
 	coursedetailServer     http.Handler
 	coursesServer          http.Handler
 	degreePlanDetailServer http.Handler
 	degreePlansServer      http.Handler
+	teacherServer          http.Handler
 	static                 http.Handler
 }
 
@@ -352,10 +495,12 @@ const (
 	pageRoot             = "/page/"
 	homeRoot             = "/"
 	blueprintRoot        = "/blueprint/"
+	sharedBlueprintRoot  = "/shared/"
 	courseDetailRoot     = "/course/"
 	coursesRoot          = "/courses/"
 	degreePlanDetailRoot = "/degreeplan/"
 	degreePlansRoot      = "/degreeplans/"
+	teacherRoot          = "/teacher/"
 )
 
 const (
@@ -363,6 +508,11 @@ const (
 	developmentEnvironment = "development"
 )
 
+const (
+	meilisearchBackend = "meilisearch"
+	postgresBackend    = "postgres"
+)
+
 type config struct {
 	Environment string `toml:"environment"`
 	Server      struct {
@@ -380,6 +530,10 @@ type config struct {
 		Host string `toml:"host"`
 		Key  string `toml:"key"`
 	} `toml:"meilisearch"`
+	Search struct {
+		// meilisearch (default) or postgres
+		Backend string `toml:"backend"`
+	} `toml:"search"`
 	Recommender struct {
 		Host string `toml:"host"`
 		Port int    `toml:"port"`
@@ -391,4 +545,8 @@ type config struct {
 		Certificate string `toml:"certificate"`
 		Key         string `toml:"key"`
 	} `toml:"ssl"`
+	BlueprintCache struct {
+		TTL        time.Duration `toml:"ttl"`
+		MaxEntries int           `toml:"max_entries"`
+	} `toml:"blueprint_cache"`
 }
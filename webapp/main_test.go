@@ -1,13 +1,19 @@
 package main
 
 import (
+	"bytes"
 	"crypto/tls"
+	"io"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"regexp"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/michalhercik/RecSIS/blueprint"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -34,8 +40,16 @@ func TestHomeServer(t *testing.T) {
 			"GET", "/cs/home/", http.StatusOK},
 		testCase{"en home path should return 200",
 			"GET", "/en/home/", http.StatusOK},
+		testCase{"mark seen of non-existent saved search should return 200",
+			"POST", "/home/saved-searches/0/seen", http.StatusOK},
 
 		// Errors
+		testCase{"mark seen of invalid saved search should return 400",
+			"POST", "/home/saved-searches/lorem/seen", http.StatusBadRequest},
+		testCase{"delete invalid saved search should return 400",
+			"DELETE", "/home/saved-searches/lorem", http.StatusBadRequest},
+		testCase{"delete non-existent saved search should return 404",
+			"DELETE", "/home/saved-searches/0", http.StatusNotFound},
 		testCase{"root non-existent page should return 404",
 			"GET", "/homer/", http.StatusNotFound},
 		testCase{"root non-existing page should return 404",
@@ -79,8 +93,48 @@ func TestCoursesServer(t *testing.T) {
 			"GET", "/courses/search?page=2", http.StatusOK},
 		testCase{"search load more courses on search should return 200",
 			"GET", "/courses/search?hitsPerPage=10", http.StatusOK},
+		testCase{"courses sorted by credits should return 200",
+			"GET", "/courses/?sort=credits-desc", http.StatusOK},
+		testCase{"search sorted by title on second page should return 200",
+			"GET", "/courses/search?search=programming&sort=title&page=2", http.StatusOK},
+		testCase{"search sorted by rating in english should return 200",
+			"GET", "/en/courses/search?sort=rating", http.StatusOK},
+		testCase{"search sorted by weekly hours should return 200",
+			"GET", "/courses/search?sort=hours-asc&hitsPerPage=10", http.StatusOK},
+		testCase{"search with inline qualifiers should return 200",
+			"GET", "/courses/?search=credits%3E%3D5%20lang%3Aen%20graphs", http.StatusOK},
+		testCase{"search with negated qualifier and teacher should return 200",
+			"GET", "/en/courses/search?search=-lang%3Acze%20teacher%3AMare%C5%A1", http.StatusOK},
 		testCase{"courses add course to blueprint should return 200",
 			"POST", "/courses/blueprint?course=NSWI120&year=0&semester=0", http.StatusOK},
+		testCase{"search in my blueprint should return 200",
+			"GET", "/courses/?my-blueprint=in", http.StatusOK},
+		testCase{"search not in my blueprint with prerequisites satisfied should return 200",
+			"GET", "/courses/search?my-blueprint=out&prerequisites-before=2-1&search=programming", http.StatusOK},
+		testCase{"search in or not in my blueprint should return 200",
+			"GET", "/en/courses/search?my-blueprint=in&my-blueprint=out&prerequisites-before=1-2&prerequisites-before=3-1", http.StatusOK},
+		testCase{"courses personal filters should return 200",
+			"GET", "/courses/personal-filters?search=programming", http.StatusOK},
+		testCase{"courses personal filters with selected value should return 200",
+			"GET", "/en/courses/personal-filters?my-blueprint=in&prerequisites-before=1-2", http.StatusOK},
+		testCase{"courses export in my blueprint should return 200",
+			"GET", "/courses/export?format=csv&my-blueprint=in", http.StatusOK},
+		testCase{"courses save search should return 200",
+			"POST", "/courses/saved-searches?saved-search-name=english&search=lang%3Aen%20graphs&sort=credits-desc", http.StatusOK},
+		testCase{"courses save search without query should return 200",
+			"POST", "/courses/saved-searches?saved-search-name=all", http.StatusOK},
+		testCase{"search unknown qualifier should return 200 with hint",
+			"GET", "/courses/search?search=lorem%3Aipsum", http.StatusOK},
+		testCase{"courses save search with unknown qualifier should return 200",
+			"POST", "/courses/saved-searches?saved-search-name=lorem&search=lorem%3Aipsum", http.StatusOK},
+		testCase{"courses export with unknown qualifier should return 200",
+			"GET", "/courses/export?format=csv&search=lorem%3Aipsum", http.StatusOK},
+		testCase{"courses export csv should return 200",
+			"GET", "/courses/export?format=csv", http.StatusOK},
+		testCase{"courses export xlsx with filters should return 200",
+			"GET", "/courses/export?format=xlsx&search=lang%3Aen%20graphs&sort=code", http.StatusOK},
+		testCase{"courses export xlsx in english should return 200",
+			"GET", "/en/courses/export?format=xlsx&page=3&hitsPerPage=5", http.StatusOK},
 
 		// Errors
 		testCase{"courses non-existent page should return 404",
@@ -115,6 +169,38 @@ func TestCoursesServer(t *testing.T) {
 			"GET", "/courses/search?hitsPerPage=-1", http.StatusBadRequest},
 		testCase{"search zero hitsPerPage should return 400",
 			"GET", "/courses/search?hitsPerPage=0", http.StatusBadRequest},
+		testCase{"search invalid sort should return 400",
+			"GET", "/courses/search?sort=lorem", http.StatusBadRequest},
+		testCase{"search unknown qualifier value should return 200",
+			"GET", "/courses/?search=lang%3Aklingon", http.StatusOK},
+		testCase{"search strict comparison qualifier should return 400",
+			"GET", "/courses/search?search=credits%3E5", http.StatusBadRequest},
+		testCase{"courses save search with existing name should return 409",
+			"POST", "/courses/saved-searches?saved-search-name=english&search=programming", http.StatusConflict},
+		testCase{"courses save search without name should return 400",
+			"POST", "/courses/saved-searches?search=programming", http.StatusBadRequest},
+		testCase{"courses save search with blank name should return 400",
+			"POST", "/courses/saved-searches?saved-search-name=%20%20", http.StatusBadRequest},
+		testCase{"courses save search with GET should return 404",
+			"GET", "/courses/saved-searches?saved-search-name=lorem", http.StatusNotFound},
+		testCase{"search invalid my blueprint value should return 400",
+			"GET", "/courses/search?my-blueprint=maybe", http.StatusBadRequest},
+		testCase{"search unknown degree plan bloc should return 400",
+			"GET", "/courses/search?my-degree-plan=lorem", http.StatusBadRequest},
+		testCase{"courses personal filters unknown degree plan bloc should return 400",
+			"GET", "/courses/personal-filters?my-degree-plan=lorem", http.StatusBadRequest},
+		testCase{"search prerequisites before year zero should return 400",
+			"GET", "/courses/search?prerequisites-before=0-1", http.StatusBadRequest},
+		testCase{"search prerequisites before invalid semester should return 400",
+			"GET", "/courses/?prerequisites-before=1-3", http.StatusBadRequest},
+		testCase{"search prerequisites before non-number should return 400",
+			"GET", "/courses/search?prerequisites-before=lorem", http.StatusBadRequest},
+		testCase{"courses export without format should return 400",
+			"GET", "/courses/export", http.StatusBadRequest},
+		testCase{"courses export with invalid format should return 400",
+			"GET", "/courses/export?format=pdf", http.StatusBadRequest},
+		testCase{"courses export with invalid sort should return 400",
+			"GET", "/courses/export?format=csv&sort=lorem", http.StatusBadRequest},
 		testCase{"courses add same course to blueprint should return 409",
 			"POST", "/courses/blueprint?course=NSWI120&year=0&semester=0", http.StatusConflict},
 		testCase{"courses add course to blueprint without course should return 400",
@@ -193,10 +279,40 @@ func TestCourseDetailServer(t *testing.T) {
 			"DELETE", "/course/rating/NSWI120", http.StatusOK},
 		testCase{"add course to blueprint should return 200",
 			"POST", "/course/blueprint?course=NSWI120&year=0&semester=0", http.StatusOK},
+		testCase{"compare tray should return 200",
+			"GET", "/course/compare/tray", http.StatusOK},
+		testCase{"add NSWI120 to compare tray should return 200",
+			"POST", "/course/compare/tray/NSWI120", http.StatusOK},
+		testCase{"repeated add NSWI120 to compare tray should return 200",
+			"POST", "/course/compare/tray/NSWI120", http.StatusOK},
+		testCase{"compare courses of compare tray should return 200",
+			"GET", "/course/compare", http.StatusOK},
+		testCase{"remove NSWI120 from compare tray should return 200",
+			"DELETE", "/course/compare/tray/NSWI120", http.StatusOK},
+		testCase{"remove course not in compare tray should return 200",
+			"DELETE", "/course/compare/tray/NSWI120", http.StatusOK},
+		testCase{"compare NSWI120 and NPRG024 should return 200",
+			"GET", "/course/compare?codes=NSWI120,NPRG024", http.StatusOK},
+		testCase{"compare four courses should return 200",
+			"GET", "/en/course/compare?codes=NSWI120,NPRG024,NSWI166,NPRG030", http.StatusOK},
+		testCase{"compare repeated course should return 200",
+			"GET", "/course/compare?codes=NSWI120,NSWI120", http.StatusOK},
+		testCase{"course history of NSWI120 should return 200",
+			"GET", "/course/history/NSWI120", http.StatusOK},
+		testCase{"course history of NSWI120 in english should return 200",
+			"GET", "/en/course/history/NSWI120", http.StatusOK},
+		testCase{"course history of non-existent course should return 200",
+			"GET", "/course/history/XXNOTXX", http.StatusOK},
 
 		// Errors
 		testCase{"course detail page for non-existent course should return 404",
 			"GET", "/course/XXNOTXX", http.StatusNotFound},
+		testCase{"compare five courses should return 400",
+			"GET", "/course/compare?codes=NSWI120,NPRG024,NSWI166,NPRG030,NPRG031", http.StatusBadRequest},
+		testCase{"compare non-existent course should return 404",
+			"GET", "/course/compare?codes=NSWI120,XXNOTXX", http.StatusNotFound},
+		testCase{"add non-existent course to compare tray should return 404",
+			"POST", "/course/compare/tray/XXNOTXX", http.StatusNotFound},
 		testCase{"survey for non-existent course should return 404",
 			"GET", "/course/XXNOTXX/survey", http.StatusNotFound},
 		testCase{"next survey for non-existent course should return 404",
@@ -264,6 +380,33 @@ func TestCourseDetailServer(t *testing.T) {
 	runTests(t, tests)
 }
 
+func TestTeacherServer(t *testing.T) {
+	tests := []testRunner{
+		// Happy path
+		testCase{"teacher survey should return 200",
+			"GET", "/teacher/survey/XXNOTXX", http.StatusOK},
+		testCase{"teacher survey with cs should return 200",
+			"GET", "/cs/teacher/survey/XXNOTXX", http.StatusOK},
+		testCase{"teacher survey with en should return 200",
+			"GET", "/en/teacher/survey/XXNOTXX", http.StatusOK},
+		testCase{"next teacher survey should return 200",
+			"GET", "/teacher/survey/next/XXNOTXX?survey-offset=20", http.StatusOK},
+		testCase{"teacher survey with search should return 200",
+			"GET", "/teacher/survey/XXNOTXX?survey-search=lorem", http.StatusOK},
+		// Error path
+		testCase{"non-existent teacher should return 404",
+			"GET", "/teacher/XXNOTXX", http.StatusNotFound},
+		testCase{"non-existent teacher with en should return 404",
+			"GET", "/en/teacher/XXNOTXX", http.StatusNotFound},
+		testCase{"teacher without id should return 404",
+			"GET", "/teacher/", http.StatusNotFound},
+		testCase{"unknown teacher route should return 404",
+			"GET", "/teacher/lorem/ipsum", http.StatusNotFound},
+	}
+
+	runTests(t, tests)
+}
+
 func TestBlueprintServer(t *testing.T) {
 	tests := []testRunner{
 		// Happy path
@@ -305,6 +448,12 @@ func TestBlueprintServer(t *testing.T) {
 		testCaseWithReferer{"blueprint add NDBI046 to third year second semester from degreeplan should return 200",
 			"POST", "/degreeplan/blueprint?course=NDBI046&year=3&semester=2", "/degreeplan/NIPVS19B", http.StatusOK},
 		// =========================================
+		testCase{"blueprint dry run of removing first year first semester should return 200",
+			"DELETE", "/blueprint/courses?type=semester-remove&year=1&semester=1&dry-run=true", http.StatusOK},
+		testCase{"blueprint dry run of removing last year should return 200",
+			"DELETE", "/blueprint/year?unassign=false&dry-run=true", http.StatusOK},
+		testCase{"blueprint dry run of unassigning second year first semester should return 200",
+			"PATCH", "/blueprint/courses?type=semester-unassign&year=2&semester=1&dry-run=true", http.StatusOK},
 		testCase{"blueprint unassign second year first semester should return 200",
 			"PATCH", "/blueprint/courses?type=semester-unassign&year=2&semester=1", http.StatusOK},
 		testCase{"blueprint delete second year second semester should return 200",
@@ -315,6 +464,32 @@ func TestBlueprintServer(t *testing.T) {
 			"PATCH", "/blueprint/fold?year=1&semester=2&folded=false", http.StatusOK},
 		testCase{"blueprint folding second year first semester should return 200",
 			"PATCH", "/blueprint/fold?year=2&semester=1&folded=true", http.StatusOK},
+		testCase{"blueprint export json should return 200",
+			"GET", "/blueprint/export", http.StatusOK},
+		testCase{"blueprint export csv should return 200",
+			"GET", "/blueprint/export?format=csv", http.StatusOK},
+		testCase{"blueprint export pdf should return 200",
+			"GET", "/blueprint/export?format=pdf", http.StatusOK},
+		testCase{"blueprint export pdf in english should return 200",
+			"GET", "/en/blueprint/export?format=pdf", http.StatusOK},
+		testCase{"blueprint schedule preview of unassigned courses should return 200",
+			"GET", "/blueprint/schedule?source=unassigned", http.StatusOK},
+		testCase{"blueprint schedule preview of degree plan courses should return 200",
+			"GET", "/blueprint/schedule?source=degree-plan&cap=25&years=4", http.StatusOK},
+		testCase{"blueprint workload should return 200",
+			"GET", "/blueprint/workload", http.StatusOK},
+		testCase{"blueprint workload json with target should return 200",
+			"GET", "/blueprint/workload?format=json&target=25", http.StatusOK},
+		testCase{"blueprint undo last change should return 200",
+			"POST", "/blueprint/undo", http.StatusOK},
+		testCase{"blueprint redo undone change should return 200",
+			"POST", "/blueprint/redo", http.StatusOK},
+		testCase{"blueprint redo without undone change should return 409",
+			"POST", "/blueprint/redo", http.StatusConflict},
+		testCase{"blueprint add custom course should return 200",
+			"POST", "/blueprint/custom?custom-title=Erasmus%20course&custom-credits=5&custom-semester=1", http.StatusOK},
+		testCase{"blueprint add custom course mapped to NPRG030 should return 200",
+			"POST", "/blueprint/custom?custom-title=Programming&custom-credits=5&custom-semester=3&custom-mapping=NPRG030", http.StatusOK},
 
 		// Errors
 		testCase{"blueprint page for non-existent page should return 404",
@@ -327,6 +502,8 @@ func TestBlueprintServer(t *testing.T) {
 			"DELETE", "/blueprint/year?unassign=", http.StatusBadRequest},
 		testCase{"blueprint remove year with invalid unassign should return 400",
 			"DELETE", "/blueprint/year?unassign=lorem", http.StatusBadRequest},
+		testCase{"blueprint remove year with invalid dry run should return 400",
+			"DELETE", "/blueprint/year?unassign=true&dry-run=lorem", http.StatusBadRequest},
 		testCase{"blueprint fold without year should return 400",
 			"PATCH", "/blueprint/fold?semester=1&folded=true", http.StatusBadRequest},
 		testCase{"blueprint fold with empty year should return 400",
@@ -351,6 +528,18 @@ func TestBlueprintServer(t *testing.T) {
 			"PATCH", "/blueprint/fold?year=1&semester=1&folded=lorem", http.StatusBadRequest},
 		testCase{"blueprint move course with bad ID should return 400",
 			"PATCH", "/blueprint/course/987654321?year=1&semester=1&position=-1", http.StatusBadRequest},
+		testCase{"blueprint add custom course without title should return 400",
+			"POST", "/blueprint/custom?custom-credits=5&custom-semester=1", http.StatusBadRequest},
+		testCase{"blueprint add custom course with too many credits should return 400",
+			"POST", "/blueprint/custom?custom-title=lorem&custom-credits=99&custom-semester=1", http.StatusBadRequest},
+		testCase{"blueprint add custom course with invalid semester should return 400",
+			"POST", "/blueprint/custom?custom-title=lorem&custom-credits=5&custom-semester=4", http.StatusBadRequest},
+		testCase{"blueprint add custom course mapped to unknown course should return 400",
+			"POST", "/blueprint/custom?custom-title=lorem&custom-credits=5&custom-semester=1&custom-mapping=LOREM", http.StatusBadRequest},
+		testCase{"blueprint edit custom course with invalid ID should return 400",
+			"PATCH", "/blueprint/custom/lorem?custom-title=lorem&custom-credits=5&custom-semester=1", http.StatusBadRequest},
+		testCase{"blueprint edit non-existent custom course should return 404",
+			"PATCH", "/blueprint/custom/987654321?custom-title=lorem&custom-credits=5&custom-semester=1", http.StatusNotFound},
 		testCase{"blueprint move courses without type should return 400",
 			"PATCH", "/blueprint/courses", http.StatusBadRequest},
 		testCase{"blueprint move courses with empty type should return 400",
@@ -419,11 +608,297 @@ func TestBlueprintServer(t *testing.T) {
 			"DELETE", "/blueprint/courses?type=semester-remove&year=1&semester=5", http.StatusBadRequest},
 		testCase{"blueprint remove courses without selected should return 400",
 			"DELETE", "/blueprint/courses?type=selected-remove", http.StatusBadRequest},
+		testCase{"blueprint export with invalid format should return 400",
+			"GET", "/blueprint/export?format=xml", http.StatusBadRequest},
+		testCase{"blueprint import without mode should return 400",
+			"POST", "/blueprint/import", http.StatusBadRequest},
+		testCase{"blueprint import with invalid mode should return 400",
+			"POST", "/blueprint/import?mode=lorem", http.StatusBadRequest},
+		testCase{"blueprint import without file should return 400",
+			"POST", "/blueprint/import?mode=merge", http.StatusBadRequest},
+		testCase{"blueprint set status of non-existent course should return 400",
+			"PATCH", "/blueprint/course/987654321/status?status=passed", http.StatusBadRequest},
+		testCase{"blueprint set status with invalid course ID should return 400",
+			"PATCH", "/blueprint/course/lorem/status?status=passed", http.StatusBadRequest},
+		testCase{"blueprint set status without status should return 400",
+			"PATCH", "/blueprint/course/987654321/status", http.StatusBadRequest},
+		testCase{"blueprint set invalid status should return 400",
+			"PATCH", "/blueprint/course/987654321/status?status=lorem", http.StatusBadRequest},
+		testCase{"blueprint set status with invalid grade should return 400",
+			"PATCH", "/blueprint/course/987654321/status?status=passed&grade=5", http.StatusBadRequest},
+		testCase{"blueprint set status with invalid completion date should return 400",
+			"PATCH", "/blueprint/course/987654321/status?status=passed&completed=lorem", http.StatusBadRequest},
+		testCase{"blueprint schedule preview without source should return 400",
+			"GET", "/blueprint/schedule", http.StatusBadRequest},
+		testCase{"blueprint schedule preview with invalid source should return 400",
+			"GET", "/blueprint/schedule?source=lorem", http.StatusBadRequest},
+		testCase{"blueprint schedule preview with invalid credit cap should return 400",
+			"GET", "/blueprint/schedule?source=unassigned&cap=0", http.StatusBadRequest},
+		testCase{"blueprint schedule preview with invalid years should return 400",
+			"GET", "/blueprint/schedule?source=unassigned&years=lorem", http.StatusBadRequest},
+		testCase{"blueprint workload with invalid target should return 400",
+			"GET", "/blueprint/workload?target=lorem", http.StatusBadRequest},
+		testCase{"blueprint workload with invalid format should return 400",
+			"GET", "/blueprint/workload?format=csv", http.StatusBadRequest},
+		testCase{"blueprint schedule accept without placements should return 400",
+			"POST", "/blueprint/schedule", http.StatusBadRequest},
+		testCase{"blueprint schedule accept with invalid placement should return 400",
+			"POST", "/blueprint/schedule?placement=NPRG030:1", http.StatusBadRequest},
+		testCase{"blueprint schedule accept with invalid semester should return 400",
+			"POST", "/blueprint/schedule?placement=NPRG030:1:3", http.StatusBadRequest},
+		testCase{"blueprint schedule accept with unknown course should return 400",
+			"POST", "/blueprint/schedule?placement=LOREM:1:1", http.StatusBadRequest},
+		testCase{"blueprint apply fix without action should return 400",
+			"POST", "/blueprint/fix", http.StatusBadRequest},
+		testCase{"blueprint apply fix with invalid action should return 400",
+			"POST", "/blueprint/fix?fix=lorem&course=1", http.StatusBadRequest},
+		testCase{"blueprint apply move fix with invalid course ID should return 400",
+			"POST", "/blueprint/fix?fix=move&course=lorem&year=1&semester=1", http.StatusBadRequest},
+		testCase{"blueprint apply move fix without target should return 400",
+			"POST", "/blueprint/fix?fix=move&course=1", http.StatusBadRequest},
+		testCase{"blueprint apply move fix with inconsistent target should return 400",
+			"POST", "/blueprint/fix?fix=move&course=1&year=1&semester=0", http.StatusBadRequest},
+		testCase{"blueprint apply add fix without code should return 400",
+			"POST", "/blueprint/fix?fix=add&year=0&semester=0", http.StatusBadRequest},
+		testCase{"blueprint apply add fix with unknown course should return 400",
+			"POST", "/blueprint/fix?fix=add&code=LOREM&year=0&semester=0", http.StatusBadRequest},
+		testCase{"blueprint apply remove fix of non-existent course should return 409",
+			"POST", "/blueprint/fix?fix=remove&course=987654321", http.StatusConflict},
+		testCase{"blueprint apply move fix of non-existent course should return 409",
+			"POST", "/blueprint/fix?fix=move&course=987654321&year=1&semester=1", http.StatusConflict},
+		testCase{"blueprint shares page should return 200",
+			"GET", "/blueprint/shares", http.StatusOK},
+		testCase{"blueprint create share should return 200",
+			"POST", "/blueprint/shares?days=7", http.StatusOK},
+		testCase{"blueprint create share with default expiration should return 200",
+			"POST", "/blueprint/shares", http.StatusOK},
+		testCase{"blueprint create share with invalid expiration should return 400",
+			"POST", "/blueprint/shares?days=lorem", http.StatusBadRequest},
+		testCase{"blueprint create share with too long expiration should return 400",
+			"POST", "/blueprint/shares?days=366", http.StatusBadRequest},
+		testCase{"blueprint revoke share with invalid ID should return 400",
+			"DELETE", "/blueprint/shares/lorem", http.StatusBadRequest},
+		testCase{"blueprint revoke non-existent share should return 404",
+			"DELETE", "/blueprint/shares/987654321", http.StatusNotFound},
+		testCase{"blueprint set start year should return 200",
+			"PATCH", "/blueprint/start-year?start=2023", http.StatusOK},
+		testCase{"blueprint clear start year should return 200",
+			"PATCH", "/blueprint/start-year?start=", http.StatusOK},
+		testCase{"blueprint set invalid start year should return 400",
+			"PATCH", "/blueprint/start-year?start=lorem", http.StatusBadRequest},
+		testCase{"blueprint set too early start year should return 400",
+			"PATCH", "/blueprint/start-year?start=1900", http.StatusBadRequest},
+		testCase{"blueprint restore history with invalid ID should return 400",
+			"POST", "/blueprint/history/lorem", http.StatusBadRequest},
+		testCase{"blueprint restore non-existent history should return 404",
+			"POST", "/blueprint/history/987654321", http.StatusNotFound},
+		// Scenarios
+		testCase{"blueprint create scenario should return 200",
+			"POST", "/blueprint/scenario?name=Plan%20B", http.StatusOK},
+		testCase{"blueprint create scenario with duplicate name should return 409",
+			"POST", "/blueprint/scenario?name=Plan%20B", http.StatusConflict},
+		testCase{"blueprint create scenario without name should return 400",
+			"POST", "/blueprint/scenario", http.StatusBadRequest},
+		testCase{"blueprint create scenario with blank name should return 400",
+			"POST", "/blueprint/scenario?name=%20%20", http.StatusBadRequest},
+		testCase{"blueprint create scenario with too long name should return 400",
+			"POST", "/blueprint/scenario?name=" + strings.Repeat("a", 101), http.StatusBadRequest},
+		testCase{"blueprint clone scenario with invalid source should return 400",
+			"POST", "/blueprint/scenario?name=Copy&from=lorem", http.StatusBadRequest},
+		testCase{"blueprint clone non-existent scenario should return 404",
+			"POST", "/blueprint/scenario?name=Copy&from=987654321", http.StatusNotFound},
+		testCase{"blueprint rename non-existent scenario should return 404",
+			"PATCH", "/blueprint/scenario/987654321?name=Lorem", http.StatusNotFound},
+		testCase{"blueprint rename scenario with invalid ID should return 400",
+			"PATCH", "/blueprint/scenario/lorem?name=Lorem", http.StatusBadRequest},
+		testCase{"blueprint activate non-existent scenario should return 404",
+			"PATCH", "/blueprint/scenario/987654321/active", http.StatusNotFound},
+		testCase{"blueprint delete non-existent scenario should return 404",
+			"DELETE", "/blueprint/scenario/987654321", http.StatusNotFound},
+		testCase{"blueprint delete scenario with invalid ID should return 400",
+			"DELETE", "/blueprint/scenario/lorem", http.StatusBadRequest},
+		testCase{"blueprint page with new scenario should return 200",
+			"GET", "/blueprint/", http.StatusOK},
 	}
 
 	runTests(t, tests)
 }
 
+func TestSharedBlueprintServer(t *testing.T) {
+	tests := []testRunner{
+		// Errors
+		testCase{"shared blueprint without token should return 404",
+			"GET", "/shared/", http.StatusNotFound},
+		testCase{"shared blueprint with malformed token should return 404",
+			"GET", "/shared/lorem", http.StatusNotFound},
+		testCase{"shared blueprint with forged token should return 404",
+			"GET", "/shared/AAAAAAAAAAAAAAAAAAAAAA.9999999999.lorem", http.StatusNotFound},
+		testCase{"en shared blueprint with forged token should return 404",
+			"GET", "/en/shared/AAAAAAAAAAAAAAAAAAAAAA.9999999999.lorem", http.StatusNotFound},
+	}
+	runTests(t, tests)
+}
+
+// TestSharedBlueprintLink creates a share, opens it without a session cookie
+// and checks that the link stops working after it is revoked.
+func TestSharedBlueprintLink(t *testing.T) {
+	ts := setupTestServer(t)
+	defer ts.Close()
+	client := ts.Client()
+	client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+	sessionCookie := setupTestUser(ts, t)
+
+	do := func(method, url string, cookie *http.Cookie) (int, string) {
+		req, err := http.NewRequest(method, url, nil)
+		if err != nil {
+			t.Fatalf("Failed to create request: %v", err)
+		}
+		if cookie != nil {
+			req.AddCookie(cookie)
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, string(body)
+	}
+
+	code, body := do("POST", ts.URL+"/blueprint/shares", sessionCookie)
+	assert.Equal(t, http.StatusOK, code)
+	link := regexp.MustCompile(`/shared/[A-Za-z0-9_-]+\.[0-9]+\.[A-Za-z0-9_-]+`).FindString(body)
+	if link == "" {
+		t.Fatalf("Share link not found in response")
+	}
+	shareID := regexp.MustCompile(`/blueprint/shares/([0-9]+)`).FindStringSubmatch(body)
+	if shareID == nil {
+		t.Fatalf("Share ID not found in response")
+	}
+
+	code, body = do("GET", ts.URL+link, nil)
+	assert.Equal(t, http.StatusOK, code)
+	assert.NotContains(t, body, testUserID)
+	assert.NotContains(t, body, "hx-delete")
+
+	code, _ = do("DELETE", ts.URL+"/blueprint/shares/"+shareID[1], sessionCookie)
+	assert.Equal(t, http.StatusOK, code)
+	code, _ = do("GET", ts.URL+link, nil)
+	assert.Equal(t, http.StatusNotFound, code)
+}
+
+// TestBlueprintVersion checks that a change made against an outdated version
+// of the blueprint (e.g. from another tab) is rejected.
+func TestBlueprintVersion(t *testing.T) {
+	ts := setupTestServer(t)
+	defer ts.Close()
+	client := ts.Client()
+	sessionCookie := setupTestUser(ts, t)
+
+	do := func(method, url, version string) (int, string) {
+		req, err := http.NewRequest(method, url, nil)
+		if err != nil {
+			t.Fatalf("Failed to create request: %v", err)
+		}
+		req.AddCookie(sessionCookie)
+		if version != "" {
+			req.Header.Set("X-Blueprint-Version", version)
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, string(body)
+	}
+	versionPattern := regexp.MustCompile(`X-Blueprint-Version&#34;: &#34;([0-9]+)`)
+
+	code, body := do("GET", ts.URL+"/blueprint", "")
+	assert.Equal(t, http.StatusOK, code)
+	version := versionPattern.FindStringSubmatch(body)
+	if version == nil {
+		t.Fatalf("Blueprint version not found in page")
+	}
+
+	code, body = do("POST", ts.URL+"/blueprint/year", version[1])
+	assert.Equal(t, http.StatusOK, code)
+	current := versionPattern.FindStringSubmatch(body)
+	if current == nil {
+		t.Fatalf("Blueprint version not found in response")
+	}
+	assert.NotEqual(t, version[1], current[1])
+
+	code, body = do("DELETE", ts.URL+"/blueprint/year?unassign=true", version[1])
+	assert.Equal(t, http.StatusConflict, code)
+	assert.Contains(t, body, "alert-warning")
+	code, _ = do("DELETE", ts.URL+"/blueprint/year?unassign=true", current[1])
+	assert.Equal(t, http.StatusOK, code)
+	code, _ = do("POST", ts.URL+"/blueprint/year", "lorem")
+	assert.Equal(t, http.StatusBadRequest, code)
+}
+
+func TestBlueprintTransferCustomCourse(t *testing.T) {
+	ts := setupTestServer(t)
+	defer ts.Close()
+	client := ts.Client()
+	sessionCookie := setupTestUser(ts, t)
+
+	do := func(req *http.Request) (int, string) {
+		req.AddCookie(sessionCookie)
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, string(body)
+	}
+	get := func(method, url string) (int, string) {
+		req, err := http.NewRequest(method, url, nil)
+		if err != nil {
+			t.Fatalf("Failed to create request: %v", err)
+		}
+		return do(req)
+	}
+	upload := func(url, content string) (int, string) {
+		var buf bytes.Buffer
+		form := multipart.NewWriter(&buf)
+		file, err := form.CreateFormFile("file", "blueprint")
+		if err != nil {
+			t.Fatalf("Failed to create form file: %v", err)
+		}
+		file.Write([]byte(content))
+		form.Close()
+		req, err := http.NewRequest("POST", url, &buf)
+		if err != nil {
+			t.Fatalf("Failed to create request: %v", err)
+		}
+		req.Header.Set("Content-Type", form.FormDataContentType())
+		return do(req)
+	}
+	const title = "Exchange course"
+
+	code, _ := get("POST", ts.URL+"/blueprint/custom?custom-title=Exchange%20course&custom-credits=4&custom-semester=2&custom-mapping=NPRG030")
+	assert.Equal(t, http.StatusOK, code)
+
+	for _, format := range []string{"json", "csv"} {
+		code, exported := get("GET", ts.URL+"/blueprint/export?format="+format)
+		assert.Equal(t, http.StatusOK, code)
+		assert.Contains(t, exported, title)
+
+		code, body := upload(ts.URL+"/blueprint/import?mode=replace", exported)
+		assert.Equal(t, http.StatusOK, code, format)
+		assert.Contains(t, body, title, format)
+
+		code, reexported := get("GET", ts.URL+"/blueprint/export?format="+format)
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, exported, reexported, format)
+	}
+}
+
 func TestDegreePlanDetailServer(t *testing.T) {
 	tests := []testRunner{
 		// Happy path
@@ -475,6 +950,16 @@ func TestDegreePlanDetailServer(t *testing.T) {
 			"POST", "/degreeplan/blueprint?course=NSWI120&year=0&semester=0", "/degreeplan/NIPVS19B", http.StatusOK},
 		testCaseWithReferer{"degree plan add courses to blueprint should return 200",
 			"POST", "/degreeplan/blueprint?selected-courses=NSWI035&selected-courses=NPRG024&year=0&semester=0", "/degreeplan/NIPVS19B", http.StatusOK},
+		testCase{"degree plan diff with blueprint should return 200",
+			"GET", "/degreeplan/diff/NIPVS19B", http.StatusOK},
+		testCase{"degree plan diff with blueprint for one year plan should return 200",
+			"GET", "/degreeplan/diff/NISD23N", http.StatusOK},
+		testCase{"degree plan diff with blueprint for empty recommended plan should return 200",
+			"GET", "/degreeplan/diff/NIUI20N", http.StatusOK},
+		testCase{"degree plan pdf should return 200",
+			"GET", "/degreeplan/pdf/NIPVS19B", http.StatusOK},
+		testCase{"degree plan pdf in english should return 200",
+			"GET", "/en/degreeplan/pdf/NIPVS19B", http.StatusOK},
 
 		// remove BP years
 		testCase{"blueprint remove third year should return 200",
@@ -511,6 +996,14 @@ func TestDegreePlanDetailServer(t *testing.T) {
 			"POST", "/degreeplan/blueprint?course=NSWI120&year=0&semester=asdf", "/degreeplan/NIPVS19B", http.StatusBadRequest},
 		testCaseWithReferer{"degree plan add course to blueprint with negative semester should return 400",
 			"POST", "/degreeplan/blueprint?course=NSWI120&year=0&semester=-1", "/degreeplan/NIPVS19B", http.StatusBadRequest},
+		testCase{"degree plan diff for non-existent plan should return 404",
+			"GET", "/degreeplan/diff/lorem", http.StatusNotFound},
+		testCase{"degree plan pdf for non-existent plan should return 404",
+			"GET", "/degreeplan/pdf/lorem", http.StatusNotFound},
+		testCase{"degree plan diff apply without selected items should return 400",
+			"POST", "/degreeplan/diff/NIPVS19B", http.StatusBadRequest},
+		testCase{"degree plan diff apply with unknown item should return 409",
+			"POST", "/degreeplan/diff/NIPVS19B?diff-item=missing:XXNOTXX:1:1", http.StatusConflict},
 		testCaseWithReferer{"degree plan rewrite blueprint with empty recommended plan should return 400",
 			"PUT", "/degreeplan/plan-to-blueprint/NIUI20N?maxYear=3", "/degreeplan/NIUI20N", http.StatusBadRequest},
 		testCaseWithReferer{"degree plan rewrite blueprint with non-existing plan should return 400",
@@ -608,6 +1101,10 @@ func TestPageServer(t *testing.T) {
 			"GET", "/page/quicksearch?q=peska", http.StatusOK},
 		testCase{"quicksearching random text should return 200",
 			"GET", "/page/quicksearch?q=asagafe45", http.StatusOK},
+		testCase{"quicksearching with inline qualifiers should return 200",
+			"GET", "/page/quicksearch?search=credits%3E%3D5%20lang%3Aen%20graphs", http.StatusOK},
+		testCase{"quicksearching with unknown qualifier should return 200 with message",
+			"GET", "/page/quicksearch?search=lorem%3Aipsum", http.StatusOK},
 		testCase{"quicksearching empty query should return 200",
 			"GET", "/page/quicksearch?q=", http.StatusOK},
 		testCase{"quicksearching with missing query equals empty query should return 200",
@@ -617,6 +1114,92 @@ func TestPageServer(t *testing.T) {
 	runTests(t, tests)
 }
 
+// TestFullTextSearch runs search pages with PostgreSQL full-text search
+// instead of Meilisearch.
+func TestFullTextSearch(t *testing.T) {
+	tests := []testRunner{
+		// Happy path
+		testCase{"full-text courses search should return 200",
+			"GET", "/courses/search?search=programming", http.StatusOK},
+		testCase{"full-text courses search with empty query should return 200",
+			"GET", "/courses/search?search=", http.StatusOK},
+		testCase{"full-text courses search with prefix should return 200",
+			"GET", "/en/courses/search?search=prog%20jaz", http.StatusOK},
+		testCase{"full-text courses search with inline qualifiers should return 200",
+			"GET", "/courses/?search=credits%3E%3D5%20lang%3Aen%20graphs", http.StatusOK},
+		testCase{"full-text courses search with negated qualifier should return 200",
+			"GET", "/en/courses/search?search=-lang%3Acze", http.StatusOK},
+		testCase{"full-text courses search sorted should return 200",
+			"GET", "/courses/search?search=programming&sort=title&page=2", http.StatusOK},
+		testCase{"full-text courses search sorted by rating should return 200",
+			"GET", "/en/courses/search?sort=rating", http.StatusOK},
+		testCase{"full-text degree plan search should return 200",
+			"GET", "/degreeplans/search?search-dp-query=software", http.StatusOK},
+		testCase{"full-text degree plan search by code should return 200",
+			"GET", "/degreeplans/?search-dp-query=NIPVS19B", http.StatusOK},
+		testCase{"full-text quicksearch should return 200",
+			"GET", "/page/quicksearch?q=NSWI153", http.StatusOK},
+		testCase{"full-text quicksearch with inline qualifiers should return 200",
+			"GET", "/page/quicksearch?search=credits%3E%3D5%20lang%3Aen%20graphs", http.StatusOK},
+		testCase{"full-text course survey should return 200",
+			"GET", "/course/survey/NSWI120", http.StatusOK},
+		testCase{"full-text teacher survey with search should return 200",
+			"GET", "/teacher/survey/XXNOTXX?survey-search=lorem", http.StatusOK},
+
+		// Errors
+		testCase{"full-text courses search with invalid sort should return 400",
+			"GET", "/courses/search?sort=lorem", http.StatusBadRequest},
+	}
+
+	conf := configFrom("./config.dev.toml")
+	conf.Search.Backend = postgresBackend
+	runTestsWithConfig(t, tests, conf)
+}
+
+//================================================================================
+// Benchmarks
+//================================================================================
+
+// BenchmarkBlueprintPage compares rendering of the blueprint page served from
+// the blueprint cache with loading it from the database on every request.
+func BenchmarkBlueprintPage(b *testing.B) {
+	b.Run("cached", func(b *testing.B) {
+		benchmarkBlueprintPage(b, blueprint.DefaultCacheTTL)
+	})
+	b.Run("uncached", func(b *testing.B) {
+		// entries expire before the next request
+		benchmarkBlueprintPage(b, time.Nanosecond)
+	})
+}
+
+func benchmarkBlueprintPage(b *testing.B, ttl time.Duration) {
+	conf := configFrom("./config.dev.toml")
+	conf.BlueprintCache.TTL = ttl
+	ts := setupTestServerWithConfig(b, conf)
+	defer ts.Close()
+
+	client := ts.Client()
+	sessionCookie := setupTestUser(ts, b)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		req, err := http.NewRequest("GET", ts.URL+"/blueprint/", nil)
+		if err != nil {
+			b.Fatalf("Failed to create request: %v", err)
+		}
+		req.AddCookie(sessionCookie)
+		resp, err := client.Do(req)
+		if err != nil {
+			b.Fatalf("Request failed: %v", err)
+		}
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			b.Fatalf("Unexpected status code: %d", resp.StatusCode)
+		}
+	}
+}
+
 //================================================================================
 // Test Utilities
 //================================================================================
@@ -657,7 +1240,11 @@ func (tc testCaseWithReferer) getReferer() string { return tc.referer }
 func (tc testCaseWithReferer) getWant() int       { return tc.want }
 
 func runTests(t *testing.T, tests []testRunner) {
-	ts := setupTestServer(t)
+	runTestsWithConfig(t, tests, configFrom("./config.dev.toml"))
+}
+
+func runTestsWithConfig(t *testing.T, tests []testRunner, conf config) {
+	ts := setupTestServerWithConfig(t, conf)
 	defer ts.Close()
 
 	client := ts.Client()
@@ -686,14 +1273,17 @@ func runTests(t *testing.T, tests []testRunner) {
 	}
 }
 
-func setupTestServer(t *testing.T) *httptest.Server {
-	conf := configFrom("./config.dev.toml")
+func setupTestServer(t testing.TB) *httptest.Server {
+	return setupTestServerWithConfig(t, configFrom("./config.dev.toml"))
+}
+
+func setupTestServerWithConfig(t testing.TB, conf config) *httptest.Server {
 	handler := setupHandler(conf)
 	removeTestUserFromDB(t, conf)
 	return httptest.NewTLSServer(handler)
 }
 
-func removeTestUserFromDB(t *testing.T, conf config) {
+func removeTestUserFromDB(t testing.TB, conf config) {
 	db := setupDB(conf)
 	defer db.Close()
 	_, err := db.Exec("DELETE FROM users WHERE id = $1", testUserID)
@@ -702,7 +1292,7 @@ func removeTestUserFromDB(t *testing.T, conf config) {
 	}
 }
 
-func setupTestUser(ts *httptest.Server, t *testing.T) *http.Cookie {
+func setupTestUser(ts *httptest.Server, t testing.TB) *http.Cookie {
 	insecureClient := &http.Client{
 		Transport: &http.Transport{
 			TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
@@ -0,0 +1,192 @@
+package pdf
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+type font int
+
+const (
+	regular font = iota
+	bold
+)
+
+// resource returns the name of the font in page resources.
+func (f font) resource() string {
+	if f == bold {
+		return "/F2"
+	}
+	return "/F1"
+}
+
+func (f font) baseFont() string {
+	if f == bold {
+		return "/Helvetica-Bold"
+	}
+	return "/Helvetica"
+}
+
+// Widths of printable ASCII characters (32-126) in 1/1000 of the font size
+// taken from the Adobe font metrics of the standard fonts.
+var (
+	regularWidths = [95]int{
+		278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278,
+		556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556,
+		1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778,
+		667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556,
+		333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556,
+		556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584,
+	}
+	boldWidths = [95]int{
+		278, 333, 474, 556, 556, 889, 722, 238, 333, 333, 389, 584, 278, 333, 278, 278,
+		556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 333, 333, 584, 584, 584, 611,
+		975, 722, 722, 722, 722, 667, 611, 778, 722, 278, 556, 722, 611, 833, 722, 778,
+		667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 333, 278, 333, 584, 556,
+		333, 556, 611, 556, 611, 556, 333, 611, 611, 278, 278, 556, 278, 889, 611, 611,
+		611, 611, 389, 556, 333, 611, 556, 778, 556, 556, 500, 389, 280, 389, 584,
+	}
+)
+
+// glyph is a character outside of ASCII which the standard fonts can draw.
+// Characters are encoded from code 128 upwards in the order of glyphs (see
+// the /Differences array of the font encoding).
+type glyph struct {
+	char rune
+	name string
+	// width of the glyph in the regular and the bold font
+	regular int
+	bold    int
+}
+
+var glyphs = []glyph{
+	{'á', "aacute", 556, 556}, {'Á', "Aacute", 667, 722},
+	{'č', "ccaron", 500, 556}, {'Č', "Ccaron", 722, 722},
+	{'ď', "dcaron", 643, 743}, {'Ď', "Dcaron", 722, 722},
+	{'é', "eacute", 556, 556}, {'É', "Eacute", 667, 667},
+	{'ě', "ecaron", 556, 556}, {'Ě', "Ecaron", 667, 667},
+	{'í', "iacute", 278, 278}, {'Í', "Iacute", 278, 278},
+	{'ň', "ncaron", 556, 611}, {'Ň', "Ncaron", 722, 722},
+	{'ó', "oacute", 556, 611}, {'Ó', "Oacute", 778, 778},
+	{'ř', "rcaron", 333, 389}, {'Ř', "Rcaron", 722, 722},
+	{'š', "scaron", 500, 556}, {'Š', "Scaron", 667, 667},
+	{'ť', "tcaron", 316, 389}, {'Ť', "Tcaron", 611, 611},
+	{'ú', "uacute", 556, 611}, {'Ú', "Uacute", 722, 722},
+	{'ů', "uring", 556, 611}, {'Ů', "Uring", 722, 722},
+	{'ý', "yacute", 500, 556}, {'Ý', "Yacute", 667, 667},
+	{'ž', "zcaron", 500, 500}, {'Ž', "Zcaron", 611, 611},
+	{'ä', "adieresis", 556, 556}, {'Ä', "Adieresis", 667, 722},
+	{'ô', "ocircumflex", 556, 611}, {'Ô', "Ocircumflex", 778, 778},
+	{'ľ', "lcaron", 299, 400}, {'Ľ', "Lcaron", 556, 611},
+	{'ĺ', "lacute", 222, 278}, {'Ĺ', "Lacute", 556, 611},
+	{'ŕ', "racute", 333, 389}, {'Ŕ', "Racute", 722, 722},
+	{'ö', "odieresis", 556, 611}, {'Ö', "Odieresis", 778, 778},
+	{'ü', "udieresis", 556, 611}, {'Ü', "Udieresis", 722, 722},
+	{'–', "endash", 556, 556}, {'—', "emdash", 1000, 1000},
+	{'„', "quotedblbase", 333, 500}, {'“', "quotedblleft", 333, 500},
+	{'”', "quotedblright", 333, 500}, {'‘', "quoteleft", 222, 278},
+	{'’', "quoteright", 222, 278}, {'•', "bullet", 350, 350},
+	{'…', "ellipsis", 1000, 1000}, {'×', "multiply", 584, 584},
+	{'·', "periodcentered", 278, 278}, {'°', "degree", 400, 400},
+}
+
+const firstGlyphCode = 128
+
+var glyphCodes = func() map[rune]byte {
+	codes := make(map[rune]byte, len(glyphs))
+	for i, g := range glyphs {
+		codes[g.char] = byte(firstGlyphCode + i)
+	}
+	return codes
+}()
+
+// encode converts s to the single byte encoding of the fonts. Characters
+// which cannot be drawn are replaced by '?'.
+func encode(s string) []byte {
+	result := make([]byte, 0, len(s))
+	for _, r := range s {
+		switch {
+		case r >= 32 && r <= 126:
+			result = append(result, byte(r))
+		case r == '\t' || r == '\n' || r == '\u00a0':
+			result = append(result, ' ')
+		default:
+			if code, ok := glyphCodes[r]; ok {
+				result = append(result, code)
+			} else {
+				result = append(result, '?')
+			}
+		}
+	}
+	return result
+}
+
+// width returns the width of s in points.
+func (f font) width(s string, size float64) float64 {
+	total := 0
+	for _, c := range encode(s) {
+		total += f.charWidth(c)
+	}
+	return float64(total) * size / 1000
+}
+
+func (f font) charWidth(c byte) int {
+	if c >= firstGlyphCode && int(c-firstGlyphCode) < len(glyphs) {
+		g := glyphs[c-firstGlyphCode]
+		if f == bold {
+			return g.bold
+		}
+		return g.regular
+	}
+	if c < 32 || c > 126 {
+		c = '?'
+	}
+	if f == bold {
+		return boldWidths[c-32]
+	}
+	return regularWidths[c-32]
+}
+
+// wrap splits s into lines not wider than width. Words longer than a line
+// are split.
+func (f font) wrap(s string, size, width float64) []string {
+	var lines []string
+	line := ""
+	for _, word := range strings.Fields(s) {
+		candidate := word
+		if line != "" {
+			candidate = line + " " + word
+		}
+		if f.width(candidate, size) <= width {
+			line = candidate
+			continue
+		}
+		if line != "" {
+			lines = append(lines, line)
+		}
+		line = word
+		for f.width(line, size) > width && utf8.RuneCountInString(line) > 1 {
+			cut := f.fit(line, size, width)
+			lines = append(lines, line[:cut])
+			line = line[cut:]
+		}
+	}
+	if line != "" || len(lines) == 0 {
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// fit returns the byte length of the longest prefix of s (at least one
+// character) not wider than width.
+func (f font) fit(s string, size, width float64) int {
+	end := 0
+	for i, r := range s {
+		next := i + utf8.RuneLen(r)
+		if end > 0 && f.width(s[:next], size) > width {
+			break
+		}
+		end = next
+	}
+	return end
+}
@@ -0,0 +1,323 @@
+package pdf
+
+/** PACKAGE DESCRIPTION
+
+The pdf package generates simple printable documents (headings, paragraphs and tables flowing over A4 pages) without any external tool. Documents use the standard Helvetica fonts which every PDF reader provides, so no font is embedded. The fonts are encoded to support Czech and Slovak characters; other characters outside of ASCII are printed as '?'.
+
+Content is added from top to bottom and pages are broken automatically. Table headers are repeated on every page the table continues on. Every page has a footer with the document title and the page number.
+
+*/
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf16"
+)
+
+//================================================================================
+// Constants
+//================================================================================
+
+// A4 portrait in points
+const (
+	pageWidth    = 595.28
+	pageHeight   = 841.89
+	margin       = 42.0
+	contentWidth = pageWidth - 2*margin
+	// bottom of the content, the footer is below
+	contentBottom = pageHeight - margin - 14
+)
+
+const (
+	titleSize   = 16.0
+	headingSize = 12.0
+	textSize    = 9.5
+	noteSize    = 8.0
+	footerSize  = 7.5
+	lineSpacing = 1.3
+	cellPadding = 3.0
+)
+
+type Align int
+
+const (
+	AlignLeft Align = iota
+	AlignRight
+)
+
+//================================================================================
+// Document
+//================================================================================
+
+// Document is a PDF document being built. The zero value is not usable, use
+// New.
+type Document struct {
+	title string
+	pages []*bytes.Buffer
+	// y is the distance of the next content from the top of the page
+	y float64
+}
+
+func New(title string) *Document {
+	d := &Document{title: title}
+	d.newPage()
+	return d
+}
+
+func (d *Document) page() *bytes.Buffer {
+	return d.pages[len(d.pages)-1]
+}
+
+func (d *Document) newPage() {
+	d.pages = append(d.pages, &bytes.Buffer{})
+	d.y = margin
+}
+
+// ensure breaks the page if less than height is left on the current one.
+func (d *Document) ensure(height float64) bool {
+	if d.y+height > contentBottom && d.y > margin {
+		d.newPage()
+		return true
+	}
+	return false
+}
+
+// Title adds the main title of the document.
+func (d *Document) Title(text string) {
+	d.lines(bold, titleSize, text, 0, contentWidth, 0)
+	d.y += 4
+}
+
+// Heading adds a section heading. The heading is kept on the same page with
+// at least a few lines of the following content.
+func (d *Document) Heading(text string) {
+	d.ensure(headingSize*lineSpacing + 4*textSize*lineSpacing)
+	d.y += 6
+	d.lines(bold, headingSize, text, 0, contentWidth, 0)
+	d.y += 2
+}
+
+// Text adds a paragraph.
+func (d *Document) Text(text string) {
+	d.lines(regular, textSize, text, 0, contentWidth, 0)
+}
+
+// Note adds a paragraph in small gray letters.
+func (d *Document) Note(text string) {
+	d.lines(regular, noteSize, text, 0, contentWidth, 0.4)
+}
+
+// Space adds vertical space.
+func (d *Document) Space(height float64) {
+	d.y += height
+}
+
+// lines writes wrapped text at x (relative to the left margin) breaking
+// pages as needed.
+func (d *Document) lines(f font, size float64, text string, x, width, gray float64) {
+	lineHeight := size * lineSpacing
+	for _, line := range f.wrap(text, size, width) {
+		d.ensure(lineHeight)
+		d.text(f, size, margin+x, d.y+size, line, gray)
+		d.y += lineHeight
+	}
+}
+
+// text draws a single line with baseline at y from the top of the page.
+func (d *Document) text(f font, size, x, y float64, text string, gray float64) {
+	fmt.Fprintf(d.page(), "BT %s %.1f Tf %.2f g %.2f %.2f Td <%X> Tj ET\n", f.resource(), size, gray, x, pageHeight-y, encode(text))
+}
+
+func (d *Document) rect(x, y, width, height, gray float64) {
+	fmt.Fprintf(d.page(), "%.2f g %.2f %.2f %.2f %.2f re f\n", gray, x, pageHeight-y-height, width, height)
+}
+
+func (d *Document) line(x1, y1, x2, y2, gray float64) {
+	fmt.Fprintf(d.page(), "%.2f G 0.5 w %.2f %.2f m %.2f %.2f l S\n", gray, x1, pageHeight-y1, x2, pageHeight-y2)
+}
+
+//================================================================================
+// Table
+//================================================================================
+
+// Column of a table. Width is relative to widths of other columns.
+type Column struct {
+	Title string
+	Width float64
+	Align Align
+}
+
+// Table is a table flowing over pages. Rows are written immediately.
+type Table struct {
+	doc     *Document
+	columns []Column
+	widths  []float64
+}
+
+// Table starts a new table with a header row.
+func (d *Document) Table(columns ...Column) *Table {
+	total := 0.0
+	for _, c := range columns {
+		total += c.Width
+	}
+	t := &Table{doc: d, columns: columns, widths: make([]float64, len(columns))}
+	for i, c := range columns {
+		t.widths[i] = contentWidth * c.Width / total
+	}
+	d.ensure(3 * textSize * lineSpacing)
+	t.header()
+	return t
+}
+
+func (t *Table) header() {
+	titles := make([]string, len(t.columns))
+	for i, c := range t.columns {
+		titles[i] = c.Title
+	}
+	t.row(bold, 0.9, titles, true)
+}
+
+// Row adds a row of cells. Long cells are wrapped.
+func (t *Table) Row(cells ...string) {
+	t.row(regular, -1, cells, false)
+}
+
+// SumRow adds a row in bold letters, e.g. with totals.
+func (t *Table) SumRow(cells ...string) {
+	t.row(bold, 0.96, cells, false)
+}
+
+// Note adds a line spanning all columns in small gray letters under the last
+// row, e.g. with a warning about it.
+func (t *Table) Note(text string) {
+	d := t.doc
+	lineHeight := noteSize * lineSpacing
+	for _, line := range regular.wrap(text, noteSize, contentWidth-3*cellPadding) {
+		if d.ensure(lineHeight) {
+			t.header()
+		}
+		d.text(regular, noteSize, margin+3*cellPadding, d.y+noteSize, line, 0.35)
+		d.y += lineHeight
+	}
+	d.line(margin, d.y, margin+contentWidth, d.y, 0.85)
+}
+
+// row writes cells with the background gray (negative means none). The
+// header is repeated if the row starts a new page.
+func (t *Table) row(f font, background float64, cells []string, isHeader bool) {
+	d := t.doc
+	lineHeight := textSize * lineSpacing
+	wrapped := make([][]string, len(t.widths))
+	lines := 1
+	for i := range t.widths {
+		cell := ""
+		if i < len(cells) {
+			cell = cells[i]
+		}
+		wrapped[i] = f.wrap(cell, textSize, t.widths[i]-2*cellPadding)
+		lines = max(lines, len(wrapped[i]))
+	}
+	height := float64(lines)*lineHeight + cellPadding
+	if d.ensure(height) && !isHeader {
+		t.header()
+	}
+	if background >= 0 {
+		d.rect(margin, d.y, contentWidth, height, background)
+	}
+	x := margin
+	for i, width := range t.widths {
+		for j, line := range wrapped[i] {
+			lineX := x + cellPadding
+			if t.columns[i].Align == AlignRight {
+				lineX = x + width - cellPadding - f.width(line, textSize)
+			}
+			d.text(f, textSize, lineX, d.y+cellPadding/2+float64(j)*lineHeight+textSize, line, 0)
+		}
+		x += width
+	}
+	d.y += height
+	d.line(margin, d.y, margin+contentWidth, d.y, 0.85)
+}
+
+// End finishes the table and adds space after it.
+func (t *Table) End() {
+	t.doc.y += 8
+}
+
+//================================================================================
+// Output
+//================================================================================
+
+// WriteTo writes the document in the PDF format.
+func (d *Document) WriteTo(w io.Writer) (int64, error) {
+	var out bytes.Buffer
+	var offsets []int
+	object := func(content string) {
+		offsets = append(offsets, out.Len())
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", len(offsets), content)
+	}
+	out.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+
+	// 1 catalog, 2 page tree, 3 regular font, 4 bold font, 5 info, then
+	// a page and its content for every page
+	const firstPage = 6
+	kids := make([]string, len(d.pages))
+	for i := range d.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", firstPage+2*i)
+	}
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(d.pages)))
+	object(fontObject(regular))
+	object(fontObject(bold))
+	object(fmt.Sprintf("<< /Title <%X> /Producer (RecSIS) >>", utf16BE(d.title)))
+	for i, page := range d.pages {
+		content := page.String() + d.footer(i+1)
+		object(fmt.Sprintf(
+			"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.2f %.2f] /Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>",
+			pageWidth, pageHeight, firstPage+2*i+1,
+		))
+		object(fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", len(content), content))
+	}
+
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R /Info 5 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+	return out.WriteTo(w)
+}
+
+// footer returns content of the footer of the page with number.
+func (d *Document) footer(number int) string {
+	var content strings.Builder
+	y := pageHeight - margin + footerSize
+	pageNumber := fmt.Sprintf("%d / %d", number, len(d.pages))
+	fmt.Fprintf(&content, "%.2f G 0.5 w %.2f %.2f m %.2f %.2f l S\n", 0.7, margin, margin, margin+contentWidth, margin)
+	fmt.Fprintf(&content, "BT /F1 %.1f Tf 0.4 g %.2f %.2f Td <%X> Tj ET\n", footerSize, margin, pageHeight-y, encode(d.title))
+	x := margin + contentWidth - regular.width(pageNumber, footerSize)
+	fmt.Fprintf(&content, "BT /F1 %.1f Tf 0.4 g %.2f %.2f Td <%X> Tj ET\n", footerSize, x, pageHeight-y, encode(pageNumber))
+	return content.String()
+}
+
+func fontObject(f font) string {
+	names := make([]string, len(glyphs))
+	for i, g := range glyphs {
+		names[i] = "/" + g.name
+	}
+	return fmt.Sprintf(
+		"<< /Type /Font /Subtype /Type1 /BaseFont %s /Encoding << /Type /Encoding /BaseEncoding /WinAnsiEncoding /Differences [%d %s] >> >>",
+		f.baseFont(), firstGlyphCode, strings.Join(names, " "),
+	)
+}
+
+// utf16BE encodes s as a PDF text string (UTF-16BE with byte order mark).
+func utf16BE(s string) []byte {
+	result := []byte{0xfe, 0xff}
+	for _, u := range utf16.Encode([]rune(s)) {
+		result = append(result, byte(u>>8), byte(u))
+	}
+	return result
+}
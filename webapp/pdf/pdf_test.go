@@ -0,0 +1,124 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestEncode(t *testing.T) {
+	tests := []struct {
+		text string
+		want []byte
+	}{
+		{"NSWI120", []byte("NSWI120")},
+		{"a\tb\nc d", []byte("a b c d")},
+		{"ř", []byte{glyphCodes['ř']}},
+		{"Žluťoučký kůň", []byte{
+			glyphCodes['Ž'], 'l', 'u', glyphCodes['ť'], 'o', 'u', glyphCodes['č'], 'k', glyphCodes['ý'],
+			' ', 'k', glyphCodes['ů'], glyphCodes['ň'],
+		}},
+		{"€ł中", []byte("???")},
+		{"", []byte{}},
+	}
+	for _, tt := range tests {
+		if got := encode(tt.text); !bytes.Equal(got, tt.want) {
+			t.Errorf("encode(%q) = %v, want %v", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestGlyphCodes(t *testing.T) {
+	if len(glyphs) > 256-firstGlyphCode {
+		t.Fatalf("%d glyphs do not fit into codes %d-255", len(glyphs), firstGlyphCode)
+	}
+	for i, g := range glyphs {
+		if code := glyphCodes[g.char]; int(code) != firstGlyphCode+i {
+			t.Errorf("glyph %q has code %d, want %d", g.char, code, firstGlyphCode+i)
+		}
+	}
+}
+
+func TestWrap(t *testing.T) {
+	const size, width = textSize, 60.0
+	tests := []string{
+		"Nejneobhospodařovávatelnějšími",
+		"Příliš žluťoučký kůň úpěl ďábelské ódy",
+		strings.Repeat("ř", 50),
+		strings.Repeat("Ďábelské ", 10),
+	}
+	for _, text := range tests {
+		for _, f := range []font{regular, bold} {
+			lines := f.wrap(text, size, width)
+			for _, line := range lines {
+				if !utf8.ValidString(line) {
+					t.Errorf("wrap(%q) line %q is not valid UTF-8", text, line)
+				}
+				if f.width(line, size) > width && utf8.RuneCountInString(line) > 1 {
+					t.Errorf("wrap(%q) line %q is wider than %.0f", text, line, width)
+				}
+			}
+			joined := strings.ReplaceAll(strings.Join(lines, ""), " ", "")
+			if want := strings.ReplaceAll(text, " ", ""); joined != want {
+				t.Errorf("wrap(%q) lost characters: %q", text, joined)
+			}
+		}
+	}
+}
+
+func TestWrapEmpty(t *testing.T) {
+	if lines := regular.wrap("", textSize, contentWidth); len(lines) != 1 || lines[0] != "" {
+		t.Errorf("wrap(\"\") = %q, want one empty line", lines)
+	}
+}
+
+func TestWriteTo(t *testing.T) {
+	doc := New("Studijní plán – test")
+	doc.Title("Studijní plán")
+	table := doc.Table(Column{Title: "Kód", Width: 1}, Column{Title: "Název", Width: 4}, Column{Title: "Kredity", Width: 1, Align: AlignRight})
+	for i := 0; i < 120; i++ {
+		table.Row(fmt.Sprintf("NPRG%03d", i), "Programování v jazyce C++ – pokročilé techniky", "5")
+	}
+	table.End()
+	var buf bytes.Buffer
+	if _, err := doc.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.Bytes()
+	if !bytes.HasPrefix(out, []byte("%PDF-1.4\n")) {
+		t.Errorf("output does not start with PDF header")
+	}
+	if len(doc.pages) < 2 {
+		t.Fatalf("document has %d pages, want table over several pages", len(doc.pages))
+	}
+
+	// every page repeats the table header
+	header := fmt.Sprintf("<%X>", encode("Kód"))
+	if n := bytes.Count(out, []byte(header)); n != len(doc.pages) {
+		t.Errorf("table header is on %d pages, want %d", n, len(doc.pages))
+	}
+
+	match := regexp.MustCompile(`startxref\n(\d+)\n%%EOF\n$`).FindSubmatch(out)
+	if match == nil {
+		t.Fatalf("startxref not found at the end of output")
+	}
+	xref, _ := strconv.Atoi(string(match[1]))
+	if !bytes.HasPrefix(out[xref:], []byte("xref\n")) {
+		t.Fatalf("startxref %d does not point at xref table", xref)
+	}
+	entries := regexp.MustCompile(`(\d{10}) 00000 n \n`).FindAllSubmatch(out[xref:], -1)
+	if want := 5 + 2*len(doc.pages); len(entries) != want {
+		t.Fatalf("xref has %d objects, want %d", len(entries), want)
+	}
+	for i, entry := range entries {
+		offset, _ := strconv.Atoi(string(entry[1]))
+		want := fmt.Sprintf("%d 0 obj\n", i+1)
+		if !bytes.HasPrefix(out[offset:], []byte(want)) {
+			t.Errorf("xref entry %d points at %q, want %q", i+1, out[offset:min(offset+len(want), len(out))], want)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package recommend
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/michalhercik/RecSIS/fulltext"
+)
+
+// FullTextSimilarToBlueprint recommends courses sharing the most words with
+// courses in the blueprint. Unlike MeiliSearchSimilarToBlueprint it does not
+// need Meilisearch as it uses full-text search of PostgreSQL (see fulltext
+// package).
+type FullTextSimilarToBlueprint struct {
+	SearchIndex string
+	DB          *sqlx.DB
+}
+
+func (m FullTextSimilarToBlueprint) Recommend(userID string) ([]string, error) {
+	codes, descriptions, err := blueprintCourses(m.DB, userID)
+	if err != nil {
+		// TODO: add context
+		return nil, err
+	}
+	query := anyWord(descriptions)
+	if query == "" {
+		return nil, nil
+	}
+	var similarCourses []string
+	sql := `--sql
+		SELECT doc->>'code'
+		FROM search_documents
+		WHERE index_uid = $1
+		AND search_en @@ to_tsquery('english', $2)
+		AND NOT (doc->>'code' = ANY($3))
+		AND doc->>'section' = 'NI'
+		ORDER BY ts_rank(search_en, to_tsquery('english', $2)) DESC
+		LIMIT 30
+	`
+	err = m.DB.Select(&similarCourses, sql, m.SearchIndex, query, pq.Array(codes))
+	if err != nil {
+		// TODO: add context
+		return nil, err
+	}
+	selected := chooseRandom(similarCourses, 10)
+	return selected, nil
+}
+
+// anyWord returns text search query matching any word of the texts.
+func anyWord(texts []string) string {
+	var words []string
+	for _, text := range texts {
+		words = append(words, strings.FieldsFunc(text, func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+		})...)
+	}
+	return strings.Join(words, " | ")
+}
+
+// Recommender is implemented by MeiliSearchSimilarToBlueprint and
+// FullTextSimilarToBlueprint.
+type Recommender interface {
+	Recommend(userID string) ([]string, error)
+}
+
+// Fallback recommends with the full-text search while Meilisearch used by
+// the primary recommender is unavailable.
+type Fallback struct {
+	fulltext.Fallback[Recommender]
+}
+
+func (f Fallback) Recommend(userID string) ([]string, error) {
+	return fulltext.Call(f.Fallback, func(r Recommender) ([]string, error) { return r.Recommend(userID) })
+}
@@ -24,7 +24,8 @@ func (m NewCourses) Recommend(userID string) ([]string, error) {
 			FROM blueprint_years by, blueprint_semesters bs, blueprint_courses bc
 			WHERE by.id = bs.blueprint_year_id
 			AND bs.id = bc.blueprint_semester_id
-			AND by.user_id = $1
+			AND by.scenario_id = (SELECT id FROM blueprint_scenarios WHERE user_id = $1 AND active)
+			AND bc.course_code IS NOT NULL
 		)
 		ORDER BY valid_from DESC
 		LIMIT 30;
@@ -17,7 +17,7 @@ type MeiliSearchSimilarToBlueprint struct {
 }
 
 func (m MeiliSearchSimilarToBlueprint) Recommend(userID string) ([]string, error) {
-	codes, descriptions, err := m.blueprintCourses(userID)
+	codes, descriptions, err := blueprintCourses(m.DB, userID)
 	if err != nil {
 		// TODO: add context
 		return nil, err
@@ -33,7 +33,7 @@ func (m MeiliSearchSimilarToBlueprint) Recommend(userID string) ([]string, error
 	return selected, nil
 }
 
-func (m MeiliSearchSimilarToBlueprint) blueprintCourses(userID string) ([]string, []string, error) {
+func blueprintCourses(db *sqlx.DB, userID string) ([]string, []string, error) {
 	var courses []struct {
 		Code        string `db:"code"`
 		Description string `db:"description"`
@@ -43,10 +43,10 @@ func (m MeiliSearchSimilarToBlueprint) blueprintCourses(userID string) ([]string
 		INNER JOIN blueprint_semesters bs ON by.id = bs.blueprint_year_id
 		INNER JOIN blueprint_courses bc ON bs.id = bc.blueprint_semester_id
 		INNER JOIN courses c ON bc.course_code = c.code 
-		WHERE by.user_id = $1
+		WHERE by.scenario_id = (SELECT id FROM blueprint_scenarios WHERE user_id = $1 AND active)
 		AND c.lang = 'en'
 	`
-	err := m.DB.Select(&courses, query, userID)
+	err := db.Select(&courses, query, userID)
 	if err != nil {
 		// TODO: add context
 		return nil, nil, err
@@ -90,8 +90,10 @@ function handleTdClick(event) {
 }
 
 // update position of course in database using dynamic htmx patch
+// (the blueprint page is the source so that the request carries its version)
 function sortHxPatch(item, year, semester, position, language) {
 	htmx.ajax('PATCH', '/' + language + '/blueprint/course/' + item, {
+		source: '#blueprint-page',
 		target: '#blueprint-page',
 		swap: 'outerHTML',
 		values: { year: year, semester: semester, position: position + 1 }
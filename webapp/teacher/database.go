@@ -0,0 +1,101 @@
+package teacher
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/michalhercik/RecSIS/dbds"
+	"github.com/michalhercik/RecSIS/errorx"
+	"github.com/michalhercik/RecSIS/language"
+	"github.com/michalhercik/RecSIS/teacher/internal/sqlquery"
+)
+
+type DBManager struct {
+	DB *sqlx.DB
+}
+
+type dbTeacher struct {
+	SisID       string          `db:"id"`
+	FirstName   string          `db:"first_name"`
+	LastName    string          `db:"last_name"`
+	TitleBefore string          `db:"title_before"`
+	TitleAfter  string          `db:"title_after"`
+	Department  dbds.Department `db:"department"`
+}
+
+type dbTeacherCourse struct {
+	Code        string          `db:"code"`
+	Title       string          `db:"title"`
+	Credits     int             `db:"credits"`
+	IsGuarantor bool            `db:"is_guarantor"`
+	IsTeacher   bool            `db:"is_teacher"`
+	AvgRating   sql.NullFloat64 `db:"avg_rating"`
+	RatingCount sql.NullInt64   `db:"rating_count"`
+}
+
+func (m DBManager) teacher(id string, lang language.Language) (teacherPage, error) {
+	var result teacherPage
+	var t dbTeacher
+	if err := m.DB.Get(&t, sqlquery.Teacher, id, lang); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return result, errorx.NewHTTPErr(
+				errorx.AddContext(errors.New("teacher not found"), errorx.P("sisID", id), errorx.P("lang", lang)),
+				http.StatusNotFound,
+				fmt.Sprintf(texts[lang].errTeacherNotFound, id),
+			)
+		}
+		return result, errorx.NewHTTPErr(
+			errorx.AddContext(fmt.Errorf("sqlquery.Teacher: %w", err), errorx.P("sisID", id), errorx.P("lang", lang)),
+			http.StatusInternalServerError,
+			texts[lang].errCannotGetTeacher,
+		)
+	}
+	var courses []dbTeacherCourse
+	if err := m.DB.Select(&courses, sqlquery.Courses, id, lang); err != nil {
+		return result, errorx.NewHTTPErr(
+			errorx.AddContext(fmt.Errorf("sqlquery.Courses: %w", err), errorx.P("sisID", id), errorx.P("lang", lang)),
+			http.StatusInternalServerError,
+			texts[lang].errCannotGetCourses,
+		)
+	}
+	result = teacherPage{
+		teacher: intoTeacher(t),
+		courses: intoTeacherCourses(courses),
+	}
+	return result, nil
+}
+
+func intoTeacher(from dbTeacher) teacher {
+	return teacher{
+		sisID:       from.SisID,
+		firstName:   from.FirstName,
+		lastName:    from.LastName,
+		titleBefore: from.TitleBefore,
+		titleAfter:  from.TitleAfter,
+		department: department{
+			id:   from.Department.ID,
+			name: from.Department.Name,
+		},
+	}
+}
+
+func intoTeacherCourses(from []dbTeacherCourse) []teacherCourse {
+	result := make([]teacherCourse, len(from))
+	for i, c := range from {
+		result[i] = teacherCourse{
+			code:        c.Code,
+			title:       c.Title,
+			credits:     c.Credits,
+			isGuarantor: c.IsGuarantor,
+			isTeacher:   c.IsTeacher,
+			rating: courseRating{
+				avgRating:   c.AvgRating,
+				ratingCount: c.RatingCount,
+			},
+		}
+	}
+	return result
+}
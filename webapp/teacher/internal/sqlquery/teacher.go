@@ -0,0 +1,41 @@
+package sqlquery
+
+const Teacher = `--sql
+SELECT
+	t.id,
+	COALESCE(t.first_name, '') AS first_name,
+	COALESCE(t.last_name, '') AS last_name,
+	COALESCE(t.title_before, '') AS title_before,
+	COALESCE(t.title_after, '') AS title_after,
+	COALESCE(t.department, '{}'::jsonb) AS department
+FROM teachers t
+WHERE t.id = $1
+	AND t.lang = $2;
+`
+
+// Courses returns currently valid courses the teacher guarantees or teaches
+// together with their overall ratings.
+const Courses = `--sql
+WITH teacher AS (
+	SELECT jsonb_build_array(jsonb_build_object('id', $1::text)) AS id
+)
+SELECT
+	c.code,
+	COALESCE(c.title, '') AS title,
+	COALESCE(c.credits, 0) AS credits,
+	COALESCE(c.guarantors @> t.id, FALSE) AS is_guarantor,
+	COALESCE(c.teachers @> t.id, FALSE) AS is_teacher,
+	r.avg_rating,
+	r.rating_count
+FROM courses c
+CROSS JOIN teacher t
+LEFT JOIN LATERAL (
+	SELECT AVG(cor.rating) AS avg_rating, COUNT(cor.rating) AS rating_count
+	FROM course_overall_ratings cor
+	WHERE cor.course_code = c.code
+) r ON TRUE
+WHERE c.lang = $2
+	AND c.valid_to = 9999
+	AND (c.guarantors @> t.id OR c.teachers @> t.id)
+ORDER BY c.code;
+`
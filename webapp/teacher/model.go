@@ -0,0 +1,191 @@
+package teacher
+
+import (
+	"database/sql"
+	"encoding/json"
+	"iter"
+
+	"github.com/michalhercik/RecSIS/filters"
+	"github.com/michalhercik/RecSIS/language"
+)
+
+//================================================================================
+// Constants
+//================================================================================
+
+const (
+	defaultSurveyOffset = 0
+	resultsPerPage      = 20
+	ttDelay             = 200 // tooltip delay in ms
+)
+
+const (
+	searchQuery  = "survey-search"
+	surveyOffset = "survey-offset"
+)
+
+const sisID = "sisID"
+
+const (
+	meiliTeacherID = "teacher.id"
+	meiliSort      = "academic_year:desc"
+)
+
+//================================================================================
+// Data Types and Methods
+//================================================================================
+
+type teacherPage struct {
+	teacher teacher
+	courses []teacherCourse
+}
+
+// guaranteed returns courses the teacher is a guarantor of.
+func (tp teacherPage) guaranteed() []teacherCourse {
+	result := []teacherCourse{}
+	for _, c := range tp.courses {
+		if c.isGuarantor {
+			result = append(result, c)
+		}
+	}
+	return result
+}
+
+// taught returns courses the teacher teaches.
+func (tp teacherPage) taught() []teacherCourse {
+	result := []teacherCourse{}
+	for _, c := range tp.courses {
+		if c.isTeacher {
+			result = append(result, c)
+		}
+	}
+	return result
+}
+
+// rating aggregates overall ratings of all courses of the teacher. Each rating
+// of a course counts once, so courses with more ratings weigh more.
+func (tp teacherPage) rating() courseRating {
+	var sum float64
+	var count int64
+	for _, c := range tp.courses {
+		if c.rating.avgRating.Valid && c.rating.ratingCount.Valid {
+			sum += c.rating.avgRating.Float64 * float64(c.rating.ratingCount.Int64)
+			count += c.rating.ratingCount.Int64
+		}
+	}
+	if count == 0 {
+		return courseRating{}
+	}
+	return courseRating{
+		avgRating:   sql.NullFloat64{Float64: sum / float64(count), Valid: true},
+		ratingCount: sql.NullInt64{Int64: count, Valid: true},
+	}
+}
+
+type teacher struct {
+	sisID       string
+	firstName   string
+	lastName    string
+	titleBefore string
+	titleAfter  string
+	department  department
+}
+
+func (t teacher) fullName() string {
+	return t.firstName + " " + t.lastName
+}
+
+type department struct {
+	id   string
+	name string
+}
+
+type teacherCourse struct {
+	code        string
+	title       string
+	credits     int
+	isGuarantor bool
+	isTeacher   bool
+	rating      courseRating
+}
+
+type courseRating struct {
+	avgRating   sql.NullFloat64
+	ratingCount sql.NullInt64
+}
+
+type surveyViewModel struct {
+	lang   language.Language
+	sisID  string
+	query  string
+	survey []survey
+	offset int
+	isEnd  bool
+	facets iter.Seq[filters.FacetIterator]
+}
+
+type survey struct {
+	student
+	Type         string `json:"target_type"`
+	CourseCode   string `json:"course_code"`
+	AcademicYear int    `json:"academic_year"`
+	Content      string `json:"content"`
+}
+
+type student struct {
+	Year  int        `json:"study_year"`
+	Field studyField `json:"study_field"`
+	Study studyType  `json:"study_type"`
+}
+
+type studyField struct {
+	ID   string
+	Name string
+}
+
+func (s *studyField) UnmarshalJSON(data []byte) error {
+	var temp struct {
+		ID   string `json:"id"`
+		Name struct {
+			CS string `json:"cs"`
+			EN string `json:"en"`
+		} `json:"name"`
+	}
+	if err := json.Unmarshal(data, &temp); err != nil {
+		return err
+	}
+	s.ID = temp.ID
+	s.Name = temp.Name.CS
+	if s.Name == "" {
+		s.Name = temp.Name.EN
+	}
+	return nil
+}
+
+type studyType struct {
+	Abbr string
+	Name string
+}
+
+func (s *studyType) UnmarshalJSON(data []byte) error {
+	var temp struct {
+		Abbr struct {
+			CS string `json:"cs"`
+			EN string `json:"en"`
+		} `json:"abbr"`
+		Name struct {
+			CS string `json:"cs"`
+			EN string `json:"en"`
+		} `json:"name"`
+	}
+	if err := json.Unmarshal(data, &temp); err != nil {
+		return err
+	}
+	s.Abbr = temp.Abbr.CS
+	s.Name = temp.Name.CS
+	if s.Abbr == "" && s.Name == "" {
+		s.Abbr = temp.Abbr.EN
+		s.Name = temp.Name.EN
+	}
+	return nil
+}
@@ -0,0 +1,237 @@
+package teacher
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/meilisearch/meilisearch-go"
+	"github.com/michalhercik/RecSIS/errorx"
+	"github.com/michalhercik/RecSIS/filters"
+	"github.com/michalhercik/RecSIS/fulltext"
+	"github.com/michalhercik/RecSIS/language"
+)
+
+//================================================================================
+// Search Backends
+//================================================================================
+
+// SearchEngine is implemented by every search backend of survey comments.
+type SearchEngine interface {
+	comments(r request) (response, error)
+}
+
+// FallbackSearch switches survey search of teachers between Meilisearch and
+// the full-text search, see fulltext.Fallback.
+type FallbackSearch struct {
+	fulltext.Fallback[SearchEngine]
+}
+
+func (s FallbackSearch) comments(r request) (response, error) {
+	return fulltext.Call(s.Fallback, func(e SearchEngine) (response, error) { return e.comments(r) })
+}
+
+//================================================================================
+// Meilisearch
+//================================================================================
+
+type MeiliSearch struct {
+	Client meilisearch.ServiceManager
+	Survey meilisearch.IndexConfig
+}
+
+// comments searches survey comments targeting the teacher.
+func (s MeiliSearch) comments(r request) (response, error) {
+	t := texts[r.lang]
+	var result response
+	searchReq := makeMultiSearchRequest(r, s.Survey)
+	response, err := s.Client.MultiSearch(searchReq)
+	if err != nil {
+		return result, errorx.NewHTTPErr(
+			errorx.AddContext(err),
+			http.StatusInternalServerError,
+			t.errCannotSearchForSurvey,
+		)
+	}
+	rawResponse, err := response.MarshalJSON()
+	if err != nil {
+		return result, errorx.NewHTTPErr(
+			errorx.AddContext(err),
+			http.StatusInternalServerError,
+			t.errCannotSearchForSurvey,
+		)
+	}
+	multi := multiResponse{}
+	if err = json.Unmarshal(rawResponse, &multi); err != nil {
+		return result, errorx.NewHTTPErr(
+			errorx.AddContext(err),
+			http.StatusInternalServerError,
+			t.errCannotSearchForSurvey,
+		)
+	}
+	result = multi.Results[0]
+	for _, res := range multi.Results[1:] {
+		for param, distribution := range res.FacetDistribution {
+			result.FacetDistribution[param] = distribution
+		}
+	}
+	return result, nil
+}
+
+type expression interface {
+	String() string
+	Except() func(func(string, string) bool)
+	JSONPath() string
+	ExceptJSONPath() func(func(string, string) bool)
+	ConditionsCount() int
+	Append(param string, values ...string)
+}
+
+type request struct {
+	userID string
+	// teacherID restricts all queries including those for disjunctive facets,
+	// unlike filter which is left out for facets of its own params
+	teacherID string
+	query     string
+	offset    int
+	limit     int
+	lang      language.Language
+	filter    expression
+	facets    []string
+	sort      string
+}
+
+type response struct {
+	EstimatedTotalHits int                       `json:"estimatedTotalHits"`
+	Survey             []survey                  `json:"Hits"`
+	FacetDistribution  map[string]map[string]int `json:"FacetDistribution"`
+}
+
+type multiResponse struct {
+	Results []response `json:"results"`
+}
+
+func makeMultiSearchRequest(r request, index meilisearch.IndexConfig) *meilisearch.MultiSearchRequest {
+	scope := fmt.Sprintf("%s = %s", meiliTeacherID, filters.Quote(r.teacherID))
+	numOfReq := 1 + r.filter.ConditionsCount()
+	result := &meilisearch.MultiSearchRequest{
+		Queries: make([]*meilisearch.SearchRequest, 0, numOfReq),
+	}
+	result.Queries = append(result.Queries, &meilisearch.SearchRequest{
+		IndexUID:             index.Uid,
+		Query:                r.query,
+		Limit:                int64(r.limit),
+		Offset:               int64(r.offset),
+		AttributesToRetrieve: attributesToRetrieve(r.lang),
+		Filter:               and(scope, r.filter.String()),
+		Facets:               r.facets,
+		Sort:                 []string{r.sort},
+	})
+	for param, filter := range r.filter.Except() {
+		result.Queries = append(result.Queries, &meilisearch.SearchRequest{
+			IndexUID:             index.Uid,
+			Query:                r.query,
+			Limit:                0,
+			AttributesToRetrieve: []string{},
+			Filter:               and(scope, filter),
+			Facets:               []string{param},
+		})
+	}
+	return result
+}
+
+func and(filters ...string) string {
+	nonEmpty := make([]string, 0, len(filters))
+	for _, f := range filters {
+		if f != "" {
+			nonEmpty = append(nonEmpty, f)
+		}
+	}
+	return strings.Join(nonEmpty, " AND ")
+}
+
+func attributesToRetrieve(lang language.Language) []string {
+	var (
+		studyTypeName  string
+		studyTypeAbbr  string
+		studyFieldName string
+	)
+	if lang == language.CS {
+		studyTypeName = "study_type.name.cs"
+		studyTypeAbbr = "study_type.abbr.cs"
+		studyFieldName = "study_field.name.cs"
+	} else {
+		studyTypeName = "study_type.name.en"
+		studyTypeAbbr = "study_type.abbr.en"
+		studyFieldName = "study_field.name.en"
+	}
+	attrs := []string{
+		"content",
+		"course_code",
+		"study_year",
+		"academic_year",
+		"target_type",
+		"study_field.id",
+		studyFieldName,
+		studyTypeName,
+		studyTypeAbbr,
+	}
+	return attrs
+}
+
+//================================================================================
+// PostgreSQL Full-Text Search
+//================================================================================
+
+// FullTextSearch searches survey comments in PostgreSQL when Meilisearch is
+// not available, see fulltext package.
+type FullTextSearch struct {
+	Engine fulltext.Engine
+	Survey string
+}
+
+func (s FullTextSearch) comments(r request) (response, error) {
+	t := texts[r.lang]
+	var result response
+	scope := fulltext.Equals(meiliTeacherID, r.teacherID)
+	except := func(yield func(string, string) bool) {
+		for param, filter := range r.filter.ExceptJSONPath() {
+			if !yield(param, fulltext.And(scope, filter)) {
+				return
+			}
+		}
+	}
+	filter := fulltext.And(scope, r.filter.JSONPath())
+	res, err := s.Engine.Search(fulltext.Request{
+		Index:      s.Survey,
+		Query:      r.query,
+		Lang:       r.lang,
+		Filter:     filter,
+		Facets:     fulltext.DisjunctiveFacets(r.facets, filter, except),
+		Sort:       []string{r.sort},
+		Attributes: attributesToRetrieve(r.lang),
+		Offset:     r.offset,
+		Limit:      r.limit,
+	})
+	if err != nil {
+		return result, errorx.NewHTTPErr(
+			errorx.AddContext(err),
+			http.StatusInternalServerError,
+			t.errCannotSearchForSurvey,
+		)
+	}
+	result.EstimatedTotalHits = res.TotalHits
+	result.FacetDistribution = res.FacetDistribution
+	result.Survey = make([]survey, len(res.Hits))
+	for i, hit := range res.Hits {
+		if err := json.Unmarshal(hit, &result.Survey[i]); err != nil {
+			return result, errorx.NewHTTPErr(
+				errorx.AddContext(err),
+				http.StatusInternalServerError,
+				t.errCannotSearchForSurvey,
+			)
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,195 @@
+package teacher
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/a-h/templ"
+	"github.com/michalhercik/RecSIS/errorx"
+	"github.com/michalhercik/RecSIS/filters"
+	"github.com/michalhercik/RecSIS/language"
+)
+
+//================================================================================
+// Server Type
+//================================================================================
+
+type Server struct {
+	Auth    Authentication
+	Data    DBManager
+	Error   Error
+	Filters filters.Filters
+	Search  SearchEngine
+	Page    Page
+	router  http.Handler
+}
+
+func (s *Server) Init() {
+	if err := s.Filters.Init(); err != nil {
+		log.Fatal("teacher.Init: ", err)
+	}
+	s.initRouter()
+}
+
+type Authentication interface {
+	// Returns the user ID from an HTTP request.
+	UserID(r *http.Request) string
+}
+
+type Error interface {
+	// Logs the provided error.
+	Log(err error)
+
+	// Renders an error message to the user as a floating window, with a status code and localized message.
+	Render(w http.ResponseWriter, r *http.Request, code int, userMsg string, lang language.Language)
+
+	// Renders a full error page, including title and user ID, for major errors or page-level failures.
+	RenderPage(w http.ResponseWriter, r *http.Request, code int, userMsg string, title string, userID string, lang language.Language)
+
+	// Renders a fallback error page when a regular page cannot be rendered due to an error.
+	CannotRenderPage(w http.ResponseWriter, r *http.Request, title string, userID string, err error, lang language.Language)
+
+	// Renders a floating window with error when any component cannot be rendered due to an error.
+	CannotRenderComponent(w http.ResponseWriter, r *http.Request, err error, lang language.Language)
+}
+
+type Page interface {
+	// Returns the page view component with injected main content, parameterized by language, title, and user ID.
+	// Page adds header with navbar and footer.
+	View(main templ.Component, lang language.Language, title string, userID string) templ.Component
+}
+
+//================================================================================
+// Routing
+//================================================================================
+
+func (s Server) Router() http.Handler {
+	return s.router
+}
+
+func (s *Server) initRouter() {
+	router := http.NewServeMux()
+	router.HandleFunc(fmt.Sprintf("GET /{%s}", sisID), s.page)
+	router.HandleFunc(fmt.Sprintf("GET /survey/{%s}", sisID), s.survey)
+	router.HandleFunc(fmt.Sprintf("GET /survey/next/{%s}", sisID), s.surveyNext)
+	router.HandleFunc("/", s.pageNotFound)
+	s.router = router
+}
+
+//================================================================================
+// Handlers
+//================================================================================
+
+func (s Server) page(w http.ResponseWriter, r *http.Request) {
+	lang := language.FromContext(r.Context())
+	t := texts[lang]
+	userID := s.Auth.UserID(r)
+	id := r.PathValue(sisID)
+	teacherPage, err := s.Data.teacher(id, lang)
+	if err != nil {
+		code, userMsg := errorx.UnwrapError(err, lang)
+		s.Error.Log(errorx.AddContext(err))
+		s.Error.RenderPage(w, r, code, userMsg, t.errPageTitle, userID, lang)
+		return
+	}
+	title := teacherPage.teacher.fullName()
+	main := Content(&teacherPage, t)
+	page := s.Page.View(main, lang, title, userID)
+	err = page.Render(r.Context(), w)
+	if err != nil {
+		s.Error.CannotRenderPage(w, r, title, userID, errorx.AddContext(err), lang)
+	}
+}
+
+func (s Server) survey(w http.ResponseWriter, r *http.Request) {
+	lang := language.FromContext(r.Context())
+	model, err := s.surveyViewModel(r)
+	if err != nil {
+		code, userMsg := errorx.UnwrapError(err, lang)
+		userMsg = fmt.Sprintf("%s: %s", texts[lang].errCannotLoadSurvey, userMsg)
+		s.Error.Log(errorx.AddContext(err))
+		s.Error.Render(w, r, code, userMsg, lang)
+		return
+	}
+	content := SurveyFiltersContent(model, texts[lang])
+	err = content.Render(r.Context(), w)
+	if err != nil {
+		s.Error.CannotRenderComponent(w, r, errorx.AddContext(err), lang)
+	}
+}
+
+func (s Server) surveyNext(w http.ResponseWriter, r *http.Request) {
+	lang := language.FromContext(r.Context())
+	model, err := s.surveyViewModel(r)
+	if err != nil {
+		code, userMsg := errorx.UnwrapError(err, lang)
+		userMsg = fmt.Sprintf("%s: %s", texts[lang].errCannotLoadSurvey, userMsg)
+		s.Error.Log(errorx.AddContext(err))
+		s.Error.Render(w, r, code, userMsg, lang)
+		return
+	}
+	content := SurveysContent(model, texts[lang])
+	err = content.Render(r.Context(), w)
+	if err != nil {
+		s.Error.CannotRenderComponent(w, r, errorx.AddContext(err), lang)
+	}
+}
+
+func (s Server) surveyViewModel(r *http.Request) (surveyViewModel, error) {
+	req, err := s.parseQueryRequest(r)
+	if err != nil {
+		return surveyViewModel{}, errorx.AddContext(err)
+	}
+	id := r.PathValue(sisID)
+	req.teacherID = id
+	searchResponse, err := s.Search.comments(req)
+	if err != nil {
+		return surveyViewModel{}, errorx.AddContext(err, errorx.P(sisID, id))
+	}
+	result := surveyViewModel{
+		lang:   req.lang,
+		sisID:  id,
+		query:  req.query,
+		survey: searchResponse.Survey,
+		offset: req.offset,
+		isEnd:  searchResponse.EstimatedTotalHits <= req.offset+req.limit,
+		facets: s.Filters.IterFiltersWithFacets(searchResponse.FacetDistribution, r.URL.Query(), req.lang),
+	}
+	return result, nil
+}
+
+func (s Server) parseQueryRequest(r *http.Request) (request, error) {
+	var req request
+	userID := s.Auth.UserID(r)
+	lang := language.FromContext(r.Context())
+	query := r.FormValue(searchQuery)
+	offset, err := strconv.Atoi(r.FormValue(surveyOffset))
+	if err != nil {
+		offset = defaultSurveyOffset
+	}
+	filter, err := s.Filters.ParseURLQuery(r.URL.Query(), lang)
+	if err != nil {
+		return req, errorx.AddContext(err)
+	}
+
+	req = request{
+		userID: userID,
+		query:  query,
+		offset: offset,
+		limit:  resultsPerPage,
+		lang:   lang,
+		filter: expression(&filter),
+		facets: s.Filters.Facets(),
+		sort:   meiliSort,
+	}
+	return req, nil
+}
+
+func (s Server) pageNotFound(w http.ResponseWriter, r *http.Request) {
+	lang := language.FromContext(r.Context())
+	t := texts[lang]
+	userID := s.Auth.UserID(r)
+	s.Error.RenderPage(w, r, http.StatusNotFound, t.errPageNotFound, t.errPageTitle, userID, lang)
+}
@@ -0,0 +1,110 @@
+package teacher
+
+import (
+	"strconv"
+
+	"github.com/michalhercik/RecSIS/language"
+)
+
+type text struct {
+	department              string
+	guaranteedCourses       string
+	taughtCourses           string
+	noCourses               string
+	code                    string
+	title                   string
+	credits                 string
+	rating                  string
+	coursesRating           string
+	coursesRatingHelp       string
+	noRatings               string
+	courses                 string
+	survey                  string
+	year                    string
+	noSurvey                string
+	surveySearchPlaceholder string
+	cancelFilters           string
+	scrollToSearch          string
+	// language
+	language language.Language
+	// errors
+	errTeacherNotFound       string
+	errCannotGetTeacher      string
+	errCannotGetCourses      string
+	errCannotLoadSurvey      string
+	errCannotSearchForSurvey string
+	errPageTitle             string
+	errPageNotFound          string
+}
+
+func (t text) yearStr(year int) string {
+	if t.language == language.CS {
+		return strconv.Itoa(year) + ". " + t.year
+	} else if t.language == language.EN {
+		return t.year + " " + strconv.Itoa(year)
+	}
+	return ""
+}
+
+var texts = map[language.Language]text{
+	language.CS: {
+		department:              "Katedra",
+		guaranteedCourses:       "Garantované předměty",
+		taughtCourses:           "Vyučované předměty",
+		noCourses:               "Žádné předměty.",
+		code:                    "Kód",
+		title:                   "Název",
+		credits:                 "Kredity",
+		rating:                  "Hodnocení",
+		coursesRating:           "Hodnocení předmětů",
+		coursesRatingHelp:       "Podíl kladných hodnocení všech garantovaných a vyučovaných předmětů.",
+		noRatings:               "Žádná hodnocení",
+		courses:                 "Předměty",
+		survey:                  "Anketa",
+		year:                    "ročník",
+		noSurvey:                "Nejsou k dispozici žádné komentáře.",
+		surveySearchPlaceholder: "Hledat v anketě...",
+		cancelFilters:           "Zrušit vybrané filtry",
+		scrollToSearch:          "Nahoru na vyhledávání",
+		// language
+		language: language.CS,
+		// errors
+		errTeacherNotFound:       "Vyučující s ID %s nebyl nalezen",
+		errCannotGetTeacher:      "Nebylo možné získat vyučujícího z databáze",
+		errCannotGetCourses:      "Nebylo možné získat předměty vyučujícího z databáze",
+		errCannotLoadSurvey:      "Nebylo možné načíst anketu",
+		errCannotSearchForSurvey: "vyhledávání selhalo",
+		errPageTitle:             "Vyučující",
+		errPageNotFound:          "Stránka nenalezena",
+	},
+	language.EN: {
+		department:              "Department",
+		guaranteedCourses:       "Guaranteed courses",
+		taughtCourses:           "Taught courses",
+		noCourses:               "No courses.",
+		code:                    "Code",
+		title:                   "Title",
+		credits:                 "Credits",
+		rating:                  "Rating",
+		coursesRating:           "Courses rating",
+		coursesRatingHelp:       "Share of positive ratings of all guaranteed and taught courses.",
+		noRatings:               "No ratings",
+		courses:                 "Courses",
+		survey:                  "Survey",
+		year:                    "Year",
+		noSurvey:                "No comments available.",
+		surveySearchPlaceholder: "Search in survey...",
+		cancelFilters:           "Cancel selected filters",
+		scrollToSearch:          "Scroll to search",
+		// language
+		language: language.EN,
+		// errors
+		errTeacherNotFound:       "Teacher with ID %s was not found",
+		errCannotGetTeacher:      "Unable to retrieve teacher from database",
+		errCannotGetCourses:      "Unable to retrieve courses of the teacher from database",
+		errCannotLoadSurvey:      "Unable to load survey",
+		errCannotSearchForSurvey: "Search failed",
+		errPageTitle:             "Teacher",
+		errPageNotFound:          "Page not found",
+	},
+}
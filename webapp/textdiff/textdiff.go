@@ -0,0 +1,199 @@
+package textdiff
+
+/** PACKAGE DESCRIPTION
+
+The textdiff package compares two texts word by word. Texts are split into words and runs of whitespace, the shortest edit script between them is found by the Myers algorithm and returned as a sequence of operations which keep, insert or delete a part of the text. Consecutive operations of the same kind are merged, so the result can be rendered directly, e.g. with <ins> and <del> elements.
+
+The algorithm takes O((N+M)·D) time and O(D²) memory where D is the number of edits. To keep the cost bounded for long texts which differ a lot, comparison gives up after MaxEdits edits and the whole old text is reported as deleted and the whole new text as inserted.
+
+*/
+
+import (
+	"strings"
+	"unicode"
+)
+
+//================================================================================
+// Constants
+//================================================================================
+
+// MaxEdits is the number of inserted and deleted words and whitespace runs
+// after which the texts are considered completely different.
+const MaxEdits = 1000
+
+//================================================================================
+// Data Types and Methods
+//================================================================================
+
+type Kind int
+
+const (
+	Equal Kind = iota
+	Insert
+	Delete
+)
+
+// Op is a part of the text which is kept, inserted or deleted.
+type Op struct {
+	Kind Kind
+	Text string
+}
+
+//================================================================================
+// Diff
+//================================================================================
+
+// Words returns operations transforming text a into text b. Concatenating
+// texts of Equal and Delete operations gives a, Equal and Insert gives b.
+func Words(a, b string) []Op {
+	return merge(diff(split(a), split(b)))
+}
+
+// split divides the text into words and runs of whitespace.
+func split(text string) []string {
+	var result []string
+	start := 0
+	for i, r := range text {
+		if i > start && unicode.IsSpace(r) != isSpaceAt(text, start) {
+			result = append(result, text[start:i])
+			start = i
+		}
+	}
+	if start < len(text) {
+		result = append(result, text[start:])
+	}
+	return result
+}
+
+func isSpaceAt(text string, i int) bool {
+	for _, r := range text[i:] {
+		return unicode.IsSpace(r)
+	}
+	return false
+}
+
+// diff finds the shortest edit script by the Myers algorithm. For each number
+// of edits d it keeps the furthest reaching x on every diagonal k = x - y and
+// stores the diagonals of the previous round for backtracking.
+func diff(a, b []string) []Op {
+	n, m := len(a), len(b)
+	maxD := min(n+m, MaxEdits)
+	offset := maxD + 1
+	v := make([]int, 2*maxD+3)
+	var trace [][]int
+	for d := 0; d <= maxD; d++ {
+		trace = append(trace, append([]int(nil), v[offset-d-1:offset+d+2]...))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				return backtrack(trace, a, b)
+			}
+		}
+	}
+	return replace(a, b)
+}
+
+// backtrack walks the trace from the end of both texts to their start and
+// returns the operations in order.
+func backtrack(trace [][]int, a, b []string) []Op {
+	var result []Op
+	x, y := len(a), len(b)
+	for d := len(trace) - 1; d >= 0; d-- {
+		// trace[d] holds diagonals -d-1..d+1
+		get := func(k int) int { return trace[d][k+d+1] }
+		k := x - y
+		var prevK int
+		if k == -d || (k != d && get(k-1) < get(k+1)) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := get(prevK)
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			result = append(result, Op{Kind: Equal, Text: a[x-1]})
+			x--
+			y--
+		}
+		if d > 0 {
+			if x == prevX {
+				result = append(result, Op{Kind: Insert, Text: b[y-1]})
+			} else {
+				result = append(result, Op{Kind: Delete, Text: a[x-1]})
+			}
+		}
+		x, y = prevX, prevY
+	}
+	for i, j := 0, len(result)-1; i < j; i, j = i+1, j-1 {
+		result[i], result[j] = result[j], result[i]
+	}
+	return result
+}
+
+func replace(a, b []string) []Op {
+	var result []Op
+	for _, word := range a {
+		result = append(result, Op{Kind: Delete, Text: word})
+	}
+	for _, word := range b {
+		result = append(result, Op{Kind: Insert, Text: word})
+	}
+	return result
+}
+
+// merge joins consecutive operations of the same kind. Deletions go before
+// insertions when they alternate so that a replaced part reads as one, and
+// whitespace kept between two changes becomes part of them.
+func merge(ops []Op) []Op {
+	var result []Op
+	for i := 0; i < len(ops); {
+		if ops[i].Kind == Equal {
+			j := i
+			var text strings.Builder
+			for ; j < len(ops) && ops[j].Kind == Equal; j++ {
+				text.WriteString(ops[j].Text)
+			}
+			result = append(result, Op{Kind: Equal, Text: text.String()})
+			i = j
+			continue
+		}
+		j := i
+		var deleted, inserted strings.Builder
+		for ; j < len(ops) && (ops[j].Kind != Equal || isSpaceBetweenChanges(ops, j)); j++ {
+			switch ops[j].Kind {
+			case Delete:
+				deleted.WriteString(ops[j].Text)
+			case Insert:
+				inserted.WriteString(ops[j].Text)
+			default:
+				deleted.WriteString(ops[j].Text)
+				inserted.WriteString(ops[j].Text)
+			}
+		}
+		if deleted.Len() > 0 {
+			result = append(result, Op{Kind: Delete, Text: deleted.String()})
+		}
+		if inserted.Len() > 0 {
+			result = append(result, Op{Kind: Insert, Text: inserted.String()})
+		}
+		i = j
+	}
+	return result
+}
+
+func isSpaceBetweenChanges(ops []Op, i int) bool {
+	return i > 0 && i+1 < len(ops) &&
+		ops[i-1].Kind != Equal && ops[i+1].Kind != Equal &&
+		strings.TrimSpace(ops[i].Text) == ""
+}
@@ -0,0 +1,69 @@
+package textdiff
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestWords(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		want []Op
+	}{
+		{"both empty", "", "", nil},
+		{"equal", "Lineární algebra", "Lineární algebra", []Op{{Equal, "Lineární algebra"}}},
+		{"from empty", "", "new text", []Op{{Insert, "new text"}}},
+		{"to empty", "old text", "", []Op{{Delete, "old text"}}},
+		{"insert word", "basic course", "basic advanced course", []Op{
+			{Equal, "basic "}, {Insert, "advanced "}, {Equal, "course"},
+		}},
+		{"delete word", "exam and credit", "exam", []Op{
+			{Equal, "exam"}, {Delete, " and credit"},
+		}},
+		{"replace word", "5 credits", "6 credits", []Op{
+			{Delete, "5"}, {Insert, "6"}, {Equal, " credits"},
+		}},
+		{"replace words", "one two three four", "one five six four", []Op{
+			{Equal, "one "}, {Delete, "two three"}, {Insert, "five six"}, {Equal, " four"},
+		}},
+		{"newline", "first\nsecond", "first\n\nsecond", []Op{
+			{Equal, "first"}, {Delete, "\n"}, {Insert, "\n\n"}, {Equal, "second"},
+		}},
+	}
+	for _, tt := range tests {
+		if got := Words(tt.a, tt.b); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: Words(%q, %q) = %v, want %v", tt.name, tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestWordsReconstruct(t *testing.T) {
+	a := "The course covers sets, relations and functions. Exam is written."
+	b := "The course covers relations, functions and graphs. Exam is oral and written."
+	var oldText, newText strings.Builder
+	for _, op := range Words(a, b) {
+		if op.Kind != Insert {
+			oldText.WriteString(op.Text)
+		}
+		if op.Kind != Delete {
+			newText.WriteString(op.Text)
+		}
+	}
+	if oldText.String() != a {
+		t.Errorf("old text = %q, want %q", oldText.String(), a)
+	}
+	if newText.String() != b {
+		t.Errorf("new text = %q, want %q", newText.String(), b)
+	}
+}
+
+func TestWordsTooManyEdits(t *testing.T) {
+	a := strings.Repeat("a ", MaxEdits)
+	b := strings.Repeat("b ", MaxEdits)
+	want := []Op{{Delete, a}, {Insert, b}}
+	if got := Words(a, b); !reflect.DeepEqual(got, want) {
+		t.Errorf("Words of completely different texts = %d ops, want delete and insert", len(got))
+	}
+}
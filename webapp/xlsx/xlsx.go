@@ -0,0 +1,245 @@
+package xlsx
+
+/** PACKAGE DESCRIPTION
+
+The xlsx package generates simple spreadsheets in the Office Open XML format (.xlsx) without any external library. A workbook has a single sheet with a bold header row, which stays visible when the sheet is scrolled, followed by rows of text and number cells. Columns are wide enough for their longest cell up to a limit.
+
+Content is kept in memory and written at once by WriteTo, so the package is meant for exports of at most thousands of rows. Strings are stored inline in cells; characters not allowed in XML are replaced by U+FFFD.
+
+*/
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+//================================================================================
+// Constants
+//================================================================================
+
+const (
+	// Excel limit of sheet name length
+	maxSheetName = 31
+	// column widths in characters
+	minColumnWidth = 8
+	maxColumnWidth = 60
+)
+
+// cell styles defined in styles.xml
+const (
+	styleDefault = 0
+	styleBold    = 1
+)
+
+//================================================================================
+// Sheet
+//================================================================================
+
+// Cell is a single value of a row. Use Text or Number to create one; the zero
+// value is an empty cell.
+type Cell struct {
+	text     string
+	number   float64
+	isNumber bool
+}
+
+// Text returns a string cell.
+func Text(s string) Cell {
+	return Cell{text: s}
+}
+
+// Number returns a numeric cell.
+func Number(n float64) Cell {
+	return Cell{number: n, isNumber: true}
+}
+
+func (c Cell) width() int {
+	if c.isNumber {
+		return len(strconv.FormatFloat(c.number, 'f', -1, 64))
+	}
+	return utf8.RuneCountInString(c.text)
+}
+
+// Sheet is a workbook with a single sheet being built. The zero value is not
+// usable, use New.
+type Sheet struct {
+	name   string
+	header []string
+	rows   [][]Cell
+}
+
+// New creates a workbook with a sheet of the given name and header row.
+// Characters not allowed in sheet names are replaced and the name is
+// shortened to 31 characters.
+func New(name string, header ...string) *Sheet {
+	name = strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`[]:*?/\`, r) {
+			return '_'
+		}
+		return r
+	}, name)
+	if runes := []rune(name); len(runes) > maxSheetName {
+		name = string(runes[:maxSheetName])
+	}
+	if name == "" {
+		name = "Sheet1"
+	}
+	return &Sheet{name: name, header: header}
+}
+
+// Row adds a row of cells under the previous one.
+func (s *Sheet) Row(cells ...Cell) {
+	s.rows = append(s.rows, cells)
+}
+
+//================================================================================
+// Output
+//================================================================================
+
+// WriteTo writes the workbook in the XLSX format.
+func (s *Sheet) WriteTo(w io.Writer) (int64, error) {
+	var out bytes.Buffer
+	archive := zip.NewWriter(&out)
+	parts := []struct {
+		name    string
+		content string
+	}{
+		{"[Content_Types].xml", contentTypes},
+		{"_rels/.rels", rootRels},
+		{"xl/workbook.xml", s.workbook()},
+		{"xl/_rels/workbook.xml.rels", workbookRels},
+		{"xl/styles.xml", styles},
+		{"xl/worksheets/sheet1.xml", s.worksheet()},
+	}
+	for _, part := range parts {
+		f, err := archive.Create(part.name)
+		if err != nil {
+			return 0, fmt.Errorf("xlsx: %s: %w", part.name, err)
+		}
+		if _, err = io.WriteString(f, part.content); err != nil {
+			return 0, fmt.Errorf("xlsx: %s: %w", part.name, err)
+		}
+	}
+	if err := archive.Close(); err != nil {
+		return 0, fmt.Errorf("xlsx: %w", err)
+	}
+	return out.WriteTo(w)
+}
+
+func (s *Sheet) workbook() string {
+	return xml.Header + `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+		`<sheets><sheet name="` + escape(s.name) + `" sheetId="1" r:id="rId1"/></sheets></workbook>`
+}
+
+func (s *Sheet) worksheet() string {
+	var sb strings.Builder
+	sb.WriteString(xml.Header)
+	sb.WriteString(`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">`)
+	// keep the header visible when scrolling
+	sb.WriteString(`<sheetViews><sheetView workbookViewId="0"><pane ySplit="1" topLeftCell="A2" activePane="bottomLeft" state="frozen"/></sheetView></sheetViews>`)
+	sb.WriteString(`<cols>`)
+	for i, width := range s.columnWidths() {
+		fmt.Fprintf(&sb, `<col min="%d" max="%d" width="%d" customWidth="1"/>`, i+1, i+1, width)
+	}
+	sb.WriteString(`</cols><sheetData>`)
+	header := make([]Cell, len(s.header))
+	for i, h := range s.header {
+		header[i] = Text(h)
+	}
+	writeRow(&sb, 1, header, styleBold)
+	for i, row := range s.rows {
+		writeRow(&sb, i+2, row, styleDefault)
+	}
+	sb.WriteString(`</sheetData></worksheet>`)
+	return sb.String()
+}
+
+func (s *Sheet) columnWidths() []int {
+	var result []int
+	measure := func(i, width int) {
+		for len(result) <= i {
+			result = append(result, minColumnWidth)
+		}
+		// +2 for padding and bold letters of the header
+		result[i] = min(max(result[i], width+2), maxColumnWidth)
+	}
+	for i, h := range s.header {
+		measure(i, utf8.RuneCountInString(h))
+	}
+	for _, row := range s.rows {
+		for i, cell := range row {
+			measure(i, cell.width())
+		}
+	}
+	return result
+}
+
+// writeRow writes the row with number (starting at 1). Empty cells are
+// omitted.
+func writeRow(sb *strings.Builder, number int, cells []Cell, style int) {
+	fmt.Fprintf(sb, `<row r="%d">`, number)
+	for i, cell := range cells {
+		ref := columnName(i) + strconv.Itoa(number)
+		switch {
+		case cell.isNumber:
+			fmt.Fprintf(sb, `<c r="%s" s="%d"><v>%s</v></c>`, ref, style, strconv.FormatFloat(cell.number, 'f', -1, 64))
+		case cell.text != "":
+			fmt.Fprintf(sb, `<c r="%s" s="%d" t="inlineStr"><is><t xml:space="preserve">%s</t></is></c>`, ref, style, escape(cell.text))
+		}
+	}
+	sb.WriteString(`</row>`)
+}
+
+// columnName returns the name of the column with index (starting at 0), e.g.
+// A, Z, AA.
+func columnName(index int) string {
+	name := ""
+	for index >= 0 {
+		name = string(rune('A'+index%26)) + name
+		index = index/26 - 1
+	}
+	return name
+}
+
+func escape(s string) string {
+	var sb strings.Builder
+	xml.EscapeText(&sb, []byte(s))
+	return sb.String()
+}
+
+//================================================================================
+// Static Parts
+//================================================================================
+
+const contentTypes = xml.Header + `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+	`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+	`<Default Extension="xml" ContentType="application/xml"/>` +
+	`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+	`<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>` +
+	`<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>` +
+	`</Types>`
+
+const rootRels = xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+	`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+	`</Relationships>`
+
+const workbookRels = xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+	`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>` +
+	`<Relationship Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>` +
+	`</Relationships>`
+
+// styles has the default (0) and bold (1) cell style
+const styles = xml.Header + `<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+	`<fonts count="2"><font><sz val="11"/><name val="Calibri"/></font><font><b/><sz val="11"/><name val="Calibri"/></font></fonts>` +
+	`<fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills>` +
+	`<borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders>` +
+	`<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>` +
+	`<cellXfs count="2"><xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/><xf numFmtId="0" fontId="1" fillId="0" borderId="0" xfId="0" applyFont="1"/></cellXfs>` +
+	`<cellStyles count="1"><cellStyle name="Normal" xfId="0" builtinId="0"/></cellStyles>` +
+	`</styleSheet>`
@@ -0,0 +1,92 @@
+package xlsx
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestColumnName(t *testing.T) {
+	tests := []struct {
+		index int
+		want  string
+	}{
+		{0, "A"},
+		{25, "Z"},
+		{26, "AA"},
+		{51, "AZ"},
+		{52, "BA"},
+		{701, "ZZ"},
+		{702, "AAA"},
+	}
+	for _, tt := range tests {
+		if got := columnName(tt.index); got != tt.want {
+			t.Errorf("columnName(%d) = %s, want %s", tt.index, got, tt.want)
+		}
+	}
+}
+
+func TestNewSheetName(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"Předměty", "Předměty"},
+		{"a/b:c", "a_b_c"},
+		{"", "Sheet1"},
+		{strings.Repeat("ř", 40), strings.Repeat("ř", 31)},
+	}
+	for _, tt := range tests {
+		if got := New(tt.name).name; got != tt.want {
+			t.Errorf("New(%q).name = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestWriteTo(t *testing.T) {
+	sheet := New("Courses", "Code", "Credits")
+	sheet.Row(Text("NSWI120"), Number(5))
+	sheet.Row(Text("<a & b>"), Cell{})
+	var buf bytes.Buffer
+	if _, err := sheet.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	archive, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	parts := map[string]string{}
+	for _, f := range archive.File {
+		r, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		content, err := io.ReadAll(r)
+		r.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		parts[f.Name] = string(content)
+	}
+	for _, name := range []string{"[Content_Types].xml", "_rels/.rels", "xl/workbook.xml", "xl/_rels/workbook.xml.rels", "xl/styles.xml", "xl/worksheets/sheet1.xml"} {
+		if _, ok := parts[name]; !ok {
+			t.Errorf("missing part %s", name)
+		}
+	}
+	worksheet := parts["xl/worksheets/sheet1.xml"]
+	for _, want := range []string{
+		`<c r="A1" s="1" t="inlineStr"><is><t xml:space="preserve">Code</t></is></c>`,
+		`<c r="B2" s="0"><v>5</v></c>`,
+		`<t xml:space="preserve">&lt;a &amp; b&gt;</t>`,
+		`<row r="3"><c r="A3"`,
+	} {
+		if !strings.Contains(worksheet, want) {
+			t.Errorf("worksheet does not contain %s", want)
+		}
+	}
+	if strings.Contains(worksheet, `r="B3"`) {
+		t.Errorf("worksheet contains empty cell B3")
+	}
+}